@@ -9,6 +9,7 @@ import (
 	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"github.com/sigitisme/amf-loan-service/internal/infrastructure/database"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/encryption"
 	"github.com/sigitisme/amf-loan-service/internal/infrastructure/repository"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -17,6 +18,13 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Borrower and investor personal data is encrypted with the server's keys
+	fieldCipher, err := encryption.New(&cfg.Encryption)
+	if err != nil {
+		log.Fatal("Failed to initialize field encryption:", err)
+	}
+	encryption.RegisterSerializer(fieldCipher)
+
 	// Connect to database
 	db, err := database.NewPostgresConnection(&cfg.Database)
 	if err != nil {
@@ -31,11 +39,14 @@ func main() {
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
-	borrowerRepo := repository.NewBorrowerRepository(db)
-	investorRepo := repository.NewInvestorRepository(db)
+	borrowerRepo := repository.NewBorrowerRepository(db, fieldCipher)
+	investorRepo := repository.NewInvestorRepository(db, fieldCipher)
 
 	ctx := context.Background()
 
+	// Mock accounts skip email and identity verification
+	verifiedAt := time.Now()
+
 	log.Println("Creating mock users, borrowers, and investors...")
 
 	// Create mock borrowers
@@ -46,6 +57,10 @@ func main() {
 		phoneNumber    string
 		address        string
 		identityNumber string
+		region         string
+		branch         string
+		latitude       float64
+		longitude      float64
 	}{
 		{
 			email:          "borrower1@example.com",
@@ -54,6 +69,10 @@ func main() {
 			phoneNumber:    "+1234567890",
 			address:        "123 Main St, New York, NY",
 			identityNumber: "B001234567",
+			region:         "NY",
+			branch:         "NY-01",
+			latitude:       40.7128,
+			longitude:      -74.0060,
 		},
 		{
 			email:          "borrower2@example.com",
@@ -62,6 +81,10 @@ func main() {
 			phoneNumber:    "+1234567891",
 			address:        "456 Oak Ave, Los Angeles, CA",
 			identityNumber: "B001234568",
+			region:         "CA",
+			branch:         "CA-01",
+			latitude:       34.0522,
+			longitude:      -118.2437,
 		},
 		{
 			email:          "borrower3@example.com",
@@ -70,6 +93,10 @@ func main() {
 			phoneNumber:    "+1234567892",
 			address:        "789 Pine St, Chicago, IL",
 			identityNumber: "B001234569",
+			region:         "IL",
+			branch:         "IL-01",
+			latitude:       41.8781,
+			longitude:      -87.6298,
 		},
 	}
 
@@ -83,12 +110,14 @@ func main() {
 
 		// Create user
 		user := &domain.User{
-			ID:        uuid.New(),
-			Email:     b.email,
-			Password:  string(hashedPassword),
-			Role:      domain.RoleBorrower,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ID:              uuid.New(),
+			Email:           b.email,
+			Password:        string(hashedPassword),
+			Role:            domain.RoleBorrower,
+			EmailVerifiedAt: &verifiedAt,
+			KYCVerifiedAt:   &verifiedAt,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
 		}
 
 		err = userRepo.Create(ctx, user)
@@ -105,6 +134,10 @@ func main() {
 			PhoneNumber:    b.phoneNumber,
 			Address:        b.address,
 			IdentityNumber: b.identityNumber,
+			Region:         b.region,
+			Branch:         b.branch,
+			Latitude:       &b.latitude,
+			Longitude:      &b.longitude,
 			CreatedAt:      time.Now(),
 			UpdatedAt:      time.Now(),
 		}
@@ -179,12 +212,14 @@ func main() {
 
 		// Create user
 		user := &domain.User{
-			ID:        uuid.New(),
-			Email:     i.email,
-			Password:  string(hashedPassword),
-			Role:      domain.RoleInvestor,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ID:              uuid.New(),
+			Email:           i.email,
+			Password:        string(hashedPassword),
+			Role:            domain.RoleInvestor,
+			EmailVerifiedAt: &verifiedAt,
+			KYCVerifiedAt:   &verifiedAt,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
 		}
 
 		err = userRepo.Create(ctx, user)
@@ -221,18 +256,49 @@ func main() {
 		password string
 		role     domain.UserRole
 		name     string
+		region   string
+		branch   string
 	}{
 		{
 			email:    "validator@amf.com",
 			password: "validator123",
 			role:     domain.RoleFieldValidator,
 			name:     "Field Validator",
+			region:   "NY",
+			branch:   "NY-01",
+		},
+		{
+			email:    "validator2@amf.com",
+			password: "validator123",
+			role:     domain.RoleFieldValidator,
+			name:     "Field Validator",
+			region:   "CA",
+			branch:   "CA-01",
 		},
 		{
 			email:    "officer@amf.com",
 			password: "officer123",
 			role:     domain.RoleFieldOfficer,
 			name:     "Field Officer",
+			branch:   "NY-01",
+		},
+		{
+			email:    "analyst@amf.com",
+			password: "analyst123",
+			role:     domain.RoleCreditAnalyst,
+			name:     "Credit Analyst",
+		},
+		{
+			email:    "committee@amf.com",
+			password: "committee123",
+			role:     domain.RoleCreditCommittee,
+			name:     "Credit Committee Member",
+		},
+		{
+			email:    "admin@amf.com",
+			password: "admin123",
+			role:     domain.RoleAdmin,
+			name:     "Administrator",
 		},
 	}
 
@@ -246,12 +312,15 @@ func main() {
 
 		// Create user
 		user := &domain.User{
-			ID:        uuid.New(),
-			Email:     s.email,
-			Password:  string(hashedPassword),
-			Role:      s.role,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			ID:              uuid.New(),
+			Email:           s.email,
+			Password:        string(hashedPassword),
+			Role:            s.role,
+			Region:          s.region,
+			Branch:          s.branch,
+			EmailVerifiedAt: &verifiedAt,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
 		}
 
 		err = userRepo.Create(ctx, user)
@@ -281,6 +350,9 @@ func main() {
 	log.Println("Staff:")
 	log.Println("   - validator@amf.com (Field Validator)")
 	log.Println("   - officer@amf.com (Field Officer)")
+	log.Println("   - analyst@amf.com (Credit Analyst)")
+	log.Println("   - committee@amf.com (Credit Committee Member)")
+	log.Println("   - admin@amf.com (Administrator)")
 	log.Println("")
-	log.Println("All passwords: password123 (except staff: validator123/officer123)")
+	log.Println("All passwords: password123 (except staff: validator123/officer123/analyst123/committee123/admin123)")
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/oidc/oidctest"
+)
+
+// Local identity provider for trying single sign-on with the mock staff accounts.
+// Anyone who can reach it signs in as any listed user, use it for development only.
+func main() {
+	// Load configuration
+	cfg := config.Load()
+
+	issuer, err := url.Parse(cfg.OIDC.IssuerURL)
+	if err != nil {
+		log.Fatal("Invalid OIDC_ISSUER_URL:", err)
+	}
+
+	users := []oidctest.User{
+		{Subject: "staff-validator", Email: "validator@amf.com", EmailVerified: true, Name: "Field Validator", Groups: []string{"amf-field-validators"}},
+		{Subject: "staff-validator2", Email: "validator2@amf.com", EmailVerified: true, Name: "Field Validator", Groups: []string{"amf-field-validators"}},
+		{Subject: "staff-officer", Email: "officer@amf.com", EmailVerified: true, Name: "Field Officer", Groups: []string{"amf-field-officers"}},
+		{Subject: "staff-analyst", Email: "analyst@amf.com", EmailVerified: true, Name: "Credit Analyst", Groups: []string{"amf-credit-analysts"}},
+		{Subject: "staff-committee", Email: "committee@amf.com", EmailVerified: true, Name: "Credit Committee Member", Groups: []string{"amf-credit-committee", "amf-credit-analysts"}},
+		{Subject: "staff-admin", Email: "admin@amf.com", EmailVerified: true, Name: "Administrator", Groups: []string{"amf-admins"}},
+		// Has no account yet, the first sign-on provisions one
+		{Subject: "staff-new-analyst", Email: "new.analyst@amf.com", EmailVerified: true, Name: "New Credit Analyst", Groups: []string{"amf-credit-analysts"}},
+		// Belongs to no mapped group, the sign-on is refused
+		{Subject: "contractor", Email: "contractor@amf.com", EmailVerified: true, Name: "Contractor", Groups: []string{"amf-contractors"}},
+	}
+	clients := []oidctest.Client{
+		{ID: cfg.OIDC.ClientID, Secret: cfg.OIDC.ClientSecret, RedirectURIs: []string{cfg.OIDC.RedirectURL}},
+	}
+
+	provider, err := oidctest.NewProvider(cfg.OIDC.IssuerURL, users, clients)
+	if err != nil {
+		log.Fatal("Failed to create identity provider:", err)
+	}
+
+	log.Printf("Development identity provider listening on %s", cfg.OIDC.IssuerURL)
+	if err := http.ListenAndServe(":"+issuer.Port(), provider.Handler()); err != nil {
+		log.Fatal("Failed to start identity provider:", err)
+	}
+}
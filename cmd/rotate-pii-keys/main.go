@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/database"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/encryption"
+	"gorm.io/gorm"
+)
+
+// storedPII is a borrower or investor row as stored, without the encryption serializer
+type storedPII struct {
+	ID                  uuid.UUID
+	PhoneNumber         string
+	Address             string
+	IdentityNumber      string
+	IdentityNumberIndex string
+}
+
+// Re-encrypts borrower and investor personal data and the token signing keys under the active key and
+// refreshes the blind indexes. Personal data written before encryption was enabled is encrypted too, and
+// values encrypted before they were bound to their row are bound. Old keys must stay in
+// the keyfile until this command finished without failures.
+//
+// A new installation creates its keyfile once with -init-key, the servers refuse to start without it.
+//
+// Rotating a key takes two runs: -new-key adds the key, then every server is restarted so it can
+// read values under the new key, then a run without flags re-encrypts.
+func main() {
+	initKey := flag.Bool("init-key", false, "create the local keyfile with a fresh key and exit, refuses to replace an existing keyfile")
+	newKey := flag.Bool("new-key", false, "add a new key to the local keyfile, make it active and exit")
+	batchSize := flag.Int("batch-size", 200, "rows loaded per query")
+	flag.Parse()
+
+	// Load configuration
+	cfg := config.Load()
+
+	if *initKey {
+		if cfg.Encryption.KeyProvider != encryption.ProviderLocal {
+			log.Fatalf("-init-key only works with the %q key provider", encryption.ProviderLocal)
+		}
+		if err := encryption.CreateLocalKeyFile(cfg.Encryption.KeyFile); err != nil {
+			log.Fatal("Failed to create encryption keyfile:", err)
+		}
+		log.Printf("Created %s, keep it out of database backups", cfg.Encryption.KeyFile)
+		return
+	}
+
+	if *newKey {
+		if cfg.Encryption.KeyProvider != encryption.ProviderLocal {
+			log.Fatalf("-new-key only works with the %q key provider", encryption.ProviderLocal)
+		}
+		keyID, err := encryption.AddLocalKey(cfg.Encryption.KeyFile)
+		if err != nil {
+			log.Fatal("Failed to add encryption key:", err)
+		}
+		log.Printf("Added key %s to %s, restart the servers and run this command again without -new-key", keyID, cfg.Encryption.KeyFile)
+		return
+	}
+
+	fieldCipher, err := encryption.New(&cfg.Encryption)
+	if err != nil {
+		log.Fatal("Failed to initialize field encryption:", err)
+	}
+	encryption.RegisterSerializer(fieldCipher)
+
+	// Connect to database
+	db, err := database.NewPostgresConnection(&cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	// Run migrations, older databases get the blind index columns
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	ctx := context.Background()
+	failed := 0
+	for _, model := range []interface{}{&domain.Borrower{}, &domain.Investor{}} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			log.Fatal("Failed to parse model:", err)
+		}
+		updated, failures, err := reEncrypt(ctx, db, fieldCipher, stmt.Schema.Table, *batchSize)
+		if err != nil {
+			log.Fatalf("Failed to re-encrypt %s: %v", stmt.Schema.Table, err)
+		}
+		log.Printf("Re-encrypted %d %s", updated, stmt.Schema.Table)
+		failed += failures
+	}
+
+	updated, failures, err := reEncryptSigningKeys(ctx, db, fieldCipher)
+	if err != nil {
+		log.Fatalf("Failed to re-encrypt token signing keys: %v", err)
+	}
+	log.Printf("Re-encrypted %d token signing keys", updated)
+	failed += failures
+
+	if failed > 0 {
+		log.Fatalf("%d rows could not be re-encrypted, keep the old keys and run the command again", failed)
+	}
+	log.Println("All personal data is encrypted under the active key")
+}
+
+// reEncrypt rewrites the rows of the table that are not encrypted under the active key or whose
+// blind index is outdated, and returns how many were rewritten and how many failed
+func reEncrypt(ctx context.Context, db *gorm.DB, cipher domain.FieldCipher, table string, batchSize int) (int, int, error) {
+	updated, failed := 0, 0
+
+	var rows []storedPII
+	result := db.WithContext(ctx).Table(table).
+		Select("id, phone_number, address, identity_number, identity_number_index").
+		FindInBatches(&rows, batchSize, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				values, err := reEncryptRow(ctx, cipher, table, row)
+				if err != nil {
+					log.Printf("Failed to re-encrypt %s %s: %v", table, row.ID, err)
+					failed++
+					continue
+				}
+				if values == nil {
+					continue
+				}
+				if err := db.WithContext(ctx).Table(table).Where("id = ?", row.ID).Updates(values).Error; err != nil {
+					log.Printf("Failed to update %s %s: %v", table, row.ID, err)
+					failed++
+					continue
+				}
+				updated++
+			}
+			return nil
+		})
+
+	return updated, failed, result.Error
+}
+
+// reEncryptSigningKeys rewrites the private keys of token signing keys that are not encrypted under
+// the active key. Keys stored unencrypted are left alone, the server never loads them.
+func reEncryptSigningKeys(ctx context.Context, db *gorm.DB, cipher domain.FieldCipher) (int, int, error) {
+	var keys []domain.SigningKey
+	if err := db.WithContext(ctx).Select("id, private_key").Find(&keys).Error; err != nil {
+		return 0, 0, err
+	}
+
+	updated, failed := 0, 0
+	for _, key := range keys {
+		if cipher.IsCurrent(key.PrivateKey) || strings.HasPrefix(key.PrivateKey, "-----BEGIN") {
+			continue
+		}
+		plaintext, err := cipher.Decrypt(ctx, key.PrivateKey, key.PrivateKeyLocation())
+		if err != nil {
+			log.Printf("Failed to re-encrypt token signing key %s: %v", key.ID, err)
+			failed++
+			continue
+		}
+		encrypted, err := cipher.Encrypt(ctx, plaintext, key.PrivateKeyLocation())
+		if err != nil {
+			log.Printf("Failed to re-encrypt token signing key %s: %v", key.ID, err)
+			failed++
+			continue
+		}
+		if err := db.WithContext(ctx).Model(&domain.SigningKey{}).Where("id = ?", key.ID).Update("private_key", encrypted).Error; err != nil {
+			log.Printf("Failed to update token signing key %s: %v", key.ID, err)
+			failed++
+			continue
+		}
+		updated++
+	}
+	return updated, failed, nil
+}
+
+// reEncryptRow returns the columns to update, or nil when the row is up to date
+func reEncryptRow(ctx context.Context, cipher domain.FieldCipher, table string, row storedPII) (map[string]interface{}, error) {
+	location := func(column string) domain.FieldLocation {
+		return domain.FieldLocation{Table: table, Column: column, RowID: row.ID.String()}
+	}
+
+	identityNumber, err := cipher.Decrypt(ctx, row.IdentityNumber, location("identity_number"))
+	if err != nil {
+		return nil, err
+	}
+	index := cipher.BlindIndex(identityNumber)
+
+	if cipher.IsCurrent(row.PhoneNumber) && cipher.IsCurrent(row.Address) &&
+		cipher.IsCurrent(row.IdentityNumber) && row.IdentityNumberIndex == index {
+		return nil, nil
+	}
+
+	values := map[string]interface{}{"identity_number_index": index}
+	for column, stored := range map[string]string{
+		"phone_number":    row.PhoneNumber,
+		"address":         row.Address,
+		"identity_number": row.IdentityNumber,
+	} {
+		plaintext, err := cipher.Decrypt(ctx, stored, location(column))
+		if err != nil {
+			return nil, err
+		}
+		if values[column], err = cipher.Encrypt(ctx, plaintext, location(column)); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -7,8 +7,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/infrastructure/database"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/email"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/encryption"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/exif"
 	"github.com/sigitisme/amf-loan-service/internal/infrastructure/kafka"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/oidc"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/pdf"
 	"github.com/sigitisme/amf-loan-service/internal/infrastructure/repository"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/storage"
 	"github.com/sigitisme/amf-loan-service/internal/routes"
 	"github.com/sigitisme/amf-loan-service/internal/service"
 )
@@ -17,6 +23,18 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Signed download links are only as secret as their key
+	if err := cfg.Document.Validate(); err != nil {
+		log.Fatalf("Invalid document configuration: %v", err)
+	}
+
+	// Personal data is encrypted by GORM, the serializer must be registered before the first query
+	fieldCipher, err := encryption.New(&cfg.Encryption)
+	if err != nil {
+		log.Fatalf("Failed to initialize field encryption: %v", err)
+	}
+	encryption.RegisterSerializer(fieldCipher)
+
 	// Connect to database
 	db, err := database.NewPostgresConnection(&cfg.Database)
 	if err != nil {
@@ -30,22 +48,69 @@ func main() {
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
-	borrowerRepo := repository.NewBorrowerRepository(db)
-	investorRepo := repository.NewInvestorRepository(db)
+	borrowerRepo := repository.NewBorrowerRepository(db, fieldCipher)
+	investorRepo := repository.NewInvestorRepository(db, fieldCipher)
 	loanRepo := repository.NewLoanRepository(db)
 	approvalRepo := repository.NewApprovalRepository(db)
+	approvalStageRepo := repository.NewApprovalStageRepository(db)
 	investmentRepo := repository.NewInvestmentRepository(db)
 	disbursementRepo := repository.NewDisbursementRepository(db)
+	holdRepo := repository.NewInvestmentHoldRepository(db)
+	waitlistRepo := repository.NewWaitlistRepository(db)
+	photoProofRepo := repository.NewPhotoProofRepository(db)
+	documentRepo := repository.NewDocumentRepository(db)
+	signatureRepo := repository.NewAgreementSignatureRepository(db)
+	taskRepo := repository.NewVerificationTaskRepository(db)
+	relationshipRepo := repository.NewStaffRelationshipRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	verificationRepo := repository.NewEmailVerificationRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	loginEventRepo := repository.NewLoginEventRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	challengeRepo := repository.NewTwoFactorChallengeRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	ssoStateRepo := repository.NewSingleSignOnStateRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	kycSubmissionRepo := repository.NewKYCSubmissionRepository(db)
+	erasureRequestRepo := repository.NewErasureRequestRepository(db, fieldCipher)
+	outboxRepo := repository.NewOutboxRepository(db)
 
 	// Initialize infrastructure services
 	kafkaProducer := kafka.NewProducer(&cfg.Kafka)
 	defer kafkaProducer.Close()
 
+	fileStorage, err := storage.New(&cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
+	pdfRenderer := pdf.NewRenderer()
+	emailService := email.NewService(&cfg.SMTP)
+	identityProvider := oidc.NewClient(&cfg.OIDC)
+
 	// Initialize business services
-	authService := service.NewAuthService(userRepo, borrowerRepo, investorRepo, &cfg.JWT)
-	loanService := service.NewLoanService(loanRepo, approvalRepo, disbursementRepo, investmentRepo, borrowerRepo)
-	notificationService := service.NewNotificationService(loanRepo, investmentRepo)
-	investmentService := service.NewInvestmentService(investmentRepo, loanRepo, investorRepo, kafkaProducer, notificationService)
+	permissionPolicy := service.NewPermissionPolicy(&cfg.Authorization)
+	tokenKeyService := service.NewTokenKeyService(signingKeyRepo, fieldCipher, &cfg.JWT)
+	if err := tokenKeyService.Rotate(context.Background()); err != nil {
+		log.Fatalf("Failed to prepare token signing keys: %v", err)
+	}
+	segregationService := service.NewSegregationService(userRepo, borrowerRepo, relationshipRepo, auditRepo, &cfg.Segregation)
+	taskService := service.NewTaskService(taskRepo, userRepo, loanRepo, segregationService, &cfg.Task)
+	loanService := service.NewLoanService(loanRepo, approvalRepo, approvalStageRepo, photoProofRepo, documentRepo, signatureRepo, fileStorage, disbursementRepo, investmentRepo, waitlistRepo, borrowerRepo, taskService, segregationService, &cfg.Approval)
+	photoProofService := service.NewPhotoProofService(photoProofRepo, loanRepo, fileStorage, exif.NewReader(), &cfg.Storage)
+	documentService := service.NewDocumentService(documentRepo, loanRepo, fileStorage, permissionPolicy, &cfg.Document)
+	notificationService := service.NewNotificationService(loanRepo, investmentRepo, documentService, pdfRenderer)
+	authService := service.NewAuthService(userRepo, borrowerRepo, investorRepo, verificationRepo, refreshTokenRepo, revokedTokenRepo, loginEventRepo, passwordResetRepo, challengeRepo, recoveryCodeRepo, ssoStateRepo, notificationService, emailService, identityProvider, permissionPolicy, tokenKeyService, &cfg.JWT, &cfg.Registration, &cfg.Password, &cfg.TwoFactor, &cfg.Lockout, &cfg.OIDC)
+	kycService := service.NewKYCService(kycSubmissionRepo, userRepo, borrowerRepo, investorRepo, auditRepo, fileStorage, notificationService, &cfg.KYC)
+	privacyService := service.NewPrivacyService(erasureRequestRepo, userRepo, borrowerRepo, investorRepo, loanRepo, approvalStageRepo, investmentRepo, holdRepo, documentRepo, signatureRepo, kycSubmissionRepo, loginEventRepo, auditRepo, fileStorage)
+	userAdminService := service.NewUserAdminService(userRepo, loginEventRepo, auditRepo, authService, taskService, &cfg.Password)
+	serviceAccountService := service.NewServiceAccountService(userRepo, apiKeyRepo, auditRepo, permissionPolicy, &cfg.APIKey)
+	agreementService := service.NewAgreementService(loanRepo, documentRepo, signatureRepo, documentService, notificationService, pdfRenderer, permissionPolicy, &cfg.Signature)
+	waitlistService := service.NewWaitlistService(waitlistRepo, loanRepo, investorRepo, holdRepo, kafkaProducer, notificationService, &cfg.Investment)
+	investmentService := service.NewInvestmentService(investmentRepo, loanRepo, investorRepo, holdRepo, kafkaProducer, notificationService, waitlistService, agreementService, &cfg.Investment)
+	outboxService := service.NewOutboxService(outboxRepo, kafkaProducer, &cfg.Outbox)
 
 	// Initialize and start Kafka consumer
 	consumer := kafka.NewConsumer(&cfg.Kafka, investmentService)
@@ -56,6 +121,31 @@ func main() {
 	}()
 	defer consumer.StopConsumer()
 
+	// Start relay that publishes the events recorded in the outbox
+	outboxRelay := service.NewOutboxRelay(outboxService, cfg.Outbox.RelayInterval)
+	go outboxRelay.Start(context.Background())
+	defer outboxRelay.Stop()
+
+	// Start sweeper that releases expired investment holds
+	holdSweeper := service.NewHoldSweeper(investmentService, cfg.Investment.HoldSweepInterval)
+	go holdSweeper.Start(context.Background())
+	defer holdSweeper.Stop()
+
+	// Start sweeper that retries agreement letters that failed to send
+	letterSweeper := service.NewAgreementLetterSweeper(investmentService, cfg.Investment.LetterRetryInterval)
+	go letterSweeper.Start(context.Background())
+	defer letterSweeper.Stop()
+
+	// Start sweeper that assigns pending loans and reassigns overdue verification tasks
+	taskSweeper := service.NewTaskSweeper(taskService, cfg.Task.SweepInterval)
+	go taskSweeper.Start(context.Background())
+	defer taskSweeper.Stop()
+
+	// Start rotator that publishes the next token signing key before the current one retires
+	tokenKeyRotator := service.NewTokenKeyRotator(tokenKeyService, cfg.JWT.KeyCheckInterval)
+	go tokenKeyRotator.Start(context.Background())
+	defer tokenKeyRotator.Stop()
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -75,7 +165,7 @@ func main() {
 	})
 
 	// Setup routes
-	routes.SetupRoutes(r, authService, loanService, investmentService)
+	routes.SetupRoutes(r, authService, loanService, investmentService, waitlistService, photoProofService, cfg.Storage.PhotoProofMaxSize, documentService, cfg.Document.MaxUploadSize, kycService, cfg.KYC.MaxUploadSize, privacyService, agreementService, taskService, segregationService, userAdminService, serviceAccountService, tokenKeyService, permissionPolicy)
 
 	// Start server
 	log.Printf("Server starting on port %s", cfg.API.Port)
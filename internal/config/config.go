@@ -1,19 +1,39 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Kafka    KafkaConfig
-	SMTP     SMTPConfig
-	API      APIConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	Kafka         KafkaConfig
+	Outbox        OutboxConfig
+	SMTP          SMTPConfig
+	API           APIConfig
+	Investment    InvestmentConfig
+	Approval      ApprovalConfig
+	Storage       StorageConfig
+	Document      DocumentConfig
+	KYC           KYCConfig
+	Encryption    EncryptionConfig
+	Signature     SignatureConfig
+	Task          TaskConfig
+	Segregation   SegregationConfig
+	Registration  RegistrationConfig
+	Password      PasswordConfig
+	TwoFactor     TwoFactorConfig
+	Lockout       LockoutConfig
+	APIKey        APIKeyConfig
+	OIDC          OIDCConfig
+	Authorization AuthorizationConfig
 }
 
 type DatabaseConfig struct {
@@ -26,8 +46,15 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry time.Duration
+	Algorithm        string        // RS256 or EdDSA, applies to keys created from now on
+	Issuer           string        // iss claim of issued tokens, required when verifying
+	Audience         string        // aud claim of issued tokens, required when verifying
+	Expiry           time.Duration // Lifetime of an access token, keep it short since only revoked tokens are tracked
+	RefreshTTL       time.Duration // Lifetime of a refresh token, every refresh issues a new one
+	Leeway           time.Duration // Clock skew tolerated for exp, nbf and iat
+	KeyLifetime      time.Duration // How long a key signs before its successor takes over
+	KeyPublishLead   time.Duration // How long the successor is in the JWKS before it signs, longer than verifiers cache the JWKS
+	KeyCheckInterval time.Duration // How often the rotation schedule is checked
 }
 
 type KafkaConfig struct {
@@ -36,6 +63,15 @@ type KafkaConfig struct {
 	FullyFundedTopic string
 }
 
+type OutboxConfig struct {
+	RelayInterval time.Duration // How often due events are published
+	BatchSize     int           // Events published per relay run
+	PublishLease  time.Duration // An event being published is not picked up by another relay before it runs out
+	RetryBackoff  time.Duration // Delay after the first failed attempt, doubled after every further one
+	MaxBackoff    time.Duration // Upper bound of the delay between attempts
+	Retention     time.Duration // How long sent events are kept
+}
+
 type SMTPConfig struct {
 	Host     string
 	Port     string
@@ -47,14 +83,213 @@ type APIConfig struct {
 	Port string
 }
 
+type InvestmentConfig struct {
+	HoldTTL             time.Duration // How long a reservation is kept before it is released
+	HoldSweepInterval   time.Duration // How often expired reservations are released
+	CoolingOffPeriod    time.Duration // How long an investor can cancel an investment until the loan is disbursed, 0 disables
+	LetterRetryInterval time.Duration // How often agreement letters that failed to send are retried
+}
+
+type ApprovalConfig struct {
+	CreditReviewRequired bool    // Whether a credit analyst must review every loan after field verification
+	CommitteeThreshold   float64 // Loans with a principal above this amount need committee sign-off, 0 disables
+	CommitteeQuorum      int     // Number of distinct committee members that must approve
+
+	// Field visit evidence checks, flagged approvals always go through credit review. 0 disables a check.
+	EvidenceMaxDistance       float64       // Meters the photo may be taken from the borrower's registered address
+	EvidenceMaxAge            time.Duration // How old the photo may be when the approval is submitted
+	EvidenceExifDistanceLimit float64       // Meters the EXIF location may differ from the reported one
+	EvidenceExifTimeLimit     time.Duration // How far the EXIF capture time may differ from the reported one
+}
+
+type StorageConfig struct {
+	Driver            string // "local" or "s3"
+	LocalDir          string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	PhotoProofMaxSize int64 // Maximum accepted photo proof upload in bytes
+}
+
+type DocumentConfig struct {
+	PublicBaseURL string        // Base URL used when building links to documents
+	URLSigningKey string        // HMAC key for signed download URLs, required
+	URLTTL        time.Duration // How long a signed download URL stays valid
+	MaxUploadSize int64         // Maximum accepted document upload in bytes
+}
+
+type KYCConfig struct {
+	MaxUploadSize int64 // Maximum accepted ID card or selfie image in bytes
+}
+
+type EncryptionConfig struct {
+	KeyProvider   string // "local", where the keys encrypting personal data come from
+	KeyFile       string // Keyfile of the local provider
+	CreateKeyFile bool   // Create a missing keyfile with a fresh key instead of refusing to start, for development only
+}
+
+type SignatureConfig struct {
+	OTPTTL         time.Duration // How long a signing code stays valid
+	OTPMaxAttempts int           // Wrong entries allowed before a new code must be requested
+	OTPMaxIssued   int           // Codes a borrower can request for one agreement within OTPIssueWindow
+	OTPIssueWindow time.Duration
+}
+
+type TaskConfig struct {
+	VerificationDueIn time.Duration // Time a validator has to complete a field verification
+	SweepInterval     time.Duration // How often unassigned loans and overdue tasks are picked up
+}
+
+type SegregationConfig struct {
+	DistinctActors       bool     // One person may act in only one lifecycle stage of a loan
+	EnforceRelationships bool     // Staff may not act on loans of borrowers they declared a relationship with
+	SameBranchForbidden  []string // Lifecycle actions staff of the borrower's branch may not perform
+}
+
+type RegistrationConfig struct {
+	VerificationTTL time.Duration // How long an email verification link stays valid
+	VerificationURL string        // Link sent in verification emails, the token is appended as a query parameter
+}
+
+type PasswordConfig struct {
+	MinLength     int           // Shortest password accepted when one is set
+	RequireUpper  bool          // At least one upper case letter
+	RequireLower  bool          // At least one lower case letter
+	RequireDigit  bool          // At least one digit
+	RequireSymbol bool          // At least one character that is not a letter or digit
+	ResetTTL      time.Duration // How long a password reset link stays valid
+	ResetURL      string        // Page linked from reset emails, the token is appended as a query parameter
+}
+
+type TwoFactorConfig struct {
+	Issuer        string        // Account issuer shown in authenticator apps
+	RequiredRoles []string      // Roles that must use two-factor authentication, as do roles allowed to manage users
+	ChallengeTTL  time.Duration // How long the second step of a login may take
+	MaxAttempts   int           // Wrong codes allowed before the user has to log in again
+	RecoveryCodes int           // Recovery codes issued when two-factor authentication is enabled
+}
+
+type OIDCConfig struct {
+	Enabled          bool
+	IssuerURL        string          // The discovery document is read from <issuer>/.well-known/openid-configuration
+	ClientID         string          // Also the audience of the ID tokens
+	ClientSecret     string          // Sent with HTTP basic authentication, empty for a public client
+	RedirectURL      string          // Callback of this service registered at the identity provider
+	Scopes           []string        // Scopes requested with the login, openid is always included
+	GroupsClaim      string          // ID token claim that lists the user's groups
+	GroupRoles       []OIDCGroupRole // The first listed group the user belongs to decides the role
+	StateTTL         time.Duration   // How long the user may take at the identity provider
+	Leeway           time.Duration   // Clock skew tolerated for the ID token times
+	RequiredForStaff bool            // Staff cannot sign in with a password while single sign-on is enabled
+}
+
+// OIDCGroupRole grants a staff role to the members of an identity provider group
+type OIDCGroupRole struct {
+	Group string
+	Role  string
+}
+
+type LockoutConfig struct {
+	FreeAttempts    int           // Consecutive failures of an account before logins are delayed
+	BaseDelay       time.Duration // First delay, doubled with every further failure
+	MaxDelay        time.Duration // Longest delay between attempts before the lockout threshold
+	Threshold       int           // Consecutive failures that lock the account
+	LockoutDuration time.Duration // How long a locked account refuses logins unless an administrator unlocks it
+	IPFreeAttempts  int           // Failures from one address within the window before its logins are delayed
+	IPThreshold     int           // Failures from one address within the window that block it until the window passes
+	IPWindow        time.Duration // Period over which failures from one address are counted
+}
+
+type APIKeyConfig struct {
+	DefaultTTL    time.Duration // Lifetime of a key issued without one
+	MaxTTL        time.Duration // Longest lifetime a key may be issued with
+	RotationGrace time.Duration // How long a rotated key keeps working so clients can switch to its successor
+}
+
+type AuthorizationConfig struct {
+	RolePermissions map[string][]string // Permissions granted to each role, PERMISSIONS_<ROLE> replaces a role's defaults
+}
+
+// DefaultRolePermissions is the permission set of every built-in role
+func DefaultRolePermissions() map[string][]string {
+	staffEvidence := []string{"loan:browse", "loan:view_evidence", "loan:view_pii", "document:view_all", "staff:declare_relationship"}
+
+	return map[string][]string{
+		"borrower": {"loan:create", "loan:view_own", "agreement:sign", "kyc:submit"},
+		"investor": {"loan:browse", "investment:create", "investment:view_own", "kyc:submit"},
+		"field_validator": append([]string{
+			"loan:approve", "task:manage",
+		}, staffEvidence...),
+		"credit_analyst": append([]string{
+			"loan:review", "loan:view_flagged", "kyc:review",
+		}, staffEvidence...),
+		"credit_committee": append([]string{
+			"loan:committee_signoff", "loan:view_flagged",
+		}, staffEvidence...),
+		"admin": {"user:manage", "privacy:manage"},
+		"field_officer": append([]string{
+			"loan:disburse", "loan:upload_document", "loan:view_flagged",
+		}, staffEvidence...),
+	}
+}
+
+// loadGroupRoles reads OIDC_GROUP_ROLES as comma separated group=role pairs, keeping their order
+// minURLSigningKeyLength is the shortest accepted download link key, 32 bytes as for an HMAC-SHA256 key
+const minURLSigningKeyLength = 32
+
+// placeholderURLSigningKey is the example key that older .env files were copied with
+const placeholderURLSigningKey = "your-document-signing-key"
+
+// Validate refuses a signing key anyone could guess, it would let them forge download links for any document
+func (c *DocumentConfig) Validate() error {
+	switch key := c.URLSigningKey; {
+	case key == "":
+		return fmt.Errorf("DOCUMENT_URL_SIGNING_KEY is not set")
+	case key == placeholderURLSigningKey:
+		return fmt.Errorf("DOCUMENT_URL_SIGNING_KEY is the example value, generate a random key")
+	case len(key) < minURLSigningKeyLength:
+		return fmt.Errorf("DOCUMENT_URL_SIGNING_KEY must be at least %d characters, got %d", minURLSigningKeyLength, len(key))
+	}
+	return nil
+}
+
+func loadGroupRoles() []OIDCGroupRole {
+	var groupRoles []OIDCGroupRole
+	for _, pair := range getListEnv("OIDC_GROUP_ROLES", []string{
+		"amf-admins=admin",
+		"amf-credit-committee=credit_committee",
+		"amf-credit-analysts=credit_analyst",
+		"amf-field-officers=field_officer",
+		"amf-field-validators=field_validator",
+	}) {
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok {
+			log.Printf("Ignoring OIDC group mapping %q, expected group=role", pair)
+			continue
+		}
+		groupRoles = append(groupRoles, OIDCGroupRole{Group: strings.TrimSpace(group), Role: strings.TrimSpace(role)})
+	}
+	return groupRoles
+}
+
+func loadRolePermissions() map[string][]string {
+	rolePermissions := DefaultRolePermissions()
+	for role, permissions := range rolePermissions {
+		rolePermissions[role] = getListEnv("PERMISSIONS_"+strings.ToUpper(role), permissions)
+	}
+	return rolePermissions
+}
+
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	expiry, err := time.ParseDuration(getEnv("JWT_EXPIRY", "24h"))
+	expiry, err := time.ParseDuration(getEnv("JWT_EXPIRY", "15m"))
 	if err != nil {
-		expiry = 24 * time.Hour
+		expiry = 15 * time.Minute
 	}
 
 	return &Config{
@@ -67,14 +302,29 @@ func Load() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-super-secret-key"),
-			Expiry: expiry,
+			Algorithm:        getEnv("JWT_ALGORITHM", "RS256"),
+			Issuer:           getEnv("JWT_ISSUER", "amf-loan-service"),
+			Audience:         getEnv("JWT_AUDIENCE", "amf-loan-service"),
+			Expiry:           expiry,
+			RefreshTTL:       getDurationEnv("JWT_REFRESH_TTL", 7*24*time.Hour),
+			Leeway:           getDurationEnv("JWT_LEEWAY", 30*time.Second),
+			KeyLifetime:      getDurationEnv("JWT_KEY_LIFETIME", 30*24*time.Hour),
+			KeyPublishLead:   getDurationEnv("JWT_KEY_PUBLISH_LEAD", 24*time.Hour),
+			KeyCheckInterval: getDurationEnv("JWT_KEY_CHECK_INTERVAL", time.Hour),
 		},
 		Kafka: KafkaConfig{
 			Brokers:          []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 			InvestmentTopic:  getEnv("KAFKA_INVESTMENT_TOPIC", "investment_processing"),
 			FullyFundedTopic: getEnv("KAFKA_FULLY_FUNDED_TOPIC", "loan_fully_funded"),
 		},
+		Outbox: OutboxConfig{
+			RelayInterval: getDurationEnv("OUTBOX_RELAY_INTERVAL", 2*time.Second),
+			BatchSize:     getIntEnv("OUTBOX_BATCH_SIZE", 100),
+			PublishLease:  getDurationEnv("OUTBOX_PUBLISH_LEASE", time.Minute),
+			RetryBackoff:  getDurationEnv("OUTBOX_RETRY_BACKOFF", 5*time.Second),
+			MaxBackoff:    getDurationEnv("OUTBOX_MAX_BACKOFF", 10*time.Minute),
+			Retention:     getDurationEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
 		SMTP: SMTPConfig{
 			Host:     getEnv("SMTP_HOST", "smtp.gmail.com"),
 			Port:     getEnv("SMTP_PORT", "587"),
@@ -84,6 +334,112 @@ func Load() *Config {
 		API: APIConfig{
 			Port: getEnv("API_PORT", "8080"),
 		},
+		Investment: InvestmentConfig{
+			HoldTTL:             getDurationEnv("INVESTMENT_HOLD_TTL", 5*time.Minute),
+			HoldSweepInterval:   getDurationEnv("INVESTMENT_HOLD_SWEEP_INTERVAL", 30*time.Second),
+			CoolingOffPeriod:    getDurationEnv("INVESTMENT_COOLING_OFF_PERIOD", 48*time.Hour),
+			LetterRetryInterval: getDurationEnv("INVESTMENT_LETTER_RETRY_INTERVAL", 5*time.Minute),
+		},
+		Approval: ApprovalConfig{
+			CreditReviewRequired: getBoolEnv("APPROVAL_CREDIT_REVIEW_REQUIRED", true),
+			CommitteeThreshold:   getFloatEnv("APPROVAL_COMMITTEE_THRESHOLD", 500000),
+			CommitteeQuorum:      getIntEnv("APPROVAL_COMMITTEE_QUORUM", 1),
+
+			EvidenceMaxDistance:       getFloatEnv("APPROVAL_EVIDENCE_MAX_DISTANCE_METERS", 500),
+			EvidenceMaxAge:            getDurationEnv("APPROVAL_EVIDENCE_MAX_AGE", 48*time.Hour),
+			EvidenceExifDistanceLimit: getFloatEnv("APPROVAL_EVIDENCE_EXIF_DISTANCE_METERS", 100),
+			EvidenceExifTimeLimit:     getDurationEnv("APPROVAL_EVIDENCE_EXIF_TIME_DIFF", 15*time.Minute),
+		},
+		Storage: StorageConfig{
+			Driver:            getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:          getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+			S3Endpoint:        getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:          getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:          getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKeyID:     getEnv("STORAGE_S3_ACCESS_KEY_ID", ""),
+			S3SecretAccessKey: getEnv("STORAGE_S3_SECRET_ACCESS_KEY", ""),
+			PhotoProofMaxSize: int64(getIntEnv("PHOTO_PROOF_MAX_SIZE", 10<<20)),
+		},
+		Document: DocumentConfig{
+			PublicBaseURL: getEnv("DOCUMENT_PUBLIC_BASE_URL", "http://localhost:8080"),
+			URLSigningKey: getEnv("DOCUMENT_URL_SIGNING_KEY", ""),
+			URLTTL:        getDurationEnv("DOCUMENT_URL_TTL", 15*time.Minute),
+			MaxUploadSize: int64(getIntEnv("DOCUMENT_MAX_SIZE", 20<<20)),
+		},
+		KYC: KYCConfig{
+			MaxUploadSize: int64(getIntEnv("KYC_MAX_UPLOAD_SIZE", 10<<20)),
+		},
+		Encryption: EncryptionConfig{
+			KeyProvider:   getEnv("PII_KEY_PROVIDER", "local"),
+			KeyFile:       getEnv("PII_KEY_FILE", "./keys/pii-keys.json"),
+			CreateKeyFile: getBoolEnv("PII_KEY_FILE_CREATE", false),
+		},
+		Signature: SignatureConfig{
+			OTPTTL:         getDurationEnv("SIGNATURE_OTP_TTL", 10*time.Minute),
+			OTPMaxAttempts: getIntEnv("SIGNATURE_OTP_MAX_ATTEMPTS", 5),
+			OTPMaxIssued:   getIntEnv("SIGNATURE_OTP_MAX_ISSUED", 5),
+			OTPIssueWindow: getDurationEnv("SIGNATURE_OTP_ISSUE_WINDOW", time.Hour),
+		},
+		Task: TaskConfig{
+			VerificationDueIn: getDurationEnv("TASK_VERIFICATION_DUE_IN", 72*time.Hour),
+			SweepInterval:     getDurationEnv("TASK_SWEEP_INTERVAL", 5*time.Minute),
+		},
+		Segregation: SegregationConfig{
+			DistinctActors:       getBoolEnv("SOD_DISTINCT_ACTORS", true),
+			EnforceRelationships: getBoolEnv("SOD_ENFORCE_RELATIONSHIPS", true),
+			SameBranchForbidden:  getListEnv("SOD_SAME_BRANCH_FORBIDDEN", nil),
+		},
+		Registration: RegistrationConfig{
+			VerificationTTL: getDurationEnv("REGISTRATION_VERIFICATION_TTL", 24*time.Hour),
+			VerificationURL: getEnv("REGISTRATION_VERIFICATION_URL", "http://localhost:8080/api/auth/verify-email"),
+		},
+		Password: PasswordConfig{
+			MinLength:     getIntEnv("PASSWORD_MIN_LENGTH", 10),
+			RequireUpper:  getBoolEnv("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:  getBoolEnv("PASSWORD_REQUIRE_LOWER", true),
+			RequireDigit:  getBoolEnv("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol: getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+			ResetTTL:      getDurationEnv("PASSWORD_RESET_TTL", 30*time.Minute),
+			ResetURL:      getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:        getEnv("TWO_FACTOR_ISSUER", "AMF Loan Service"),
+			RequiredRoles: getListEnv("TWO_FACTOR_REQUIRED_ROLES", []string{"field_officer", "field_validator", "admin"}),
+			ChallengeTTL:  getDurationEnv("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+			MaxAttempts:   getIntEnv("TWO_FACTOR_MAX_ATTEMPTS", 5),
+			RecoveryCodes: getIntEnv("TWO_FACTOR_RECOVERY_CODES", 10),
+		},
+		Lockout: LockoutConfig{
+			FreeAttempts:    getIntEnv("LOGIN_FREE_ATTEMPTS", 3),
+			BaseDelay:       getDurationEnv("LOGIN_BASE_DELAY", time.Second),
+			MaxDelay:        getDurationEnv("LOGIN_MAX_DELAY", time.Minute),
+			Threshold:       getIntEnv("LOGIN_LOCKOUT_THRESHOLD", 10),
+			LockoutDuration: getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			IPFreeAttempts:  getIntEnv("LOGIN_IP_FREE_ATTEMPTS", 10),
+			IPThreshold:     getIntEnv("LOGIN_IP_THRESHOLD", 30),
+			IPWindow:        getDurationEnv("LOGIN_IP_WINDOW", 15*time.Minute),
+		},
+		APIKey: APIKeyConfig{
+			DefaultTTL:    getDurationEnv("API_KEY_DEFAULT_TTL", 90*24*time.Hour),
+			MaxTTL:        getDurationEnv("API_KEY_MAX_TTL", 365*24*time.Hour),
+			RotationGrace: getDurationEnv("API_KEY_ROTATION_GRACE", 24*time.Hour),
+		},
+		OIDC: OIDCConfig{
+			Enabled:          getBoolEnv("OIDC_ENABLED", false),
+			IssuerURL:        strings.TrimSuffix(getEnv("OIDC_ISSUER_URL", "http://localhost:9000"), "/"),
+			ClientID:         getEnv("OIDC_CLIENT_ID", "amf-loan-service"),
+			ClientSecret:     getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:      getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
+			Scopes:           getListEnv("OIDC_SCOPES", []string{"openid", "email", "profile", "groups"}),
+			GroupsClaim:      getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			GroupRoles:       loadGroupRoles(),
+			StateTTL:         getDurationEnv("OIDC_STATE_TTL", 10*time.Minute),
+			Leeway:           getDurationEnv("OIDC_LEEWAY", 30*time.Second),
+			RequiredForStaff: getBoolEnv("OIDC_REQUIRED_FOR_STAFF", true),
+		},
+		Authorization: AuthorizationConfig{
+			RolePermissions: loadRolePermissions(),
+		},
 	}
 }
 
@@ -93,3 +449,51 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, strconv.FormatFloat(defaultValue, 'f', -1, 64)), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getListEnv reads a comma separated list, ignoring blank items
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(getEnv(key, defaultValue.String()))
+	if err != nil {
+		return defaultValue
+	}
+	return duration
+}
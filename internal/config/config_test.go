@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Document Config - Download Links Are Not Signed With A Missing, Example Or Short Key
+func TestDocumentConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "missing", key: "", wantErr: true},
+		{name: "example value", key: "your-document-signing-key", wantErr: true},
+		{name: "too short", key: "0123456789abcdef0123456789abcde", wantErr: true},
+		{name: "random key", key: "q3Xn0c3Jk0m8VYH2t1bLwS5uPz6eRa9dGfK4hJ7iNoM=", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&DocumentConfig{URLSigningKey: tt.key}).Validate()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// Test Config Loading - The Signing Key Has No Built-In Default
+func TestLoad_NoDefaultURLSigningKey(t *testing.T) {
+	t.Setenv("DOCUMENT_URL_SIGNING_KEY", "")
+
+	cfg := Load()
+
+	assert.Empty(t, cfg.Document.URLSigningKey)
+	assert.Error(t, cfg.Document.Validate())
+}
@@ -1,6 +1,11 @@
 package domain
 
 import (
+	"crypto"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,31 +14,311 @@ import (
 type UserRole string
 
 const (
-	RoleBorrower       UserRole = "borrower"
-	RoleInvestor       UserRole = "investor"
-	RoleFieldOfficer   UserRole = "field_officer"
-	RoleFieldValidator UserRole = "field_validator"
+	RoleBorrower        UserRole = "borrower"
+	RoleInvestor        UserRole = "investor"
+	RoleFieldOfficer    UserRole = "field_officer"
+	RoleFieldValidator  UserRole = "field_validator"
+	RoleCreditAnalyst   UserRole = "credit_analyst"
+	RoleCreditCommittee UserRole = "credit_committee"
+	RoleAdmin           UserRole = "admin"
 )
 
+// IsStaff reports whether the role belongs to an internal employee rather than a customer
+func (r UserRole) IsStaff() bool {
+	switch r {
+	case RoleFieldOfficer, RoleFieldValidator, RoleCreditAnalyst, RoleCreditCommittee, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// Permission is an action a role may perform. Roles are mapped to permissions in configuration
+// so the HTTP layer and services check capabilities rather than role names.
+type Permission string
+
+const (
+	PermissionLoanCreate               Permission = "loan:create"
+	PermissionLoanViewOwn              Permission = "loan:view_own"
+	PermissionLoanBrowse               Permission = "loan:browse" // List loans of any borrower by state
+	PermissionLoanApprove              Permission = "loan:approve"
+	PermissionLoanReview               Permission = "loan:review"
+	PermissionLoanCommitteeSignoff     Permission = "loan:committee_signoff"
+	PermissionLoanDisburse             Permission = "loan:disburse"
+	PermissionLoanUploadDocument       Permission = "loan:upload_document"
+	PermissionLoanViewEvidence         Permission = "loan:view_evidence" // Photo proofs and approval stages
+	PermissionLoanViewFlagged          Permission = "loan:view_flagged"
+	PermissionLoanViewPII              Permission = "loan:view_pii" // Unmasked personal data of every borrower and investor
+	PermissionAgreementSign            Permission = "agreement:sign"
+	PermissionInvestmentCreate         Permission = "investment:create"
+	PermissionInvestmentViewOwn        Permission = "investment:view_own"
+	PermissionTaskManage               Permission = "task:manage"
+	PermissionDocumentViewAll          Permission = "document:view_all"
+	PermissionStaffDeclareRelationship Permission = "staff:declare_relationship"
+	PermissionUserManage               Permission = "user:manage"
+	PermissionKYCSubmit                Permission = "kyc:submit"
+	PermissionKYCReview                Permission = "kyc:review"     // Review queue, identity documents and decisions
+	PermissionPrivacyManage            Permission = "privacy:manage" // Export any customer's data and process erasure requests
+)
+
+// Permissions is stored as a comma separated list
+type Permissions []Permission
+
+func (p Permissions) Contains(permission Permission) bool {
+	for _, granted := range p {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Permissions) Value() (driver.Value, error) {
+	values := make([]string, len(p))
+	for i, permission := range p {
+		values[i] = string(permission)
+	}
+	return strings.Join(values, ","), nil
+}
+
+func (p *Permissions) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		raw = ""
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Permissions", value)
+	}
+
+	*p = nil
+	for _, part := range strings.Split(raw, ",") {
+		if part != "" {
+			*p = append(*p, Permission(part))
+		}
+	}
+	return nil
+}
+
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Role      UserRole  `json:"role" gorm:"not null"`
+	ID                uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email             string      `json:"email" gorm:"unique;not null"`
+	Password          string      `json:"-" gorm:"not null"`
+	Role              UserRole    `json:"role" gorm:"not null"`
+	Region            string      `json:"region,omitempty" gorm:"index"`                 // Area a field employee covers
+	Branch            string      `json:"branch,omitempty"`                              // Branch a staff member works at
+	EmailVerifiedAt   *time.Time  `json:"email_verified_at,omitempty"`                   // Self-registered customers cannot borrow or invest before it is set
+	KYCVerifiedAt     *time.Time  `json:"kyc_verified_at,omitempty"`                     // Set when a reviewer verified the customer's identity, required to borrow or invest
+	SessionsRevokedAt *time.Time  `json:"-"`                                             // Access tokens issued before this time are rejected
+	DeactivatedAt     *time.Time  `json:"deactivated_at,omitempty"`                      // Deactivated accounts can no longer sign in
+	ErasedAt          *time.Time  `json:"erased_at,omitempty"`                           // Personal data was anonymized on request, the account stays deactivated
+	TOTPSecret        string      `json:"-"`                                             // Authenticator secret, generated at setup and confirmed by a first code
+	TOTPEnabledAt     *time.Time  `json:"two_factor_enabled_at,omitempty"`               // Logins need a code from the authenticator once it is set
+	TOTPLastStep      int64       `json:"-"`                                             // Time step of the last accepted code, a code is never accepted twice
+	FailedLoginCount  int         `json:"failed_login_count" gorm:"not null;default:0"`  // Consecutive failed sign-ins, reset by a successful one
+	LockedUntil       *time.Time  `json:"locked_until,omitempty"`                        // Sign-in attempts are refused until then
+	ServiceAccount    bool        `json:"service_account" gorm:"not null;default:false"` // Machine client, it authenticates with API keys and never with a password
+	ExternalSubject   *string     `json:"-" gorm:"uniqueIndex"`                          // Subject at the identity provider, linked at the first single sign-on
+	Scopes            Permissions `json:"-" gorm:"-"`                                    // Set when the request was authenticated with an API key, which may use only these permissions
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) IsKYCVerified() bool {
+	return u.KYCVerifiedAt != nil
+}
+
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+func (u *User) IsErased() bool {
+	return u.ErasedAt != nil
+}
+
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// LoginEvent records a sign-in attempt, successful or not, for the user's login history
+type LoginEvent struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"` // Unset when the email is unknown
+	Email     string     `json:"email" gorm:"not null;index"`
+	Succeeded bool       `json:"succeeded" gorm:"not null"`
+	Reason    string     `json:"reason,omitempty"` // Why a failed attempt was refused
+	IPAddress string     `json:"ip_address" gorm:"index"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
+
+// LoginFailureStats summarizes the failed sign-in attempts of a client in a time window
+type LoginFailureStats struct {
+	Count  int64
+	LastAt *time.Time
+}
+
+// RefreshToken is a server side login session, rotated on every use. Tokens rotated from the same
+// login share a family so a replayed token can revoke the whole chain. Only a hash of the token is stored.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id,omitempty" gorm:"type:uuid"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RevokedToken lists access tokens, by their jti claim, that must be refused before they expire
+type RevokedToken struct {
+	JTI       uuid.UUID `json:"jti" gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"` // The entry is useless once the token expired
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Access token signing algorithms
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is an asymmetric key pair that signs access tokens, its public half is published as a JWK.
+// A key signs from ActivatesAt until RetiresAt and verifies until the tokens it signed have expired.
+type SigningKey struct {
+	ID          string        `json:"kid" gorm:"type:varchar(64);primary_key"`
+	Algorithm   string        `json:"alg" gorm:"type:varchar(16);not null"`
+	PrivateKey  string        `json:"-" gorm:"type:text;not null"` // PKCS #8 PEM, encrypted with the field cipher
+	PublicKey   string        `json:"-" gorm:"type:text;not null"` // PKIX PEM
+	ActivatesAt time.Time     `json:"activates_at" gorm:"not null"`
+	RetiresAt   time.Time     `json:"retires_at" gorm:"not null;index"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Signer      crypto.Signer `json:"-" gorm:"-"` // Parsed from PrivateKey when the key is loaded
+}
+
+// CanSign reports whether new tokens are signed with the key at the given time
+func (k *SigningKey) CanSign(now time.Time) bool {
+	return !now.Before(k.ActivatesAt) && now.Before(k.RetiresAt)
+}
+
+// PrivateKeyLocation is where the encrypted private key is stored, it is encrypted for this key only
+func (k *SigningKey) PrivateKeyLocation() FieldLocation {
+	return FieldLocation{Table: "signing_keys", Column: "private_key", RowID: k.ID}
+}
+
+// EmailVerificationToken proves ownership of a registered email address, only a hash of the token is stored
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PasswordResetToken lets a user who forgot their password set a new one, only a hash of the token is stored
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type TwoFactorChallengePurpose string
+
+const (
+	// TwoFactorChallengeLogin waits for a code from the user's authenticator or a recovery code
+	TwoFactorChallengeLogin TwoFactorChallengePurpose = "login"
+	// TwoFactorChallengeEnrolment lets a user who must use two-factor authentication set it up before the first login
+	TwoFactorChallengeEnrolment TwoFactorChallengePurpose = "enrolment"
+)
+
+// TwoFactorChallenge is the second step of a login whose password was accepted, only a hash of the token is stored
+type TwoFactorChallenge struct {
+	ID        uuid.UUID                 `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID                 `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string                    `json:"-" gorm:"not null;uniqueIndex"`
+	Purpose   TwoFactorChallengePurpose `json:"purpose" gorm:"not null"`
+	Attempts  int                       `json:"attempts" gorm:"not null;default:0"` // Wrong codes entered so far
+	IPAddress string                    `json:"ip_address"`                         // Client of the login, recorded when the challenge completes
+	UserAgent string                    `json:"user_agent"`
+	ExpiresAt time.Time                 `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time                `json:"used_at,omitempty"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+// SingleSignOnState is a login started at the identity provider, only a hash of its state parameter is stored.
+// The nonce and PKCE code verifier stay on the server until the user comes back with an authorization code.
+type SingleSignOnState struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StateHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	Nonce        string     `json:"-" gorm:"not null"`
+	CodeVerifier string     `json:"-" gorm:"not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RecoveryCode replaces an authenticator code once when the device is lost, only a hash of the code is stored
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKey authenticates a service account. The key is shown once at issue; only its public
+// prefix, which identifies it, and a SHA-256 hash of the secret part are stored.
+type APIKey struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID   `json:"service_account_id" gorm:"type:uuid;not null;index"`
+	Name         string      `json:"name" gorm:"not null"`
+	Prefix       string      `json:"prefix" gorm:"not null;uniqueIndex"`
+	SecretHash   string      `json:"-" gorm:"not null"`
+	Scopes       Permissions `json:"scopes" gorm:"type:text;not null"` // Permissions of the account's role the key may use
+	ExpiresAt    time.Time   `json:"expires_at" gorm:"not null"`
+	LastUsedAt   *time.Time  `json:"last_used_at,omitempty"`
+	RevokedAt    *time.Time  `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID  `json:"replaced_by_id,omitempty" gorm:"type:uuid"` // Key issued when this one was rotated
+	CreatedByID  uuid.UUID   `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
 }
 
 // Borrower entity for storing borrower-specific information
 type Borrower struct {
-	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID         uuid.UUID `json:"user_id" gorm:"not null;unique"`
-	FullName       string    `json:"full_name" gorm:"not null"`
-	PhoneNumber    string    `json:"phone_number" gorm:"not null"`
-	Address        string    `json:"address" gorm:"not null"`
-	IdentityNumber string    `json:"identity_number" gorm:"not null;unique"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                  uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID              uuid.UUID `json:"user_id" gorm:"not null;unique"`
+	FullName            string    `json:"full_name" gorm:"not null"`
+	PhoneNumber         string    `json:"phone_number" gorm:"not null;serializer:encrypted"`    // Encrypted at rest
+	Address             string    `json:"address" gorm:"not null;serializer:encrypted"`         // Encrypted at rest
+	IdentityNumber      string    `json:"identity_number" gorm:"not null;serializer:encrypted"` // Encrypted at rest, looked up by IdentityNumberIndex
+	IdentityNumberIndex string    `json:"-" gorm:"size:64;uniqueIndex"`                         // Blind index of the identity number, keeps it unique
+	Region              string    `json:"region,omitempty"`                                     // Used to route field verification to local validators
+	Latitude            *float64  `json:"latitude,omitempty"`                                   // Geocoded registered address, field visits are checked against it
+	Longitude           *float64  `json:"longitude,omitempty"`
+	Branch              string    `json:"branch,omitempty"` // Branch servicing the borrower
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
 
 	// Relations
 	User  User   `json:"user" gorm:"foreignKey:UserID"`
@@ -42,21 +327,92 @@ type Borrower struct {
 
 // Investor entity for storing investor-specific information
 type Investor struct {
-	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID         uuid.UUID `json:"user_id" gorm:"not null;unique"`
-	FullName       string    `json:"full_name" gorm:"not null"`
-	PhoneNumber    string    `json:"phone_number" gorm:"not null"`
-	Address        string    `json:"address" gorm:"not null"`
-	IdentityNumber string    `json:"identity_number" gorm:"not null;unique"`
-	TotalInvested  float64   `json:"total_invested" gorm:"default:0"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                  uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID              uuid.UUID `json:"user_id" gorm:"not null;unique"`
+	FullName            string    `json:"full_name" gorm:"not null"`
+	PhoneNumber         string    `json:"phone_number" gorm:"not null;serializer:encrypted"`    // Encrypted at rest
+	Address             string    `json:"address" gorm:"not null;serializer:encrypted"`         // Encrypted at rest
+	IdentityNumber      string    `json:"identity_number" gorm:"not null;serializer:encrypted"` // Encrypted at rest, looked up by IdentityNumberIndex
+	IdentityNumberIndex string    `json:"-" gorm:"size:64;uniqueIndex"`                         // Blind index of the identity number, keeps it unique
+	TotalInvested       float64   `json:"total_invested" gorm:"default:0"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
 
 	// Relations
 	User        User         `json:"user" gorm:"foreignKey:UserID"`
 	Investments []Investment `json:"investments,omitempty" gorm:"foreignKey:InvestorID"`
 }
 
+type KYCStatus string
+
+const (
+	KYCStatusPending  KYCStatus = "pending"
+	KYCStatusVerified KYCStatus = "verified"
+	KYCStatusRejected KYCStatus = "rejected"
+)
+
+type KYCDocumentType string
+
+const (
+	KYCDocumentIDCard KYCDocumentType = "id_card"
+	KYCDocumentSelfie KYCDocumentType = "selfie"
+)
+
+// KYCSubmission asks staff to verify a customer's identity from an ID card and a selfie.
+// A pending submission is verified or rejected once; a rejected customer may submit again.
+type KYCSubmission struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Status          KYCStatus  `json:"status" gorm:"not null;default:'pending';index"`
+	ReviewerID      *uuid.UUID `json:"reviewer_id,omitempty" gorm:"type:uuid"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"` // Shown to the customer so they can correct the next submission
+	CreatedAt       time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	User      User          `json:"user" gorm:"foreignKey:UserID"`
+	Documents []KYCDocument `json:"documents,omitempty" gorm:"foreignKey:SubmissionID"`
+}
+
+// KYCDocument is an identity document image of a submission, only staff reviewing it can download it
+type KYCDocument struct {
+	ID           uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubmissionID uuid.UUID       `json:"submission_id" gorm:"type:uuid;not null;index"`
+	Type         KYCDocumentType `json:"type" gorm:"not null"`
+	StorageKey   string          `json:"-" gorm:"not null;uniqueIndex"`
+	ContentType  string          `json:"content_type" gorm:"not null"`
+	Size         int64           `json:"size" gorm:"not null"`
+	SHA256       string          `json:"sha256" gorm:"not null"`
+	OriginalName string          `json:"original_name"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type ErasureStatus string
+
+const (
+	ErasureStatusPending   ErasureStatus = "pending"
+	ErasureStatusCompleted ErasureStatus = "completed"
+	ErasureStatusRejected  ErasureStatus = "rejected"
+)
+
+// ErasureRequest asks to erase a customer's personal data. Staff complete it once the customer has
+// no loan or investment in progress, or reject it with a reason. Financial records are kept.
+type ErasureRequest struct {
+	ID              uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID     `json:"user_id" gorm:"type:uuid;not null;index"`
+	RequestedByID   uuid.UUID     `json:"requested_by_id" gorm:"type:uuid;not null"` // The customer, or staff filing on their behalf
+	Status          ErasureStatus `json:"status" gorm:"not null;default:'pending';index"`
+	ProcessedByID   *uuid.UUID    `json:"processed_by_id,omitempty" gorm:"type:uuid"`
+	ProcessedAt     *time.Time    `json:"processed_at,omitempty"`
+	RejectionReason string        `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time     `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+
+	// Relations
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
 type LoanState string
 
 const (
@@ -64,6 +420,7 @@ const (
 	LoanStateApproved  LoanState = "approved"
 	LoanStateInvested  LoanState = "invested"
 	LoanStateDisbursed LoanState = "disbursed"
+	LoanStateRejected  LoanState = "rejected"
 )
 
 type Loan struct {
@@ -72,42 +429,186 @@ type Loan struct {
 	PrincipalAmount     float64   `json:"principal_amount" gorm:"not null"`
 	InvestedAmount      float64   `json:"invested_amount" gorm:"default:0"`
 	RemainingInvestment float64   `json:"remaining_investment" gorm:"not null"`
-	Rate                float64   `json:"rate" gorm:"not null"`           // Interest rate for borrower
-	ROI                 float64   `json:"roi" gorm:"not null"`            // Return on investment for investors (calculated)
-	TotalInterest       float64   `json:"total_interest" gorm:"not null"` // Total interest borrower must pay
+	ReservedAmount      float64   `json:"reserved_amount" gorm:"default:0"` // Held by active investment holds
+	Rate                float64   `json:"rate" gorm:"not null"`             // Interest rate for borrower
+	ROI                 float64   `json:"roi" gorm:"not null"`              // Return on investment for investors (calculated)
+	TotalInterest       float64   `json:"total_interest" gorm:"not null"`   // Total interest borrower must pay
+	TenorMonths         int       `json:"tenor_months" gorm:"not null;default:12"`
 	State               LoanState `json:"state" gorm:"not null;default:'proposed'"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 
 	// Relations
-	Borrower     Borrower      `json:"borrower" gorm:"foreignKey:BorrowerID"`
-	Approval     *Approval     `json:"approval,omitempty"`
-	Investments  []Investment  `json:"investments,omitempty"`
-	Disbursement *Disbursement `json:"disbursement,omitempty"`
+	Borrower       Borrower        `json:"borrower" gorm:"foreignKey:BorrowerID"`
+	Approval       *Approval       `json:"approval,omitempty"`
+	ApprovalStages []ApprovalStage `json:"approval_stages,omitempty"`
+	Investments    []Investment    `json:"investments,omitempty"`
+	Disbursement   *Disbursement   `json:"disbursement,omitempty"`
+}
+
+// AvailableForInvestment returns the amount that can still be reserved by new investment holds
+func (l *Loan) AvailableForInvestment() float64 {
+	return l.RemainingInvestment - l.ReservedAmount
 }
 
 type Approval struct {
-	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	LoanID        uuid.UUID `json:"loan_id" gorm:"not null"`
-	ValidatorID   uuid.UUID `json:"validator_id" gorm:"not null"`
-	PhotoProofURL string    `json:"photo_proof_url" gorm:"not null"`
-	ApprovalDate  time.Time `json:"approval_date" gorm:"not null"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID        uuid.UUID  `json:"loan_id" gorm:"not null"`
+	ValidatorID   uuid.UUID  `json:"validator_id" gorm:"not null"`
+	PhotoProofID  *uuid.UUID `json:"photo_proof_id,omitempty" gorm:"type:uuid"`
+	PhotoProofURL string     `json:"photo_proof_url" gorm:"not null"` // Download path of the stored photo proof
+	ApprovalDate  time.Time  `json:"approval_date" gorm:"not null"`
+
+	// Field visit evidence as reported by the validator's device
+	Latitude       float64       `json:"latitude"`
+	Longitude      float64       `json:"longitude"`
+	CapturedAt     time.Time     `json:"captured_at"`
+	DistanceMeters *float64      `json:"distance_meters,omitempty"` // From the borrower's registered address, nil when it has no coordinates
+	EvidenceFlags  EvidenceFlags `json:"evidence_flags" gorm:"type:text"`
+	NeedsReview    bool          `json:"needs_review" gorm:"not null;default:false;index"`
+
+	CreatedAt time.Time `json:"created_at"`
 
 	// Relations
-	Loan      Loan `json:"loan" gorm:"foreignKey:LoanID"`
-	Validator User `json:"validator" gorm:"foreignKey:ValidatorID"`
+	Loan       Loan        `json:"loan" gorm:"foreignKey:LoanID"`
+	Validator  User        `json:"validator" gorm:"foreignKey:ValidatorID"`
+	PhotoProof *PhotoProof `json:"photo_proof,omitempty" gorm:"foreignKey:PhotoProofID"`
+}
+
+// PhotoProof is an image uploaded by a field validator as evidence of a borrower visit
+type PhotoProof struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID       uuid.UUID `json:"loan_id" gorm:"not null;index"`
+	UploaderID   uuid.UUID `json:"uploader_id" gorm:"not null"`
+	StorageKey   string    `json:"-" gorm:"not null;uniqueIndex"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	SHA256       string    `json:"sha256" gorm:"not null;index"`
+	OriginalName string    `json:"original_name"`
+
+	// Metadata embedded in the image by the camera, nil when absent
+	ExifLatitude   *float64   `json:"exif_latitude,omitempty"`
+	ExifLongitude  *float64   `json:"exif_longitude,omitempty"`
+	ExifCapturedAt *time.Time `json:"exif_captured_at,omitempty"`
+	ExifTimeZoned  bool       `json:"-"` // False when the camera recorded local time without an offset
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EvidenceFlag names a reason field visit evidence needs a closer look
+type EvidenceFlag string
+
+const (
+	EvidenceFlagTooFar               EvidenceFlag = "too_far"
+	EvidenceFlagTooOld               EvidenceFlag = "too_old"
+	EvidenceFlagCapturedInFuture     EvidenceFlag = "captured_in_future"
+	EvidenceFlagBorrowerLocation     EvidenceFlag = "borrower_location_unknown"
+	EvidenceFlagExifMissing          EvidenceFlag = "exif_missing"
+	EvidenceFlagExifLocationMismatch EvidenceFlag = "exif_location_mismatch"
+	EvidenceFlagExifTimeMismatch     EvidenceFlag = "exif_time_mismatch"
+)
+
+// EvidenceFlags is stored as a comma separated list
+type EvidenceFlags []EvidenceFlag
+
+func (f EvidenceFlags) Value() (driver.Value, error) {
+	values := make([]string, len(f))
+	for i, flag := range f {
+		values[i] = string(flag)
+	}
+	return strings.Join(values, ","), nil
+}
+
+func (f *EvidenceFlags) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		raw = ""
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EvidenceFlags", value)
+	}
+
+	*f = nil
+	for _, part := range strings.Split(raw, ",") {
+		if part != "" {
+			*f = append(*f, EvidenceFlag(part))
+		}
+	}
+	return nil
+}
+
+type ApprovalStageType string
+
+const (
+	ApprovalStageFieldVerification ApprovalStageType = "field_verification"
+	ApprovalStageCreditReview      ApprovalStageType = "credit_review"
+	ApprovalStageCommitteeSignoff  ApprovalStageType = "committee_signoff"
+)
+
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApproved ApprovalDecision = "approved"
+	ApprovalDecisionRejected ApprovalDecision = "rejected"
+)
+
+// ApprovalStage records one actor's decision for a stage of the loan approval workflow
+type ApprovalStage struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID    uuid.UUID         `json:"loan_id" gorm:"not null;index"`
+	Stage     ApprovalStageType `json:"stage" gorm:"not null"`
+	ActorID   uuid.UUID         `json:"actor_id" gorm:"not null"`
+	ActorRole UserRole          `json:"actor_role" gorm:"not null"`
+	Decision  ApprovalDecision  `json:"decision" gorm:"not null"`
+	Comment   string            `json:"comment"`
+	DecidedAt time.Time         `json:"decided_at" gorm:"not null"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	// Relations
+	Actor User `json:"actor" gorm:"foreignKey:ActorID"`
+}
+
+// StageRequirement is an approval stage a loan has to pass and how many distinct approvers it needs
+type StageRequirement struct {
+	Stage     ApprovalStageType
+	Approvals int
+}
+
+// StageApprovers counts the distinct actors that approved the stage
+func StageApprovers(stages []ApprovalStage, stageType ApprovalStageType) int {
+	approvers := make(map[uuid.UUID]struct{})
+	for _, stage := range stages {
+		if stage.Stage == stageType && stage.Decision == ApprovalDecisionApproved {
+			approvers[stage.ActorID] = struct{}{}
+		}
+	}
+	return len(approvers)
+}
+
+// StagesPassed reports whether the recorded decisions satisfy every requirement
+func StagesPassed(stages []ApprovalStage, required []StageRequirement) bool {
+	for _, requirement := range required {
+		if StageApprovers(stages, requirement.Stage) < requirement.Approvals {
+			return false
+		}
+	}
+	return true
 }
 
 type Investment struct {
-	ID                 uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	LoanID             uuid.UUID `json:"loan_id" gorm:"not null"`
-	InvestorID         uuid.UUID `json:"investor_id" gorm:"not null"`
-	Amount             float64   `json:"amount" gorm:"not null"`
-	Status             string    `json:"status" gorm:"default:'pending'"` // pending, completed, failed
-	AgreementLetterURL string    `json:"agreement_letter_url"`            // PDF link for the investor
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID              uuid.UUID  `json:"loan_id" gorm:"not null"`
+	InvestorID          uuid.UUID  `json:"investor_id" gorm:"not null"`
+	Amount              float64    `json:"amount" gorm:"not null"`
+	Status              string     `json:"status" gorm:"default:'pending'"` // pending, completed, failed, cancelled
+	AgreementLetterURL  string     `json:"agreement_letter_url"`            // Document link for the investor
+	AgreementDocumentID *uuid.UUID `json:"agreement_document_id,omitempty" gorm:"type:uuid"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 
 	// Relations
 	Loan     Loan     `json:"loan" gorm:"foreignKey:LoanID"`
@@ -115,23 +616,280 @@ type Investment struct {
 }
 
 type Disbursement struct {
-	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	LoanID           uuid.UUID `json:"loan_id" gorm:"not null"`
-	OfficerID        uuid.UUID `json:"officer_id" gorm:"not null"`
-	AgreementFileURL string    `json:"agreement_file_url" gorm:"not null"`
-	DisbursementDate time.Time `json:"disbursement_date" gorm:"not null"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID              uuid.UUID  `json:"loan_id" gorm:"not null"`
+	OfficerID           uuid.UUID  `json:"officer_id" gorm:"not null"`
+	AgreementDocumentID *uuid.UUID `json:"agreement_document_id,omitempty" gorm:"type:uuid"`
+	AgreementFileURL    string     `json:"agreement_file_url" gorm:"not null"` // Document link of the signed agreement
+	DisbursementDate    time.Time  `json:"disbursement_date" gorm:"not null"`
+	CreatedAt           time.Time  `json:"created_at"`
+
+	// Relations
+	Loan              Loan      `json:"loan" gorm:"foreignKey:LoanID"`
+	Officer           User      `json:"officer" gorm:"foreignKey:OfficerID"`
+	AgreementDocument *Document `json:"agreement_document,omitempty" gorm:"foreignKey:AgreementDocumentID"`
+}
+
+type DocumentType string
+
+const (
+	DocumentTypeInvestorAgreement     DocumentType = "investor_agreement"
+	DocumentTypeDisbursementAgreement DocumentType = "disbursement_agreement"
+	DocumentTypeBorrowerAgreement     DocumentType = "borrower_agreement"
+)
+
+// Document is a stored file attached to a loan. Documents with an owner are only visible to
+// that user and staff, the others to the loan's borrower and staff.
+type Document struct {
+	ID              uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID          uuid.UUID    `json:"loan_id" gorm:"not null;index"`
+	Type            DocumentType `json:"type" gorm:"not null"`
+	OwnerUserID     *uuid.UUID   `json:"owner_user_id,omitempty" gorm:"type:uuid;index"`
+	FileName        string       `json:"file_name" gorm:"not null"`
+	StorageKey      string       `json:"-" gorm:"not null;uniqueIndex"`
+	ContentType     string       `json:"content_type" gorm:"not null"`
+	Size            int64        `json:"size" gorm:"not null"`
+	SHA256          string       `json:"sha256" gorm:"not null"`
+	TemplateVersion string       `json:"template_version,omitempty"`            // Template a generated document was rendered from
+	CreatedBy       *uuid.UUID   `json:"created_by,omitempty" gorm:"type:uuid"` // Nil for system generated documents
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// AgreementSignature records a borrower's electronic signature of an agreement document. The
+// document hash is captured at signing time so the signature only covers that exact file.
+type AgreementSignature struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID         uuid.UUID `json:"loan_id" gorm:"not null;index"`
+	DocumentID     uuid.UUID `json:"document_id" gorm:"type:uuid;not null;uniqueIndex"`
+	SignerUserID   uuid.UUID `json:"signer_user_id" gorm:"type:uuid;not null"`
+	DocumentSHA256 string    `json:"document_sha256" gorm:"not null"`
+	SignedName     string    `json:"signed_name" gorm:"not null"` // Full name typed by the borrower
+	ConsentText    string    `json:"consent_text" gorm:"not null"`
+	IPAddress      string    `json:"ip_address" gorm:"not null"`
+	UserAgent      string    `json:"user_agent"`
+	SignedAt       time.Time `json:"signed_at" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SignatureOTP is a one-time code sent to the borrower to confirm signing a document
+type SignatureOTP struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DocumentID uuid.UUID  `json:"document_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	CodeHash   string     `json:"-" gorm:"not null"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type TaskStatus string
+
+const (
+	TaskStatusOpen      TaskStatus = "open"
+	TaskStatusCompleted TaskStatus = "completed"
+)
+
+// VerificationTask assigns the field verification of a proposed loan to one validator.
+// Reassignment moves the same task to another validator with a fresh due date.
+type VerificationTask struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID            uuid.UUID  `json:"loan_id" gorm:"type:uuid;not null;uniqueIndex"`
+	AssigneeID        *uuid.UUID `json:"assignee_id" gorm:"type:uuid;index"` // Nil while no validator can take the task over
+	Region            string     `json:"region"`
+	Status            TaskStatus `json:"status" gorm:"not null;default:'open';index"`
+	DueAt             time.Time  `json:"due_at" gorm:"not null;index"`
+	AssignedAt        time.Time  `json:"assigned_at" gorm:"not null"`
+	ReassignmentCount int        `json:"reassignment_count" gorm:"not null;default:0"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 
 	// Relations
-	Loan    Loan `json:"loan" gorm:"foreignKey:LoanID"`
-	Officer User `json:"officer" gorm:"foreignKey:OfficerID"`
+	Loan     Loan  `json:"loan" gorm:"foreignKey:LoanID"`
+	Assignee *User `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID"`
+}
+
+// IsOverdue reports whether an open task has passed its due date
+func (t *VerificationTask) IsOverdue(now time.Time) bool {
+	return t.Status == TaskStatusOpen && now.After(t.DueAt)
+}
+
+// IsAssignedTo reports whether the task is assigned to the user
+func (t *VerificationTask) IsAssignedTo(userID uuid.UUID) bool {
+	return t.AssigneeID != nil && *t.AssigneeID == userID
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive    HoldStatus = "active"
+	HoldStatusConverted HoldStatus = "converted"
+	HoldStatusReleased  HoldStatus = "released"
+	HoldStatusExpired   HoldStatus = "expired"
+)
+
+// InvestmentHold reserves part of a loan's remaining amount for an investor until
+// the investment event is processed or the hold expires
+type InvestmentHold struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID       uuid.UUID  `json:"loan_id" gorm:"not null;index"`
+	InvestorID   uuid.UUID  `json:"investor_id" gorm:"not null"`
+	Amount       float64    `json:"amount" gorm:"not null"`
+	Status       HoldStatus `json:"status" gorm:"not null;default:'active';index"`
+	InvestmentID *uuid.UUID `json:"investment_id,omitempty" gorm:"type:uuid"` // Set once converted
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Loan     Loan     `json:"loan" gorm:"foreignKey:LoanID"`
+	Investor Investor `json:"investor" gorm:"foreignKey:InvestorID"`
+}
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusConverted WaitlistStatus = "converted"
+	WaitlistStatusCancelled WaitlistStatus = "cancelled"
+)
+
+// WaitlistEntry queues an investor for a loan without free capacity; entries are served in FIFO order
+type WaitlistEntry struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LoanID     uuid.UUID      `json:"loan_id" gorm:"not null;index"`
+	InvestorID uuid.UUID      `json:"investor_id" gorm:"not null;index"`
+	Amount     float64        `json:"amount" gorm:"not null"`
+	Status     WaitlistStatus `json:"status" gorm:"not null;default:'waiting';index"`
+	HoldID     *uuid.UUID     `json:"hold_id,omitempty" gorm:"type:uuid"` // Set once converted into an investment request
+	Position   int            `json:"position,omitempty" gorm:"-"`        // 1-based place in the queue, computed on read
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+
+	// Relations
+	Loan     Loan     `json:"loan" gorm:"foreignKey:LoanID"`
+	Investor Investor `json:"investor" gorm:"foreignKey:InvestorID"`
+}
+
+// LoanAction is a step of the loan lifecycle performed by a staff member
+type LoanAction string
+
+const (
+	LoanActionFieldVerification LoanAction = "field_verification"
+	LoanActionCreditReview      LoanAction = "credit_review"
+	LoanActionCommitteeSignoff  LoanAction = "committee_signoff"
+	LoanActionDisbursement      LoanAction = "disbursement"
+)
+
+type RelationshipType string
+
+const (
+	RelationshipFamily   RelationshipType = "family"
+	RelationshipBusiness RelationshipType = "business"
+	RelationshipPersonal RelationshipType = "personal"
+	RelationshipOther    RelationshipType = "other"
+)
+
+// StaffRelationship is a conflict of interest a staff member declared with a borrower
+type StaffRelationship struct {
+	ID           uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StaffUserID  uuid.UUID        `json:"staff_user_id" gorm:"not null;uniqueIndex:idx_staff_borrower"`
+	BorrowerID   uuid.UUID        `json:"borrower_id" gorm:"not null;uniqueIndex:idx_staff_borrower;index"`
+	Relationship RelationshipType `json:"relationship" gorm:"not null"`
+	Note         string           `json:"note,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeAllowed AuditOutcome = "allowed"
+	AuditOutcomeDenied  AuditOutcome = "denied"
+)
+
+// AuditEntry records a security relevant action and its outcome, entries are never updated
+type AuditEntry struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ActorID    *uuid.UUID   `json:"actor_id,omitempty" gorm:"type:uuid;index"`
+	Action     string       `json:"action" gorm:"not null;index"`
+	EntityType string       `json:"entity_type" gorm:"not null"`
+	EntityID   *uuid.UUID   `json:"entity_id,omitempty" gorm:"type:uuid;index"`
+	Outcome    AuditOutcome `json:"outcome" gorm:"not null"`
+	Reason     string       `json:"reason,omitempty"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
 }
 
 // Investment event for Kafka
 type InvestmentEvent struct {
 	ID         uuid.UUID `json:"id"`
+	HoldID     uuid.UUID `json:"hold_id,omitempty"` // Reservation to convert, uuid.Nil for legacy events
 	LoanID     uuid.UUID `json:"loan_id"`
 	InvestorID uuid.UUID `json:"investor_id"`
 	Amount     float64   `json:"amount"`
 	Timestamp  time.Time `json:"timestamp"`
 }
+
+// FullyFundedLoanEvent is published once a loan has received its full principal.
+// It carries no borrower or investor data, which stays encrypted in their profiles.
+type FullyFundedLoanEvent struct {
+	ID              uuid.UUID `json:"id"`
+	LoanID          uuid.UUID `json:"loan_id"`
+	BorrowerID      uuid.UUID `json:"borrower_id"`
+	PrincipalAmount float64   `json:"principal_amount"`
+	InvestedAmount  float64   `json:"invested_amount"`
+	Rate            float64   `json:"rate"`
+	ROI             float64   `json:"roi"`
+	TotalInterest   float64   `json:"total_interest"`
+	TenorMonths     int       `json:"tenor_months"`
+	FundedAt        time.Time `json:"funded_at"`
+}
+
+// OutboxEventType decides the Kafka topic an outbox event is published to
+type OutboxEventType string
+
+const (
+	OutboxEventLoanFullyFunded OutboxEventType = "loan.fully_funded"
+)
+
+// OutboxEvent is an event stored in the same transaction as the change it describes.
+// The outbox relay publishes it to Kafka until the broker accepts it, so it is delivered at least once.
+type OutboxEvent struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"` // Sent as the event_id header, consumers use it to skip duplicates
+	Type          OutboxEventType `json:"type" gorm:"not null"`
+	Key           string          `json:"key" gorm:"not null"` // Message key, events of the same aggregate stay in one partition
+	Payload       string          `json:"payload" gorm:"type:jsonb;not null"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null;index"`
+	LastError     string          `json:"last_error,omitempty"`
+	SentAt        *time.Time      `json:"sent_at,omitempty" gorm:"index"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// NewFullyFundedLoanEvent returns the pending outbox event announcing that the loan is fully funded
+func NewFullyFundedLoanEvent(loan *Loan, now time.Time) (*OutboxEvent, error) {
+	id := uuid.New()
+	payload, err := json.Marshal(FullyFundedLoanEvent{
+		ID:              id,
+		LoanID:          loan.ID,
+		BorrowerID:      loan.BorrowerID,
+		PrincipalAmount: loan.PrincipalAmount,
+		InvestedAmount:  loan.InvestedAmount,
+		Rate:            loan.Rate,
+		ROI:             loan.ROI,
+		TotalInterest:   loan.TotalInterest,
+		TenorMonths:     loan.TenorMonths,
+		FundedAt:        now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		ID:            id,
+		Type:          OutboxEventLoanFullyFunded,
+		Key:           loan.ID.String(),
+		Payload:       string(payload),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -36,6 +37,9 @@ func TestUserRoles(t *testing.T) {
 	assert.Equal(t, UserRole("investor"), RoleInvestor)
 	assert.Equal(t, UserRole("field_officer"), RoleFieldOfficer)
 	assert.Equal(t, UserRole("field_validator"), RoleFieldValidator)
+	assert.Equal(t, UserRole("credit_analyst"), RoleCreditAnalyst)
+	assert.Equal(t, UserRole("credit_committee"), RoleCreditCommittee)
+	assert.Equal(t, UserRole("admin"), RoleAdmin)
 }
 
 // Test Loan States
@@ -45,6 +49,7 @@ func TestLoanStates(t *testing.T) {
 	assert.Equal(t, LoanState("approved"), LoanStateApproved)
 	assert.Equal(t, LoanState("invested"), LoanStateInvested)
 	assert.Equal(t, LoanState("disbursed"), LoanStateDisbursed)
+	assert.Equal(t, LoanState("rejected"), LoanStateRejected)
 }
 
 // Test Loan Entity Creation
@@ -175,6 +180,38 @@ func TestInvestmentEvent_Creation(t *testing.T) {
 	assert.Equal(t, timestamp, event.Timestamp)
 }
 
+// Test Fully Funded Loan Event - Pending Outbox Event Without Personal Data
+func TestNewFullyFundedLoanEvent(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	loan := &Loan{
+		ID:              uuid.New(),
+		BorrowerID:      uuid.New(),
+		PrincipalAmount: 100000.0,
+		InvestedAmount:  100000.0,
+		State:           LoanStateInvested,
+		Borrower:        Borrower{FullName: "John Doe", IdentityNumber: "3171234567890001"},
+	}
+
+	// Act
+	event, err := NewFullyFundedLoanEvent(loan, now)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, OutboxEventLoanFullyFunded, event.Type)
+	assert.Equal(t, loan.ID.String(), event.Key)
+	assert.Equal(t, now, event.NextAttemptAt)
+	assert.Nil(t, event.SentAt)
+
+	var payload FullyFundedLoanEvent
+	assert.NoError(t, json.Unmarshal([]byte(event.Payload), &payload))
+	assert.Equal(t, event.ID, payload.ID)
+	assert.Equal(t, loan.ID, payload.LoanID)
+	assert.Equal(t, 100000.0, payload.InvestedAmount)
+	assert.NotContains(t, event.Payload, "John Doe")
+	assert.NotContains(t, event.Payload, "3171234567890001")
+}
+
 // Test Login Response Creation
 func TestLoginResponse_Creation(t *testing.T) {
 	// Arrange & Act
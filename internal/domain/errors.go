@@ -1,15 +1,80 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	// Auth errors
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrEmailExists        = errors.New("email already exists")
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserAlreadyExists   = errors.New("user already exists")
+	ErrEmailExists         = errors.New("email already exists")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrAccountDeactivated  = errors.New("account is deactivated")
+	ErrLoginThrottled      = errors.New("too many failed login attempts")
+	ErrNoSigningKey        = errors.New("no active token signing key")
+
+	// Registration errors
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrVerificationTokenExpired = errors.New("verification token has expired")
+
+	// KYC errors
+	ErrKYCNotVerified         = errors.New("identity verification (KYC) is not completed")
+	ErrKYCPending             = errors.New("identity verification is already awaiting review")
+	ErrKYCAlreadyVerified     = errors.New("identity is already verified")
+	ErrKYCSubmissionNotFound  = errors.New("KYC submission not found")
+	ErrKYCDocumentNotFound    = errors.New("KYC document not found")
+	ErrKYCAlreadyReviewed     = errors.New("KYC submission has already been reviewed")
+	ErrRejectionReasonMissing = errors.New("a reason is required to reject a KYC submission")
+
+	// Personal data erasure errors
+	ErrErasureRequestNotFound = errors.New("erasure request not found")
+	ErrErasurePending         = errors.New("an erasure request is already awaiting processing")
+	ErrErasureProcessed       = errors.New("erasure request has already been processed")
+	ErrErasureBlocked         = errors.New("personal data cannot be erased while a loan or investment is in progress")
+	ErrAlreadyErased          = errors.New("personal data of the user is already erased")
+	ErrErasureReasonMissing   = errors.New("a reason is required to reject an erasure request")
+
+	// Password errors
+	ErrWeakPassword              = errors.New("password does not meet the password policy")
+	ErrPasswordUnchanged         = errors.New("new password must differ from the current password")
+	ErrInvalidPasswordResetToken = errors.New("invalid password reset token")
+	ErrPasswordResetTokenExpired = errors.New("password reset token has expired")
+
+	// Two-factor authentication errors
+	ErrInvalidTwoFactorCode       = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorChallenge  = errors.New("two-factor challenge is invalid or expired, please log in again")
+	ErrTwoFactorAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled        = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp          = errors.New("two-factor authentication has not been set up, request a new secret first")
+	ErrTwoFactorMandatory         = errors.New("two-factor authentication is mandatory for this role")
+	ErrTwoFactorEnrolmentRequired = errors.New("two-factor authentication must be set up, please log in again")
+
+	// Single sign-on errors
+	ErrSingleSignOnDisabled = errors.New("single sign-on is not enabled")
+	ErrSingleSignOnRequired = errors.New("staff accounts sign in through single sign-on")
+	ErrInvalidSingleSignOn  = errors.New("sign-on request is invalid or expired, please start again")
+	ErrSingleSignOnFailed   = errors.New("sign-on with the identity provider failed")
+	ErrNoRoleForGroups      = errors.New("none of the identity provider groups grants a staff role")
+	ErrSingleSignOnConflict = errors.New("the email address belongs to an account that cannot use single sign-on")
+
+	// User administration errors
+	ErrSelfAdministration = errors.New("administrators cannot deactivate, change the role or reset two-factor authentication of their own account")
+
+	// Service account errors
+	ErrServiceAccount        = errors.New("service accounts authenticate with API keys only")
+	ErrNotServiceAccount     = errors.New("user is not a service account")
+	ErrInvalidServiceAccount = errors.New("service account names use 3 to 50 lower case letters, digits and dashes")
+	ErrServiceAccountExists  = errors.New("a service account with this name already exists")
+	ErrInvalidAPIKey         = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound        = errors.New("API key not found")
+	ErrInvalidScope          = errors.New("API key scopes must be permissions of the service account's role")
+	ErrInvalidAPIKeyExpiry   = errors.New("API key expiry is outside the allowed lifetime")
 
 	// Loan errors
 	ErrLoanNotFound         = errors.New("loan not found")
@@ -19,13 +84,82 @@ var (
 	ErrLoanNotInvested      = errors.New("loan is not fully invested yet")
 	ErrLoanAlreadyDisbursed = errors.New("loan is already disbursed")
 	ErrInvalidLoanState     = errors.New("invalid loan state for this operation")
+	ErrLoanRejected         = errors.New("loan has been rejected")
+
+	// Approval workflow errors
+	ErrApprovalStageOutOfOrder  = errors.New("previous approval stage has not passed yet")
+	ErrApprovalStageCompleted   = errors.New("approval stage is already completed")
+	ErrApprovalStageNotRequired = errors.New("approval stage is not required for this loan")
+	ErrInvalidApprovalDecision  = errors.New("invalid approval decision")
+
+	// File upload errors
+	ErrFileTooLarge         = errors.New("file exceeds the maximum allowed size")
+	ErrUnsupportedFileType  = errors.New("file type is not supported")
+	ErrEmptyFile            = errors.New("file is empty")
+	ErrStoredObjectNotFound = errors.New("stored object not found")
+	ErrPhotoProofNotFound   = errors.New("photo proof not found")
+
+	// Document errors
+	ErrDocumentNotFound         = errors.New("document not found")
+	ErrInvalidDocumentType      = errors.New("invalid document type for this operation")
+	ErrInvalidDownloadSignature = errors.New("invalid download signature")
+	ErrDownloadURLExpired       = errors.New("download link has expired")
+
+	// Agreement signing errors
+	ErrAgreementNotSigned       = errors.New("borrower has not signed the loan agreement")
+	ErrAgreementAlreadySigned   = errors.New("loan agreement is already signed")
+	ErrSignatureConsentRequired = errors.New("explicit consent is required to sign the agreement")
+	ErrSignatureNameMismatch    = errors.New("typed name does not match the borrower's name")
+	ErrInvalidOTP               = errors.New("invalid one-time code")
+	ErrOTPExpired               = errors.New("one-time code has expired")
+	ErrOTPAttemptsExceeded      = errors.New("too many attempts for this one-time code")
+	ErrOTPRateLimited           = errors.New("too many one-time codes requested, try again later")
+
+	// Verification task errors
+	ErrTaskNotFound         = errors.New("verification task not found")
+	ErrTaskNotAssigned      = errors.New("loan verification is not assigned to this validator")
+	ErrTaskCompleted        = errors.New("verification task is already completed")
+	ErrNoValidatorAvailable = errors.New("no field validator available for assignment")
+	ErrInvalidTaskAssignee  = errors.New("assignee must be a field validator")
+
+	// Segregation of duties errors
+	ErrSegregationOfDuties = errors.New("action conflicts with the segregation of duties policy")
+	ErrBorrowerNotFound    = errors.New("borrower not found")
+	ErrRelationshipExists  = errors.New("relationship with this borrower is already declared")
 
 	// Investment errors
 	ErrInvestmentExceedsLimit  = errors.New("investment amount exceeds remaining loan amount")
 	ErrInvalidInvestmentAmount = errors.New("investment amount must be greater than 0")
 	ErrSelfInvestment          = errors.New("borrower cannot invest in their own loan")
+	ErrInvestmentNotFound      = errors.New("investment not found")
+	ErrCoolingOffEnded         = errors.New("investment can no longer be cancelled")
+
+	// Investment hold errors
+	ErrHoldNotFound  = errors.New("investment hold not found")
+	ErrHoldExpired   = errors.New("investment hold has expired")
+	ErrHoldNotActive = errors.New("investment hold is no longer active")
+
+	// Waitlist errors
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrAlreadyOnWaitlist     = errors.New("investor is already on the waitlist for this loan")
+	ErrWaitlistNotNeeded     = errors.New("loan still has capacity for this amount, invest directly")
 
 	// Permission errors
 	ErrInsufficientPermission = errors.New("insufficient permission for this operation")
 	ErrInvalidRole            = errors.New("invalid role for this operation")
 )
+
+// LoginThrottledError refuses a login attempt until RetryAt, after repeated failures for the
+// account or from the client. It matches ErrLoginThrottled with errors.Is.
+type LoginThrottledError struct {
+	RetryAt       time.Time
+	AccountLocked bool // The account reached the lockout threshold, rather than a short delay
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrLoginThrottled.Error()
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrLoginThrottled
+}
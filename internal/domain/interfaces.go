@@ -2,40 +2,378 @@ package domain
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// JSONWebKey is the public half of a signing key as published in the JWKS (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP or EC curve
+	X         string `json:"x,omitempty"`   // OKP public key or EC x coordinate
+	Y         string `json:"y,omitempty"`   // EC y coordinate
+}
+
+// JSONWebKeySet lists the keys that verify access tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
 // LoginResponse represents the response returned after a successful login
 type LoginResponse struct {
-	UserID    uuid.UUID `json:"user_id"`
-	Email     string    `json:"email"`
-	Token     string    `json:"token"`
+	UserID           uuid.UUID `json:"user_id"`
+	Email            string    `json:"email"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	// RecoveryCodes are shown once, when two-factor authentication is enabled during a login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// TwoFactor is set instead of the tokens when the login needs a second step
+	TwoFactor *TwoFactorPrompt `json:"-"`
+}
+
+// TwoFactorPrompt asks the client to complete a login with a code, or to set up two-factor
+// authentication first when the user's role requires it and they have not enrolled yet
+type TwoFactorPrompt struct {
+	ChallengeToken    string
+	EnrolmentRequired bool
+	ExpiresAt         time.Time
+}
+
+// TwoFactorEnrolment is a new authenticator secret, confirmed by the first code generated from it
+type TwoFactorEnrolment struct {
+	Secret          string
+	ProvisioningURI string // otpauth:// URI, rendered as a QR code by the client
+}
+
+// LoginInput is a sign-in attempt and the client it came from, recorded in the login history
+type LoginInput struct {
+	Email     string
+	Password  string
+	IPAddress string
+	UserAgent string
+}
+
+// SingleSignOnStart is where to send the user to sign in at the identity provider
+type SingleSignOnStart struct {
+	AuthorizationURL string
+	State            string // Bound to the browser by the caller, e.g. in a cookie, and checked at the callback
+	ExpiresAt        time.Time
+}
+
+// SingleSignOnCallback is the redirect back from the identity provider
+type SingleSignOnCallback struct {
+	Code      string
+	State     string
+	Error     string // Set by the identity provider instead of a code when the sign-in failed
+	IPAddress string
+	UserAgent string
+}
+
+// ExternalIdentity is the user described by a verified ID token
+type ExternalIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// ServiceAccountInput is a machine client created by an administrator
+type ServiceAccountInput struct {
+	Name string // Identifies the client, e.g. the partner or batch job
+	Role UserRole
+}
+
+// APIKeyInput describes a key issued to a service account
+type APIKeyInput struct {
+	Name   string
+	Scopes []Permission
+	TTL    time.Duration // Zero uses the configured default lifetime
+}
+
+// IssuedAPIKey is a new key together with its secret value, which is never available again
+type IssuedAPIKey struct {
+	Key    string
+	APIKey *APIKey
+}
+
+// StaffUserInput is an employee account created by an administrator
+type StaffUserInput struct {
+	Email    string
+	Password string
+	Role     UserRole
+	Region   string
+	Branch   string
+}
+
+// RegistrationInput is the account and profile a borrower or investor submits when signing up
+type RegistrationInput struct {
+	Email          string
+	Password       string
+	FullName       string
+	PhoneNumber    string
+	Address        string
+	IdentityNumber string
+	Region         string   // Borrowers only
+	Latitude       *float64 // Borrowers only, geocoded address field visits are checked against
+	Longitude      *float64
+}
+
+// KYCUpload is an identity document image a customer submits for verification
+type KYCUpload struct {
+	FileName string
+	Data     []byte
+}
+
+// KYCReview is a submission together with the profile details its documents are checked against
+type KYCReview struct {
+	Submission     *KYCSubmission
+	FullName       string
+	IdentityNumber string
+}
+
+// PersonalDataArchive is a zip file of JSON files with everything stored about a user, together
+// with the user's documents and identity images
+type PersonalDataArchive struct {
+	FileName string
+	Data     []byte
+}
+
+// PersonalDataErasure is the anonymized account and profile that replace a customer's personal data
+type PersonalDataErasure struct {
+	Request  *ErasureRequest
+	User     *User
+	Borrower *Borrower // Nil unless the customer is a borrower
+	Investor *Investor // Nil unless the customer is an investor
+}
+
+// DocumentDownload is a short lived signed link to a stored document
+type DocumentDownload struct {
+	Document  *Document `json:"document"`
+	URL       string    `json:"url"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// BorrowerAgreement is the generated borrower agreement of a loan and its signature, if signed
+type BorrowerAgreement struct {
+	Document  *Document           `json:"document"`
+	Signature *AgreementSignature `json:"signature,omitempty"`
+}
+
+// SignAgreementInput carries what the borrower submits, and the request details recorded, when signing
+type SignAgreementInput struct {
+	TypedName string
+	Consent   bool
+	OTP       string
+	IPAddress string
+	UserAgent string
+}
+
+// FieldEvidence is where and when the validator's device captured the field visit photo
+type FieldEvidence struct {
+	Latitude   float64
+	Longitude  float64
+	CapturedAt time.Time
+}
+
+// ImageMetadata is the capture location and time embedded in an image, fields are nil when absent
+type ImageMetadata struct {
+	Latitude   *float64
+	Longitude  *float64
+	CapturedAt *time.Time
+	// CapturedAtZoned is false when the camera recorded local time without an offset,
+	// CapturedAt then holds that wall clock time in UTC
+	CapturedAtZoned bool
+}
+
+// TextDocument is a printable document made of headed sections of paragraphs
+type TextDocument struct {
+	Title    string
+	Sections []TextSection
+}
+
+type TextSection struct {
+	Heading string
+	Lines   []string
+}
+
 // Repository interfaces for clean architecture
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	GetByExternalSubject(ctx context.Context, subject string) (*User, error)
+	GetByRole(ctx context.Context, role UserRole) ([]User, error) // Active staff only, deactivated and service accounts are left out
+	// List returns a page of users, filtered by role unless it is empty, and the total count
+	List(ctx context.Context, role UserRole, limit, offset int) ([]User, int64, error)
+	GetServiceAccounts(ctx context.Context) ([]User, error)
 	Update(ctx context.Context, user *User) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// IncrementFailedLogins adds a failed sign-in atomically and returns the new count
+	IncrementFailedLogins(ctx context.Context, id uuid.UUID) (int, error)
+	SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error
+	// ResetFailedLogins clears the failure count and any lock
+	ResetFailedLogins(ctx context.Context, id uuid.UUID) error
+}
+
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *LoginEvent) error
+	// GetByUserID returns the most recent attempts first, all of them when limit is 0
+	GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]LoginEvent, error)
+	// Search returns the most recent attempts matching the filter first
+	Search(ctx context.Context, filter LoginEventFilter, limit int) ([]LoginEvent, error)
+	// FailureStatsByIP counts the failed attempts from an address since a time, limited to the given reasons
+	FailureStatsByIP(ctx context.Context, ipAddress string, reasons []string, since time.Time) (*LoginFailureStats, error)
+}
+
+// LoginEventFilter narrows the login history for a security review, empty fields match everything
+type LoginEventFilter struct {
+	IPAddress  string
+	Email      string
+	FailedOnly bool
+}
+
+type TwoFactorChallengeRepository interface {
+	Create(ctx context.Context, challenge *TwoFactorChallenge) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*TwoFactorChallenge, error)
+	RecordFailedAttempt(ctx context.Context, id uuid.UUID) error
+	// MarkUsed completes an open challenge, returning false if it was already used
+	MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+type SingleSignOnStateRepository interface {
+	Create(ctx context.Context, state *SingleSignOnState) error
+	GetByStateHash(ctx context.Context, stateHash string) (*SingleSignOnState, error)
+	// MarkUsed consumes an open sign-on, returning false if it was already used
+	MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// GetByUserID returns the keys of a service account, newest first
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	// Revoke revokes an active key, returning false if it was already revoked
+	Revoke(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// Rotate stores the successor of a key and, in the same transaction, links it to the key and shortens the
+	// key's lifetime to expiresAt. It returns false and stores nothing if the key was revoked or already rotated.
+	Rotate(ctx context.Context, id uuid.UUID, successor *APIKey, expiresAt time.Time) (bool, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, now time.Time) error
+}
+
+type RecoveryCodeRepository interface {
+	// ReplaceForUser drops every code of the user and stores the new set
+	ReplaceForUser(ctx context.Context, userID uuid.UUID, codes []RecoveryCode) error
+	// MarkUsed consumes an unused code of the user, returning false if there is none with this hash
+	MarkUsed(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
 }
 
 type BorrowerRepository interface {
 	Create(ctx context.Context, borrower *Borrower) error
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*Borrower, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Borrower, error)
+	GetByIdentityNumber(ctx context.Context, identityNumber string) (*Borrower, error)
+	// CreateWithUser creates borrower.User and the borrower profile in one transaction
+	CreateWithUser(ctx context.Context, borrower *Borrower) error
 	Update(ctx context.Context, borrower *Borrower) error
 }
 
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// Rotate revokes an active token in favour of its successor and stores the successor in the same transaction.
+	// It returns false and stores nothing if the token was no longer active.
+	Rotate(ctx context.Context, id uuid.UUID, successor *RefreshToken, now time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error
+	RevokeByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error
+}
+
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, token *RevokedToken) error
+	IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+}
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *SigningKey) error
+	// GetRetiringAfter returns the keys that retire after the given time, including keys not active yet
+	GetRetiringAfter(ctx context.Context, after time.Time) ([]SigningKey, error)
+	DeleteRetiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *EmailVerificationToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*EmailVerificationToken, error)
+	Update(ctx context.Context, token *EmailVerificationToken) error
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	// MarkUsed consumes an unused token, returning false if it was already used
+	MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// InvalidateByUserID consumes every outstanding token of the user
+	InvalidateByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error
+}
+
+type StaffRelationshipRepository interface {
+	Create(ctx context.Context, relationship *StaffRelationship) error
+	Exists(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID) (bool, error)
+	GetByStaffUserID(ctx context.Context, staffUserID uuid.UUID) ([]StaffRelationship, error)
+}
+
+// AuditRepository is append only
+type AuditRepository interface {
+	Create(ctx context.Context, entry *AuditEntry) error
+}
+
+type KYCSubmissionRepository interface {
+	// Create saves the submission together with its documents
+	Create(ctx context.Context, submission *KYCSubmission) error
+	GetByID(ctx context.Context, id uuid.UUID) (*KYCSubmission, error)
+	// GetLatestByUserID returns the user's most recent submission
+	GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*KYCSubmission, error)
+	// ListByStatus returns a page of submissions in the status, oldest first, and the total count
+	ListByStatus(ctx context.Context, status KYCStatus, limit, offset int) ([]KYCSubmission, int64, error)
+	// ListByUserID returns all submissions of the user, oldest first
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]KYCSubmission, error)
+	// Decide records the review of a pending submission, and marks the user verified when it passed,
+	// in one transaction. It returns false if the submission was already reviewed.
+	Decide(ctx context.Context, submission *KYCSubmission) (bool, error)
+}
+
+type ErasureRequestRepository interface {
+	Create(ctx context.Context, request *ErasureRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*ErasureRequest, error)
+	// GetLatestByUserID returns the user's most recent request
+	GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*ErasureRequest, error)
+	// ListByStatus returns a page of requests in the status, oldest first, and the total count
+	ListByStatus(ctx context.Context, status ErasureStatus, limit, offset int) ([]ErasureRequest, int64, error)
+	// Reject records the rejection of a pending request. It returns false if the request was already processed.
+	Reject(ctx context.Context, request *ErasureRequest) (bool, error)
+	// Complete marks a pending request completed and, in the same transaction, overwrites the account
+	// and profile, deletes the identity images, anonymizes the login history, two-factor challenges and signatures,
+	// cancels the investor's waitlist entries and ends every session.
+	// It returns false if the request was already processed.
+	Complete(ctx context.Context, erasure *PersonalDataErasure) (bool, error)
+}
+
 type InvestorRepository interface {
 	Create(ctx context.Context, investor *Investor) error
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*Investor, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Investor, error)
+	GetByIdentityNumber(ctx context.Context, identityNumber string) (*Investor, error)
+	// CreateWithUser creates investor.User and the investor profile in one transaction
+	CreateWithUser(ctx context.Context, investor *Investor) error
 	Update(ctx context.Context, investor *Investor) error
 }
 
@@ -52,6 +390,21 @@ type LoanRepository interface {
 type ApprovalRepository interface {
 	Create(ctx context.Context, approval *Approval) error
 	GetByLoanID(ctx context.Context, loanID uuid.UUID) (*Approval, error)
+	// GetNeedingReview returns field verifications whose evidence was flagged, newest first
+	GetNeedingReview(ctx context.Context) ([]Approval, error)
+}
+
+type PhotoProofRepository interface {
+	Create(ctx context.Context, proof *PhotoProof) error
+	GetByID(ctx context.Context, id uuid.UUID) (*PhotoProof, error)
+}
+
+type ApprovalStageRepository interface {
+	Create(ctx context.Context, stage *ApprovalStage) error
+	// RecordDecision stores the stage, and the field verification approval when given, against the locked
+	// loan and moves it to rejected or, once every requirement has passed, to approved. Returns the loan state.
+	RecordDecision(ctx context.Context, stage *ApprovalStage, approval *Approval, required []StageRequirement) (LoanState, error)
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]ApprovalStage, error)
 }
 
 type InvestmentRepository interface {
@@ -60,10 +413,87 @@ type InvestmentRepository interface {
 	GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]Investment, error)
 	GetTotalInvestedAmount(ctx context.Context, loanID uuid.UUID) (float64, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
-	UpdateAgreementLetterURL(ctx context.Context, id uuid.UUID, url string) error
-	CreateWithTx(ctx context.Context, investment *Investment, loan *Loan) error // Transaction method
+	UpdateAgreementLetter(ctx context.Context, id uuid.UUID, documentID uuid.UUID, url string) error
+	CreateWithTx(ctx context.Context, investment *Investment, loan *Loan) error // Transaction method, records the fully funded event when the loan is invested
 	// New method that handles locking + transaction atomically
 	CreateInvestmentWithLoanLock(ctx context.Context, investment *Investment, loanID uuid.UUID) (*Loan, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Investment, error)
+	// CancelWithLoanLock cancels a completed investment and gives its amount back to the locked loan,
+	// an invested loan is open for funding again afterwards
+	CancelWithLoanLock(ctx context.Context, id uuid.UUID) (*Loan, error)
+	// GetLoanIDsPendingAgreementLetter returns funded loans with a completed investment whose agreement letter
+	// has not been delivered yet
+	GetLoanIDsPendingAgreementLetter(ctx context.Context, limit int) ([]uuid.UUID, error)
+}
+
+type InvestmentHoldRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*InvestmentHold, error)
+	// CreateWithLoanLock locks the loan, validates capacity and reserves the hold amount atomically
+	CreateWithLoanLock(ctx context.Context, hold *InvestmentHold) (*Loan, error)
+	// ConvertToInvestment turns an active hold into a completed investment in a single transaction,
+	// recording the fully funded event in the outbox when it completes the loan
+	ConvertToInvestment(ctx context.Context, holdID uuid.UUID, investment *Investment) (*Loan, error)
+	// Release returns the reserved amount of an active hold to the loan and marks it with status
+	Release(ctx context.Context, holdID uuid.UUID, status HoldStatus) error
+	GetExpired(ctx context.Context, now time.Time, limit int) ([]InvestmentHold, error)
+	// GetActiveByInvestorID returns the investor's holds that are still reserving an amount
+	GetActiveByInvestorID(ctx context.Context, investorID uuid.UUID) ([]InvestmentHold, error)
+}
+
+// OutboxRepository stores events written with the state change they describe until the relay has sent them.
+// Events are appended inside the repositories' own transactions, this interface serves the relay.
+type OutboxRepository interface {
+	// ClaimDue locks due unsent events, oldest first, skipping those claimed by another relay, and moves
+	// their next attempt to now plus lease so an event is not published twice while it is being sent
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OutboxEvent, error)
+	MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	// MarkFailed records a failed attempt and when the event is tried again
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type WaitlistRepository interface {
+	Create(ctx context.Context, entry *WaitlistEntry) error
+	GetActiveByLoanAndInvestor(ctx context.Context, loanID, investorID uuid.UUID) (*WaitlistEntry, error)
+	GetWaitingByLoanID(ctx context.Context, loanID uuid.UUID) ([]WaitlistEntry, error) // FIFO ordered
+	GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]WaitlistEntry, error)
+	CountAhead(ctx context.Context, entry *WaitlistEntry) (int64, error)
+	// MarkConverted flips a waiting entry to converted, returning false if it was no longer waiting
+	MarkConverted(ctx context.Context, id uuid.UUID, holdID uuid.UUID) (bool, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status WaitlistStatus) error
+	// CancelWaitingByLoanID cancels the entries still waiting for the loan
+	CancelWaitingByLoanID(ctx context.Context, loanID uuid.UUID) (int64, error)
+}
+
+type DocumentRepository interface {
+	Create(ctx context.Context, document *Document) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Document, error)
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]Document, error)
+	GetLatestByLoanAndType(ctx context.Context, loanID uuid.UUID, docType DocumentType) (*Document, error)
+}
+
+type AgreementSignatureRepository interface {
+	Create(ctx context.Context, signature *AgreementSignature) error
+	GetByDocumentID(ctx context.Context, documentID uuid.UUID) (*AgreementSignature, error)
+	CreateOTP(ctx context.Context, otp *SignatureOTP) error
+	GetLatestOTP(ctx context.Context, documentID uuid.UUID, userID uuid.UUID) (*SignatureOTP, error)
+	// CountOTPsSince returns how many codes were issued to the user for the document since the given time
+	CountOTPsSince(ctx context.Context, documentID uuid.UUID, userID uuid.UUID, since time.Time) (int64, error)
+	// RecordOTPAttempt counts an attempt against the code unless maxAttempts were already made and reports whether it did
+	RecordOTPAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (bool, error)
+	// ConsumeOTP marks an unused code used and reports whether it did
+	ConsumeOTP(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error)
+}
+
+type VerificationTaskRepository interface {
+	Create(ctx context.Context, task *VerificationTask) error
+	GetByID(ctx context.Context, id uuid.UUID) (*VerificationTask, error)
+	GetByLoanID(ctx context.Context, loanID uuid.UUID) (*VerificationTask, error)
+	GetByAssigneeID(ctx context.Context, assigneeID uuid.UUID, status TaskStatus) ([]VerificationTask, error) // Ordered by due date
+	GetOverdue(ctx context.Context, now time.Time, limit int) ([]VerificationTask, error)                     // Includes open tasks without an assignee
+	// CountOpenByAssignee returns the number of open tasks per assignee, users without open tasks are omitted
+	CountOpenByAssignee(ctx context.Context, assigneeIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	Update(ctx context.Context, task *VerificationTask) error
 }
 
 type DisbursementRepository interface {
@@ -74,35 +504,307 @@ type DisbursementRepository interface {
 // Service interfaces
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string) (*LoginResponse, error)
+	// Login records every attempt in the login history, deactivated accounts get ErrAccountDeactivated.
+	// Repeated failures for the account or from the client are refused with a *LoginThrottledError.
+	Login(ctx context.Context, input LoginInput) (*LoginResponse, error)
 	ValidateToken(tokenString string) (*User, error)
+	// Refresh rotates a refresh token and issues a new access token, a replayed token revokes its whole session
+	Refresh(ctx context.Context, refreshToken string) (*LoginResponse, error)
+	// Logout revokes the access token and the session of the refresh token
+	Logout(ctx context.Context, accessToken string, refreshToken string) error
+	// RevokeAllSessions invalidates every access and refresh token issued to the user so far
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+	// RegisterBorrower creates an unverified borrower account and emails a verification link
+	RegisterBorrower(ctx context.Context, input RegistrationInput) (*Borrower, error)
+	// RegisterInvestor creates an unverified investor account and emails a verification link
+	RegisterInvestor(ctx context.Context, input RegistrationInput) (*Investor, error)
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerification issues a new link, it does nothing for unknown or already verified addresses
+	ResendVerification(ctx context.Context, email string) error
+	// ForgotPassword emails a single use reset link, it does nothing for unknown or deactivated accounts
+	ForgotPassword(ctx context.Context, email string) error
+	// ResetPassword sets a new password with a reset token and ends every session of the user
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	// ChangePassword replaces the password of a signed in user, ends their other sessions and issues new tokens
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword string, newPassword string) (*LoginResponse, error)
+	// VerifyTwoFactor completes a login challenge with an authenticator or recovery code
+	VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (*LoginResponse, error)
+	// SetupTwoFactor generates a new authenticator secret for a signed in user
+	SetupTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactorEnrolment, error)
+	// ActivateTwoFactor enables two-factor authentication with a first code and returns the recovery codes
+	ActivateTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// SetupTwoFactorWithChallenge generates a secret for a user whose login is waiting for a mandatory enrolment
+	SetupTwoFactorWithChallenge(ctx context.Context, challengeToken string) (*TwoFactorEnrolment, error)
+	// ActivateTwoFactorWithChallenge enables two-factor authentication and completes the login with recovery codes
+	ActivateTwoFactorWithChallenge(ctx context.Context, challengeToken string, code string) (*LoginResponse, error)
+	// RegenerateRecoveryCodes replaces every recovery code after checking a current code
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// DisableTwoFactor turns two-factor authentication off, refused for roles that require it
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error
+	// ResetTwoFactor removes the authenticator and recovery codes of a user who lost both and ends their sessions
+	ResetTwoFactor(ctx context.Context, userID uuid.UUID) error
+	// StartSingleSignOn begins a staff login at the identity provider
+	StartSingleSignOn(ctx context.Context) (*SingleSignOnStart, error)
+	// CompleteSingleSignOn finishes a staff login, provisioning or linking the account and taking its role from the groups
+	CompleteSingleSignOn(ctx context.Context, callback SingleSignOnCallback) (*LoginResponse, error)
+}
+
+// TokenKeyService holds the asymmetric keys that sign and verify access tokens
+type TokenKeyService interface {
+	// SigningKey returns the key new access tokens are signed with
+	SigningKey(ctx context.Context) (*SigningKey, error)
+	// VerificationKey returns the key with the given kid while tokens it signed can still be valid
+	VerificationKey(ctx context.Context, kid string) (*SigningKey, error)
+	// JWKS returns every key a verifier should accept, the next key is published before it signs
+	JWKS(ctx context.Context) (*JSONWebKeySet, error)
+	// Rotate creates the next key ahead of the current key's retirement and removes keys nothing can verify with anymore
+	Rotate(ctx context.Context) error
+}
+
+// UserAdminService lets administrators manage accounts, every change is written to the audit log
+type UserAdminService interface {
+	ListUsers(ctx context.Context, role UserRole, limit, offset int) ([]User, int64, error)
+	CreateStaffUser(ctx context.Context, actorID uuid.UUID, input StaffUserInput) (*User, error)
+	// DeactivateUser blocks sign-in and ends every session of the user
+	DeactivateUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*User, error)
+	// ChangeRole moves an employee to another staff role, customer accounts keep their role
+	ChangeRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, role UserRole) (*User, error)
+	// ResetPassword replaces the password with a generated one, returned only here, and ends every session
+	ResetPassword(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (string, error)
+	// ResetTwoFactor removes the user's authenticator so they can enrol again, and ends every session
+	ResetTwoFactor(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error
+	// UnlockUser lifts a lockout after failed sign-ins and clears the failure count
+	UnlockUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*User, error)
+	// SearchLoginEvents returns recent sign-in attempts across all accounts, e.g. from one address
+	SearchLoginEvents(ctx context.Context, actorID uuid.UUID, filter LoginEventFilter, limit int) ([]LoginEvent, error)
+	GetLoginHistory(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, limit int) ([]LoginEvent, error)
+}
+
+// ServiceAccountService manages machine clients and their API keys, every change is written to the audit log
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, actorID uuid.UUID, input ServiceAccountInput) (*User, error)
+	ListServiceAccounts(ctx context.Context) ([]User, error)
+	// IssueAPIKey returns the key value, which is shown only once
+	IssueAPIKey(ctx context.Context, actorID uuid.UUID, serviceAccountID uuid.UUID, input APIKeyInput) (*IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]APIKey, error)
+	// RotateAPIKey issues a successor with the same name and scopes, the old key keeps working for the rotation grace period
+	RotateAPIKey(ctx context.Context, actorID uuid.UUID, keyID uuid.UUID) (*IssuedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, actorID uuid.UUID, keyID uuid.UUID) error
+	// Authenticate returns the service account of an active key, limited to the key's scopes
+	Authenticate(ctx context.Context, key string) (*User, *APIKey, error)
 }
 
 type LoanService interface {
-	CreateLoan(ctx context.Context, borrowerID uuid.UUID, principalAmount, rate float64) (*Loan, error)
-	ApproveLoan(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID, photoProofURL string, approvalDate time.Time) error
+	CreateLoan(ctx context.Context, borrowerID uuid.UUID, principalAmount, rate float64, tenorMonths int) (*Loan, error)
+	ApproveLoan(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID, photoProofID uuid.UUID, approvalDate time.Time, evidence FieldEvidence) error
 	GetLoansByState(ctx context.Context, state LoanState) ([]Loan, error)
 	GetLoanByID(ctx context.Context, id uuid.UUID) (*Loan, error)
 	GetBorrowerLoans(ctx context.Context, borrowerID uuid.UUID) ([]Loan, error)
 	GetBorrowerLoansByUserID(ctx context.Context, userID uuid.UUID) ([]Loan, error)
-	DisburseLoan(ctx context.Context, loanID uuid.UUID, officerID uuid.UUID, agreementFileURL string, disbursementDate time.Time) error
+	DisburseLoan(ctx context.Context, loanID uuid.UUID, officerID uuid.UUID, agreementDocumentID uuid.UUID, disbursementDate time.Time) error
+	SubmitCreditReview(ctx context.Context, loanID uuid.UUID, analystID uuid.UUID, decision ApprovalDecision, comment string) error
+	SubmitCommitteeDecision(ctx context.Context, loanID uuid.UUID, memberID uuid.UUID, decision ApprovalDecision, comment string) error
+	GetApprovalStages(ctx context.Context, loanID uuid.UUID) ([]ApprovalStage, error)
+	GetFlaggedApprovals(ctx context.Context) ([]Approval, error)
+}
+
+type TaskService interface {
+	// CreateVerificationTask assigns a new loan to the least loaded validator of the region
+	CreateVerificationTask(ctx context.Context, loanID uuid.UUID, region string) (*VerificationTask, error)
+	GetMyTasks(ctx context.Context, userID uuid.UUID, status TaskStatus) ([]VerificationTask, error)
+	// ReassignTask hands an open task to assigneeID, or to the least loaded other validator when nil
+	ReassignTask(ctx context.Context, actorID uuid.UUID, taskID uuid.UUID, assigneeID *uuid.UUID) (*VerificationTask, error)
+	// EnsureAssignee returns ErrTaskNotAssigned unless validatorID holds the open task of the loan
+	EnsureAssignee(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID) error
+	CompleteVerificationTask(ctx context.Context, loanID uuid.UUID) error
+	// HandOffTasks takes every open task off a validator who can no longer verify loans, returning how many
+	HandOffTasks(ctx context.Context, assigneeID uuid.UUID) (int, error)
+	// SweepTasks assigns proposed loans that have no task and reassigns overdue tasks
+	SweepTasks(ctx context.Context) (assigned int, reassigned int, err error)
+}
+
+// PermissionPolicy resolves what a role may do from the configured role to permission mapping.
+// The route middleware and the services share one policy so a role is defined in one place.
+type PermissionPolicy interface {
+	Can(role UserRole, permission Permission) bool
+	// Allows reports whether the user's role grants the permission and, for a request made with
+	// an API key, the key's scopes include it
+	Allows(user *User, permission Permission) bool
+	// Require returns ErrInsufficientPermission unless Allows grants the permission
+	Require(user *User, permission Permission) error
+}
+
+// SegregationService enforces the segregation of duties policy between loan lifecycle actors
+type SegregationService interface {
+	// CheckActor returns ErrSegregationOfDuties, and audits the refusal, when actorID may not perform action on the loan
+	CheckActor(ctx context.Context, loan *Loan, action LoanAction, actorID uuid.UUID) error
+	// Permits reports whether actorID may perform action on the loan without auditing, for picking candidates
+	Permits(ctx context.Context, loan *Loan, action LoanAction, actorID uuid.UUID) (bool, error)
+	DeclareRelationship(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID, relationship RelationshipType, note string) (*StaffRelationship, error)
+	GetDeclaredRelationships(ctx context.Context, staffUserID uuid.UUID) ([]StaffRelationship, error)
+}
+
+type PhotoProofService interface {
+	// UploadPhotoProof validates and stores an image for a loan awaiting field verification
+	UploadPhotoProof(ctx context.Context, loanID uuid.UUID, uploaderID uuid.UUID, filename string, data []byte) (*PhotoProof, error)
+	GetPhotoProof(ctx context.Context, loanID uuid.UUID, proofID uuid.UUID) (*PhotoProof, io.ReadCloser, error)
+}
+
+// KYCService verifies the identity of customers, who cannot borrow or invest before they pass
+type KYCService interface {
+	// SubmitKYC stores the customer's ID card and selfie and queues them for review
+	SubmitKYC(ctx context.Context, userID uuid.UUID, idCard KYCUpload, selfie KYCUpload) (*KYCSubmission, error)
+	// GetMyKYC returns the customer's most recent submission
+	GetMyKYC(ctx context.Context, userID uuid.UUID) (*KYCSubmission, error)
+	// GetReviewQueue returns a page of submissions in the status, oldest first, and the total count
+	GetReviewQueue(ctx context.Context, status KYCStatus, limit, offset int) ([]KYCSubmission, int64, error)
+	GetReview(ctx context.Context, submissionID uuid.UUID) (*KYCReview, error)
+	GetKYCDocument(ctx context.Context, submissionID uuid.UUID, documentID uuid.UUID) (*KYCDocument, io.ReadCloser, error)
+	ApproveKYC(ctx context.Context, reviewerID uuid.UUID, submissionID uuid.UUID) (*KYCSubmission, error)
+	RejectKYC(ctx context.Context, reviewerID uuid.UUID, submissionID uuid.UUID, reason string) (*KYCSubmission, error)
+}
+
+// PrivacyService answers data subject requests of customers, every export and erasure is written to the audit log
+type PrivacyService interface {
+	// ExportPersonalData builds an archive of the user's profile, loans, investments, approvals,
+	// documents, identity verifications and login history
+	ExportPersonalData(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*PersonalDataArchive, error)
+	// RequestErasure queues the erasure of a customer's personal data, filed by the customer or by staff
+	RequestErasure(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*ErasureRequest, error)
+	// GetMyErasureRequest returns the customer's most recent request
+	GetMyErasureRequest(ctx context.Context, userID uuid.UUID) (*ErasureRequest, error)
+	// ListErasureRequests returns a page of requests in the status, oldest first, and the total count
+	ListErasureRequests(ctx context.Context, status ErasureStatus, limit, offset int) ([]ErasureRequest, int64, error)
+	// ApproveErasure anonymizes the customer's personal data, loans, investments and agreements are kept
+	ApproveErasure(ctx context.Context, actorID uuid.UUID, requestID uuid.UUID) (*ErasureRequest, error)
+	RejectErasure(ctx context.Context, actorID uuid.UUID, requestID uuid.UUID, reason string) (*ErasureRequest, error)
+}
+
+type DocumentService interface {
+	// StoreDocument hashes the content, writes it to file storage and saves the document metadata
+	StoreDocument(ctx context.Context, document *Document, data []byte) error
+	UploadDocument(ctx context.Context, loanID uuid.UUID, uploaderID uuid.UUID, docType DocumentType, filename string, data []byte) (*Document, error)
+	GetDocument(ctx context.Context, user *User, id uuid.UUID) (*Document, error)
+	GetLoanDocuments(ctx context.Context, user *User, loanID uuid.UUID) ([]Document, error)
+	// IssueDownloadURL checks access for user and returns an expiring signed download link
+	IssueDownloadURL(ctx context.Context, user *User, id uuid.UUID) (*DocumentDownload, error)
+	// OpenSignedDownload verifies a signed link, the caller must close the reader
+	OpenSignedDownload(ctx context.Context, id uuid.UUID, expires int64, signature string) (*Document, io.ReadCloser, error)
+	// DocumentURL is the stable API link to a document, used in emails and entity references
+	DocumentURL(id uuid.UUID) string
+}
+
+type AgreementService interface {
+	// GenerateBorrowerAgreement renders and stores the borrower agreement of an invested loan, once
+	GenerateBorrowerAgreement(ctx context.Context, loanID uuid.UUID) (*Document, error)
+	GetBorrowerAgreement(ctx context.Context, user *User, loanID uuid.UUID) (*BorrowerAgreement, error)
+	// RequestSigningOTP sends the borrower a one-time code for signing and returns when it expires
+	RequestSigningOTP(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) (time.Time, error)
+	SignBorrowerAgreement(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, input SignAgreementInput) (*AgreementSignature, error)
 }
 
 type InvestmentService interface {
-	RequestInvestment(ctx context.Context, investorID uuid.UUID, loanID uuid.UUID, amount float64) error // Just validate and publish
-	ProcessInvestment(ctx context.Context, event InvestmentEvent) error                                  // Consumer logic
+	RequestInvestment(ctx context.Context, investorID uuid.UUID, loanID uuid.UUID, amount float64) (*InvestmentHold, error) // Reserve and publish
+	ProcessInvestment(ctx context.Context, event InvestmentEvent) error                                                     // Consumer logic
+	GetHold(ctx context.Context, userID uuid.UUID, holdID uuid.UUID) (*InvestmentHold, error)
+	// CancelInvestment withdraws the investor's investment within the cooling-off period, the amount goes to the waitlist
+	CancelInvestment(ctx context.Context, userID uuid.UUID, investmentID uuid.UUID) (*Investment, error)
+	ReleaseExpiredHolds(ctx context.Context) (int, error)
+	// RetryAgreementLetters delivers the agreement letters that failed when their loan was fully funded
+	RetryAgreementLetters(ctx context.Context) (int, error)
 	GetInvestorInvestments(ctx context.Context, investorID uuid.UUID) ([]Investment, error)
 	GetInvestorInvestmentsByUserID(ctx context.Context, userID uuid.UUID) ([]Investment, error)
 	GetLoanInvestments(ctx context.Context, loanID uuid.UUID) ([]Investment, error)
 }
 
+// OutboxService publishes the events of the transactional outbox
+type OutboxService interface {
+	// RelayDue publishes due events, returning how many were sent; failed ones are retried later with backoff
+	RelayDue(ctx context.Context) (int, error)
+	// PurgeSent removes events sent longer ago than the retention
+	PurgeSent(ctx context.Context) (int64, error)
+}
+
+type WaitlistService interface {
+	JoinWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, amount float64) (*WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) error
+	GetMyWaitlist(ctx context.Context, userID uuid.UUID) ([]WaitlistEntry, error)
+	// ProcessWaitlist converts waiting entries that fit the loan's capacity into investment requests
+	ProcessWaitlist(ctx context.Context, loanID uuid.UUID) error
+}
+
 type NotificationService interface {
 	SendAgreementLetters(ctx context.Context, loanID uuid.UUID) error
+	NotifyWaitlistPromoted(ctx context.Context, entry *WaitlistEntry, hold *InvestmentHold) error
+	SendSigningOTP(ctx context.Context, borrower *Borrower, loanID uuid.UUID, code string, expiresAt time.Time) error
+	SendEmailVerification(ctx context.Context, user *User, link string, expiresAt time.Time) error
+	NotifyKYCDecision(ctx context.Context, submission *KYCSubmission) error
+}
+
+// EmailSender delivers transactional emails that must reach the user's inbox
+type EmailSender interface {
+	SendPasswordReset(ctx context.Context, to string, link string, expiresAt time.Time) error
+}
+
+// IdentityProvider is the corporate OpenID Connect provider staff sign in with
+type IdentityProvider interface {
+	// AuthorizationURL returns where to send the user, with the PKCE challenge (S256) of the code verifier
+	AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+	// Exchange redeems an authorization code and returns the identity from the validated ID token
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*ExternalIdentity, error)
+}
+
+// FileStorage stores binary objects such as uploaded photos under opaque keys
+type FileStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// KeyProvider holds the key encryption keys that wrap the data key of every encrypted field
+type KeyProvider interface {
+	// ActiveKeyID names the key new data keys are wrapped with
+	ActiveKeyID() string
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	// BlindIndexKey is the HMAC key of blind indexes, it is kept when the wrapping keys rotate
+	BlindIndexKey() []byte
+}
+
+// FieldLocation is the table, column and row an encrypted value is stored in. Values are bound to their
+// location, so one copied to another row or column cannot be decrypted there.
+type FieldLocation struct {
+	Table  string
+	Column string
+	RowID  string
+}
+
+// FieldCipher encrypts personal data before it is written to the database
+type FieldCipher interface {
+	Encrypt(ctx context.Context, plaintext string, location FieldLocation) (string, error)
+	// Decrypt returns stored values written before encryption was enabled unchanged, and reads values
+	// encrypted before they were bound to a location wherever they are stored
+	Decrypt(ctx context.Context, stored string, location FieldLocation) (string, error)
+	// IsCurrent reports whether a stored value is encrypted under the active key and bound to its location
+	IsCurrent(stored string) bool
+	// BlindIndex is a keyed hash of the value, equal values have equal indexes so they can be looked up
+	BlindIndex(value string) string
+}
+
+// ImageMetadataReader extracts capture metadata such as EXIF GPS and time from an image
+type ImageMetadataReader interface {
+	Read(data []byte) (*ImageMetadata, error)
+}
+
+// PDFRenderer turns a text document into a PDF file
+type PDFRenderer interface {
+	Render(doc *TextDocument) ([]byte, error)
 }
 
 type KafkaProducer interface {
 	PublishInvestmentEvent(ctx context.Context, event InvestmentEvent) error
-	PublishFullyFundedLoan(ctx context.Context, loan *Loan) error
+	// PublishOutboxEvent sends a stored event to the topic of its type
+	PublishOutboxEvent(ctx context.Context, event *OutboxEvent) error
 }
 
 type InvestmentConsumer interface {
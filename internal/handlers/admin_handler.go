@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// loginHistoryLimit is the number of most recent sign-in attempts returned to administrators
+const loginHistoryLimit = 100
+
+type AdminHandler struct {
+	userAdminService domain.UserAdminService
+}
+
+func NewAdminHandler(userAdminService domain.UserAdminService) *AdminHandler {
+	return &AdminHandler{
+		userAdminService: userAdminService,
+	}
+}
+
+// ListUsers returns a page of accounts, optionally filtered by role
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var filter UsersFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	offset, limit := GetOffsetAndLimit(filter.Page, filter.PageSize)
+	users, total, err := h.userAdminService.ListUsers(c.Request.Context(), filter.Role, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedSuccessResponse(MapAdminUsersToResponse(users), CalculatePagination(filter.Page, filter.PageSize, total)))
+}
+
+// CreateUser creates an employee account, customers register themselves
+func (h *AdminHandler) CreateUser(c *gin.Context) {
+	var req CreateStaffUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	created, err := h.userAdminService.CreateStaffUser(c.Request.Context(), userObj.ID, domain.StaffUserInput{
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     req.Role,
+		Region:   req.Region,
+		Branch:   req.Branch,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrWeakPassword):
+			respondWeakPassword(c, err)
+		case err == domain.ErrEmailExists:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "account_exists",
+				Message: "An account with this email already exists",
+			})
+		case err == domain.ErrInvalidRole:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_role",
+				Message: "Only staff roles can be assigned to accounts created by an administrator",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "internal_error",
+				Message: "Failed to create user",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("User created", MapAdminUserToResponse(created)))
+}
+
+// DeactivateUser blocks an account from signing in and ends its sessions
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	deactivated, err := h.userAdminService.DeactivateUser(c.Request.Context(), userObj.ID, userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to deactivate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("User deactivated", MapAdminUserToResponse(deactivated)))
+}
+
+// ChangeRole moves an employee to another staff role
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	updated, err := h.userAdminService.ChangeRole(c.Request.Context(), userObj.ID, userID, req.Role)
+	if err != nil {
+		h.respondError(c, err, "Failed to change role")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Role changed", MapAdminUserToResponse(updated)))
+}
+
+// ResetPassword replaces the user's password with a generated temporary one
+func (h *AdminHandler) ResetPassword(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	temporaryPassword, err := h.userAdminService.ResetPassword(c.Request.Context(), userObj.ID, userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Password reset, all sessions of the user were ended", PasswordResetResponse{
+		UserID:            userID,
+		TemporaryPassword: temporaryPassword,
+	}))
+}
+
+// ResetTwoFactor removes the authenticator of a user who lost it, they enrol again at the next login
+func (h *AdminHandler) ResetTwoFactor(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	if err := h.userAdminService.ResetTwoFactor(c.Request.Context(), userObj.ID, userID); err != nil {
+		h.respondError(c, err, "Failed to reset two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Two-factor authentication reset, all sessions of the user were ended", nil))
+}
+
+// UnlockUser lifts a lockout after failed sign-ins before it runs out
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	unlocked, err := h.userAdminService.UnlockUser(c.Request.Context(), userObj.ID, userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to unlock user")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("User unlocked", MapAdminUserToResponse(unlocked)))
+}
+
+// GetLoginHistory returns the most recent sign-in attempts of a user
+func (h *AdminHandler) GetLoginHistory(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	events, err := h.userAdminService.GetLoginHistory(c.Request.Context(), userObj.ID, userID, loginHistoryLimit)
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch login history")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapLoginEventsToResponse(events)))
+}
+
+// SearchLoginEvents returns the most recent sign-in attempts across all accounts, e.g. from one address
+func (h *AdminHandler) SearchLoginEvents(c *gin.Context) {
+	var filter LoginEventsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	events, err := h.userAdminService.SearchLoginEvents(c.Request.Context(), userObj.ID, domain.LoginEventFilter{
+		IPAddress:  filter.IPAddress,
+		Email:      filter.Email,
+		FailedOnly: filter.FailedOnly,
+	}, loginHistoryLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Failed to fetch login events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapLoginEventsToResponse(events)))
+}
+
+// respondError maps the errors shared by the actions on an existing account
+func (h *AdminHandler) respondError(c *gin.Context, err error, failureMessage string) {
+	switch err {
+	case domain.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "user_not_found",
+			Message: "The specified user was not found",
+		})
+	case domain.ErrSelfAdministration:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "self_administration",
+			Message: err.Error(),
+		})
+	case domain.ErrInvalidRole:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_role",
+			Message: "Customer accounts cannot change role and staff cannot become customers",
+		})
+	case domain.ErrAccountDeactivated:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "account_deactivated",
+			Message: "The account is already deactivated",
+		})
+	case domain.ErrServiceAccount:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "service_account",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: failureMessage,
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type AgreementHandler struct {
+	agreementService domain.AgreementService
+}
+
+func NewAgreementHandler(agreementService domain.AgreementService) *AgreementHandler {
+	return &AgreementHandler{
+		agreementService: agreementService,
+	}
+}
+
+// GetBorrowerAgreement returns the loan's borrower agreement and whether it has been signed
+func (h *AgreementHandler) GetBorrowerAgreement(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	agreement, err := h.agreementService.GetBorrowerAgreement(c.Request.Context(), userObj, loanID)
+	if err != nil {
+		h.handleAgreementError(c, err, "Failed to fetch borrower agreement")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapBorrowerAgreementToResponse(agreement)))
+}
+
+// RequestSigningOTP sends the borrower a one-time code to confirm the signature with
+func (h *AgreementHandler) RequestSigningOTP(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	expiresAt, err := h.agreementService.RequestSigningOTP(c.Request.Context(), userObj.ID, loanID)
+	if err != nil {
+		h.handleAgreementError(c, err, "Failed to send signing code")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Signing code sent", SigningOTPResponse{ExpiresAt: expiresAt}))
+}
+
+// SignAgreement records the borrower's signature of the loan agreement
+func (h *AgreementHandler) SignAgreement(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	var req SignAgreementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	signature, err := h.agreementService.SignBorrowerAgreement(c.Request.Context(), userObj.ID, loanID, domain.SignAgreementInput{
+		TypedName: req.TypedName,
+		Consent:   req.Consent,
+		OTP:       req.OTP,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleAgreementError(c, err, "Failed to sign borrower agreement")
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Agreement signed", MapAgreementSignatureToResponse(signature)))
+}
+
+// handleAgreementError maps agreement and signing errors to responses, fallback is used for unexpected errors
+func (h *AgreementHandler) handleAgreementError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case domain.ErrLoanNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "loan_not_found",
+			Message: "The specified loan was not found",
+		})
+	case domain.ErrDocumentNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "agreement_not_found",
+			Message: "No borrower agreement has been generated for this loan yet",
+		})
+	case domain.ErrInsufficientPermission:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "forbidden",
+			Message: "You do not have access to this agreement",
+		})
+	case domain.ErrLoanNotInvested:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_loan_state",
+			Message: "Agreements can only be signed for fully funded loans",
+		})
+	case domain.ErrAgreementAlreadySigned:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "already_signed",
+			Message: err.Error(),
+		})
+	case domain.ErrSignatureConsentRequired, domain.ErrSignatureNameMismatch:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_signature",
+			Message: err.Error(),
+		})
+	case domain.ErrInvalidOTP, domain.ErrOTPExpired, domain.ErrOTPAttemptsExceeded:
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Success: false,
+			Error:   "invalid_otp",
+			Message: err.Error(),
+		})
+	case domain.ErrOTPRateLimited:
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Success: false,
+			Error:   "too_many_codes",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: fallback,
+		})
+	}
+}
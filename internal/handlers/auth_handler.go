@@ -1,12 +1,24 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
+const (
+	// singleSignOnStateCookie carries the state of a sign-on from its start to the callback
+	singleSignOnStateCookie = "amf_sso_state"
+	singleSignOnCookiePath  = "/api/auth/oidc"
+)
+
 type AuthHandler struct {
 	authService domain.AuthService
 }
@@ -29,8 +41,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Convert handler DTO to service parameters
-	domainResponse, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	domainResponse, err := h.authService.Login(c.Request.Context(), domain.LoginInput{
+		Email:     req.Email,
+		Password:  req.Password,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
 	if err != nil {
+		var throttled *domain.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondLoginThrottled(c, throttled)
+			return
+		}
+
 		switch err {
 		case domain.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -38,6 +61,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 				Error:   "invalid_credentials",
 				Message: "Invalid email or password",
 			})
+		case domain.ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "account_deactivated",
+				Message: "This account has been deactivated",
+			})
+		case domain.ErrSingleSignOnRequired:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "single_sign_on_required",
+				Message: "Staff accounts sign in through /api/auth/oidc/login",
+			})
 		default:
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Success: false,
@@ -48,12 +83,807 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if prompt := domainResponse.TwoFactor; prompt != nil {
+		c.JSON(http.StatusOK, TwoFactorChallengeResponse{
+			UserID:            domainResponse.UserID,
+			Email:             domainResponse.Email,
+			TwoFactorRequired: true,
+			EnrolmentRequired: prompt.EnrolmentRequired,
+			ChallengeToken:    prompt.ChallengeToken,
+			ExpiresAt:         prompt.ExpiresAt,
+		})
+		return
+	}
+
 	// Convert domain response to handler response
 	response := domain.LoginResponse{
-		Token:     domainResponse.Token,
-		UserID:    domainResponse.UserID,
-		Email:     domainResponse.Email,
-		ExpiresAt: domainResponse.ExpiresAt,
+		Token:            domainResponse.Token,
+		UserID:           domainResponse.UserID,
+		Email:            domainResponse.Email,
+		ExpiresAt:        domainResponse.ExpiresAt,
+		RefreshToken:     domainResponse.RefreshToken,
+		RefreshExpiresAt: domainResponse.RefreshExpiresAt,
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidRefreshToken:
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Success: false,
+				Error:   "invalid_refresh_token",
+				Message: "The refresh token is invalid, expired or was already used, please log in again",
+			})
+		case domain.ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "account_deactivated",
+				Message: "This account has been deactivated",
+			})
+		case domain.ErrTwoFactorEnrolmentRequired:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "two_factor_enrolment_required",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "refresh_failed",
+				Message: "An error occurred while refreshing the session",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	// The body is optional, without it only the access token is revoked
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "validation_failed",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	var err error
+	if req.AllSessions {
+		err = h.authService.RevokeAllSessions(c.Request.Context(), userObj.ID)
+	} else {
+		err = h.authService.Logout(c.Request.Context(), c.GetString("access_token"), req.RefreshToken)
+	}
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidRefreshToken:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_refresh_token",
+				Message: "The refresh token does not belong to this session",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "logout_failed",
+				Message: "An error occurred during logout",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Logged out", nil))
+}
+
+func (h *AuthHandler) RegisterBorrower(c *gin.Context) {
+	var req RegisterBorrowerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	input := MapRegisterRequestToInput(&req.RegisterRequest)
+	input.Region = req.Region
+	input.Latitude = req.Latitude
+	input.Longitude = req.Longitude
+
+	borrower, err := h.authService.RegisterBorrower(c.Request.Context(), input)
+	if err != nil {
+		h.respondRegistrationError(c, err)
+		return
+	}
+
+	borrowerResp := MapBorrowerToResponse(borrower)
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Registration received, check your email to verify your address", RegistrationResponse{
+		User:     MapUserToResponse(&borrower.User),
+		Borrower: &borrowerResp,
+	}))
+}
+
+func (h *AuthHandler) RegisterInvestor(c *gin.Context) {
+	var req RegisterInvestorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	investor, err := h.authService.RegisterInvestor(c.Request.Context(), MapRegisterRequestToInput(&req.RegisterRequest))
+	if err != nil {
+		h.respondRegistrationError(c, err)
+		return
+	}
+
+	investorResp := MapInvestorToResponse(investor)
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Registration received, check your email to verify your address", RegistrationResponse{
+		User:     MapUserToResponse(&investor.User),
+		Investor: &investorResp,
+	}))
+}
+
+func (h *AuthHandler) respondRegistrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrWeakPassword):
+		respondWeakPassword(c, err)
+	case err == domain.ErrEmailExists:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "account_exists",
+			Message: "An account with this email or identity number already exists",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "registration_failed",
+			Message: "An error occurred during registration",
+		})
+	}
+}
+
+// VerifyEmail is opened from the link in the verification email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: "token is required",
+		})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(c.Request.Context(), token); err != nil {
+		switch err {
+		case domain.ErrInvalidVerificationToken:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_token",
+				Message: "The verification link is invalid or was already used",
+			})
+		case domain.ErrVerificationTokenExpired:
+			c.JSON(http.StatusGone, ErrorResponse{
+				Success: false,
+				Error:   "token_expired",
+				Message: "The verification link has expired, request a new one",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "verification_failed",
+				Message: "An error occurred during email verification",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Email address verified", nil))
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "verification_failed",
+			Message: "An error occurred while sending the verification email",
+		})
+		return
+	}
+
+	// The same answer for every address, so registered emails cannot be discovered
+	c.JSON(http.StatusAccepted, SuccessResponseWithMessage("If the address belongs to an unverified account, a new verification link was sent", nil))
+}
+
+// ForgotPassword emails a reset link, answering the same for every address
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "reset_failed",
+			Message: "An error occurred while sending the password reset email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SuccessResponseWithMessage("If the address belongs to an active account, a password reset link was sent", nil))
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrWeakPassword):
+			respondWeakPassword(c, err)
+		case err == domain.ErrInvalidPasswordResetToken:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_token",
+				Message: "The reset link is invalid or was already used",
+			})
+		case err == domain.ErrPasswordResetTokenExpired:
+			c.JSON(http.StatusGone, ErrorResponse{
+				Success: false,
+				Error:   "token_expired",
+				Message: "The reset link has expired, request a new one",
+			})
+		case err == domain.ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "account_deactivated",
+				Message: "This account has been deactivated",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "reset_failed",
+				Message: "An error occurred while resetting the password",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Password reset, log in with the new password", nil))
+}
+
+// ChangePassword replaces the caller's password and returns tokens for a new session
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	response, err := h.authService.ChangePassword(c.Request.Context(), userObj.ID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrWeakPassword):
+			respondWeakPassword(c, err)
+		case err == domain.ErrInvalidCredentials:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_current_password",
+				Message: "The current password is incorrect",
+			})
+		case err == domain.ErrPasswordUnchanged:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "password_unchanged",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "change_failed",
+				Message: "An error occurred while changing the password",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Password changed, other sessions were signed out", response))
+}
+
+// respondWeakPassword explains which rule of the password policy was broken
+func respondWeakPassword(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Success: false,
+		Error:   "weak_password",
+		Message: err.Error(),
+	})
+}
+
+// VerifyTwoFactor completes a login with a code from the authenticator app or a recovery code
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.VerifyTwoFactor(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred during login")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// EnrolTwoFactor starts the mandatory enrolment of a user whose login is waiting for it
+func (h *AuthHandler) EnrolTwoFactor(c *gin.Context) {
+	var req TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	enrolment, err := h.authService.SetupTwoFactorWithChallenge(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred while setting up two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorSetupResponse{
+		Secret:          enrolment.Secret,
+		ProvisioningURI: enrolment.ProvisioningURI,
+	})
+}
+
+// ConfirmTwoFactorEnrolment enables two-factor authentication and completes the waiting login
+func (h *AuthHandler) ConfirmTwoFactorEnrolment(c *gin.Context) {
+	var req TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.ActivateTwoFactorWithChallenge(c.Request.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred while enabling two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactor generates an authenticator secret for the signed in user
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	enrolment, err := h.authService.SetupTwoFactor(c.Request.Context(), userObj.ID)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred while setting up two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorSetupResponse{
+		Secret:          enrolment.Secret,
+		ProvisioningURI: enrolment.ProvisioningURI,
+	})
+}
+
+// ActivateTwoFactor enables two-factor authentication with the first code of the new secret
+func (h *AuthHandler) ActivateTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.authService.ActivateTwoFactor(c.Request.Context(), userObj.ID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred while enabling two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Two-factor authentication enabled, store the recovery codes safely", RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	}))
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the signed in user
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userObj.ID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "An error occurred while generating recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("New recovery codes generated, the previous ones no longer work", RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	}))
+}
+
+// DisableTwoFactor turns two-factor authentication off for roles that do not require it
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), userObj.ID, req.Code); err != nil {
+		respondTwoFactorError(c, err, "An error occurred while disabling two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Two-factor authentication disabled", nil))
+}
+
+// SingleSignOn sends the browser to the identity provider, the state cookie ties the callback to this browser
+func (h *AuthHandler) SingleSignOn(c *gin.Context) {
+	start, err := h.authService.StartSingleSignOn(c.Request.Context())
+	if err != nil {
+		respondSingleSignOnError(c, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(singleSignOnStateCookie, start.State, int(time.Until(start.ExpiresAt).Seconds()), singleSignOnCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, start.AuthorizationURL)
+}
+
+// SingleSignOnCallback completes the login when the identity provider redirects back
+func (h *AuthHandler) SingleSignOnCallback(c *gin.Context) {
+	state := c.Query("state")
+	cookie, _ := c.Cookie(singleSignOnStateCookie)
+	// The state is used once either way
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(singleSignOnStateCookie, "", -1, singleSignOnCookiePath, "", c.Request.TLS != nil, true)
+
+	// A callback the browser did not start is a forged login attempt
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_sso_state",
+			Message: domain.ErrInvalidSingleSignOn.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.CompleteSingleSignOn(c.Request.Context(), domain.SingleSignOnCallback{
+		Code:      c.Query("code"),
+		State:     state,
+		Error:     c.Query("error"),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		respondSingleSignOnError(c, err)
+		return
 	}
+
 	c.JSON(http.StatusOK, response)
 }
+
+// respondSingleSignOnError maps the errors of the single sign-on endpoints
+func respondSingleSignOnError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSingleSignOnDisabled):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "single_sign_on_disabled",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrInvalidSingleSignOn):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_sso_state",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrNoRoleForGroups):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "no_role_for_groups",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrAccountDeactivated):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "account_deactivated",
+			Message: "This account has been deactivated",
+		})
+	case errors.Is(err, domain.ErrSingleSignOnConflict):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "single_sign_on_conflict",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrSingleSignOnFailed):
+		// The details stay in the server log
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "single_sign_on_failed",
+			Message: domain.ErrSingleSignOnFailed.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "single_sign_on_failed",
+			Message: "An error occurred during single sign-on",
+		})
+	}
+}
+
+// respondLoginThrottled tells the client when it may try to log in again
+func respondLoginThrottled(c *gin.Context, throttled *domain.LoginThrottledError) {
+	retryAfter := int(math.Ceil(time.Until(throttled.RetryAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	if throttled.AccountLocked {
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Success: false,
+			Error:   "account_locked",
+			Message: fmt.Sprintf("The account is locked after too many failed logins, try again in %d seconds or ask an administrator to unlock it", retryAfter),
+		})
+		return
+	}
+	c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Success: false,
+		Error:   "too_many_attempts",
+		Message: fmt.Sprintf("Too many failed logins, try again in %d seconds", retryAfter),
+	})
+}
+
+// respondTwoFactorError maps the errors shared by the two-factor endpoints
+func respondTwoFactorError(c *gin.Context, err error, failureMessage string) {
+	var throttled *domain.LoginThrottledError
+	if errors.As(err, &throttled) {
+		respondLoginThrottled(c, throttled)
+		return
+	}
+
+	switch err {
+	case domain.ErrInvalidTwoFactorCode:
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "invalid_two_factor_code",
+			Message: "The code is incorrect, expired or was already used",
+		})
+	case domain.ErrInvalidTwoFactorChallenge:
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "invalid_challenge",
+			Message: err.Error(),
+		})
+	case domain.ErrTwoFactorAlreadyEnabled, domain.ErrTwoFactorNotEnabled, domain.ErrTwoFactorNotSetUp:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "two_factor_state",
+			Message: err.Error(),
+		})
+	case domain.ErrTwoFactorMandatory:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "two_factor_mandatory",
+			Message: err.Error(),
+		})
+	case domain.ErrAccountDeactivated:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "account_deactivated",
+			Message: "This account has been deactivated",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: failureMessage,
+		})
+	}
+}
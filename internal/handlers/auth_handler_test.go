@@ -21,8 +21,8 @@ type mockAuthService struct {
 	mock.Mock
 }
 
-func (m *mockAuthService) Login(ctx context.Context, email, password string) (*domain.LoginResponse, error) {
-	args := m.Called(ctx, email, password)
+func (m *mockAuthService) Login(ctx context.Context, input domain.LoginInput) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, input)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -37,6 +37,142 @@ func (m *mockAuthService) ValidateToken(tokenString string) (*domain.User, error
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *mockAuthService) Refresh(ctx context.Context, refreshToken string) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginResponse), args.Error(1)
+}
+
+func (m *mockAuthService) Logout(ctx context.Context, accessToken string, refreshToken string) error {
+	args := m.Called(ctx, accessToken, refreshToken)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) RegisterBorrower(ctx context.Context, input domain.RegistrationInput) (*domain.Borrower, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Borrower), args.Error(1)
+}
+
+func (m *mockAuthService) RegisterInvestor(ctx context.Context, input domain.RegistrationInput) (*domain.Investor, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Investor), args.Error(1)
+}
+
+func (m *mockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) ResendVerification(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) ForgotPassword(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, challengeToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginResponse), args.Error(1)
+}
+
+func (m *mockAuthService) SetupTwoFactor(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorEnrolment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TwoFactorEnrolment), args.Error(1)
+}
+
+func (m *mockAuthService) ActivateTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockAuthService) SetupTwoFactorWithChallenge(ctx context.Context, challengeToken string) (*domain.TwoFactorEnrolment, error) {
+	args := m.Called(ctx, challengeToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TwoFactorEnrolment), args.Error(1)
+}
+
+func (m *mockAuthService) ActivateTwoFactorWithChallenge(ctx context.Context, challengeToken string, code string) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, challengeToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginResponse), args.Error(1)
+}
+
+func (m *mockAuthService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockAuthService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) ResetTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword string, newPassword string) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, userID, currentPassword, newPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginResponse), args.Error(1)
+}
+
+func (m *mockAuthService) StartSingleSignOn(ctx context.Context) (*domain.SingleSignOnStart, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SingleSignOnStart), args.Error(1)
+}
+
+func (m *mockAuthService) CompleteSingleSignOn(ctx context.Context, callback domain.SingleSignOnCallback) (*domain.LoginResponse, error) {
+	args := m.Called(ctx, callback)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginResponse), args.Error(1)
+}
+
 // Test Auth Handler Login - Happy Flow
 func TestAuthHandler_Login_Success(t *testing.T) {
 	// Setup Gin in test mode
@@ -58,7 +194,9 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 		ExpiresAt: time.Now().Add(time.Hour),
 	}
 
-	mockAuthService.On("Login", mock.Anything, loginReq.Email, loginReq.Password).Return(expectedResponse, nil)
+	mockAuthService.On("Login", mock.Anything, mock.MatchedBy(func(input domain.LoginInput) bool {
+		return input.Email == loginReq.Email && input.Password == loginReq.Password
+	})).Return(expectedResponse, nil)
 
 	// Create HTTP request
 	reqBody, _ := json.Marshal(loginReq)
@@ -88,6 +226,56 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	mockAuthService.AssertExpectations(t)
 }
 
+// Test Auth Handler Login - Two-Factor Challenge Instead Of Tokens
+func TestAuthHandler_Login_TwoFactorChallenge(t *testing.T) {
+	// Setup Gin in test mode
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	mockAuthService := new(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService)
+
+	loginReq := LoginRequest{
+		Email:    "officer@amf.com",
+		Password: "password123",
+	}
+
+	expectedResponse := &domain.LoginResponse{
+		UserID: uuid.New(),
+		Email:  "officer@amf.com",
+		TwoFactor: &domain.TwoFactorPrompt{
+			ChallengeToken:    "challenge-token",
+			EnrolmentRequired: true,
+			ExpiresAt:         time.Now().Add(5 * time.Minute),
+		},
+	}
+
+	mockAuthService.On("Login", mock.Anything, mock.AnythingOfType("domain.LoginInput")).Return(expectedResponse, nil)
+
+	reqBody, _ := json.Marshal(loginReq)
+	req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	// Act
+	authHandler.Login(c)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, true, response["two_factor_required"])
+	assert.Equal(t, true, response["enrolment_required"])
+	assert.Equal(t, "challenge-token", response["challenge_token"])
+	assert.NotContains(t, response, "token")
+	assert.NotContains(t, response, "refresh_token")
+}
+
 // Test Auth Handler Login - Invalid Credentials
 func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 	// Setup Gin in test mode
@@ -102,7 +290,9 @@ func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 		Password: "wrongpassword",
 	}
 
-	mockAuthService.On("Login", mock.Anything, loginReq.Email, loginReq.Password).Return(nil, domain.ErrInvalidCredentials)
+	mockAuthService.On("Login", mock.Anything, mock.MatchedBy(func(input domain.LoginInput) bool {
+		return input.Email == loginReq.Email && input.Password == loginReq.Password
+	})).Return(nil, domain.ErrInvalidCredentials)
 
 	// Create HTTP request
 	reqBody, _ := json.Marshal(loginReq)
@@ -131,6 +321,46 @@ func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 	mockAuthService.AssertExpectations(t)
 }
 
+// Test Auth Handler Login - Locked Account Gets 429 With Retry-After
+func TestAuthHandler_Login_AccountLocked(t *testing.T) {
+	// Setup Gin in test mode
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	mockAuthService := new(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService)
+
+	loginReq := LoginRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+
+	mockAuthService.On("Login", mock.Anything, mock.AnythingOfType("domain.LoginInput")).Return(nil, &domain.LoginThrottledError{
+		RetryAt:       time.Now().Add(15 * time.Minute),
+		AccountLocked: true,
+	})
+
+	reqBody, _ := json.Marshal(loginReq)
+	req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	// Act
+	authHandler.Login(c)
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "900", w.Header().Get("Retry-After"))
+
+	var response ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "account_locked", response.Error)
+}
+
 // Test Auth Handler Login - Invalid JSON
 func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 	// Setup Gin in test mode
@@ -163,3 +393,121 @@ func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 	assert.False(t, response.Success)
 	assert.Equal(t, "validation_failed", response.Error)
 }
+
+// Test Auth Handler Single Sign-On - The Redirect Sets The State Cookie The Callback Must Present
+func TestAuthHandler_SingleSignOn(t *testing.T) {
+	// Setup Gin in test mode
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	mockAuthService := new(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService)
+
+	mockAuthService.On("StartSingleSignOn", mock.Anything).Return(&domain.SingleSignOnStart{
+		AuthorizationURL: "https://idp.example.com/authorize?state=state-1",
+		State:            "state-1",
+		ExpiresAt:        time.Now().Add(10 * time.Minute),
+	}, nil)
+	mockAuthService.On("CompleteSingleSignOn", mock.Anything, mock.MatchedBy(func(callback domain.SingleSignOnCallback) bool {
+		return callback.Code == "code-1" && callback.State == "state-1"
+	})).Return(&domain.LoginResponse{UserID: uuid.New(), Email: "analyst@amf.com", Token: "jwt-token"}, nil)
+
+	startRecorder := httptest.NewRecorder()
+	startContext, _ := gin.CreateTestContext(startRecorder)
+	startContext.Request = httptest.NewRequest("GET", "/api/auth/oidc/login", nil)
+
+	// Act
+	authHandler.SingleSignOn(startContext)
+	cookies := startRecorder.Result().Cookies()
+
+	forgedRecorder := httptest.NewRecorder()
+	forgedContext, _ := gin.CreateTestContext(forgedRecorder)
+	forgedContext.Request = httptest.NewRequest("GET", "/api/auth/oidc/callback?code=code-1&state=state-1", nil)
+	authHandler.SingleSignOnCallback(forgedContext)
+
+	callbackRecorder := httptest.NewRecorder()
+	callbackContext, _ := gin.CreateTestContext(callbackRecorder)
+	callbackContext.Request = httptest.NewRequest("GET", "/api/auth/oidc/callback?code=code-1&state=state-1", nil)
+	for _, cookie := range cookies {
+		callbackContext.Request.AddCookie(cookie)
+	}
+	authHandler.SingleSignOnCallback(callbackContext)
+
+	// Assert
+	assert.Equal(t, http.StatusFound, startRecorder.Code)
+	assert.Equal(t, "https://idp.example.com/authorize?state=state-1", startRecorder.Header().Get("Location"))
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "state-1", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, forgedRecorder.Code)
+	var forged ErrorResponse
+	assert.NoError(t, json.Unmarshal(forgedRecorder.Body.Bytes(), &forged))
+	assert.Equal(t, "invalid_sso_state", forged.Error)
+
+	assert.Equal(t, http.StatusOK, callbackRecorder.Code)
+	var response domain.LoginResponse
+	assert.NoError(t, json.Unmarshal(callbackRecorder.Body.Bytes(), &response))
+	assert.Equal(t, "jwt-token", response.Token)
+	mockAuthService.AssertNumberOfCalls(t, "CompleteSingleSignOn", 1)
+}
+
+// Test Auth Handler Register Borrower - Address Coordinates Reach The Service, Half A Pair Is Refused
+func TestAuthHandler_RegisterBorrower_Coordinates(t *testing.T) {
+	// Setup Gin in test mode
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	mockAuthService := new(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService)
+
+	latitude, longitude := 40.7205, -74.0089
+	registerReq := RegisterBorrowerRequest{
+		RegisterRequest: RegisterRequest{
+			Email:          "new.borrower@example.com",
+			Password:       "password123",
+			FullName:       "Jane Roe",
+			PhoneNumber:    "+1234567893",
+			Address:        "12 Hudson St, New York, NY",
+			IdentityNumber: "B001234570",
+		},
+		Region:    "NY",
+		Latitude:  &latitude,
+		Longitude: &longitude,
+	}
+
+	mockAuthService.On("RegisterBorrower", mock.Anything, mock.MatchedBy(func(input domain.RegistrationInput) bool {
+		return input.Latitude != nil && *input.Latitude == latitude &&
+			input.Longitude != nil && *input.Longitude == longitude
+	})).Return(&domain.Borrower{ID: uuid.New(), Latitude: &latitude, Longitude: &longitude}, nil)
+
+	register := func(body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/auth/register/borrower", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		authHandler.RegisterBorrower(c)
+		return w
+	}
+
+	halfPair := registerReq
+	halfPair.Longitude = nil
+	outOfRange := registerReq
+	invalid := 123.4
+	outOfRange.Latitude = &invalid
+
+	// Act
+	created := register(registerReq)
+	missingLongitude := register(halfPair)
+	invalidLatitude := register(outOfRange)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, http.StatusBadRequest, missingLongitude.Code)
+	assert.Equal(t, http.StatusBadRequest, invalidLatitude.Code)
+	mockAuthService.AssertNumberOfCalls(t, "RegisterBorrower", 1)
+}
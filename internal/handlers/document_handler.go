@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type DocumentHandler struct {
+	documentService domain.DocumentService
+	maxUploadSize   int64
+}
+
+func NewDocumentHandler(documentService domain.DocumentService, maxUploadSize int64) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+		maxUploadSize:   maxUploadSize,
+	}
+}
+
+// UploadDocument accepts a multipart form with the file in the "file" field and its kind in "type"
+func (h *DocumentHandler) UploadDocument(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: "Multipart field 'file' is required",
+		})
+		return
+	}
+
+	if fileHeader.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Success: false,
+			Error:   "file_too_large",
+			Message: fmt.Sprintf("Document must not exceed %d bytes", h.maxUploadSize),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	docType := domain.DocumentType(c.PostForm("type"))
+	document, err := h.documentService.UploadDocument(c.Request.Context(), loanID, userObj.ID, docType, fileHeader.Filename, data)
+	if err != nil {
+		switch err {
+		case domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+		case domain.ErrLoanNotInvested:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_loan_state",
+				Message: "Agreements can only be uploaded for fully funded loans",
+			})
+		case domain.ErrInvalidDocumentType:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_document_type",
+				Message: "Only disbursement_agreement documents can be uploaded",
+			})
+		case domain.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "empty_file",
+				Message: err.Error(),
+			})
+		case domain.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Success: false,
+				Error:   "file_too_large",
+				Message: fmt.Sprintf("Document must not exceed %d bytes", h.maxUploadSize),
+			})
+		case domain.ErrUnsupportedFileType:
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Success: false,
+				Error:   "unsupported_file_type",
+				Message: "Document must be a PDF, JPEG or PNG file",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "upload_failed",
+				Message: "Failed to store document",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Document uploaded", MapDocumentToResponse(document)))
+}
+
+func (h *DocumentHandler) GetLoanDocuments(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	documents, err := h.documentService.GetLoanDocuments(c.Request.Context(), userObj, loanID)
+	if err != nil {
+		if err == domain.ErrLoanNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch documents",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapDocumentsToResponse(documents)))
+}
+
+// GetDocument returns the document metadata together with a short lived signed download link
+func (h *DocumentHandler) GetDocument(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid document ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	download, err := h.documentService.IssueDownloadURL(c.Request.Context(), userObj, documentID)
+	if err != nil {
+		switch err {
+		case domain.ErrDocumentNotFound, domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "document_not_found",
+				Message: "The specified document was not found",
+			})
+		case domain.ErrInsufficientPermission:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "forbidden",
+				Message: "You do not have access to this document",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch document",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(DocumentDownloadResponse{
+		Document:  MapDocumentToResponse(download.Document),
+		URL:       download.URL,
+		ExpiresAt: download.ExpiresAt,
+	}))
+}
+
+// DownloadDocument serves the file behind a signed link, the signature replaces the bearer token
+func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid document ID format",
+		})
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "invalid_signature",
+			Message: "Download link is invalid",
+		})
+		return
+	}
+
+	document, content, err := h.documentService.OpenSignedDownload(c.Request.Context(), documentID, expires, c.Query("signature"))
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidDownloadSignature:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "invalid_signature",
+				Message: "Download link is invalid",
+			})
+		case domain.ErrDownloadURLExpired:
+			c.JSON(http.StatusGone, ErrorResponse{
+				Success: false,
+				Error:   "link_expired",
+				Message: "Download link has expired, request a new one",
+			})
+		case domain.ErrDocumentNotFound, domain.ErrStoredObjectNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "document_not_found",
+				Message: "The specified document was not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch document",
+			})
+		}
+		return
+	}
+	defer content.Close()
+
+	c.Header("ETag", `"`+document.SHA256+`"`)
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", document.FileName),
+	})
+}
@@ -24,9 +24,98 @@ type LoginResponse struct {
 }
 
 type UserResponse struct {
-	ID    uuid.UUID       `json:"id"`
-	Email string          `json:"email"`
-	Role  domain.UserRole `json:"role"`
+	ID            uuid.UUID       `json:"id"`
+	Email         string          `json:"email"`
+	Role          domain.UserRole `json:"role"`
+	EmailVerified bool            `json:"email_verified"`
+	KYCVerified   bool            `json:"kyc_verified"`
+}
+
+type RegisterRequest struct {
+	Email          string `json:"email" binding:"required,email,max=255"`
+	Password       string `json:"password" binding:"required"` // Checked against the password policy
+	FullName       string `json:"full_name" binding:"required,max=100"`
+	PhoneNumber    string `json:"phone_number" binding:"required,min=8,max=20"`
+	Address        string `json:"address" binding:"required,max=255"`
+	IdentityNumber string `json:"identity_number" binding:"required,min=5,max=32"`
+}
+
+type RegisterBorrowerRequest struct {
+	RegisterRequest
+	Region    string   `json:"region" binding:"max=50"`
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"` // Geocoded address, both or neither
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+}
+
+type RegisterInvestorRequest struct {
+	RegisterRequest
+}
+
+type RegistrationResponse struct {
+	User     UserResponse      `json:"user"`
+	Borrower *BorrowerResponse `json:"borrower,omitempty"`
+	Investor *InvestorResponse `json:"investor,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllSessions  bool   `json:"all_sessions"` // Sign out of every device
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Password strength is checked against the configured password policy by the service
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when a second step is needed
+type TwoFactorChallengeResponse struct {
+	UserID            uuid.UUID `json:"user_id"`
+	Email             string    `json:"email"`
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	EnrolmentRequired bool      `json:"enrolment_required"` // Set up an authenticator with the challenge token first
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// Code is the 6 digit code of the authenticator app or, where accepted, a recovery code
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // Render as a QR code for the authenticator app
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 // ============================================================================
@@ -37,9 +126,9 @@ type BorrowerResponse struct {
 	ID             uuid.UUID     `json:"id"`
 	UserID         uuid.UUID     `json:"user_id"`
 	FullName       string        `json:"full_name"`
-	PhoneNumber    string        `json:"phone_number"`
-	Address        string        `json:"address"`
-	IdentityNumber string        `json:"identity_number"`
+	PhoneNumber    string        `json:"phone_number"`      // Masked for investors
+	Address        string        `json:"address,omitempty"` // Omitted for investors
+	IdentityNumber string        `json:"identity_number"`   // Masked for investors
 	CreatedAt      time.Time     `json:"created_at"`
 	UpdatedAt      time.Time     `json:"updated_at"`
 	User           *UserResponse `json:"user,omitempty"`
@@ -69,6 +158,7 @@ type InvestorResponse struct {
 type CreateLoanRequest struct {
 	PrincipalAmount float64 `json:"principal_amount" binding:"required,min=1000"`
 	Rate            float64 `json:"rate" binding:"required,min=0.01,max=1"`
+	TenorMonths     int     `json:"tenor_months" binding:"omitempty,min=1,max=360"` // Defaults to 12 months
 }
 
 type LoanResponse struct {
@@ -77,9 +167,11 @@ type LoanResponse struct {
 	PrincipalAmount     float64          `json:"principal_amount"`
 	InvestedAmount      float64          `json:"invested_amount"`
 	RemainingInvestment float64          `json:"remaining_investment"`
+	ReservedAmount      float64          `json:"reserved_amount"`
 	Rate                float64          `json:"rate"`
 	ROI                 float64          `json:"roi"`
 	TotalInterest       float64          `json:"total_interest"`
+	TenorMonths         int              `json:"tenor_months"`
 	State               domain.LoanState `json:"state"`
 	AgreementLetterURL  string           `json:"agreement_letter_url,omitempty"`
 	CreatedAt           time.Time        `json:"created_at"`
@@ -90,13 +182,65 @@ type LoanResponse struct {
 }
 
 type ApproveLoanRequest struct {
-	PhotoProofURL string    `json:"photo_proof_url" binding:"required,url"`
-	ApprovalDate  time.Time `json:"approval_date" binding:"required"`
+	PhotoProofID uuid.UUID `json:"photo_proof_id" binding:"required"`
+	ApprovalDate time.Time `json:"approval_date" binding:"required"`
+	// Where and when the device captured the photo
+	Latitude   *float64  `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude  *float64  `json:"longitude" binding:"required,min=-180,max=180"`
+	CapturedAt time.Time `json:"captured_at" binding:"required"`
+}
+
+type ApprovalResponse struct {
+	ID             uuid.UUID             `json:"id"`
+	LoanID         uuid.UUID             `json:"loan_id"`
+	ValidatorID    uuid.UUID             `json:"validator_id"`
+	PhotoProofURL  string                `json:"photo_proof_url"`
+	ApprovalDate   time.Time             `json:"approval_date"`
+	Latitude       float64               `json:"latitude"`
+	Longitude      float64               `json:"longitude"`
+	CapturedAt     time.Time             `json:"captured_at"`
+	DistanceMeters *float64              `json:"distance_meters,omitempty"`
+	EvidenceFlags  []domain.EvidenceFlag `json:"evidence_flags"`
+	NeedsReview    bool                  `json:"needs_review"`
+	PhotoProof     *PhotoProofResponse   `json:"photo_proof,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+type PhotoProofResponse struct {
+	ID           uuid.UUID `json:"id"`
+	LoanID       uuid.UUID `json:"loan_id"`
+	UploaderID   uuid.UUID `json:"uploader_id"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256"`
+	OriginalName string    `json:"original_name,omitempty"`
+	DownloadURL  string    `json:"download_url"`
+	// Capture metadata read from the image, omitted when the camera wrote none
+	ExifLatitude   *float64   `json:"exif_latitude,omitempty"`
+	ExifLongitude  *float64   `json:"exif_longitude,omitempty"`
+	ExifCapturedAt *time.Time `json:"exif_captured_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ReviewLoanRequest struct {
+	Decision domain.ApprovalDecision `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string                  `json:"comment" binding:"max=1000"`
+}
+
+type ApprovalStageResponse struct {
+	ID        uuid.UUID                `json:"id"`
+	LoanID    uuid.UUID                `json:"loan_id"`
+	Stage     domain.ApprovalStageType `json:"stage"`
+	ActorID   uuid.UUID                `json:"actor_id"`
+	ActorRole domain.UserRole          `json:"actor_role"`
+	Decision  domain.ApprovalDecision  `json:"decision"`
+	Comment   string                   `json:"comment,omitempty"`
+	DecidedAt time.Time                `json:"decided_at"`
 }
 
 type DisburseLoanRequest struct {
-	AgreementFileURL string    `json:"agreement_file_url" binding:"required,url"`
-	DisbursementDate time.Time `json:"disbursement_date" binding:"required"`
+	AgreementDocumentID uuid.UUID `json:"agreement_document_id" binding:"required"`
+	DisbursementDate    time.Time `json:"disbursement_date" binding:"required"`
 }
 
 // ============================================================================
@@ -120,6 +264,285 @@ type InvestmentResponse struct {
 	Investor *InvestorResponse `json:"investor,omitempty"`
 }
 
+type InvestmentHoldResponse struct {
+	ID           uuid.UUID         `json:"id"`
+	LoanID       uuid.UUID         `json:"loan_id"`
+	InvestorID   uuid.UUID         `json:"investor_id"`
+	Amount       float64           `json:"amount"`
+	Status       domain.HoldStatus `json:"status"`
+	InvestmentID *uuid.UUID        `json:"investment_id,omitempty"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// ============================================================================
+// WAITLIST DTOs
+// ============================================================================
+
+type JoinWaitlistRequest struct {
+	Amount float64 `json:"amount" binding:"required,min=1000"`
+}
+
+type WaitlistEntryResponse struct {
+	ID         uuid.UUID             `json:"id"`
+	LoanID     uuid.UUID             `json:"loan_id"`
+	InvestorID uuid.UUID             `json:"investor_id"`
+	Amount     float64               `json:"amount"`
+	Status     domain.WaitlistStatus `json:"status"`
+	Position   int                   `json:"position,omitempty"`
+	HoldID     *uuid.UUID            `json:"hold_id,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+// ============================================================================
+// DOCUMENT DTOs
+// ============================================================================
+
+type DocumentResponse struct {
+	ID          uuid.UUID           `json:"id"`
+	LoanID      uuid.UUID           `json:"loan_id"`
+	Type        domain.DocumentType `json:"type"`
+	FileName    string              `json:"file_name"`
+	ContentType string              `json:"content_type"`
+	Size        int64               `json:"size"`
+	SHA256      string              `json:"sha256"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type DocumentDownloadResponse struct {
+	Document  DocumentResponse `json:"document"`
+	URL       string           `json:"url"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// ============================================================================
+// KYC DTOs
+// ============================================================================
+
+type KYCDocumentResponse struct {
+	ID          uuid.UUID              `json:"id"`
+	Type        domain.KYCDocumentType `json:"type"`
+	ContentType string                 `json:"content_type"`
+	Size        int64                  `json:"size"`
+	SHA256      string                 `json:"sha256"`
+	DownloadURL string                 `json:"download_url,omitempty"` // Reviewers only
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+type KYCSubmissionResponse struct {
+	ID              uuid.UUID             `json:"id"`
+	UserID          uuid.UUID             `json:"user_id"`
+	Status          domain.KYCStatus      `json:"status"`
+	RejectionReason string                `json:"rejection_reason,omitempty"`
+	ReviewedAt      *time.Time            `json:"reviewed_at,omitempty"`
+	Documents       []KYCDocumentResponse `json:"documents"`
+	CreatedAt       time.Time             `json:"created_at"`
+	User            *UserResponse         `json:"user,omitempty"`
+}
+
+// KYCReviewResponse adds the registered details the reviewer checks the ID card against
+type KYCReviewResponse struct {
+	KYCSubmissionResponse
+	ReviewerID     *uuid.UUID `json:"reviewer_id,omitempty"`
+	FullName       string     `json:"full_name"`
+	IdentityNumber string     `json:"identity_number"`
+}
+
+type KYCReviewFilter struct {
+	PaginationRequest
+	Status domain.KYCStatus `form:"status" binding:"omitempty,oneof=pending verified rejected"` // Defaults to pending
+}
+
+type RejectKYCRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"` // Shown to the customer
+}
+
+// ============================================================================
+// PRIVACY DTOs
+// ============================================================================
+
+type ErasureRequestResponse struct {
+	ID              uuid.UUID            `json:"id"`
+	UserID          uuid.UUID            `json:"user_id"`
+	RequestedByID   uuid.UUID            `json:"requested_by_id"`
+	Status          domain.ErasureStatus `json:"status"`
+	ProcessedByID   *uuid.UUID           `json:"processed_by_id,omitempty"`
+	ProcessedAt     *time.Time           `json:"processed_at,omitempty"`
+	RejectionReason string               `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	User            *UserResponse        `json:"user,omitempty"` // Staff only
+}
+
+type ErasureRequestFilter struct {
+	PaginationRequest
+	Status domain.ErasureStatus `form:"status" binding:"omitempty,oneof=pending completed rejected"` // Defaults to pending
+}
+
+type RejectErasureRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"` // Shown to the customer
+}
+
+// ============================================================================
+// VERIFICATION TASK DTOs
+// ============================================================================
+
+type ReassignTaskRequest struct {
+	AssigneeID *uuid.UUID `json:"assignee_id"` // Omit to pick the least loaded validator
+}
+
+type VerificationTaskResponse struct {
+	ID                uuid.UUID         `json:"id"`
+	LoanID            uuid.UUID         `json:"loan_id"`
+	AssigneeID        *uuid.UUID        `json:"assignee_id"` // Null while the task waits for a validator
+	Region            string            `json:"region,omitempty"`
+	Status            domain.TaskStatus `json:"status"`
+	DueAt             time.Time         `json:"due_at"`
+	Overdue           bool              `json:"overdue"`
+	AssignedAt        time.Time         `json:"assigned_at"`
+	ReassignmentCount int               `json:"reassignment_count"`
+	CompletedAt       *time.Time        `json:"completed_at,omitempty"`
+	// Related data - only included when loaded
+	Loan *LoanResponse `json:"loan,omitempty"`
+}
+
+// ============================================================================
+// AGREEMENT SIGNING DTOs
+// ============================================================================
+
+type SignAgreementRequest struct {
+	TypedName string `json:"typed_name" binding:"required"`
+	Consent   bool   `json:"consent"`
+	OTP       string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type SigningOTPResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AgreementSignatureResponse struct {
+	ID             uuid.UUID `json:"id"`
+	DocumentID     uuid.UUID `json:"document_id"`
+	SignerUserID   uuid.UUID `json:"signer_user_id"`
+	DocumentSHA256 string    `json:"document_sha256"`
+	SignedName     string    `json:"signed_name"`
+	ConsentText    string    `json:"consent_text"`
+	IPAddress      string    `json:"ip_address"`
+	SignedAt       time.Time `json:"signed_at"`
+}
+
+type BorrowerAgreementResponse struct {
+	Document  DocumentResponse            `json:"document"`
+	Signed    bool                        `json:"signed"`
+	Signature *AgreementSignatureResponse `json:"signature,omitempty"`
+}
+
+// ============================================================================
+// SEGREGATION OF DUTIES DTOs
+// ============================================================================
+
+type DeclareRelationshipRequest struct {
+	BorrowerID   uuid.UUID               `json:"borrower_id" binding:"required"`
+	Relationship domain.RelationshipType `json:"relationship" binding:"required,oneof=family business personal other"`
+	Note         string                  `json:"note" binding:"max=500"`
+}
+
+type StaffRelationshipResponse struct {
+	ID           uuid.UUID               `json:"id"`
+	BorrowerID   uuid.UUID               `json:"borrower_id"`
+	Relationship domain.RelationshipType `json:"relationship"`
+	Note         string                  `json:"note,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+}
+
+// ============================================================================
+// USER ADMINISTRATION DTOs
+// ============================================================================
+
+type CreateStaffUserRequest struct {
+	Email    string          `json:"email" binding:"required,email,max=255"`
+	Password string          `json:"password" binding:"required"` // Checked against the password policy
+	Role     domain.UserRole `json:"role" binding:"required,oneof=field_officer field_validator credit_analyst credit_committee admin"`
+	Region   string          `json:"region" binding:"max=50"`
+	Branch   string          `json:"branch" binding:"max=50"`
+}
+
+type ChangeRoleRequest struct {
+	Role domain.UserRole `json:"role" binding:"required,oneof=field_officer field_validator credit_analyst credit_committee admin"`
+}
+
+type UsersFilter struct {
+	PaginationRequest
+	Role domain.UserRole `form:"role"`
+}
+
+type AdminUserResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	Email          string          `json:"email"`
+	Role           domain.UserRole `json:"role"`
+	Region         string          `json:"region,omitempty"`
+	Branch         string          `json:"branch,omitempty"`
+	EmailVerified  bool            `json:"email_verified"`
+	Active         bool            `json:"active"`
+	ServiceAccount bool            `json:"service_account"`
+	DeactivatedAt  *time.Time      `json:"deactivated_at,omitempty"`
+	FailedLogins   int             `json:"failed_logins"`
+	LockedUntil    *time.Time      `json:"locked_until,omitempty"` // Logins are refused until then
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type PasswordResetResponse struct {
+	UserID            uuid.UUID `json:"user_id"`
+	TemporaryPassword string    `json:"temporary_password"` // Shown once, hand it to the user over a trusted channel
+}
+
+type CreateServiceAccountRequest struct {
+	Name string          `json:"name" binding:"required,max=50"` // Lower case letters, digits and dashes
+	Role domain.UserRole `json:"role" binding:"required"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name          string              `json:"name" binding:"required,max=100"`
+	Scopes        []domain.Permission `json:"scopes" binding:"required,min=1"`           // Permissions of the account's role
+	ExpiresInDays int                 `json:"expires_in_days" binding:"omitempty,min=1"` // Defaults to API_KEY_DEFAULT_TTL
+}
+
+type APIKeyResponse struct {
+	ID               uuid.UUID           `json:"id"`
+	ServiceAccountID uuid.UUID           `json:"service_account_id"`
+	Name             string              `json:"name"`
+	Prefix           string              `json:"prefix"`
+	Scopes           []domain.Permission `json:"scopes"`
+	Active           bool                `json:"active"`
+	ExpiresAt        time.Time           `json:"expires_at"`
+	LastUsedAt       *time.Time          `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time          `json:"revoked_at,omitempty"`
+	ReplacedByID     *uuid.UUID          `json:"replaced_by_id,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+type IssuedAPIKeyResponse struct {
+	Key    string         `json:"key"` // Shown once, send it as "Authorization: ApiKey <key>"
+	APIKey APIKeyResponse `json:"api_key"`
+}
+
+type LoginEventsFilter struct {
+	IPAddress  string `form:"ip_address" binding:"omitempty,ip"`
+	Email      string `form:"email" binding:"omitempty,max=255"`
+	FailedOnly bool   `form:"failed_only"`
+}
+
+type LoginEventResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"` // Unset when the email is unknown
+	Email     string     `json:"email"`
+	Succeeded bool       `json:"succeeded"`
+	Reason    string     `json:"reason,omitempty"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
 // ============================================================================
 // PAGINATION & FILTERING DTOs
 // ============================================================================
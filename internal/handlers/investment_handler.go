@@ -10,11 +10,13 @@ import (
 
 type InvestmentHandler struct {
 	investmentService domain.InvestmentService
+	policy            domain.PermissionPolicy
 }
 
-func NewInvestmentHandler(investmentService domain.InvestmentService) *InvestmentHandler {
+func NewInvestmentHandler(investmentService domain.InvestmentService, policy domain.PermissionPolicy) *InvestmentHandler {
 	return &InvestmentHandler{
 		investmentService: investmentService,
+		policy:            policy,
 	}
 }
 
@@ -50,18 +52,8 @@ func (h *InvestmentHandler) Invest(c *gin.Context) {
 		return
 	}
 
-	// Only investors can invest
-	if userObj.Role != domain.RoleInvestor {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Success: false,
-			Error:   "forbidden",
-			Message: "Only investors can invest in loans",
-		})
-		return
-	}
-
 	// Convert handler DTO to service parameters
-	err := h.investmentService.RequestInvestment(c.Request.Context(), userObj.ID, req.LoanID, req.Amount)
+	hold, err := h.investmentService.RequestInvestment(c.Request.Context(), userObj.ID, req.LoanID, req.Amount)
 	if err != nil {
 		switch err {
 		case domain.ErrUserNotFound:
@@ -70,6 +62,18 @@ func (h *InvestmentHandler) Invest(c *gin.Context) {
 				Error:   "investor_not_found",
 				Message: "Investor profile not found",
 			})
+		case domain.ErrEmailNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "email_not_verified",
+				Message: "Verify your email address before investing",
+			})
+		case domain.ErrKYCNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "kyc_not_verified",
+				Message: "Complete identity verification (KYC) before investing",
+			})
 		case domain.ErrLoanNotFound:
 			c.JSON(http.StatusNotFound, ErrorResponse{
 				Success: false,
@@ -116,10 +120,20 @@ func (h *InvestmentHandler) Invest(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusAccepted, SuccessResponseWithMessage("Investment request submitted for processing", nil))
+	c.JSON(http.StatusAccepted, SuccessResponseWithMessage("Investment amount reserved and submitted for processing", MapInvestmentHoldToResponse(hold)))
 }
 
-func (h *InvestmentHandler) GetMyInvestments(c *gin.Context) {
+func (h *InvestmentHandler) GetHold(c *gin.Context) {
+	holdID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid hold ID format",
+		})
+		return
+	}
+
 	// Get user from context
 	user, exists := c.Get("user")
 	if !exists {
@@ -141,12 +155,119 @@ func (h *InvestmentHandler) GetMyInvestments(c *gin.Context) {
 		return
 	}
 
-	// Only investors can view investments
-	if userObj.Role != domain.RoleInvestor {
-		c.JSON(http.StatusForbidden, ErrorResponse{
+	hold, err := h.investmentService.GetHold(c.Request.Context(), userObj.ID, holdID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "investor_not_found",
+				Message: "Investor profile not found",
+			})
+		case domain.ErrHoldNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "hold_not_found",
+				Message: "The specified investment hold was not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch investment hold",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapInvestmentHoldToResponse(hold)))
+}
+
+// CancelInvestment withdraws the investor's own investment within the cooling-off period
+func (h *InvestmentHandler) CancelInvestment(c *gin.Context) {
+	investmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid investment ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
-			Error:   "forbidden",
-			Message: "Only investors can view investments",
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	investment, err := h.investmentService.CancelInvestment(c.Request.Context(), userObj.ID, investmentID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "investor_not_found",
+				Message: "Investor profile not found",
+			})
+		case domain.ErrInvestmentNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "investment_not_found",
+				Message: "The specified investment was not found",
+			})
+		case domain.ErrCoolingOffEnded:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "cooling_off_ended",
+				Message: "The cooling-off period has ended or the loan was disbursed",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "cancel_failed",
+				Message: "Failed to cancel investment",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Investment cancelled", MapInvestmentToResponse(investment, false, false)))
+}
+
+func (h *InvestmentHandler) GetMyInvestments(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
 		})
 		return
 	}
@@ -178,6 +299,26 @@ func (h *InvestmentHandler) GetLoanInvestments(c *gin.Context) {
 		return
 	}
 
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
 	investments, err := h.investmentService.GetLoanInvestments(c.Request.Context(), loanID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -188,7 +329,7 @@ func (h *InvestmentHandler) GetLoanInvestments(c *gin.Context) {
 		return
 	}
 
-	// Convert domain entities to handler responses
-	responses := MapInvestmentsToResponse(investments, false, true)
+	// Only staff and the investor themselves see who invested
+	responses := NewPIIShaper(h.policy, userObj).Investments(investments)
 	c.JSON(http.StatusOK, responses)
 }
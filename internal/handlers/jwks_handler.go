@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set, keys are published well before they sign
+const jwksMaxAge = 300
+
+type JWKSHandler struct {
+	tokenKeyService domain.TokenKeyService
+}
+
+func NewJWKSHandler(tokenKeyService domain.TokenKeyService) *JWKSHandler {
+	return &JWKSHandler{
+		tokenKeyService: tokenKeyService,
+	}
+}
+
+// GetJWKS publishes the public keys that verify access tokens, as a plain JWK set (RFC 7517)
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	set, err := h.tokenKeyService.JWKS(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Failed to load signing keys",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", jwksMaxAge))
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type KYCHandler struct {
+	kycService    domain.KYCService
+	maxUploadSize int64
+}
+
+func NewKYCHandler(kycService domain.KYCService, maxUploadSize int64) *KYCHandler {
+	return &KYCHandler{
+		kycService:    kycService,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// SubmitKYC accepts a multipart form with the ID card photo in the "id_card" field and the selfie in "selfie"
+func (h *KYCHandler) SubmitKYC(c *gin.Context) {
+	idCard, ok := h.readUpload(c, "id_card")
+	if !ok {
+		return
+	}
+	selfie, ok := h.readUpload(c, "selfie")
+	if !ok {
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	submission, err := h.kycService.SubmitKYC(c.Request.Context(), userObj.ID, idCard, selfie)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "user_not_found",
+				Message: "User not found",
+			})
+		case domain.ErrInvalidRole:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "invalid_role",
+				Message: "Only borrowers and investors verify their identity",
+			})
+		case domain.ErrKYCAlreadyVerified:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "kyc_already_verified",
+				Message: err.Error(),
+			})
+		case domain.ErrKYCPending:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "kyc_pending",
+				Message: "Your previous submission is still awaiting review",
+			})
+		case domain.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "empty_file",
+				Message: err.Error(),
+			})
+		case domain.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Success: false,
+				Error:   "file_too_large",
+				Message: fmt.Sprintf("Each image must not exceed %d bytes", h.maxUploadSize),
+			})
+		case domain.ErrUnsupportedFileType:
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Success: false,
+				Error:   "unsupported_file_type",
+				Message: "ID card and selfie must be JPEG or PNG images",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "submission_failed",
+				Message: "Failed to submit identity verification",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Identity verification submitted for review", MapKYCSubmissionToResponse(submission)))
+}
+
+// readUpload reads one image of the multipart form, responding with the error if it is missing or unreadable
+func (h *KYCHandler) readUpload(c *gin.Context, field string) (domain.KYCUpload, bool) {
+	fileHeader, err := c.FormFile(field)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: fmt.Sprintf("Multipart field '%s' is required", field),
+		})
+		return domain.KYCUpload{}, false
+	}
+
+	if fileHeader.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Success: false,
+			Error:   "file_too_large",
+			Message: fmt.Sprintf("Each image must not exceed %d bytes", h.maxUploadSize),
+		})
+		return domain.KYCUpload{}, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return domain.KYCUpload{}, false
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so oversized uploads are still detected by the service
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return domain.KYCUpload{}, false
+	}
+
+	return domain.KYCUpload{FileName: fileHeader.Filename, Data: data}, true
+}
+
+func (h *KYCHandler) GetMyKYC(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	submission, err := h.kycService.GetMyKYC(c.Request.Context(), userObj.ID)
+	if err != nil {
+		switch err {
+		case domain.ErrKYCSubmissionNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "kyc_not_submitted",
+				Message: "You have not submitted identity verification yet",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch identity verification",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapKYCSubmissionToResponse(submission)))
+}
+
+// GetReviewQueue lists submissions oldest first, pending ones unless ?status= says otherwise
+func (h *KYCHandler) GetReviewQueue(c *gin.Context) {
+	var filter KYCReviewFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	offset, limit := GetOffsetAndLimit(filter.Page, filter.PageSize)
+	submissions, total, err := h.kycService.GetReviewQueue(c.Request.Context(), filter.Status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch KYC submissions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedSuccessResponse(MapKYCSubmissionsToReviewResponse(submissions), CalculatePagination(filter.Page, filter.PageSize, total)))
+}
+
+func (h *KYCHandler) GetReview(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid KYC submission ID format",
+		})
+		return
+	}
+
+	review, err := h.kycService.GetReview(c.Request.Context(), submissionID)
+	if err != nil {
+		respondKYCReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapKYCReviewToResponse(review)))
+}
+
+func (h *KYCHandler) DownloadKYCDocument(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid KYC submission ID format",
+		})
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("documentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid KYC document ID format",
+		})
+		return
+	}
+
+	document, content, err := h.kycService.GetKYCDocument(c.Request.Context(), submissionID, documentID)
+	if err != nil {
+		switch err {
+		case domain.ErrKYCDocumentNotFound, domain.ErrStoredObjectNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "kyc_document_not_found",
+				Message: "The specified KYC document was not found",
+			})
+		default:
+			respondKYCReviewError(c, err)
+		}
+		return
+	}
+	defer content.Close()
+
+	// Identity documents must not linger in shared caches
+	c.Header("Cache-Control", "no-store")
+	c.Header("ETag", `"`+document.SHA256+`"`)
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, nil)
+}
+
+func (h *KYCHandler) ApproveKYC(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid KYC submission ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	submission, err := h.kycService.ApproveKYC(c.Request.Context(), userObj.ID, submissionID)
+	if err != nil {
+		respondKYCReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Identity verified", MapKYCSubmissionToReviewResponse(submission)))
+}
+
+func (h *KYCHandler) RejectKYC(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid KYC submission ID format",
+		})
+		return
+	}
+
+	var req RejectKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	submission, err := h.kycService.RejectKYC(c.Request.Context(), userObj.ID, submissionID, req.Reason)
+	if err != nil {
+		respondKYCReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Identity verification rejected", MapKYCSubmissionToReviewResponse(submission)))
+}
+
+// respondKYCReviewError maps the errors of reviewing a submission to a response
+func respondKYCReviewError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrKYCSubmissionNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "kyc_submission_not_found",
+			Message: "The specified KYC submission was not found",
+		})
+	case domain.ErrKYCAlreadyReviewed:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "kyc_already_reviewed",
+			Message: err.Error(),
+		})
+	case domain.ErrRejectionReasonMissing:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+	case domain.ErrSegregationOfDuties:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "segregation_of_duties",
+			Message: "You cannot review your own identity verification",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "kyc_review_failed",
+			Message: "Failed to process the KYC submission",
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,11 +11,13 @@ import (
 
 type LoanHandler struct {
 	loanService domain.LoanService
+	policy      domain.PermissionPolicy
 }
 
-func NewLoanHandler(loanService domain.LoanService) *LoanHandler {
+func NewLoanHandler(loanService domain.LoanService, policy domain.PermissionPolicy) *LoanHandler {
 	return &LoanHandler{
 		loanService: loanService,
+		policy:      policy,
 	}
 }
 
@@ -50,24 +53,29 @@ func (h *LoanHandler) CreateLoan(c *gin.Context) {
 		return
 	}
 
-	// Only borrowers can create loans
-	if userObj.Role != domain.RoleBorrower {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Success: false,
-			Error:   "forbidden",
-			Message: "Only borrowers can create loans",
-		})
-		return
-	}
-
 	// Convert handler DTO to service parameters
-	loan, err := h.loanService.CreateLoan(c.Request.Context(), userObj.ID, req.PrincipalAmount, req.Rate)
+	loan, err := h.loanService.CreateLoan(c.Request.Context(), userObj.ID, req.PrincipalAmount, req.Rate, req.TenorMonths)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Success: false,
-			Error:   "creation_failed",
-			Message: "Failed to create loan",
-		})
+		switch err {
+		case domain.ErrEmailNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "email_not_verified",
+				Message: "Verify your email address before creating a loan",
+			})
+		case domain.ErrKYCNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "kyc_not_verified",
+				Message: "Complete identity verification (KYC) before creating a loan",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "creation_failed",
+				Message: "Failed to create loan",
+			})
+		}
 		return
 	}
 
@@ -115,34 +123,61 @@ func (h *LoanHandler) ApproveLoan(c *gin.Context) {
 		return
 	}
 
-	// Only field validators can approve loans
-	if userObj.Role != domain.RoleFieldValidator {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Success: false,
-			Error:   "forbidden",
-			Message: "Only field validators can approve loans",
-		})
-		return
-	}
-
 	// Convert handler DTO to service parameters
-	err = h.loanService.ApproveLoan(c.Request.Context(), loanID, userObj.ID, req.PhotoProofURL, req.ApprovalDate)
+	err = h.loanService.ApproveLoan(c.Request.Context(), loanID, userObj.ID, req.PhotoProofID, req.ApprovalDate, domain.FieldEvidence{
+		Latitude:   *req.Latitude,
+		Longitude:  *req.Longitude,
+		CapturedAt: req.CapturedAt,
+	})
 	if err != nil {
 		switch err {
-		case domain.ErrLoanNotFound:
+		case domain.ErrLoanNotFound, domain.ErrPhotoProofNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		case domain.ErrLoanAlreadyApproved:
+		case domain.ErrLoanAlreadyApproved, domain.ErrLoanRejected:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case domain.ErrApprovalStageCompleted, domain.ErrTaskCompleted:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case domain.ErrTaskNotAssigned, domain.ErrSegregationOfDuties:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve loan"})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Loan approved successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Field verification recorded successfully"})
 }
 
-func (h *LoanHandler) GetMyLoans(c *gin.Context) {
+func (h *LoanHandler) SubmitCreditReview(c *gin.Context) {
+	h.submitReview(c, h.loanService.SubmitCreditReview)
+}
+
+func (h *LoanHandler) SubmitCommitteeDecision(c *gin.Context) {
+	h.submitReview(c, h.loanService.SubmitCommitteeDecision)
+}
+
+// submitReview handles the shared request parsing and error mapping of the later approval stages
+func (h *LoanHandler) submitReview(c *gin.Context, submit func(ctx context.Context, loanID, actorID uuid.UUID, decision domain.ApprovalDecision, comment string) error) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	var req ReviewLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Get user from context
 	user, exists := c.Get("user")
 	if !exists {
@@ -164,12 +199,124 @@ func (h *LoanHandler) GetMyLoans(c *gin.Context) {
 		return
 	}
 
-	// Only borrowers can access this endpoint
-	if userObj.Role != domain.RoleBorrower {
-		c.JSON(http.StatusForbidden, ErrorResponse{
+	err = submit(c.Request.Context(), loanID, userObj.ID, req.Decision, req.Comment)
+	if err != nil {
+		switch err {
+		case domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+		case domain.ErrInvalidApprovalDecision:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_decision",
+				Message: err.Error(),
+			})
+		case domain.ErrLoanAlreadyApproved, domain.ErrLoanRejected:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_loan_state",
+				Message: err.Error(),
+			})
+		case domain.ErrApprovalStageNotRequired, domain.ErrApprovalStageOutOfOrder:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_approval_stage",
+				Message: err.Error(),
+			})
+		case domain.ErrApprovalStageCompleted:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "stage_completed",
+				Message: err.Error(),
+			})
+		case domain.ErrSegregationOfDuties:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "duty_conflict",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "review_failed",
+				Message: "Failed to record approval decision",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Approval decision recorded successfully", nil))
+}
+
+func (h *LoanHandler) GetApprovalStages(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	stages, err := h.loanService.GetApprovalStages(c.Request.Context(), loanID)
+	if err != nil {
+		switch err {
+		case domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch approval stages",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapApprovalStagesToResponse(stages)))
+}
+
+// GetFlaggedApprovals lists field verifications whose evidence was too far away, too old or inconsistent
+func (h *LoanHandler) GetFlaggedApprovals(c *gin.Context) {
+	approvals, err := h.loanService.GetFlaggedApprovals(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch flagged approvals",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapApprovalsToResponse(approvals)))
+}
+
+func (h *LoanHandler) GetMyLoans(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Success: false,
-			Error:   "forbidden",
-			Message: "Only borrowers can access their loans through this endpoint",
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
 		})
 		return
 	}
@@ -185,16 +332,10 @@ func (h *LoanHandler) GetMyLoans(c *gin.Context) {
 		return
 	}
 
-	// Convert domain entities to handler responses
-	responses := make([]LoanResponse, 0, len(loans))
-	for _, loan := range loans {
-		response := MapLoanToResponse(&loan, true, true) // Include borrower and investment details
-		responses = append(responses, response)
-	}
-
+	// Include borrower and investment details, shaped to the caller
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
-		Data:    responses,
+		Data:    NewPIIShaper(h.policy, userObj).Loans(loans),
 	})
 }
 
@@ -217,16 +358,19 @@ func (h *LoanHandler) GetLoans(c *gin.Context) {
 	var loans []domain.Loan
 	var err error
 
-	if userObj.Role == domain.RoleBorrower {
+	switch {
+	case h.policy.Allows(userObj, domain.PermissionLoanBrowse) && stateStr != "":
+		// Investors and staff can filter by state
+		loans, err = h.loanService.GetLoansByState(c.Request.Context(), domain.LoanState(stateStr))
+	case h.policy.Allows(userObj, domain.PermissionLoanBrowse):
+		// Without a state filter, get approved loans
+		loans, err = h.loanService.GetLoansByState(c.Request.Context(), domain.LoanStateApproved)
+	case h.policy.Allows(userObj, domain.PermissionLoanViewOwn):
 		// Borrowers can only see their own loans
 		loans, err = h.loanService.GetBorrowerLoansByUserID(c.Request.Context(), userObj.ID)
-	} else if stateStr != "" {
-		// Staff members can filter by state
-		state := domain.LoanState(stateStr)
-		loans, err = h.loanService.GetLoansByState(c.Request.Context(), state)
-	} else {
-		// For staff without state filter, get approved loans
-		loans, err = h.loanService.GetLoansByState(c.Request.Context(), domain.LoanStateApproved)
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
 	}
 
 	if err != nil {
@@ -234,7 +378,7 @@ func (h *LoanHandler) GetLoans(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, loans)
+	c.JSON(http.StatusOK, NewPIIShaper(h.policy, userObj).Loans(loans))
 }
 
 func (h *LoanHandler) GetLoan(c *gin.Context) {
@@ -245,6 +389,18 @@ func (h *LoanHandler) GetLoan(c *gin.Context) {
 		return
 	}
 
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user type"})
+		return
+	}
+
 	loan, err := h.loanService.GetLoanByID(c.Request.Context(), loanID)
 	if err != nil {
 		switch err {
@@ -256,7 +412,8 @@ func (h *LoanHandler) GetLoan(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, loan)
+	// Personal data of the borrower and investors depends on who is asking
+	c.JSON(http.StatusOK, NewPIIShaper(h.policy, userObj).Loan(loan))
 }
 
 func (h *LoanHandler) DisburseLoan(c *gin.Context) {
@@ -286,19 +443,17 @@ func (h *LoanHandler) DisburseLoan(c *gin.Context) {
 		return
 	}
 
-	// Only field officers can disburse loans
-	if userObj.Role != domain.RoleFieldOfficer {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only field officers can disburse loans"})
-		return
-	}
-
-	err = h.loanService.DisburseLoan(c.Request.Context(), loanID, userObj.ID, req.AgreementFileURL, req.DisbursementDate)
+	err = h.loanService.DisburseLoan(c.Request.Context(), loanID, userObj.ID, req.AgreementDocumentID, req.DisbursementDate)
 	if err != nil {
 		switch err {
-		case domain.ErrLoanNotFound:
+		case domain.ErrLoanNotFound, domain.ErrDocumentNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		case domain.ErrLoanNotInvested:
+		case domain.ErrLoanNotInvested, domain.ErrInvalidDocumentType:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case domain.ErrAgreementNotSigned:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case domain.ErrSegregationOfDuties:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disburse loan"})
 		}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
@@ -11,9 +14,22 @@ import (
 
 func MapUserToResponse(user *domain.User) UserResponse {
 	return UserResponse{
-		ID:    user.ID,
-		Email: user.Email,
-		Role:  user.Role,
+		ID:            user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.IsEmailVerified(),
+		KYCVerified:   user.IsKYCVerified(),
+	}
+}
+
+func MapRegisterRequestToInput(req *RegisterRequest) domain.RegistrationInput {
+	return domain.RegistrationInput{
+		Email:          req.Email,
+		Password:       req.Password,
+		FullName:       req.FullName,
+		PhoneNumber:    req.PhoneNumber,
+		Address:        req.Address,
+		IdentityNumber: req.IdentityNumber,
 	}
 }
 
@@ -79,9 +95,11 @@ func MapLoanToResponse(loan *domain.Loan, includeBorrower, includeInvestments bo
 		PrincipalAmount:     loan.PrincipalAmount,
 		InvestedAmount:      loan.InvestedAmount,
 		RemainingInvestment: loan.RemainingInvestment,
+		ReservedAmount:      loan.ReservedAmount,
 		Rate:                loan.Rate,
 		ROI:                 loan.ROI,
 		TotalInterest:       loan.TotalInterest,
+		TenorMonths:         loan.TenorMonths,
 		State:               loan.State,
 		CreatedAt:           loan.CreatedAt,
 		UpdatedAt:           loan.UpdatedAt,
@@ -101,6 +119,82 @@ func MapLoanToResponse(loan *domain.Loan, includeBorrower, includeInvestments bo
 	return response
 }
 
+func MapApprovalStageToResponse(stage *domain.ApprovalStage) ApprovalStageResponse {
+	return ApprovalStageResponse{
+		ID:        stage.ID,
+		LoanID:    stage.LoanID,
+		Stage:     stage.Stage,
+		ActorID:   stage.ActorID,
+		ActorRole: stage.ActorRole,
+		Decision:  stage.Decision,
+		Comment:   stage.Comment,
+		DecidedAt: stage.DecidedAt,
+	}
+}
+
+func MapPhotoProofToResponse(proof *domain.PhotoProof) PhotoProofResponse {
+	return PhotoProofResponse{
+		ID:           proof.ID,
+		LoanID:       proof.LoanID,
+		UploaderID:   proof.UploaderID,
+		ContentType:  proof.ContentType,
+		Size:         proof.Size,
+		SHA256:       proof.SHA256,
+		OriginalName: proof.OriginalName,
+		DownloadURL:  fmt.Sprintf("/api/loans/%s/photo-proofs/%s", proof.LoanID, proof.ID),
+
+		ExifLatitude:   proof.ExifLatitude,
+		ExifLongitude:  proof.ExifLongitude,
+		ExifCapturedAt: proof.ExifCapturedAt,
+		CreatedAt:      proof.CreatedAt,
+	}
+}
+
+func MapApprovalToResponse(approval *domain.Approval) ApprovalResponse {
+	response := ApprovalResponse{
+		ID:             approval.ID,
+		LoanID:         approval.LoanID,
+		ValidatorID:    approval.ValidatorID,
+		PhotoProofURL:  approval.PhotoProofURL,
+		ApprovalDate:   approval.ApprovalDate,
+		Latitude:       approval.Latitude,
+		Longitude:      approval.Longitude,
+		CapturedAt:     approval.CapturedAt,
+		DistanceMeters: approval.DistanceMeters,
+		EvidenceFlags:  approval.EvidenceFlags,
+		NeedsReview:    approval.NeedsReview,
+		CreatedAt:      approval.CreatedAt,
+	}
+
+	if response.EvidenceFlags == nil {
+		response.EvidenceFlags = []domain.EvidenceFlag{}
+	}
+
+	// Include photo proof if loaded
+	if approval.PhotoProof != nil {
+		proofResp := MapPhotoProofToResponse(approval.PhotoProof)
+		response.PhotoProof = &proofResp
+	}
+
+	return response
+}
+
+func MapApprovalsToResponse(approvals []domain.Approval) []ApprovalResponse {
+	responses := make([]ApprovalResponse, len(approvals))
+	for i, approval := range approvals {
+		responses[i] = MapApprovalToResponse(&approval)
+	}
+	return responses
+}
+
+func MapApprovalStagesToResponse(stages []domain.ApprovalStage) []ApprovalStageResponse {
+	responses := make([]ApprovalStageResponse, len(stages))
+	for i, stage := range stages {
+		responses[i] = MapApprovalStageToResponse(&stage)
+	}
+	return responses
+}
+
 // ============================================================================
 // INVESTMENT MAPPERS
 // ============================================================================
@@ -130,6 +224,309 @@ func MapInvestmentToResponse(investment *domain.Investment, includeLoan, include
 	return response
 }
 
+func MapInvestmentHoldToResponse(hold *domain.InvestmentHold) InvestmentHoldResponse {
+	return InvestmentHoldResponse{
+		ID:           hold.ID,
+		LoanID:       hold.LoanID,
+		InvestorID:   hold.InvestorID,
+		Amount:       hold.Amount,
+		Status:       hold.Status,
+		InvestmentID: hold.InvestmentID,
+		ExpiresAt:    hold.ExpiresAt,
+		CreatedAt:    hold.CreatedAt,
+	}
+}
+
+// ============================================================================
+// WAITLIST MAPPERS
+// ============================================================================
+
+func MapWaitlistEntryToResponse(entry *domain.WaitlistEntry) WaitlistEntryResponse {
+	return WaitlistEntryResponse{
+		ID:         entry.ID,
+		LoanID:     entry.LoanID,
+		InvestorID: entry.InvestorID,
+		Amount:     entry.Amount,
+		Status:     entry.Status,
+		Position:   entry.Position,
+		HoldID:     entry.HoldID,
+		CreatedAt:  entry.CreatedAt,
+		UpdatedAt:  entry.UpdatedAt,
+	}
+}
+
+// ============================================================================
+// DOCUMENT MAPPERS
+// ============================================================================
+
+func MapDocumentToResponse(document *domain.Document) DocumentResponse {
+	return DocumentResponse{
+		ID:          document.ID,
+		LoanID:      document.LoanID,
+		Type:        document.Type,
+		FileName:    document.FileName,
+		ContentType: document.ContentType,
+		Size:        document.Size,
+		SHA256:      document.SHA256,
+		CreatedAt:   document.CreatedAt,
+	}
+}
+
+func MapDocumentsToResponse(documents []domain.Document) []DocumentResponse {
+	responses := make([]DocumentResponse, len(documents))
+	for i, document := range documents {
+		responses[i] = MapDocumentToResponse(&document)
+	}
+	return responses
+}
+
+// ============================================================================
+// KYC MAPPERS
+// ============================================================================
+
+// MapKYCSubmissionToResponse shows the customer their submission, without links to the documents
+func MapKYCSubmissionToResponse(submission *domain.KYCSubmission) KYCSubmissionResponse {
+	response := KYCSubmissionResponse{
+		ID:              submission.ID,
+		UserID:          submission.UserID,
+		Status:          submission.Status,
+		RejectionReason: submission.RejectionReason,
+		ReviewedAt:      submission.ReviewedAt,
+		Documents:       make([]KYCDocumentResponse, len(submission.Documents)),
+		CreatedAt:       submission.CreatedAt,
+	}
+	for i, document := range submission.Documents {
+		response.Documents[i] = KYCDocumentResponse{
+			ID:          document.ID,
+			Type:        document.Type,
+			ContentType: document.ContentType,
+			Size:        document.Size,
+			SHA256:      document.SHA256,
+			CreatedAt:   document.CreatedAt,
+		}
+	}
+	return response
+}
+
+// MapKYCSubmissionToReviewResponse shows a reviewer the submission, its customer and document links
+func MapKYCSubmissionToReviewResponse(submission *domain.KYCSubmission) KYCSubmissionResponse {
+	response := MapKYCSubmissionToResponse(submission)
+	for i := range response.Documents {
+		response.Documents[i].DownloadURL = fmt.Sprintf("/api/kyc/reviews/%s/documents/%s", submission.ID, response.Documents[i].ID)
+	}
+	if submission.User.ID != uuid.Nil {
+		user := MapUserToResponse(&submission.User)
+		response.User = &user
+	}
+	return response
+}
+
+func MapKYCSubmissionsToReviewResponse(submissions []domain.KYCSubmission) []KYCSubmissionResponse {
+	responses := make([]KYCSubmissionResponse, len(submissions))
+	for i, submission := range submissions {
+		responses[i] = MapKYCSubmissionToReviewResponse(&submission)
+	}
+	return responses
+}
+
+func MapKYCReviewToResponse(review *domain.KYCReview) KYCReviewResponse {
+	return KYCReviewResponse{
+		KYCSubmissionResponse: MapKYCSubmissionToReviewResponse(review.Submission),
+		ReviewerID:            review.Submission.ReviewerID,
+		FullName:              review.FullName,
+		IdentityNumber:        review.IdentityNumber,
+	}
+}
+
+// ============================================================================
+// PRIVACY MAPPERS
+// ============================================================================
+
+// MapErasureRequestToResponse shows the customer their request, without the account details
+func MapErasureRequestToResponse(request *domain.ErasureRequest) ErasureRequestResponse {
+	return ErasureRequestResponse{
+		ID:              request.ID,
+		UserID:          request.UserID,
+		RequestedByID:   request.RequestedByID,
+		Status:          request.Status,
+		ProcessedByID:   request.ProcessedByID,
+		ProcessedAt:     request.ProcessedAt,
+		RejectionReason: request.RejectionReason,
+		CreatedAt:       request.CreatedAt,
+	}
+}
+
+// MapErasureRequestToReviewResponse shows staff the request with the account it concerns
+func MapErasureRequestToReviewResponse(request *domain.ErasureRequest) ErasureRequestResponse {
+	response := MapErasureRequestToResponse(request)
+	if request.User.ID != uuid.Nil {
+		user := MapUserToResponse(&request.User)
+		response.User = &user
+	}
+	return response
+}
+
+func MapErasureRequestsToReviewResponse(requests []domain.ErasureRequest) []ErasureRequestResponse {
+	responses := make([]ErasureRequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = MapErasureRequestToReviewResponse(&request)
+	}
+	return responses
+}
+
+// ============================================================================
+// VERIFICATION TASK MAPPERS
+// ============================================================================
+
+func MapVerificationTaskToResponse(task *domain.VerificationTask) VerificationTaskResponse {
+	response := VerificationTaskResponse{
+		ID:                task.ID,
+		LoanID:            task.LoanID,
+		AssigneeID:        task.AssigneeID,
+		Region:            task.Region,
+		Status:            task.Status,
+		DueAt:             task.DueAt,
+		Overdue:           task.IsOverdue(time.Now()),
+		AssignedAt:        task.AssignedAt,
+		ReassignmentCount: task.ReassignmentCount,
+		CompletedAt:       task.CompletedAt,
+	}
+	if task.Loan.ID != uuid.Nil {
+		loan := MapLoanToResponse(&task.Loan, true, false)
+		response.Loan = &loan
+	}
+	return response
+}
+
+func MapVerificationTasksToResponse(tasks []domain.VerificationTask) []VerificationTaskResponse {
+	responses := make([]VerificationTaskResponse, len(tasks))
+	for i, task := range tasks {
+		responses[i] = MapVerificationTaskToResponse(&task)
+	}
+	return responses
+}
+
+// ============================================================================
+// AGREEMENT SIGNING MAPPERS
+// ============================================================================
+
+func MapAgreementSignatureToResponse(signature *domain.AgreementSignature) AgreementSignatureResponse {
+	return AgreementSignatureResponse{
+		ID:             signature.ID,
+		DocumentID:     signature.DocumentID,
+		SignerUserID:   signature.SignerUserID,
+		DocumentSHA256: signature.DocumentSHA256,
+		SignedName:     signature.SignedName,
+		ConsentText:    signature.ConsentText,
+		IPAddress:      signature.IPAddress,
+		SignedAt:       signature.SignedAt,
+	}
+}
+
+func MapBorrowerAgreementToResponse(agreement *domain.BorrowerAgreement) BorrowerAgreementResponse {
+	response := BorrowerAgreementResponse{
+		Document: MapDocumentToResponse(agreement.Document),
+		Signed:   agreement.Signature != nil,
+	}
+	if agreement.Signature != nil {
+		signature := MapAgreementSignatureToResponse(agreement.Signature)
+		response.Signature = &signature
+	}
+	return response
+}
+
+// ============================================================================
+// SEGREGATION OF DUTIES MAPPERS
+// ============================================================================
+
+func MapStaffRelationshipToResponse(relationship *domain.StaffRelationship) StaffRelationshipResponse {
+	return StaffRelationshipResponse{
+		ID:           relationship.ID,
+		BorrowerID:   relationship.BorrowerID,
+		Relationship: relationship.Relationship,
+		Note:         relationship.Note,
+		CreatedAt:    relationship.CreatedAt,
+	}
+}
+
+func MapStaffRelationshipsToResponse(relationships []domain.StaffRelationship) []StaffRelationshipResponse {
+	responses := make([]StaffRelationshipResponse, len(relationships))
+	for i, relationship := range relationships {
+		responses[i] = MapStaffRelationshipToResponse(&relationship)
+	}
+	return responses
+}
+
+// ============================================================================
+// USER ADMINISTRATION MAPPERS
+// ============================================================================
+
+func MapAdminUserToResponse(user *domain.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:             user.ID,
+		Email:          user.Email,
+		Role:           user.Role,
+		Region:         user.Region,
+		Branch:         user.Branch,
+		EmailVerified:  user.IsEmailVerified(),
+		Active:         user.IsActive(),
+		ServiceAccount: user.ServiceAccount,
+		DeactivatedAt:  user.DeactivatedAt,
+		FailedLogins:   user.FailedLoginCount,
+		LockedUntil:    user.LockedUntil,
+		CreatedAt:      user.CreatedAt,
+	}
+}
+
+func MapAPIKeyToResponse(key *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:               key.ID,
+		ServiceAccountID: key.UserID,
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		Scopes:           key.Scopes,
+		Active:           key.IsActive(time.Now()),
+		ExpiresAt:        key.ExpiresAt,
+		LastUsedAt:       key.LastUsedAt,
+		RevokedAt:        key.RevokedAt,
+		ReplacedByID:     key.ReplacedByID,
+		CreatedAt:        key.CreatedAt,
+	}
+}
+
+func MapAPIKeysToResponse(keys []domain.APIKey) []APIKeyResponse {
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = MapAPIKeyToResponse(&key)
+	}
+	return responses
+}
+
+func MapAdminUsersToResponse(users []domain.User) []AdminUserResponse {
+	responses := make([]AdminUserResponse, len(users))
+	for i, user := range users {
+		responses[i] = MapAdminUserToResponse(&user)
+	}
+	return responses
+}
+
+func MapLoginEventsToResponse(events []domain.LoginEvent) []LoginEventResponse {
+	responses := make([]LoginEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = LoginEventResponse{
+			ID:        event.ID,
+			UserID:    event.UserID,
+			Email:     event.Email,
+			Succeeded: event.Succeeded,
+			Reason:    event.Reason,
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		}
+	}
+	return responses
+}
+
 // ============================================================================
 // COLLECTION MAPPERS
 // ============================================================================
@@ -150,6 +547,14 @@ func MapInvestmentsToResponse(investments []domain.Investment, includeLoan, incl
 	return responses
 }
 
+func MapWaitlistEntriesToResponse(entries []domain.WaitlistEntry) []WaitlistEntryResponse {
+	responses := make([]WaitlistEntryResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = MapWaitlistEntryToResponse(&entry)
+	}
+	return responses
+}
+
 // ============================================================================
 // HELPER FUNCTIONS FOR API RESPONSES
 // ============================================================================
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type PhotoProofHandler struct {
+	photoProofService domain.PhotoProofService
+	maxUploadSize     int64
+}
+
+func NewPhotoProofHandler(photoProofService domain.PhotoProofService, maxUploadSize int64) *PhotoProofHandler {
+	return &PhotoProofHandler{
+		photoProofService: photoProofService,
+		maxUploadSize:     maxUploadSize,
+	}
+}
+
+// UploadPhotoProof accepts a multipart form with the image in the "file" field
+func (h *PhotoProofHandler) UploadPhotoProof(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: "Multipart field 'file' is required",
+		})
+		return
+	}
+
+	if fileHeader.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Success: false,
+			Error:   "file_too_large",
+			Message: fmt.Sprintf("Photo proof must not exceed %d bytes", h.maxUploadSize),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so oversized uploads are still detected by the service
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_file",
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	proof, err := h.photoProofService.UploadPhotoProof(c.Request.Context(), loanID, userObj.ID, fileHeader.Filename, data)
+	if err != nil {
+		switch err {
+		case domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+		case domain.ErrInvalidLoanState:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_loan_state",
+				Message: "Photo proofs can only be uploaded for loans awaiting field verification",
+			})
+		case domain.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "empty_file",
+				Message: err.Error(),
+			})
+		case domain.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Success: false,
+				Error:   "file_too_large",
+				Message: fmt.Sprintf("Photo proof must not exceed %d bytes", h.maxUploadSize),
+			})
+		case domain.ErrUnsupportedFileType:
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Success: false,
+				Error:   "unsupported_file_type",
+				Message: "Photo proof must be a JPEG or PNG image",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "upload_failed",
+				Message: "Failed to store photo proof",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Photo proof uploaded", MapPhotoProofToResponse(proof)))
+}
+
+func (h *PhotoProofHandler) DownloadPhotoProof(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	proofID, err := uuid.Parse(c.Param("proofId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid photo proof ID format",
+		})
+		return
+	}
+
+	proof, content, err := h.photoProofService.GetPhotoProof(c.Request.Context(), loanID, proofID)
+	if err != nil {
+		switch err {
+		case domain.ErrPhotoProofNotFound, domain.ErrStoredObjectNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "photo_proof_not_found",
+				Message: "The specified photo proof was not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch photo proof",
+			})
+		}
+		return
+	}
+	defer content.Close()
+
+	c.Header("ETag", `"`+proof.SHA256+`"`)
+	c.DataFromReader(http.StatusOK, proof.Size, proof.ContentType, content, nil)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// PIIAccess is how much personal data of a borrower or investor a caller may see
+type PIIAccess int
+
+const (
+	PIIHidden PIIAccess = iota // The person is left out of the response
+	PIIMasked                  // Name only, identity and phone number masked, address and account omitted
+	PIIFull
+)
+
+// Characters left readable at the end of masked values
+const (
+	maskedIdentityVisible = 4
+	maskedPhoneVisible    = 3
+)
+
+// PIIShaper shapes borrower and investor data in responses to the caller. Staff allowed to view
+// personal data and the person the record belongs to see everything, investors see a masked
+// borrower of the loans they browse, everyone else sees no personal data.
+type PIIShaper struct {
+	viewerID uuid.UUID
+	viewAll  bool // loan:view_pii
+	browse   bool // loan:browse
+}
+
+func NewPIIShaper(policy domain.PermissionPolicy, viewer *domain.User) PIIShaper {
+	return PIIShaper{
+		viewerID: viewer.ID,
+		viewAll:  policy.Allows(viewer, domain.PermissionLoanViewPII),
+		browse:   policy.Allows(viewer, domain.PermissionLoanBrowse),
+	}
+}
+
+func (s PIIShaper) BorrowerAccess(borrower *domain.Borrower) PIIAccess {
+	switch {
+	case s.viewAll || borrower.UserID == s.viewerID:
+		return PIIFull
+	case s.browse:
+		return PIIMasked
+	default:
+		return PIIHidden
+	}
+}
+
+// InvestorAccess never masks, investors stay anonymous to borrowers and to each other
+func (s PIIShaper) InvestorAccess(investor *domain.Investor) PIIAccess {
+	if s.viewAll || investor.UserID == s.viewerID {
+		return PIIFull
+	}
+	return PIIHidden
+}
+
+// Borrower returns nil when the caller may not see the borrower
+func (s PIIShaper) Borrower(borrower *domain.Borrower) *BorrowerResponse {
+	access := s.BorrowerAccess(borrower)
+	if access == PIIHidden {
+		return nil
+	}
+
+	response := MapBorrowerToResponse(borrower)
+	if access == PIIMasked {
+		response.IdentityNumber = maskPII(response.IdentityNumber, maskedIdentityVisible)
+		response.PhoneNumber = maskPII(response.PhoneNumber, maskedPhoneVisible)
+		response.Address = ""
+		response.User = nil
+	}
+	return &response
+}
+
+// Investor returns nil when the caller may not see the investor
+func (s PIIShaper) Investor(investor *domain.Investor) *InvestorResponse {
+	if s.InvestorAccess(investor) == PIIHidden {
+		return nil
+	}
+	response := MapInvestorToResponse(investor)
+	return &response
+}
+
+// Loan maps the loan with its borrower and investments shaped to the caller
+func (s PIIShaper) Loan(loan *domain.Loan) LoanResponse {
+	response := MapLoanToResponse(loan, false, false)
+	if loan.Borrower.ID != uuid.Nil {
+		response.Borrower = s.Borrower(&loan.Borrower)
+	}
+	if len(loan.Investments) > 0 {
+		response.Investments = s.Investments(loan.Investments)
+	}
+	return response
+}
+
+func (s PIIShaper) Loans(loans []domain.Loan) []LoanResponse {
+	responses := make([]LoanResponse, len(loans))
+	for i, loan := range loans {
+		responses[i] = s.Loan(&loan)
+	}
+	return responses
+}
+
+// Investments maps investments with their investor shaped to the caller
+func (s PIIShaper) Investments(investments []domain.Investment) []InvestmentResponse {
+	responses := make([]InvestmentResponse, len(investments))
+	for i, investment := range investments {
+		responses[i] = MapInvestmentToResponse(&investment, false, false)
+		if investment.Investor.ID != uuid.Nil {
+			responses[i].Investor = s.Investor(&investment.Investor)
+		}
+	}
+	return responses
+}
+
+// maskPII replaces all but the last visible characters with asterisks
+func maskPII(value string, visible int) string {
+	runes := []rune(value)
+	if len(runes) <= visible {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-visible) + string(runes[len(runes)-visible:])
+}
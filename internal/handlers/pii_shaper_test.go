@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = service.NewPermissionPolicy(&config.AuthorizationConfig{RolePermissions: config.DefaultRolePermissions()})
+
+// newTestLoan returns a loan with its borrower and two investments, all with personal data loaded
+func newTestLoan() *domain.Loan {
+	borrowerUserID := uuid.New()
+	borrower := domain.Borrower{
+		ID:             uuid.New(),
+		UserID:         borrowerUserID,
+		FullName:       "John Doe",
+		PhoneNumber:    "+6281234567890",
+		Address:        "Jl. Sudirman No. 1, Jakarta",
+		IdentityNumber: "3171234567890001",
+		User:           domain.User{ID: borrowerUserID, Email: "borrower1@example.com", Role: domain.RoleBorrower},
+	}
+
+	loan := &domain.Loan{ID: uuid.New(), BorrowerID: borrower.ID, Borrower: borrower, State: domain.LoanStateApproved}
+	for _, name := range []string{"Alice Investor", "Bob Investor"} {
+		userID := uuid.New()
+		investor := domain.Investor{
+			ID:             uuid.New(),
+			UserID:         userID,
+			FullName:       name,
+			PhoneNumber:    "+6289876543210",
+			Address:        "Jl. Thamrin No. 2, Jakarta",
+			IdentityNumber: "3179876543210002",
+			User:           domain.User{ID: userID, Email: name + "@example.com", Role: domain.RoleInvestor},
+		}
+		loan.Investments = append(loan.Investments, domain.Investment{
+			ID:         uuid.New(),
+			LoanID:     loan.ID,
+			InvestorID: investor.ID,
+			Investor:   investor,
+			Amount:     50000,
+		})
+	}
+	return loan
+}
+
+// Test PII Shaper - Every Role Sees The Borrower And Investors Of A Loan As Allowed
+func TestPIIShaper_Loan_Roles(t *testing.T) {
+	loan := newTestLoan()
+
+	tests := []struct {
+		name         string
+		viewer       *domain.User
+		borrower     PIIAccess
+		ownInvestor  int // Index of the viewer's own investment, -1 if none
+		seeInvestors bool
+	}{
+		{"owning borrower", &loan.Borrower.User, PIIFull, -1, false},
+		{"other borrower", &domain.User{ID: uuid.New(), Role: domain.RoleBorrower}, PIIHidden, -1, false},
+		{"investor of the loan", &loan.Investments[0].Investor.User, PIIMasked, 0, false},
+		{"other investor", &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}, PIIMasked, -1, false},
+		{"field validator", &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}, PIIFull, -1, true},
+		{"credit analyst", &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst}, PIIFull, -1, true},
+		{"credit committee", &domain.User{ID: uuid.New(), Role: domain.RoleCreditCommittee}, PIIFull, -1, true},
+		{"field officer", &domain.User{ID: uuid.New(), Role: domain.RoleFieldOfficer}, PIIFull, -1, true},
+		{"admin", &domain.User{ID: uuid.New(), Role: domain.RoleAdmin}, PIIHidden, -1, false},
+		{
+			"API key without loan:view_pii",
+			&domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst, Scopes: domain.Permissions{domain.PermissionLoanBrowse}},
+			PIIMasked, -1, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := NewPIIShaper(testPolicy, tt.viewer).Loan(loan)
+
+			switch tt.borrower {
+			case PIIFull:
+				require.NotNil(t, response.Borrower)
+				assert.Equal(t, "3171234567890001", response.Borrower.IdentityNumber)
+				assert.Equal(t, "+6281234567890", response.Borrower.PhoneNumber)
+				assert.Equal(t, "Jl. Sudirman No. 1, Jakarta", response.Borrower.Address)
+				assert.NotNil(t, response.Borrower.User)
+			case PIIMasked:
+				require.NotNil(t, response.Borrower)
+				assert.Equal(t, "John Doe", response.Borrower.FullName)
+				assert.Equal(t, "************0001", response.Borrower.IdentityNumber)
+				assert.Equal(t, "***********890", response.Borrower.PhoneNumber)
+				assert.Empty(t, response.Borrower.Address)
+				assert.Nil(t, response.Borrower.User)
+			case PIIHidden:
+				assert.Nil(t, response.Borrower)
+			}
+
+			require.Len(t, response.Investments, 2)
+			for i, investment := range response.Investments {
+				assert.Equal(t, 50000.0, investment.Amount)
+				if tt.seeInvestors || i == tt.ownInvestor {
+					require.NotNil(t, investment.Investor)
+					assert.Equal(t, "3179876543210002", investment.Investor.IdentityNumber)
+				} else {
+					assert.Nil(t, investment.Investor)
+				}
+			}
+		})
+	}
+}
+
+// Test PII Shaper - Masked Borrowers Carry No Personal Data In The JSON
+func TestPIIShaper_Borrower_MaskedJSON(t *testing.T) {
+	loan := newTestLoan()
+	investor := &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}
+
+	body, err := json.Marshal(NewPIIShaper(testPolicy, investor).Loan(loan))
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(body), "3171234567890001")
+	assert.NotContains(t, string(body), "+6281234567890")
+	assert.NotContains(t, string(body), "Sudirman")
+	assert.NotContains(t, string(body), "borrower1@example.com")
+	assert.NotContains(t, string(body), "Alice Investor")
+}
+
+// Test PII Masking - Only The Last Characters Stay Readable
+func TestMaskPII(t *testing.T) {
+	assert.Equal(t, "************0001", maskPII("3171234567890001", 4))
+	assert.Equal(t, "***", maskPII("123", 4))
+	assert.Equal(t, "", maskPII("", 4))
+}
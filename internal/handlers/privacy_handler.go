@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type PrivacyHandler struct {
+	privacyService domain.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService domain.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// ExportMyData downloads a zip archive of everything stored about the caller
+func (h *PrivacyHandler) ExportMyData(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	h.export(c, userObj.ID, userObj.ID)
+}
+
+// ExportUserData downloads a zip archive of everything stored about a user, on the user's behalf
+func (h *PrivacyHandler) ExportUserData(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	h.export(c, userObj.ID, userID)
+}
+
+func (h *PrivacyHandler) export(c *gin.Context, actorID uuid.UUID, userID uuid.UUID) {
+	archive, err := h.privacyService.ExportPersonalData(c.Request.Context(), actorID, userID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "user_not_found",
+				Message: "User not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "export_failed",
+				Message: "Failed to export personal data",
+			})
+		}
+		return
+	}
+
+	// The archive holds identity documents, it must not linger in shared caches
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archive.FileName))
+	c.Data(http.StatusOK, "application/zip", archive.Data)
+}
+
+// RequestMyErasure asks to erase the caller's personal data
+func (h *PrivacyHandler) RequestMyErasure(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	request, err := h.privacyService.RequestErasure(c.Request.Context(), userObj.ID, userObj.ID)
+	if err != nil {
+		respondErasureRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Erasure of your personal data requested", MapErasureRequestToResponse(request)))
+}
+
+// RequestUserErasure files an erasure request on behalf of a customer, e.g. one received by mail
+func (h *PrivacyHandler) RequestUserErasure(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	request, err := h.privacyService.RequestErasure(c.Request.Context(), userObj.ID, userID)
+	if err != nil {
+		respondErasureRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Erasure requested", MapErasureRequestToReviewResponse(request)))
+}
+
+func (h *PrivacyHandler) GetMyErasureRequest(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	request, err := h.privacyService.GetMyErasureRequest(c.Request.Context(), userObj.ID)
+	if err != nil {
+		switch err {
+		case domain.ErrErasureRequestNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "erasure_not_requested",
+				Message: "You have not requested erasure of your personal data",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "fetch_failed",
+				Message: "Failed to fetch erasure request",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapErasureRequestToResponse(request)))
+}
+
+// ListErasureRequests lists requests oldest first, pending ones unless ?status= says otherwise
+func (h *PrivacyHandler) ListErasureRequests(c *gin.Context) {
+	var filter ErasureRequestFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	offset, limit := GetOffsetAndLimit(filter.Page, filter.PageSize)
+	requests, total, err := h.privacyService.ListErasureRequests(c.Request.Context(), filter.Status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch erasure requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedSuccessResponse(MapErasureRequestsToReviewResponse(requests), CalculatePagination(filter.Page, filter.PageSize, total)))
+}
+
+func (h *PrivacyHandler) ApproveErasure(c *gin.Context) {
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid erasure request ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	request, err := h.privacyService.ApproveErasure(c.Request.Context(), userObj.ID, requestID)
+	if err != nil {
+		respondErasureDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Personal data erased", MapErasureRequestToReviewResponse(request)))
+}
+
+func (h *PrivacyHandler) RejectErasure(c *gin.Context) {
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid erasure request ID format",
+		})
+		return
+	}
+
+	var req RejectErasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	request, err := h.privacyService.RejectErasure(c.Request.Context(), userObj.ID, requestID, req.Reason)
+	if err != nil {
+		respondErasureDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Erasure request rejected", MapErasureRequestToReviewResponse(request)))
+}
+
+// respondErasureRequestError maps the errors of filing an erasure request to a response
+func respondErasureRequestError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "user_not_found",
+			Message: "User not found",
+		})
+	case domain.ErrInvalidRole:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "invalid_role",
+			Message: "Only the personal data of borrowers and investors can be erased",
+		})
+	case domain.ErrAlreadyErased:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "already_erased",
+			Message: err.Error(),
+		})
+	case domain.ErrErasurePending:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "erasure_pending",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "request_failed",
+			Message: "Failed to request erasure",
+		})
+	}
+}
+
+// respondErasureDecisionError maps the errors of deciding on an erasure request to a response
+func respondErasureDecisionError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrErasureRequestNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "erasure_request_not_found",
+			Message: "The specified erasure request was not found",
+		})
+	case domain.ErrErasureProcessed:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "erasure_processed",
+			Message: err.Error(),
+		})
+	case domain.ErrErasureBlocked:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "erasure_blocked",
+			Message: "The customer still has a loan or investment in progress, reject the request or try again once it is disbursed",
+		})
+	case domain.ErrErasureReasonMissing:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+	case domain.ErrSegregationOfDuties:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Error:   "segregation_of_duties",
+			Message: "You cannot decide on the erasure of your own personal data",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "erasure_failed",
+			Message: "Failed to process the erasure request",
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type RelationshipHandler struct {
+	segregationService domain.SegregationService
+}
+
+func NewRelationshipHandler(segregationService domain.SegregationService) *RelationshipHandler {
+	return &RelationshipHandler{
+		segregationService: segregationService,
+	}
+}
+
+// DeclareRelationship records a staff member's conflict of interest with a borrower
+func (h *RelationshipHandler) DeclareRelationship(c *gin.Context) {
+	var req DeclareRelationshipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	relationship, err := h.segregationService.DeclareRelationship(c.Request.Context(), userObj.ID, req.BorrowerID, req.Relationship, req.Note)
+	if err != nil {
+		switch err {
+		case domain.ErrBorrowerNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "borrower_not_found",
+				Message: "The specified borrower was not found",
+			})
+		case domain.ErrRelationshipExists:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "relationship_exists",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "internal_error",
+				Message: "Failed to declare relationship",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Relationship declared", MapStaffRelationshipToResponse(relationship)))
+}
+
+// GetMyRelationships lists the relationships the caller declared
+func (h *RelationshipHandler) GetMyRelationships(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	relationships, err := h.segregationService.GetDeclaredRelationships(c.Request.Context(), userObj.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch relationships",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapStaffRelationshipsToResponse(relationships)))
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type ServiceAccountHandler struct {
+	serviceAccountService domain.ServiceAccountService
+}
+
+func NewServiceAccountHandler(serviceAccountService domain.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// CreateServiceAccount adds a machine client that authenticates with API keys
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	created, err := h.serviceAccountService.CreateServiceAccount(c.Request.Context(), userObj.ID, domain.ServiceAccountInput{
+		Name: req.Name,
+		Role: req.Role,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to create service account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Service account created", MapAdminUserToResponse(created)))
+}
+
+// ListServiceAccounts returns every service account, including deactivated ones
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.serviceAccountService.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Failed to fetch service accounts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapAdminUsersToResponse(accounts)))
+}
+
+// IssueAPIKey creates a key for a service account, its value is only in this response
+func (h *ServiceAccountHandler) IssueAPIKey(c *gin.Context) {
+	serviceAccountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid service account ID format",
+		})
+		return
+	}
+
+	var req IssueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	issued, err := h.serviceAccountService.IssueAPIKey(c.Request.Context(), userObj.ID, serviceAccountID, domain.APIKeyInput{
+		Name:   req.Name,
+		Scopes: req.Scopes,
+		TTL:    time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to issue API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("API key issued, store it now as it cannot be shown again", IssuedAPIKeyResponse{
+		Key:    issued.Key,
+		APIKey: MapAPIKeyToResponse(issued.APIKey),
+	}))
+}
+
+// ListAPIKeys returns the keys of a service account without their secret part
+func (h *ServiceAccountHandler) ListAPIKeys(c *gin.Context) {
+	serviceAccountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid service account ID format",
+		})
+		return
+	}
+
+	keys, err := h.serviceAccountService.ListAPIKeys(c.Request.Context(), serviceAccountID)
+	if err != nil {
+		h.respondError(c, err, "Failed to fetch API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapAPIKeysToResponse(keys)))
+}
+
+// RotateAPIKey issues a successor, the old key keeps working for the rotation grace period
+func (h *ServiceAccountHandler) RotateAPIKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid API key ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	issued, err := h.serviceAccountService.RotateAPIKey(c.Request.Context(), userObj.ID, keyID)
+	if err != nil {
+		h.respondError(c, err, "Failed to rotate API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("API key rotated, store the new key now as it cannot be shown again", IssuedAPIKeyResponse{
+		Key:    issued.Key,
+		APIKey: MapAPIKeyToResponse(issued.APIKey),
+	}))
+}
+
+// RevokeAPIKey stops a key from working immediately
+func (h *ServiceAccountHandler) RevokeAPIKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid API key ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	if err := h.serviceAccountService.RevokeAPIKey(c.Request.Context(), userObj.ID, keyID); err != nil {
+		h.respondError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("API key revoked", nil))
+}
+
+// respondError maps the errors shared by the service account endpoints
+func (h *ServiceAccountHandler) respondError(c *gin.Context, err error, failureMessage string) {
+	switch err {
+	case domain.ErrUserNotFound, domain.ErrNotServiceAccount:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "service_account_not_found",
+			Message: "The specified service account was not found",
+		})
+	case domain.ErrAPIKeyNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Error:   "api_key_not_found",
+			Message: "The specified API key was not found",
+		})
+	case domain.ErrServiceAccountExists:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "service_account_exists",
+			Message: err.Error(),
+		})
+	case domain.ErrInvalidAPIKey:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "api_key_inactive",
+			Message: "The API key is expired, revoked or was already rotated",
+		})
+	case domain.ErrAccountDeactivated:
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Error:   "account_deactivated",
+			Message: "The service account is deactivated",
+		})
+	case domain.ErrInvalidServiceAccount, domain.ErrInvalidScope, domain.ErrInvalidAPIKeyExpiry:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+	case domain.ErrInvalidRole:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_role",
+			Message: "Service accounts take a staff role that cannot manage users",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: failureMessage,
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type TaskHandler struct {
+	taskService domain.TaskService
+}
+
+func NewTaskHandler(taskService domain.TaskService) *TaskHandler {
+	return &TaskHandler{
+		taskService: taskService,
+	}
+}
+
+// GetMyTasks is the validator's inbox, open tasks by default ordered by due date
+func (h *TaskHandler) GetMyTasks(c *gin.Context) {
+	status := domain.TaskStatus(c.DefaultQuery("status", string(domain.TaskStatusOpen)))
+	if status != domain.TaskStatusOpen && status != domain.TaskStatusCompleted {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: "status must be 'open' or 'completed'",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	tasks, err := h.taskService.GetMyTasks(c.Request.Context(), userObj.ID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapVerificationTasksToResponse(tasks)))
+}
+
+// ReassignTask hands one of the caller's open tasks to another validator
+func (h *TaskHandler) ReassignTask(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid task ID format",
+		})
+		return
+	}
+
+	var req ReassignTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	task, err := h.taskService.ReassignTask(c.Request.Context(), userObj.ID, taskID, req.AssigneeID)
+	if err != nil {
+		switch err {
+		case domain.ErrTaskNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "task_not_found",
+				Message: "The specified task was not found",
+			})
+		case domain.ErrTaskNotAssigned:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "forbidden",
+				Message: "Only the current assignee can reassign this task",
+			})
+		case domain.ErrTaskCompleted:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "task_completed",
+				Message: err.Error(),
+			})
+		case domain.ErrInvalidTaskAssignee:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_assignee",
+				Message: "Assignee must be another field validator",
+			})
+		case domain.ErrNoValidatorAvailable:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "no_validator_available",
+				Message: "No other field validator is available",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "reassign_failed",
+				Message: "Failed to reassign task",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Task reassigned", MapVerificationTaskToResponse(task)))
+}
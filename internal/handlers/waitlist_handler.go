@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type WaitlistHandler struct {
+	waitlistService domain.WaitlistService
+}
+
+func NewWaitlistHandler(waitlistService domain.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{
+		waitlistService: waitlistService,
+	}
+}
+
+func (h *WaitlistHandler) JoinWaitlist(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	var req JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	entry, err := h.waitlistService.JoinWaitlist(c.Request.Context(), userObj.ID, loanID, req.Amount)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "investor_not_found",
+				Message: "Investor profile not found",
+			})
+		case domain.ErrEmailNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "email_not_verified",
+				Message: "Verify your email address before investing",
+			})
+		case domain.ErrKYCNotVerified:
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Error:   "kyc_not_verified",
+				Message: "Complete identity verification (KYC) before investing",
+			})
+		case domain.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "loan_not_found",
+				Message: "The specified loan was not found",
+			})
+		case domain.ErrInvalidLoanState:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_loan_state",
+				Message: "Only approved or fully funded loans have a waitlist",
+			})
+		case domain.ErrSelfInvestment:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "self_investment",
+				Message: "Borrowers cannot invest in their own loans",
+			})
+		case domain.ErrInvalidInvestmentAmount, domain.ErrInvestmentExceedsLimit:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Error:   "invalid_amount",
+				Message: err.Error(),
+			})
+		case domain.ErrWaitlistNotNeeded:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "waitlist_not_needed",
+				Message: err.Error(),
+			})
+		case domain.ErrAlreadyOnWaitlist:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Success: false,
+				Error:   "already_on_waitlist",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "waitlist_failed",
+				Message: "Failed to join waitlist",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponseWithMessage("Joined loan waitlist", MapWaitlistEntryToResponse(entry)))
+}
+
+func (h *WaitlistHandler) LeaveWaitlist(c *gin.Context) {
+	loanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Error:   "invalid_id",
+			Message: "Invalid loan ID format",
+		})
+		return
+	}
+
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	err = h.waitlistService.LeaveWaitlist(c.Request.Context(), userObj.ID, loanID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "investor_not_found",
+				Message: "Investor profile not found",
+			})
+		case domain.ErrWaitlistEntryNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Error:   "waitlist_entry_not_found",
+				Message: "You are not waiting on this loan",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error:   "waitlist_failed",
+				Message: "Failed to leave waitlist",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponseWithMessage("Left loan waitlist", nil))
+}
+
+func (h *WaitlistHandler) GetMyWaitlist(c *gin.Context) {
+	// Get user from context
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Error:   "unauthorized",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	userObj, ok := user.(*domain.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "internal_error",
+			Message: "Invalid user type",
+		})
+		return
+	}
+
+	entries, err := h.waitlistService.GetMyWaitlist(c.Request.Context(), userObj.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Error:   "fetch_failed",
+			Message: "Failed to fetch waitlist entries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse(MapWaitlistEntriesToResponse(entries)))
+}
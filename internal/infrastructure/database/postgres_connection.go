@@ -25,13 +25,42 @@ func NewPostgresConnection(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 func Migrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&domain.User{},
 		&domain.Borrower{},
 		&domain.Investor{},
+		&domain.KYCSubmission{},
+		&domain.KYCDocument{},
+		&domain.ErasureRequest{},
+		&domain.OutboxEvent{},
+		&domain.EmailVerificationToken{},
+		&domain.PasswordResetToken{},
+		&domain.RefreshToken{},
+		&domain.RevokedToken{},
+		&domain.SigningKey{},
+		&domain.LoginEvent{},
+		&domain.TwoFactorChallenge{},
+		&domain.RecoveryCode{},
+		&domain.SingleSignOnState{},
+		&domain.APIKey{},
 		&domain.Loan{},
+		&domain.VerificationTask{},
+		&domain.PhotoProof{},
 		&domain.Approval{},
+		&domain.ApprovalStage{},
+		&domain.Document{},
+		&domain.AgreementSignature{},
+		&domain.SignatureOTP{},
 		&domain.Investment{},
 		&domain.Disbursement{},
-	)
+		&domain.InvestmentHold{},
+		&domain.WaitlistEntry{},
+		&domain.StaffRelationship{},
+		&domain.AuditEntry{},
+	); err != nil {
+		return err
+	}
+
+	// AutoMigrate never relaxes NOT NULL, a task can be unassigned since validators hand tasks off
+	return db.Migrator().AlterColumn(&domain.VerificationTask{}, "AssigneeID")
 }
@@ -1,8 +1,11 @@
 package email
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/sigitisme/amf-loan-service/internal/config"
 	"gopkg.in/gomail.v2"
@@ -19,17 +22,12 @@ func NewService(cfg *config.SMTPConfig) *Service {
 }
 
 func (s *Service) SendAgreementLetter(to, borrowerName, loanID, agreementURL string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", s.smtpConfig.Username)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", "Loan Agreement Letter - Loan ID: "+loanID)
-
 	body := fmt.Sprintf(`
 Dear Investor,
 
 Thank you for your investment in loan ID: %s for borrower %s.
 
-The loan has been fully funded and is ready for disbursement. 
+The loan has been fully funded and is ready for disbursement.
 Please find your agreement letter at the following link:
 
 %s
@@ -38,11 +36,63 @@ Best regards,
 AMF Loan Service Team
 `, loanID, borrowerName, agreementURL)
 
+	if err := s.send(to, "Loan Agreement Letter - Loan ID: "+loanID, body); err != nil {
+		return err
+	}
+
+	log.Printf("Agreement letter sent to %s for loan %s", to, loanID)
+	return nil
+}
+
+func (s *Service) SendPasswordReset(ctx context.Context, to string, link string, expiresAt time.Time) error {
+	body := fmt.Sprintf(`
+Hello,
+
+We received a request to reset the password of your AMF Loan Service account.
+Use the link below to choose a new password, it can be used once and expires at %s:
+
+%s
+
+If you did not request a reset you can ignore this email, your password stays unchanged.
+
+Best regards,
+AMF Loan Service Team
+`, expiresAt.Format(time.RFC1123), link)
+
+	if err := s.send(to, "Reset your AMF Loan Service password", body); err != nil {
+		return err
+	}
+
+	log.Printf("Password reset email sent to %s", to)
+	return nil
+}
+
+// send delivers a plain text email. Without SMTP credentials, as in local development,
+// the email is written to the log instead.
+func (s *Service) send(to, subject, body string) error {
+	if s.smtpConfig.Username == "" {
+		log.Printf("SIMULATED EMAIL SENT (SMTP is not configured)")
+		log.Printf("To: %s", to)
+		log.Printf("Subject: %s", subject)
+		log.Printf("Body: %s", body)
+		log.Printf("---")
+		return nil
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.smtpConfig.Username)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body)
 
+	port, err := strconv.Atoi(s.smtpConfig.Port)
+	if err != nil {
+		port = 587
+	}
+
 	d := gomail.NewDialer(
 		s.smtpConfig.Host,
-		587, // Convert string to int if needed
+		port,
 		s.smtpConfig.Username,
 		s.smtpConfig.Password,
 	)
@@ -51,7 +101,5 @@ AMF Loan Service Team
 		log.Printf("Failed to send email to %s: %v", to, err)
 		return err
 	}
-
-	log.Printf("Agreement letter sent to %s for loan %s", to, loanID)
 	return nil
 }
@@ -0,0 +1,173 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	ProviderLocal = "local"
+
+	// Stored values look like enc:v2:<key id>:<wrapped data key>:<nonce and ciphertext>, the ciphertext
+	// is authenticated together with the table, column and row it is stored in
+	envelopePrefix = "enc:v2:"
+	// Values written before they were bound to their location, still read until they are re-encrypted
+	unboundEnvelopePrefix = "enc:v1:"
+	dataKeySize           = 32
+)
+
+var ErrMalformedCiphertext = errors.New("malformed encrypted value")
+
+// New returns the field cipher using the key provider selected by the configuration
+func New(cfg *config.EncryptionConfig) (domain.FieldCipher, error) {
+	switch cfg.KeyProvider {
+	case ProviderLocal, "":
+		provider, err := NewLocalKeyProvider(cfg.KeyFile, cfg.CreateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewEnvelopeCipher(provider), nil
+	default:
+		return nil, fmt.Errorf("unknown key provider: %q", cfg.KeyProvider)
+	}
+}
+
+// EnvelopeCipher encrypts every value with its own AES-256-GCM data key and stores that key
+// wrapped by the provider, so rotating the provider key only rewraps small data keys
+type EnvelopeCipher struct {
+	keys domain.KeyProvider
+}
+
+func NewEnvelopeCipher(keys domain.KeyProvider) *EnvelopeCipher {
+	return &EnvelopeCipher{keys: keys}
+}
+
+func (c *EnvelopeCipher) Encrypt(ctx context.Context, plaintext string, location domain.FieldLocation) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	keyID := c.keys.ActiveKeyID()
+	wrapped, err := c.keys.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	sealed, err := seal(dataKey, []byte(plaintext), additionalData(location))
+	if err != nil {
+		return "", err
+	}
+
+	return envelopePrefix + keyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *EnvelopeCipher) Decrypt(ctx context.Context, stored string, location domain.FieldLocation) (string, error) {
+	var envelope string
+	var aad []byte
+	switch {
+	case strings.HasPrefix(stored, envelopePrefix):
+		envelope = strings.TrimPrefix(stored, envelopePrefix)
+		aad = additionalData(location)
+	case strings.HasPrefix(stored, unboundEnvelopePrefix):
+		// Written before values were bound to their location, the rotation command re-encrypts it
+		envelope = strings.TrimPrefix(stored, unboundEnvelopePrefix)
+	default:
+		// Written before encryption was enabled, the rotation command encrypts it
+		return stored, nil
+	}
+
+	parts := strings.Split(envelope, ":")
+	if len(parts) != 3 {
+		return "", ErrMalformedCiphertext
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrMalformedCiphertext
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformedCiphertext
+	}
+
+	dataKey, err := c.keys.UnwrapKey(ctx, parts[0], wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, sealed, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func (c *EnvelopeCipher) IsCurrent(stored string) bool {
+	return strings.HasPrefix(stored, envelopePrefix+c.keys.ActiveKeyID()+":")
+}
+
+// BlindIndex ignores surrounding spaces and letter case, so differently typed identity numbers still match
+func (c *EnvelopeCipher) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, c.keys.BlindIndexKey())
+	mac.Write([]byte(strings.ToUpper(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// additionalData is the location a value is authenticated with, table and column names never contain a slash
+func additionalData(location domain.FieldLocation) []byte {
+	return []byte(location.Table + "/" + location.Column + "/" + location.RowID)
+}
+
+// seal encrypts with AES-256-GCM and prepends the random nonce
+func seal(key []byte, plaintext []byte, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key []byte, sealed []byte, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,196 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCipher creates a keyfile in a temporary directory and returns its path and a cipher using it
+func newTestCipher(t *testing.T) (string, *EnvelopeCipher) {
+	path := filepath.Join(t.TempDir(), "keys", "pii-keys.json")
+	provider, err := NewLocalKeyProvider(path, true)
+	require.NoError(t, err)
+	return path, NewEnvelopeCipher(provider)
+}
+
+// testLocation is the row and column the test values are stored in
+var testLocation = domain.FieldLocation{Table: "borrowers", Column: "identity_number", RowID: "0b0a5c9e-4f5e-4d7c-9a51-2f4f0e1c6d3a"}
+
+// Test Envelope Cipher - Values Round Trip And Never Repeat Their Ciphertext
+func TestEnvelopeCipher_EncryptDecrypt(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	ctx := context.Background()
+
+	first, err := cipher.Encrypt(ctx, "1234567890123456", testLocation)
+	require.NoError(t, err)
+	second, err := cipher.Encrypt(ctx, "1234567890123456", testLocation)
+	require.NoError(t, err)
+
+	assert.NotContains(t, first, "1234567890123456")
+	assert.NotEqual(t, first, second)
+	assert.True(t, cipher.IsCurrent(first))
+
+	plaintext, err := cipher.Decrypt(ctx, first, testLocation)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890123456", plaintext)
+}
+
+// Test Envelope Cipher - Values Stored Before Encryption Are Read As They Are
+func TestEnvelopeCipher_DecryptPlaintext(t *testing.T) {
+	_, cipher := newTestCipher(t)
+
+	plaintext, err := cipher.Decrypt(context.Background(), "Jl. Sudirman No. 1", testLocation)
+	require.NoError(t, err)
+	assert.Equal(t, "Jl. Sudirman No. 1", plaintext)
+	assert.False(t, cipher.IsCurrent("Jl. Sudirman No. 1"))
+}
+
+// Test Envelope Cipher - Tampered Ciphertext Is Refused
+func TestEnvelopeCipher_DecryptTampered(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	ctx := context.Background()
+
+	stored, err := cipher.Encrypt(ctx, "+6281234567890", testLocation)
+	require.NoError(t, err)
+
+	// Change a character inside the sealed value
+	i := len(stored) - 10
+	replacement := "A"
+	if stored[i] == 'A' {
+		replacement = "B"
+	}
+	_, err = cipher.Decrypt(ctx, stored[:i]+replacement+stored[i+1:], testLocation)
+	assert.Error(t, err)
+}
+
+// Test Envelope Cipher - A Value Copied To Another Row Or Column Is Refused
+func TestEnvelopeCipher_DecryptOtherLocation(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	ctx := context.Background()
+
+	stored, err := cipher.Encrypt(ctx, "3171234567890001", testLocation)
+	require.NoError(t, err)
+
+	otherRow := testLocation
+	otherRow.RowID = "5d6f1b7a-0c2e-4b8f-8e3d-7a9c1f2e4b60"
+	otherColumn := testLocation
+	otherColumn.Column = "phone_number"
+	otherTable := testLocation
+	otherTable.Table = "investors"
+
+	for _, location := range []domain.FieldLocation{otherRow, otherColumn, otherTable} {
+		_, err := cipher.Decrypt(ctx, stored, location)
+		assert.ErrorIs(t, err, ErrMalformedCiphertext)
+	}
+}
+
+// Test Envelope Cipher - Values Encrypted Before They Were Bound Stay Readable And Are Reported Stale
+func TestEnvelopeCipher_DecryptUnbound(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	ctx := context.Background()
+
+	// Sealed without a location the way the first envelope version did
+	dataKey := make([]byte, dataKeySize)
+	keyID := cipher.keys.ActiveKeyID()
+	wrapped, err := cipher.keys.WrapKey(ctx, keyID, dataKey)
+	require.NoError(t, err)
+	sealed, err := seal(dataKey, []byte("3171234567890001"), nil)
+	require.NoError(t, err)
+	stored := unboundEnvelopePrefix + keyID + ":" +
+		base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealed)
+
+	plaintext, err := cipher.Decrypt(ctx, stored, testLocation)
+
+	require.NoError(t, err)
+	assert.Equal(t, "3171234567890001", plaintext)
+	assert.False(t, cipher.IsCurrent(stored))
+}
+
+// Test Local Key Provider - Values Under A Rotated Key Stay Readable And Are Reported Stale
+func TestLocalKeyProvider_Rotation(t *testing.T) {
+	path, cipher := newTestCipher(t)
+	ctx := context.Background()
+
+	stored, err := cipher.Encrypt(ctx, "1234567890123456", testLocation)
+	require.NoError(t, err)
+	index := cipher.BlindIndex("1234567890123456")
+
+	keyID, err := AddLocalKey(path)
+	require.NoError(t, err)
+
+	provider, err := NewLocalKeyProvider(path, false)
+	require.NoError(t, err)
+	rotated := NewEnvelopeCipher(provider)
+	assert.Equal(t, keyID, provider.ActiveKeyID())
+
+	assert.False(t, rotated.IsCurrent(stored))
+	plaintext, err := rotated.Decrypt(ctx, stored, testLocation)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890123456", plaintext)
+
+	reEncrypted, err := rotated.Encrypt(ctx, plaintext, testLocation)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(reEncrypted, envelopePrefix+keyID+":"))
+	// The blind index key is kept, so lookups keep working without a reindex
+	assert.Equal(t, index, rotated.BlindIndex("1234567890123456"))
+}
+
+// Test Local Key Provider - The Keyfile Is Only Readable By Its Owner
+func TestLocalKeyProvider_KeyFilePermissions(t *testing.T) {
+	path, _ := newTestCipher(t)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+// Test Local Key Provider - A Missing Keyfile Stops Startup Unless Creating It Was Asked For
+func TestLocalKeyProvider_MissingKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pii-keys.json")
+
+	_, err := NewLocalKeyProvider(path, false)
+	assert.ErrorIs(t, err, ErrKeyFileMissing)
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr))
+
+	require.NoError(t, CreateLocalKeyFile(path))
+	provider, err := NewLocalKeyProvider(path, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, provider.ActiveKeyID())
+}
+
+// Test Local Key Provider - Initializing Never Replaces An Existing Keyfile
+func TestCreateLocalKeyFile_Exists(t *testing.T) {
+	path, cipher := newTestCipher(t)
+	stored, err := cipher.Encrypt(context.Background(), "1234567890123456", testLocation)
+	require.NoError(t, err)
+
+	err = CreateLocalKeyFile(path)
+
+	assert.ErrorIs(t, err, ErrKeyFileExists)
+	provider, err := NewLocalKeyProvider(path, false)
+	require.NoError(t, err)
+	plaintext, err := NewEnvelopeCipher(provider).Decrypt(context.Background(), stored, testLocation)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890123456", plaintext)
+}
+
+// Test Envelope Cipher - Blind Indexes Match Regardless Of Spacing And Case
+func TestEnvelopeCipher_BlindIndex(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	_, otherCipher := newTestCipher(t)
+
+	assert.Equal(t, cipher.BlindIndex("ab1234"), cipher.BlindIndex(" AB1234 "))
+	assert.NotEqual(t, cipher.BlindIndex("AB1234"), cipher.BlindIndex("AB1235"))
+	// Indexes are keyed, another keyfile yields different ones
+	assert.NotEqual(t, cipher.BlindIndex("AB1234"), otherCipher.BlindIndex("AB1234"))
+}
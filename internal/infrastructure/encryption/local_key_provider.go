@@ -0,0 +1,218 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown encryption key")
+	ErrKeyFileMissing = errors.New("encryption keyfile not found")
+	ErrKeyFileExists  = errors.New("encryption keyfile already exists")
+)
+
+// keyFile is the JSON document a LocalKeyProvider reads its keys from
+type keyFile struct {
+	ActiveKeyID   string            `json:"active_key_id"`
+	Keys          map[string]string `json:"keys"` // Base64 AES-256 keys by ID, retired keys stay to decrypt old values
+	BlindIndexKey string            `json:"blind_index_key"`
+}
+
+// LocalKeyProvider wraps data keys with AES-256 keys kept in a keyfile on disk
+type LocalKeyProvider struct {
+	activeKeyID   string
+	keys          map[string][]byte
+	blindIndexKey []byte
+}
+
+// NewLocalKeyProvider loads the keyfile. A missing keyfile is only created with a fresh key when create
+// is set, otherwise a lost or misplaced keyfile would leave every encrypted value unreadable.
+func NewLocalKeyProvider(path string, create bool) (*LocalKeyProvider, error) {
+	file, err := readKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if !create {
+			return nil, fmt.Errorf("%w: %s, create it with rotate-pii-keys -init-key", ErrKeyFileMissing, path)
+		}
+		log.Printf("Encryption keyfile %s not found, creating a new one", path)
+		file, err = newKeyFile()
+		if err == nil {
+			err = writeKeyFile(path, file)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &LocalKeyProvider{activeKeyID: file.ActiveKeyID, keys: make(map[string][]byte, len(file.Keys))}
+	for id, encoded := range file.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q in %s", id, path)
+		}
+		if provider.keys[id], err = decodeKey(encoded); err != nil {
+			return nil, fmt.Errorf("invalid key %q in %s: %w", id, path, err)
+		}
+	}
+	if _, ok := provider.keys[file.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("active key %q is not in %s", file.ActiveKeyID, path)
+	}
+	if provider.blindIndexKey, err = decodeKey(file.BlindIndexKey); err != nil {
+		return nil, fmt.Errorf("invalid blind index key in %s: %w", path, err)
+	}
+
+	return provider, nil
+}
+
+// CreateLocalKeyFile writes a keyfile with a fresh key, refusing to replace an existing one
+func CreateLocalKeyFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%w: %s", ErrKeyFileExists, path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	file, err := newKeyFile()
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(path, file)
+}
+
+// AddLocalKey generates a new key in the keyfile and makes it the active one.
+// Older keys are kept so values encrypted with them can still be read until they are re-encrypted.
+func AddLocalKey(path string) (string, error) {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return "", err
+	}
+	keyID, err := addKey(file)
+	if err != nil {
+		return "", err
+	}
+	if err := writeKeyFile(path, file); err != nil {
+		return "", err
+	}
+	return keyID, nil
+}
+
+func (p *LocalKeyProvider) ActiveKeyID() string {
+	return p.activeKeyID
+}
+
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return seal(key, dataKey, nil)
+}
+
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(key, wrapped, nil)
+}
+
+func (p *LocalKeyProvider) BlindIndexKey() []byte {
+	return p.blindIndexKey
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyfile %s: %w", path, err)
+	}
+	if file.Keys == nil {
+		file.Keys = map[string]string{}
+	}
+	return &file, nil
+}
+
+// writeKeyFile replaces the keyfile atomically and readable by the owner only
+func writeKeyFile(path string, file *keyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create keyfile directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keyfile-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func newKeyFile() (*keyFile, error) {
+	blindIndexKey, err := newEncodedKey()
+	if err != nil {
+		return nil, err
+	}
+	file := &keyFile{Keys: map[string]string{}, BlindIndexKey: blindIndexKey}
+	if _, err := addKey(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// addKey adds a new active key named after the time it was created
+func addKey(file *keyFile) (string, error) {
+	base := time.Now().UTC().Format("20060102T150405Z")
+	keyID := base
+	for i := 2; file.Keys[keyID] != ""; i++ {
+		keyID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	key, err := newEncodedKey()
+	if err != nil {
+		return "", err
+	}
+	file.Keys[keyID] = key
+	file.ActiveKeyID = keyID
+	return keyID, nil
+}
+
+func newEncodedKey() (string, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	return key, nil
+}
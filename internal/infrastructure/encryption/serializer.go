@@ -0,0 +1,80 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used in model tags, `gorm:"serializer:encrypted"`
+const SerializerName = "encrypted"
+
+// RegisterSerializer lets GORM encrypt tagged string fields on write and decrypt them on read.
+// It must be called before any model with encrypted fields is queried.
+func RegisterSerializer(cipher domain.FieldCipher) {
+	schema.RegisterSerializer(SerializerName, fieldSerializer{cipher: cipher})
+}
+
+type fieldSerializer struct {
+	cipher domain.FieldCipher
+}
+
+func (s fieldSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		stored = ""
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot decrypt %T into %s", dbValue, field.Name)
+	}
+
+	plaintext := stored
+	if stored != "" {
+		location, err := fieldLocation(ctx, field, dst)
+		if err != nil {
+			return err
+		}
+		if plaintext, err = s.cipher.Decrypt(ctx, stored, location); err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+	}
+
+	fieldValue := reflect.New(field.FieldType).Elem()
+	fieldValue.SetString(plaintext)
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+func (s fieldSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot encrypt %T in %s", fieldValue, field.Name)
+	}
+	location, err := fieldLocation(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+	return s.cipher.Encrypt(ctx, plaintext, location)
+}
+
+// fieldLocation is the table, column and primary key of the row a value is read from or written to.
+// Rows are scanned in column order, so the primary key is set before the encrypted columns that follow it.
+func fieldLocation(ctx context.Context, field *schema.Field, dst reflect.Value) (domain.FieldLocation, error) {
+	primary := field.Schema.PrioritizedPrimaryField
+	if primary == nil {
+		return domain.FieldLocation{}, fmt.Errorf("%s has no primary key to bind %s to", field.Schema.Name, field.Name)
+	}
+	id, zero := primary.ValueOf(ctx, dst)
+	if zero {
+		// A key generated by the database would not be known when the value is encrypted
+		return domain.FieldLocation{}, fmt.Errorf("%s needs its %s set to encrypt or decrypt %s", field.Schema.Name, primary.Name, field.Name)
+	}
+	return domain.FieldLocation{Table: field.Schema.Table, Column: field.DBName, RowID: fmt.Sprint(id)}, nil
+}
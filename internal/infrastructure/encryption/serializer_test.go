@@ -0,0 +1,117 @@
+package encryption
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+// sealedRecord is a model with an encrypted field, as the borrower and investor profiles have
+type sealedRecord struct {
+	ID             int
+	IdentityNumber string `gorm:"serializer:encrypted"`
+}
+
+// identityNumberField registers the serializer with the cipher and returns the parsed encrypted field of sealedRecord
+func identityNumberField(t *testing.T, cipher *EnvelopeCipher) *schema.Field {
+	RegisterSerializer(cipher)
+	parsed, err := schema.Parse(&sealedRecord{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	field := parsed.LookUpField("IdentityNumber")
+	require.NotNil(t, field)
+	return field
+}
+
+// Test Serializer - Tagged Fields Are Encrypted On Write And Decrypted On Read
+func TestFieldSerializer_RoundTrip(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	serializer := fieldSerializer{cipher: cipher}
+	field := identityNumberField(t, cipher)
+	ctx := context.Background()
+
+	written := sealedRecord{ID: 7, IdentityNumber: "3171234567890001"}
+	stored, err := serializer.Value(ctx, field, reflect.ValueOf(&written).Elem(), written.IdentityNumber)
+	require.NoError(t, err)
+	require.IsType(t, "", stored)
+	assert.True(t, strings.HasPrefix(stored.(string), envelopePrefix))
+	assert.NotContains(t, stored, "3171234567890001")
+
+	// Drivers hand text columns over as strings or bytes
+	for _, dbValue := range []interface{}{stored, []byte(stored.(string))} {
+		read := sealedRecord{ID: 7}
+		require.NoError(t, serializer.Scan(ctx, field, reflect.ValueOf(&read).Elem(), dbValue))
+		assert.Equal(t, "3171234567890001", read.IdentityNumber)
+	}
+}
+
+// Test Serializer - Values Are Bound To Their Row And Need Its Primary Key
+func TestFieldSerializer_BoundToRow(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	serializer := fieldSerializer{cipher: cipher}
+	field := identityNumberField(t, cipher)
+	ctx := context.Background()
+
+	written := sealedRecord{ID: 7, IdentityNumber: "3171234567890001"}
+	stored, err := serializer.Value(ctx, field, reflect.ValueOf(&written).Elem(), written.IdentityNumber)
+	require.NoError(t, err)
+
+	// Copied into another row
+	other := sealedRecord{ID: 8}
+	assert.Error(t, serializer.Scan(ctx, field, reflect.ValueOf(&other).Elem(), stored))
+	assert.Empty(t, other.IdentityNumber)
+
+	// A key the database would generate is not known yet
+	unsaved := sealedRecord{IdentityNumber: "3171234567890001"}
+	_, err = serializer.Value(ctx, field, reflect.ValueOf(&unsaved).Elem(), unsaved.IdentityNumber)
+	assert.Error(t, err)
+}
+
+// Test Serializer - Empty And Unencrypted Values Are Read As They Are
+func TestFieldSerializer_ScanPlain(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	serializer := fieldSerializer{cipher: cipher}
+	field := identityNumberField(t, cipher)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		dbValue interface{}
+		want    string
+	}{
+		{name: "null", dbValue: nil, want: ""},
+		{name: "empty", dbValue: "", want: ""},
+		{name: "written before encryption", dbValue: "3171234567890001", want: "3171234567890001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read := sealedRecord{ID: 7, IdentityNumber: "previous"}
+			require.NoError(t, serializer.Scan(ctx, field, reflect.ValueOf(&read).Elem(), tt.dbValue))
+			assert.Equal(t, tt.want, read.IdentityNumber)
+		})
+	}
+}
+
+// Test Serializer - Values That Cannot Be Decrypted Or Are Not Text Are Refused
+func TestFieldSerializer_Errors(t *testing.T) {
+	_, cipher := newTestCipher(t)
+	_, otherCipher := newTestCipher(t)
+	serializer := fieldSerializer{cipher: cipher}
+	field := identityNumberField(t, cipher)
+	ctx := context.Background()
+
+	foreign, err := otherCipher.Encrypt(ctx, "3171234567890001", testLocation)
+	require.NoError(t, err)
+
+	read := sealedRecord{ID: 7}
+	assert.Error(t, serializer.Scan(ctx, field, reflect.ValueOf(&read).Elem(), foreign))
+	assert.Error(t, serializer.Scan(ctx, field, reflect.ValueOf(&read).Elem(), 42))
+
+	_, err = serializer.Value(ctx, field, reflect.ValueOf(&read).Elem(), 42)
+	assert.Error(t, err)
+}
@@ -0,0 +1,299 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// TIFF tags read from the image
+const (
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// TIFF field types and their sizes in bytes
+const (
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+var errMalformed = errors.New("malformed EXIF data")
+
+// Reader extracts capture location and time from the EXIF block of JPEG and PNG images.
+// Only the handful of tags needed to check field visit evidence are decoded.
+type Reader struct{}
+
+func NewReader() *Reader {
+	return &Reader{}
+}
+
+// Read returns empty metadata when the image carries no EXIF block, and an error when the block is corrupt
+func (r *Reader) Read(data []byte) (*domain.ImageMetadata, error) {
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		tiff = findJPEGExif(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		tiff = findPNGExif(data)
+	}
+
+	metadata := &domain.ImageMetadata{}
+	if tiff == nil {
+		return metadata, nil
+	}
+
+	if err := parseTIFF(tiff, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// findJPEGExif walks the JPEG segments up to the image data looking for the APP1 Exif segment
+func findJPEGExif(data []byte) []byte {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		// Start of scan, the compressed image follows and no metadata comes after it
+		if marker == 0xDA {
+			return nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// findPNGExif looks for the eXIf chunk, which holds the TIFF structure directly
+func findPNGExif(data []byte) []byte {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) {
+			return nil
+		}
+
+		switch chunkType {
+		case "eXIf":
+			return data[pos+8 : pos+8+length]
+		case "IDAT", "IEND":
+			return nil
+		}
+		pos += 12 + length
+	}
+	return nil
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func parseTIFF(data []byte, metadata *domain.ImageMetadata) error {
+	if len(data) < 8 {
+		return errMalformed
+	}
+
+	r := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return errMalformed
+	}
+	if r.order.Uint16(data[2:]) != 42 {
+		return errMalformed
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(data[4:]))
+	if err != nil {
+		return err
+	}
+
+	var dateTime, dateTimeOriginal, offsetTime string
+	if entry, ok := ifd0[tagDateTime]; ok {
+		dateTime = entry.ascii()
+	}
+
+	if entry, ok := ifd0[tagExifIFD]; ok {
+		offset, err := entry.offset(r.order)
+		if err != nil {
+			return err
+		}
+		exifIFD, err := r.readIFD(offset)
+		if err != nil {
+			return err
+		}
+		if entry, ok := exifIFD[tagDateTimeOriginal]; ok {
+			dateTimeOriginal = entry.ascii()
+		}
+		if entry, ok := exifIFD[tagOffsetTimeOriginal]; ok {
+			offsetTime = entry.ascii()
+		}
+	}
+
+	// DateTime is when the file was last changed, only use it when the capture time is missing
+	if dateTimeOriginal == "" {
+		dateTimeOriginal = dateTime
+	}
+	if dateTimeOriginal != "" {
+		metadata.CapturedAt, metadata.CapturedAtZoned = parseExifTime(dateTimeOriginal, offsetTime)
+	}
+
+	if entry, ok := ifd0[tagGPSIFD]; ok {
+		offset, err := entry.offset(r.order)
+		if err != nil {
+			return err
+		}
+		gpsIFD, err := r.readIFD(offset)
+		if err != nil {
+			return err
+		}
+		metadata.Latitude = r.coordinate(gpsIFD, tagGPSLatitude, tagGPSLatitudeRef, "S")
+		metadata.Longitude = r.coordinate(gpsIFD, tagGPSLongitude, tagGPSLongitudeRef, "W")
+		if metadata.Latitude == nil || metadata.Longitude == nil {
+			metadata.Latitude, metadata.Longitude = nil, nil
+		}
+	}
+
+	return nil
+}
+
+// readIFD decodes the entries of the image file directory at offset, keyed by tag
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	start := int(offset)
+	if start < 8 || start+2 > len(r.data) {
+		return nil, errMalformed
+	}
+
+	count := int(r.order.Uint16(r.data[start:]))
+	if start+2+count*12 > len(r.data) {
+		return nil, errMalformed
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		raw := r.data[start+2+i*12 : start+14+i*12]
+		entry := ifdEntry{
+			tag:   r.order.Uint16(raw),
+			typ:   r.order.Uint16(raw[2:]),
+			count: r.order.Uint32(raw[4:]),
+		}
+
+		size, ok := typeSizes[entry.typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(entry.count)
+		if total <= 4 {
+			entry.value = raw[8 : 8+total]
+		} else {
+			valueOffset := uint64(r.order.Uint32(raw[8:]))
+			if valueOffset+total > uint64(len(r.data)) {
+				return nil, errMalformed
+			}
+			entry.value = r.data[valueOffset : valueOffset+total]
+		}
+		entries[entry.tag] = entry
+	}
+
+	return entries, nil
+}
+
+// coordinate converts a degrees, minutes, seconds rational triple to signed decimal degrees
+func (r *tiffReader) coordinate(ifd map[uint16]ifdEntry, valueTag, refTag uint16, negativeRef string) *float64 {
+	entry, ok := ifd[valueTag]
+	if !ok || entry.typ != typeRational || entry.count != 3 {
+		return nil
+	}
+
+	parts := make([]float64, 3)
+	for i := range parts {
+		numerator := r.order.Uint32(entry.value[i*8:])
+		denominator := r.order.Uint32(entry.value[i*8+4:])
+		if denominator == 0 {
+			return nil
+		}
+		parts[i] = float64(numerator) / float64(denominator)
+	}
+
+	degrees := parts[0] + parts[1]/60 + parts[2]/3600
+	if ref, ok := ifd[refTag]; ok && strings.EqualFold(ref.ascii(), negativeRef) {
+		degrees = -degrees
+	}
+	return &degrees
+}
+
+func (e ifdEntry) ascii() string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+// offset returns the value of a pointer tag such as the Exif and GPS directory offsets
+func (e ifdEntry) offset(order binary.ByteOrder) (uint32, error) {
+	switch {
+	case e.typ == typeLong && e.count == 1:
+		return order.Uint32(e.value), nil
+	case e.typ == typeShort && e.count == 1:
+		return uint32(order.Uint16(e.value)), nil
+	default:
+		return 0, fmt.Errorf("%w: unexpected pointer tag 0x%04x", errMalformed, e.tag)
+	}
+}
+
+// parseExifTime reads an EXIF timestamp, applying the offset tag when the camera wrote one
+func parseExifTime(value, offset string) (*time.Time, bool) {
+	if offset != "" {
+		if t, err := time.Parse(exifTimeLayout+"-07:00", value+offset); err == nil {
+			return &t, true
+		}
+	}
+
+	t, err := time.Parse(exifTimeLayout, value)
+	if err != nil {
+		return nil, false
+	}
+	return &t, false
+}
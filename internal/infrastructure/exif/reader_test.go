@@ -0,0 +1,443 @@
+package exif
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tiffEntry is a directory entry written by buildTIFF, values longer than four bytes go to the data area
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func asciiEntry(tag uint16, text string) tiffEntry {
+	return tiffEntry{tag: tag, typ: typeASCII, count: uint32(len(text) + 1), value: append([]byte(text), 0)}
+}
+
+// rationalEntry holds numerator and denominator pairs
+func rationalEntry(order binary.ByteOrder, tag uint16, pairs ...uint32) tiffEntry {
+	value := make([]byte, 4*len(pairs))
+	for i, v := range pairs {
+		order.PutUint32(value[i*4:], v)
+	}
+	return tiffEntry{tag: tag, typ: typeRational, count: uint32(len(pairs) / 2), value: value}
+}
+
+// buildTIFF lays out IFD0 followed by the Exif and GPS directories, adding the pointer tags for those given
+func buildTIFF(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []tiffEntry) []byte {
+	ifds := [][]tiffEntry{append([]tiffEntry{}, ifd0...)}
+	pointers := []uint16{}
+	for _, sub := range []struct {
+		tag     uint16
+		entries []tiffEntry
+	}{{tagExifIFD, exifIFD}, {tagGPSIFD, gpsIFD}} {
+		if sub.entries != nil {
+			ifds[0] = append(ifds[0], tiffEntry{tag: sub.tag, typ: typeLong, count: 1})
+			ifds = append(ifds, sub.entries)
+			pointers = append(pointers, sub.tag)
+		}
+	}
+
+	// Directories come right after the header, the values that do not fit an entry after them
+	offsets := make([]int, len(ifds))
+	end := 8
+	for i, ifd := range ifds {
+		offsets[i] = end
+		end += 2 + 12*len(ifd) + 4
+	}
+	for i, tag := range pointers {
+		for j := range ifds[0] {
+			if ifds[0][j].tag == tag {
+				ifds[0][j].value = make([]byte, 4)
+				order.PutUint32(ifds[0][j].value, uint32(offsets[i+1]))
+			}
+		}
+	}
+
+	data := make([]byte, end)
+	if order == binary.LittleEndian {
+		copy(data, "II")
+	} else {
+		copy(data, "MM")
+	}
+	order.PutUint16(data[2:], 42)
+	order.PutUint32(data[4:], uint32(offsets[0]))
+
+	for i, ifd := range ifds {
+		pos := offsets[i]
+		order.PutUint16(data[pos:], uint16(len(ifd)))
+		for j, entry := range ifd {
+			raw := data[pos+2+j*12:]
+			order.PutUint16(raw, entry.tag)
+			order.PutUint16(raw[2:], entry.typ)
+			order.PutUint32(raw[4:], entry.count)
+			if len(entry.value) <= 4 {
+				copy(raw[8:12], entry.value)
+			} else {
+				order.PutUint32(raw[8:], uint32(len(data)))
+				data = append(data, entry.value...)
+			}
+		}
+	}
+	return data
+}
+
+// wrapJPEG puts the TIFF structure into an APP1 segment behind a JFIF header
+func wrapJPEG(tiff []byte) []byte {
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data = append(data, 0xFF, 0xE1, byte((len(segment)+2)>>8), byte(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+// wrapPNG puts the TIFF structure into an eXIf chunk, checksums are not verified by the reader
+func wrapPNG(tiff []byte) []byte {
+	chunk := func(chunkType string, body []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+		out = append(out, chunkType...)
+		out = append(out, body...)
+		return append(out, 0, 0, 0, 0)
+	}
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = append(data, chunk("IHDR", make([]byte, 13))...)
+	data = append(data, chunk("eXIf", tiff)...)
+	return append(data, chunk("IEND", nil)...)
+}
+
+// fieldPhoto is the TIFF block of a photo taken in Jakarta, 6°10'30" S 106°49'12.5" E
+func fieldPhoto(order binary.ByteOrder) []byte {
+	return buildTIFF(order,
+		[]tiffEntry{asciiEntry(tagDateTime, "2024:03:06 09:00:00")},
+		[]tiffEntry{asciiEntry(tagDateTimeOriginal, "2024:03:05 14:30:00"), asciiEntry(tagOffsetTimeOriginal, "+07:00")},
+		[]tiffEntry{
+			asciiEntry(tagGPSLatitudeRef, "S"),
+			rationalEntry(order, tagGPSLatitude, 6, 1, 10, 1, 30, 1),
+			asciiEntry(tagGPSLongitudeRef, "E"),
+			rationalEntry(order, tagGPSLongitude, 106, 1, 49, 1, 125, 10),
+		})
+}
+
+// Test EXIF Reader - Location And Capture Time Are Read In Both Byte Orders And Containers
+func TestReader_Read(t *testing.T) {
+	reader := NewReader()
+	wantTime := time.Date(2024, 3, 5, 14, 30, 0, 0, time.FixedZone("", 7*60*60))
+
+	tests := []struct {
+		name  string
+		order binary.ByteOrder
+		wrap  func([]byte) []byte
+	}{
+		{name: "little endian jpeg", order: binary.LittleEndian, wrap: wrapJPEG},
+		{name: "big endian jpeg", order: binary.BigEndian, wrap: wrapJPEG},
+		{name: "little endian png", order: binary.LittleEndian, wrap: wrapPNG},
+		{name: "big endian png", order: binary.BigEndian, wrap: wrapPNG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := reader.Read(tt.wrap(fieldPhoto(tt.order)))
+
+			require.NoError(t, err)
+			require.NotNil(t, metadata.Latitude)
+			require.NotNil(t, metadata.Longitude)
+			assert.InDelta(t, -6.175, *metadata.Latitude, 1e-9)
+			assert.InDelta(t, 106.0+49.0/60+12.5/3600, *metadata.Longitude, 1e-9)
+			require.NotNil(t, metadata.CapturedAt)
+			assert.True(t, metadata.CapturedAtZoned)
+			assert.True(t, wantTime.Equal(*metadata.CapturedAt))
+		})
+	}
+}
+
+// Test EXIF Reader - Images Without An EXIF Block Yield Empty Metadata
+func TestReader_Read_NoExif(t *testing.T) {
+	reader := NewReader()
+
+	for name, data := range map[string][]byte{
+		"jpeg without app1": {0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02},
+		"png without exif":  wrapPNG(nil)[:33],
+		"other format":      []byte("GIF89a"),
+		"empty":             nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			metadata, err := reader.Read(data)
+
+			require.NoError(t, err)
+			assert.Nil(t, metadata.Latitude)
+			assert.Nil(t, metadata.Longitude)
+			assert.Nil(t, metadata.CapturedAt)
+		})
+	}
+}
+
+// Test EXIF Reader - Capture Time Falls Back To DateTime And Stays Unzoned Without An Offset
+func TestReader_Read_CaptureTime(t *testing.T) {
+	reader := NewReader()
+	order := binary.LittleEndian
+
+	tests := []struct {
+		name      string
+		ifd0      []tiffEntry
+		exifIFD   []tiffEntry
+		want      *time.Time
+		wantZoned bool
+	}{
+		{
+			name:    "original without offset",
+			ifd0:    []tiffEntry{asciiEntry(tagDateTime, "2024:03:06 09:00:00")},
+			exifIFD: []tiffEntry{asciiEntry(tagDateTimeOriginal, "2024:03:05 14:30:00")},
+			want:    timePtr(time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)),
+		},
+		{
+			name: "modification time only",
+			ifd0: []tiffEntry{asciiEntry(tagDateTime, "2024:03:06 09:00:00")},
+			want: timePtr(time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "invalid offset is ignored",
+			exifIFD: []tiffEntry{asciiEntry(tagDateTimeOriginal, "2024:03:05 14:30:00"), asciiEntry(tagOffsetTimeOriginal, "WIB")},
+			want:    timePtr(time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)),
+		},
+		{
+			name:    "unparseable time",
+			exifIFD: []tiffEntry{asciiEntry(tagDateTimeOriginal, "    :  :     :  :  ")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := reader.Read(wrapJPEG(buildTIFF(order, tt.ifd0, tt.exifIFD, nil)))
+
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, metadata.CapturedAt)
+				return
+			}
+			require.NotNil(t, metadata.CapturedAt)
+			assert.True(t, tt.want.Equal(*metadata.CapturedAt))
+			assert.Equal(t, tt.wantZoned, metadata.CapturedAtZoned)
+		})
+	}
+}
+
+// Test EXIF Reader - GPS Rationals Become Signed Decimal Degrees, Incomplete Positions Are Dropped
+func TestReader_Read_GPS(t *testing.T) {
+	reader := NewReader()
+
+	tests := []struct {
+		name    string
+		gps     func(order binary.ByteOrder) []tiffEntry
+		wantLat *float64
+		wantLon *float64
+	}{
+		{
+			name: "north east",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					asciiEntry(tagGPSLatitudeRef, "N"), rationalEntry(order, tagGPSLatitude, 1, 1, 30, 1, 0, 1),
+					asciiEntry(tagGPSLongitudeRef, "E"), rationalEntry(order, tagGPSLongitude, 103, 1, 51, 1, 36, 1),
+				}
+			},
+			wantLat: floatPtr(1.5),
+			wantLon: floatPtr(103.86),
+		},
+		{
+			name: "south west in lower case",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					asciiEntry(tagGPSLatitudeRef, "s"), rationalEntry(order, tagGPSLatitude, 33, 1, 52, 1, 4, 1),
+					asciiEntry(tagGPSLongitudeRef, "w"), rationalEntry(order, tagGPSLongitude, 151, 1, 12, 1, 36, 1),
+				}
+			},
+			wantLat: floatPtr(-(33 + 52.0/60 + 4.0/3600)),
+			wantLon: floatPtr(-151.21),
+		},
+		{
+			name: "decimal minutes without seconds",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					rationalEntry(order, tagGPSLatitude, 6, 1, 1050, 100, 0, 1),
+					rationalEntry(order, tagGPSLongitude, 106, 1, 4920833, 100000, 0, 1),
+				}
+			},
+			wantLat: floatPtr(6.175),
+			wantLon: floatPtr(106 + 49.20833/60),
+		},
+		{
+			name: "zero denominator",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					rationalEntry(order, tagGPSLatitude, 6, 1, 10, 0, 30, 1),
+					rationalEntry(order, tagGPSLongitude, 106, 1, 49, 1, 12, 1),
+				}
+			},
+		},
+		{
+			name: "longitude missing",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{rationalEntry(order, tagGPSLatitude, 6, 1, 10, 1, 30, 1)}
+			},
+		},
+		{
+			name: "only degrees and minutes",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					rationalEntry(order, tagGPSLatitude, 6, 1, 10, 1),
+					rationalEntry(order, tagGPSLongitude, 106, 1, 49, 1),
+				}
+			},
+		},
+		{
+			name: "wrong type",
+			gps: func(order binary.ByteOrder) []tiffEntry {
+				return []tiffEntry{
+					asciiEntry(tagGPSLatitude, "6.175"),
+					asciiEntry(tagGPSLongitude, "106.82"),
+				}
+			},
+		},
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, tt := range tests {
+			t.Run(order.String()+" "+tt.name, func(t *testing.T) {
+				metadata, err := reader.Read(wrapJPEG(buildTIFF(order, nil, nil, tt.gps(order))))
+
+				require.NoError(t, err)
+				if tt.wantLat == nil {
+					assert.Nil(t, metadata.Latitude)
+					assert.Nil(t, metadata.Longitude)
+					return
+				}
+				require.NotNil(t, metadata.Latitude)
+				require.NotNil(t, metadata.Longitude)
+				assert.InDelta(t, *tt.wantLat, *metadata.Latitude, 1e-9)
+				assert.InDelta(t, *tt.wantLon, *metadata.Longitude, 1e-9)
+			})
+		}
+	}
+}
+
+// Test EXIF Reader - Corrupt Blocks Are Refused Without Panicking
+func TestReader_Read_Malformed(t *testing.T) {
+	reader := NewReader()
+	order := binary.LittleEndian
+	valid := fieldPhoto(order)
+
+	// patch returns a copy of the valid block with a 32-bit value replaced
+	patch := func(offset int, value uint32) []byte {
+		data := append([]byte{}, valid...)
+		order.PutUint32(data[offset:], value)
+		return data
+	}
+	// IFD0 holds DateTime, the Exif pointer and the GPS pointer, in that order
+	exifPointer := 8 + 2 + 12 + 8
+	gpsPointer := exifPointer + 12
+
+	tests := []struct {
+		name    string
+		tiff    []byte
+		wantErr bool
+	}{
+		{name: "shorter than the header", tiff: valid[:6], wantErr: true},
+		{name: "unknown byte order", tiff: append([]byte("XX"), valid[2:]...), wantErr: true},
+		{name: "wrong magic number", tiff: append([]byte{'I', 'I', 43, 0}, valid[4:]...), wantErr: true},
+		{name: "ifd0 beyond the end", tiff: patch(4, uint32(len(valid))), wantErr: true},
+		{name: "ifd0 inside the header", tiff: patch(4, 2), wantErr: true},
+		{name: "ifd0 offset overflowing", tiff: patch(4, 0xFFFFFFFF), wantErr: true},
+		{name: "truncated before the entries end", tiff: valid[:8+2+12], wantErr: true},
+		{name: "truncated in a sub directory", tiff: valid[:70], wantErr: true},
+		{name: "exif pointer beyond the end", tiff: patch(exifPointer, 0x7FFFFFF0), wantErr: true},
+		{name: "gps pointer beyond the end", tiff: patch(gpsPointer, uint32(len(valid)-1)), wantErr: true},
+		{name: "value offset beyond the end", tiff: patch(8+2+8, 0xFFFFFFF0), wantErr: true},
+		{name: "exif pointer back to ifd0", tiff: patch(exifPointer, 8)},
+		{name: "gps pointer back to ifd0", tiff: patch(gpsPointer, 8)},
+		{name: "gps pointer to the exif directory", tiff: patch(gpsPointer, order.Uint32(valid[exifPointer:]))},
+		{
+			name:    "pointer with several values",
+			tiff:    buildTIFF(order, []tiffEntry{{tag: tagGPSIFD, typ: typeLong, count: 2, value: make([]byte, 8)}}, nil, nil),
+			wantErr: true,
+		},
+		{
+			name:    "huge value count",
+			tiff:    buildTIFF(order, []tiffEntry{{tag: tagDateTime, typ: typeRational, count: 0xFFFFFFFF, value: make([]byte, 8)}}, nil, nil),
+			wantErr: true,
+		},
+		{
+			name: "unknown field type is skipped",
+			tiff: buildTIFF(order, []tiffEntry{{tag: tagDateTime, typ: 99, count: 0xFFFFFFFF, value: make([]byte, 8)}}, nil, nil),
+		},
+	}
+	for _, tt := range tests {
+		for container, wrap := range map[string]func([]byte) []byte{"jpeg": wrapJPEG, "png": wrapPNG} {
+			t.Run(container+" "+tt.name, func(t *testing.T) {
+				var err error
+				assert.NotPanics(t, func() {
+					_, err = reader.Read(wrap(tt.tiff))
+				})
+				if tt.wantErr {
+					assert.ErrorIs(t, err, errMalformed)
+				} else {
+					assert.NoError(t, err)
+				}
+			})
+		}
+	}
+}
+
+// Test EXIF Reader - Truncated Containers Are Treated As Images Without EXIF
+func TestReader_Read_TruncatedContainer(t *testing.T) {
+	reader := NewReader()
+	jpeg := wrapJPEG(fieldPhoto(binary.BigEndian))
+	png := wrapPNG(fieldPhoto(binary.BigEndian))
+
+	for name, data := range map[string][]byte{
+		"jpeg cut inside the exif segment": jpeg[:40],
+		"jpeg cut inside a segment header": jpeg[:21],
+		"jpeg segment length below two":    {0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0x00},
+		"jpeg without segment marker":      {0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x10},
+		"png cut inside the exif chunk":    png[:50],
+		"png chunk length overflowing":     append([]byte("\x89PNG\r\n\x1a\n\xFF\xFF\xFF\xFFeXIf"), make([]byte, 8)...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var err error
+			assert.NotPanics(t, func() {
+				_, err = reader.Read(data)
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// FuzzRead checks that arbitrary input never panics and that a block read without error has complete positions
+func FuzzRead(f *testing.F) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		f.Add(wrapJPEG(fieldPhoto(order)))
+		f.Add(wrapPNG(fieldPhoto(order)))
+	}
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xDA})
+
+	reader := NewReader()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		metadata, err := reader.Read(data)
+		if err != nil {
+			return
+		}
+		if (metadata.Latitude == nil) != (metadata.Longitude == nil) {
+			t.Fatalf("latitude and longitude must be present together, got %v and %v", metadata.Latitude, metadata.Longitude)
+		}
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
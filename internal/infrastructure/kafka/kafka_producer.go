@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 
 	"github.com/segmentio/kafka-go"
@@ -76,24 +77,32 @@ func (p *Producer) PublishInvestment(ctx context.Context, investment *domain.Inv
 	return nil
 }
 
-func (p *Producer) PublishFullyFundedLoan(ctx context.Context, loan *domain.Loan) error {
-	data, err := json.Marshal(loan)
-	if err != nil {
-		return err
+// PublishOutboxEvent sends a stored event with its ID and type as headers, so consumers can skip
+// an event the relay delivered more than once
+func (p *Producer) PublishOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	var writer *kafka.Writer
+	switch event.Type {
+	case domain.OutboxEventLoanFullyFunded:
+		writer = p.fullyFundedWriter
+	default:
+		return fmt.Errorf("no topic for outbox event type %q", event.Type)
 	}
 
 	message := kafka.Message{
-		Key:   []byte(loan.ID.String()),
-		Value: data,
+		Key:   []byte(event.Key),
+		Value: []byte(event.Payload),
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.ID.String())},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
 	}
 
-	err = p.fullyFundedWriter.WriteMessages(ctx, message)
-	if err != nil {
-		log.Printf("Error publishing fully funded loan message: %v", err)
+	if err := writer.WriteMessages(ctx, message); err != nil {
+		log.Printf("Error publishing %s event %s: %v", event.Type, event.ID, err)
 		return err
 	}
 
-	log.Printf("Fully funded loan message published for loan: %s", loan.ID)
+	log.Printf("Published %s event %s for %s", event.Type, event.ID, event.Key)
 	return nil
 }
 
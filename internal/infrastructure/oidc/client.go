@@ -0,0 +1,330 @@
+// Package oidc signs staff in with an OpenID Connect provider, using the authorization code
+// flow with PKCE and validating the ID token against the keys the provider publishes.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	// discoveryTTL is how long the provider metadata is cached
+	discoveryTTL = time.Hour
+	// keyRefreshInterval limits the JWKS downloads caused by ID tokens with an unknown kid
+	keyRefreshInterval = time.Minute
+	// requestTimeout bounds every call to the provider
+	requestTimeout = 10 * time.Second
+	// maxResponseSize is the largest provider response read
+	maxResponseSize = 1 << 20
+)
+
+// idTokenAlgorithms are the signing algorithms accepted for ID tokens, never HMAC or none
+var idTokenAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// discoveryDocument is the part of the provider metadata the client uses (OpenID Connect Discovery 1.0)
+type discoveryDocument struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Client talks to the identity provider configured in OIDC_ISSUER_URL
+type Client struct {
+	oidcConfig *config.OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	discoveredAt  time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewClient(oidcConfig *config.OIDCConfig) *Client {
+	return &Client{
+		oidcConfig: oidcConfig,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *Client) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid authorization endpoint: %v", domain.ErrSingleSignOnFailed, err)
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range c.oidcConfig.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.oidcConfig.ClientID)
+	query.Set("redirect_uri", c.oidcConfig.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+func (c *Client) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalIdentity, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.oidcConfig.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {c.oidcConfig.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.oidcConfig.ClientSecret != "" {
+		// RFC 6749 section 2.3.1, both parts are form encoded first
+		req.SetBasicAuth(url.QueryEscape(c.oidcConfig.ClientID), url.QueryEscape(c.oidcConfig.ClientSecret))
+	}
+
+	var response tokenResponse
+	status, err := c.do(req, &response)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || response.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint answered %d %s %s", domain.ErrSingleSignOnFailed, status, response.Error, response.ErrorDescription)
+	}
+	if response.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no ID token", domain.ErrSingleSignOnFailed)
+	}
+
+	return c.verifyIDToken(ctx, doc, response.IDToken, nonce)
+}
+
+// verifyIDToken checks the signature, issuer, audience, times and nonce of an ID token (OpenID Connect Core 3.1.3.7)
+func (c *Client) verifyIDToken(ctx context.Context, doc *discoveryDocument, rawToken string, nonce string) (*domain.ExternalIdentity, error) {
+	token, err := jwt.Parse(rawToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.publicKey(ctx, doc, kid)
+	},
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(c.oidcConfig.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(c.oidcConfig.Leeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID token: %v", domain.ErrSingleSignOnFailed, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid ID token claims", domain.ErrSingleSignOnFailed)
+	}
+
+	// The nonce ties the token to the login this service started
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: ID token nonce does not match", domain.ErrSingleSignOnFailed)
+	}
+
+	// A token issued to several clients must name this one as the authorized party
+	audience, _ := claims.GetAudience()
+	if azp, _ := claims["azp"].(string); (len(audience) > 1 || azp != "") && azp != c.oidcConfig.ClientID {
+		return nil, fmt.Errorf("%w: ID token was issued to another client", domain.ErrSingleSignOnFailed)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: ID token has no subject", domain.ErrSingleSignOnFailed)
+	}
+
+	email, _ := claims["email"].(string)
+	return &domain.ExternalIdentity{
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: boolClaim(claims["email_verified"]),
+		Groups:        stringsClaim(claims[c.oidcConfig.GroupsClaim]),
+	}, nil
+}
+
+// discover returns the provider metadata, fetched again once discoveryTTL passed
+func (c *Client) discover(ctx context.Context) (*discoveryDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil && time.Since(c.discoveredAt) < discoveryTTL {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.oidcConfig.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc discoveryDocument
+	status, err := c.do(req, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery answered %d", domain.ErrSingleSignOnFailed, status)
+	}
+	// The metadata must describe the configured issuer, or its tokens would not validate
+	if strings.TrimSuffix(doc.Issuer, "/") != c.oidcConfig.IssuerURL {
+		return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", domain.ErrSingleSignOnFailed, doc.Issuer, c.oidcConfig.IssuerURL)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is incomplete", domain.ErrSingleSignOnFailed)
+	}
+	if len(doc.CodeChallengeMethodsSupported) > 0 && !slices.Contains(doc.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("%w: provider does not support PKCE with S256", domain.ErrSingleSignOnFailed)
+	}
+
+	c.discovery = &doc
+	c.discoveredAt = time.Now()
+	return c.discovery, nil
+}
+
+// publicKey returns the provider key with the given kid, downloading the JWKS again when the kid is new
+func (c *Client) publicKey(ctx context.Context, doc *discoveryDocument, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.findKey(kid); ok {
+		return key, nil
+	}
+	if c.keys != nil && time.Since(c.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set domain.JSONWebKeySet
+	status, err := c.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("JWKS answered %d", status)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, the provider may publish more than the client needs
+		if key, err := parseJWK(jwk); err == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	c.keys = keys
+	c.keysFetchedAt = time.Now()
+
+	if key, ok := c.findKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// findKey looks a key up by kid, a token without kid may use the only key there is
+func (c *Client) findKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// do sends a request to the provider and decodes its JSON response, whatever the status
+func (c *Client) do(req *http.Request, target interface{}) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", domain.ErrSingleSignOnFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", domain.ErrSingleSignOnFailed, err)
+	}
+	if err := json.Unmarshal(body, target); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("%w: malformed response from %s: %v", domain.ErrSingleSignOnFailed, req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// boolClaim reads a boolean claim, some providers send it as a string
+func boolClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// stringsClaim reads a claim holding a list of strings or a single string
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRedirectURL = "http://localhost:8080/api/auth/oidc/callback"
+	// Code verifier and S256 challenge from RFC 7636 appendix B
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// newTestClient starts the stand-in provider with one staff user and returns a confidential client of it
+func newTestClient(t *testing.T) *Client {
+	server, _, err := oidctest.NewServer(
+		[]oidctest.User{{Subject: "staff-analyst", Email: "analyst@amf.com", EmailVerified: true, Name: "Credit Analyst", Groups: []string{"amf-credit-analysts"}}},
+		[]oidctest.Client{{ID: "amf-loan-service", Secret: "secret", RedirectURIs: []string{testRedirectURL}}},
+	)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	client := NewClient(&config.OIDCConfig{
+		IssuerURL:    server.URL,
+		ClientID:     "amf-loan-service",
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "groups"},
+		GroupsClaim:  "groups",
+		Leeway:       30 * time.Second,
+	})
+	return client
+}
+
+// signIn follows the authorization URL as the given user and returns the code from the redirect
+func signIn(t *testing.T, authorizationURL string, email string) url.Values {
+	browser := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := browser.Get(authorizationURL + "&login_hint=" + url.QueryEscape(email))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+// Test OIDC Client - The Authorization Code Flow With PKCE Yields The Verified Identity
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	// Arrange
+	client := newTestClient(t)
+
+	// Act
+	authorizationURL, urlErr := client.AuthorizationURL(context.Background(), "state-1", "nonce-1", testCodeChallenge)
+	callback := signIn(t, authorizationURL, "analyst@amf.com")
+	identity, exchangeErr := client.Exchange(context.Background(), callback.Get("code"), testCodeVerifier, "nonce-1")
+	_, replayErr := client.Exchange(context.Background(), callback.Get("code"), testCodeVerifier, "nonce-1")
+
+	// Assert
+	assert.NoError(t, urlErr)
+	parsed, _ := url.Parse(authorizationURL)
+	assert.Equal(t, "openid email groups", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(t, "state-1", callback.Get("state"))
+	assert.NoError(t, exchangeErr)
+	assert.Equal(t, &domain.ExternalIdentity{
+		Subject:       "staff-analyst",
+		Email:         "analyst@amf.com",
+		EmailVerified: true,
+		Groups:        []string{"amf-credit-analysts"},
+	}, identity)
+	assert.ErrorIs(t, replayErr, domain.ErrSingleSignOnFailed)
+}
+
+// Test OIDC Client - A Wrong Nonce Or Code Verifier Is Rejected
+func TestClient_Exchange_Rejected(t *testing.T) {
+	// Arrange
+	client := newTestClient(t)
+
+	nonceURL, err := client.AuthorizationURL(context.Background(), "state-1", "nonce-1", testCodeChallenge)
+	require.NoError(t, err)
+	verifierURL, err := client.AuthorizationURL(context.Background(), "state-2", "nonce-2", testCodeChallenge)
+	require.NoError(t, err)
+
+	// Act
+	_, nonceErr := client.Exchange(context.Background(), signIn(t, nonceURL, "analyst@amf.com").Get("code"), testCodeVerifier, "another-nonce")
+	_, verifierErr := client.Exchange(context.Background(), signIn(t, verifierURL, "analyst@amf.com").Get("code"), testCodeVerifier+"x", "nonce-2")
+
+	// Assert
+	assert.ErrorIs(t, nonceErr, domain.ErrSingleSignOnFailed)
+	assert.Contains(t, nonceErr.Error(), "nonce")
+	assert.ErrorIs(t, verifierErr, domain.ErrSingleSignOnFailed)
+	assert.Contains(t, verifierErr.Error(), "invalid_grant")
+}
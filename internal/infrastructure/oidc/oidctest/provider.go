@@ -0,0 +1,310 @@
+// Package oidctest is a minimal OpenID Connect provider for development and tests. It signs in
+// the configured users without a password, so it must never be reachable in production.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	// codeTTL is how long an authorization code can be redeemed
+	codeTTL = time.Minute
+	// idTokenTTL is the lifetime of issued ID tokens
+	idTokenTTL = 5 * time.Minute
+	keyID      = "oidctest"
+)
+
+// User is an account of the provider
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+// Client is a relying party registered at the provider
+type Client struct {
+	ID           string
+	Secret       string // Empty for a public client
+	RedirectURIs []string
+}
+
+type authorizationCode struct {
+	user          User
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// Provider serves discovery, authorization, token and JWKS endpoints
+type Provider struct {
+	Issuer string
+
+	users   []User
+	clients []Client
+	key     *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authorizationCode
+}
+
+func NewProvider(issuer string, users []User, clients []Client) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{
+		Issuer:  issuer,
+		users:   users,
+		clients: clients,
+		key:     key,
+		codes:   make(map[string]*authorizationCode),
+	}, nil
+}
+
+// NewServer starts a provider on a local test server, its URL is the issuer
+func NewServer(users []User, clients []Client) (*httptest.Server, *Provider, error) {
+	provider, err := NewProvider("", users, clients)
+	if err != nil {
+		return nil, nil, err
+	}
+	server := httptest.NewServer(provider.Handler())
+	provider.Issuer = server.URL
+	return server, provider, nil
+}
+
+func (p *Provider) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	return mux
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile", "groups"},
+	})
+}
+
+var chooserTemplate = template.Must(template.New("chooser").Parse(`<!DOCTYPE html>
+<html><head><title>Sign in</title></head><body>
+<h1>Sign in as</h1>
+<ul>{{range .Users}}
+<li><a href="{{$.Action}}&login_hint={{.Email}}">{{.Name}} ({{.Email}})</a></li>{{end}}
+</ul>
+</body></html>
+`))
+
+// authorize signs in the user named by login_hint, or lets the browser choose one
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	client := p.client(query.Get("client_id"))
+	redirectURI := query.Get("redirect_uri")
+	if client == nil || !contains(client.RedirectURIs, redirectURI) {
+		// Never redirect to an unregistered address
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	redirect := func(params url.Values) {
+		target, _ := url.Parse(redirectURI)
+		values := target.Query()
+		for key := range params {
+			values.Set(key, params.Get(key))
+		}
+		values.Set("state", query.Get("state"))
+		target.RawQuery = values.Encode()
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	}
+
+	if query.Get("response_type") != "code" {
+		redirect(url.Values{"error": {"unsupported_response_type"}})
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		redirect(url.Values{"error": {"invalid_request"}, "error_description": {"PKCE with S256 is required"}})
+		return
+	}
+
+	hint := query.Get("login_hint")
+	if hint == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = chooserTemplate.Execute(w, map[string]interface{}{
+			"Action": template.URL("/authorize?" + r.URL.RawQuery),
+			"Users":  p.users,
+		})
+		return
+	}
+
+	user := p.user(hint)
+	if user == nil {
+		redirect(url.Values{"error": {"access_denied"}, "error_description": {"unknown user"}})
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = &authorizationCode{
+		user:          *user,
+		clientID:      client.ID,
+		redirectURI:   redirectURI,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	redirect(url.Values{"code": {code}})
+}
+
+// token redeems an authorization code once, checking the client and the PKCE verifier
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	clientID, secret, hasBasic := r.BasicAuth()
+	if hasBasic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	client := p.client(clientID)
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	code, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) || code.clientID != client.ID || code.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != code.codeChallenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            code.user.Subject,
+		"aud":            client.ID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.user.Email,
+		"email_verified": code.user.EmailVerified,
+		"name":           code.user.Name,
+		"groups":         code.user.Groups,
+	})
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, domain.JSONWebKeySet{Keys: []domain.JSONWebKey{{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     keyID,
+		N:         base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func (p *Provider) client(id string) *Client {
+	for i := range p.clients {
+		if p.clients[i].ID == id {
+			return &p.clients[i]
+		}
+	}
+	return nil
+}
+
+func (p *Provider) user(email string) *User {
+	for i := range p.users {
+		if p.users[i].Email == email {
+			return &p.users[i]
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
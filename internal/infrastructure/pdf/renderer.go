@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// Page geometry in PDF points (A4)
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	marginLeft   = 56.0
+	marginTop    = 64.0
+	marginBottom = 64.0
+
+	titleSize   = 16.0
+	headingSize = 12.0
+	bodySize    = 10.0
+	lineGap     = 1.45
+
+	// Helvetica averages about half an em per character, good enough for wrapping
+	avgCharWidth = 0.5
+)
+
+// Renderer writes simple text documents as PDF 1.4 files using the standard Helvetica fonts,
+// which every PDF reader ships, so no fonts need to be embedded
+type Renderer struct{}
+
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+type textLine struct {
+	text string
+	size float64
+	bold bool
+}
+
+func (r *Renderer) Render(doc *domain.TextDocument) ([]byte, error) {
+	lines := layout(doc)
+
+	// Split lines into pages
+	var pages [][]textLine
+	var current []textLine
+	y := pageHeight - marginTop
+	for _, line := range lines {
+		height := line.size * lineGap
+		if y-height < marginBottom && len(current) > 0 {
+			pages = append(pages, current)
+			current = nil
+			y = pageHeight - marginTop
+		}
+		current = append(current, line)
+		y -= height
+	}
+	pages = append(pages, current)
+
+	return write(doc.Title, pages), nil
+}
+
+// layout flattens the document into wrapped lines with their font settings
+func layout(doc *domain.TextDocument) []textLine {
+	var lines []textLine
+	lines = append(lines, wrap(doc.Title, titleSize, true)...)
+	lines = append(lines, textLine{size: bodySize})
+
+	for _, section := range doc.Sections {
+		if section.Heading != "" {
+			lines = append(lines, wrap(section.Heading, headingSize, true)...)
+		}
+		for _, paragraph := range section.Lines {
+			lines = append(lines, wrap(paragraph, bodySize, false)...)
+		}
+		lines = append(lines, textLine{size: bodySize})
+	}
+
+	return lines
+}
+
+// wrap breaks text into lines that fit the printable width
+func wrap(text string, size float64, bold bool) []textLine {
+	maxChars := int((pageWidth - 2*marginLeft) / (size * avgCharWidth))
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []textLine{{size: size, bold: bold}}
+	}
+
+	var lines []textLine
+	var current strings.Builder
+	for _, word := range words {
+		if current.Len() > 0 && current.Len()+1+len(word) > maxChars {
+			lines = append(lines, textLine{text: current.String(), size: size, bold: bold})
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+	}
+	lines = append(lines, textLine{text: current.String(), size: size, bold: bold})
+
+	return lines
+}
+
+// write serializes the pages into a PDF file with a valid cross-reference table
+func write(title string, pages [][]textLine) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	addObject := func(body string) int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", id, body)
+		return id
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed objects: 1 catalog, 2 page tree, 3 regular font, 4 bold font, 5 info
+	pageTreeID := 2
+	firstPageID := 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+
+	addObject(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTreeID))
+	addObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	infoID := addObject(fmt.Sprintf("<< /Title (%s) /Producer (AMF Loan Service) >>", escape(title)))
+
+	for _, page := range pages {
+		content := pageContent(page)
+		pageID := len(offsets) + 1
+		addObject(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageTreeID, pageWidth, pageHeight, pageID+1))
+		addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, infoID, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func pageContent(lines []textLine) string {
+	var b strings.Builder
+	y := pageHeight - marginTop
+	for _, line := range lines {
+		y -= line.size * lineGap
+		if line.text == "" {
+			continue
+		}
+		font := "F1"
+		if line.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, line.size, marginLeft, y, escape(line.text))
+	}
+	return b.String()
+}
+
+// escape makes text safe for a PDF literal string, replacing characters outside WinAnsi
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
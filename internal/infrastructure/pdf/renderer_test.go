@@ -0,0 +1,116 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Escape - Literal String Delimiters Are Escaped And Text Outside WinAnsi Is Replaced
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "Loan Agreement 2024", want: "Loan Agreement 2024"},
+		{name: "parentheses", text: "Rp 5.000.000 (five million)", want: `Rp 5.000.000 \(five million\)`},
+		{name: "backslash", text: `C:\loans`, want: `C:\\loans`},
+		{name: "unbalanced parenthesis", text: "a) b", want: `a\) b`},
+		{name: "latin-1 as octal", text: "José Müller", want: `Jos\351 M\374ller`},
+		{name: "non-breaking space", text: "Rp\u00a0100", want: `Rp\240100`},
+		{name: "outside winansi", text: "€ 10 ✓", want: "? 10 ?"},
+		{name: "control characters", text: "a\tb\nc", want: "a?b?c"},
+		{name: "cjk", text: "借款", want: "??"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, escape(tt.text))
+		})
+	}
+}
+
+// Test Renderer - Escaped Text Ends Up In The Title And The Page Content
+func TestRenderer_Render_EscapesText(t *testing.T) {
+	renderer := NewRenderer()
+
+	output, err := renderer.Render(&domain.TextDocument{
+		Title:    "Agreement (draft)",
+		Sections: []domain.TextSection{{Heading: "Parties", Lines: []string{`Borrower: José \ Co`}}},
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, string(output), `/Title (Agreement \(draft\))`)
+	assert.Contains(t, string(output), `(Borrower: Jos\351 \\ Co) Tj`)
+}
+
+// Test Renderer - Every Cross-Reference Entry Points At Its Object And startxref At The Table
+func TestRenderer_Render_CrossReferenceTable(t *testing.T) {
+	renderer := NewRenderer()
+
+	// Enough lines for several pages
+	var lines []string
+	for i := 0; i < 150; i++ {
+		lines = append(lines, fmt.Sprintf("Clause %d (binding) applies to the borrower and every investor of the loan.", i+1))
+	}
+	output, err := renderer.Render(&domain.TextDocument{
+		Title:    "Loan Agreement",
+		Sections: []domain.TextSection{{Heading: "Terms", Lines: lines}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, bytes.HasPrefix(output, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(output, []byte("%%EOF\n")))
+
+	// The trailer points at the start of the table
+	trailer := regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R /Info (\d+) 0 R >>\nstartxref\n(\d+)\n%%EOF\n$`).FindSubmatch(output)
+	require.NotNil(t, trailer)
+	size, _ := strconv.Atoi(string(trailer[1]))
+	startxref, _ := strconv.Atoi(string(trailer[3]))
+	require.Less(t, startxref, len(output))
+	assert.True(t, bytes.HasPrefix(output[startxref:], []byte(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", size))))
+	assert.Equal(t, startxref, bytes.LastIndex(output, []byte("xref\n0 ")))
+
+	// Each in-use entry is the byte offset of its object
+	table := strings.Split(strings.TrimSuffix(string(output[startxref:bytes.Index(output, []byte("trailer\n"))]), "\n"), "\n")
+	entries := table[3:]
+	require.Len(t, entries, size-1)
+	for i, entry := range entries {
+		require.Len(t, entry, 19, "entries are 20 bytes including the newline")
+		offset, err := strconv.Atoi(entry[:10])
+		require.NoError(t, err)
+		assert.Equal(t, " 00000 n ", entry[10:])
+		assert.True(t, bytes.HasPrefix(output[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d at offset %d", i+1, offset)
+	}
+
+	// The page tree counts every page, which the text spilled onto
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(output)
+	require.NotNil(t, count)
+	pages, _ := strconv.Atoi(string(count[1]))
+	assert.Greater(t, pages, 1)
+	assert.Equal(t, 5+2*pages, size-1)
+
+	// Stream lengths match their content
+	for _, stream := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(output, -1) {
+		length, _ := strconv.Atoi(string(stream[1]))
+		assert.Equal(t, length, len(stream[2]))
+	}
+}
+
+// Test Renderer - An Empty Document Is Still A Valid Single Page File
+func TestRenderer_Render_Empty(t *testing.T) {
+	renderer := NewRenderer()
+
+	output, err := renderer.Render(&domain.TextDocument{})
+
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "/Count 1 >>")
+	assert.True(t, bytes.HasSuffix(output, []byte("%%EOF\n")))
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type agreementSignatureRepository struct {
+	db *gorm.DB
+}
+
+func NewAgreementSignatureRepository(db *gorm.DB) domain.AgreementSignatureRepository {
+	return &agreementSignatureRepository{db: db}
+}
+
+func (r *agreementSignatureRepository) Create(ctx context.Context, signature *domain.AgreementSignature) error {
+	return r.db.WithContext(ctx).Create(signature).Error
+}
+
+func (r *agreementSignatureRepository) GetByDocumentID(ctx context.Context, documentID uuid.UUID) (*domain.AgreementSignature, error) {
+	var signature domain.AgreementSignature
+	err := r.db.WithContext(ctx).Where("document_id = ?", documentID).First(&signature).Error
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+func (r *agreementSignatureRepository) CreateOTP(ctx context.Context, otp *domain.SignatureOTP) error {
+	return r.db.WithContext(ctx).Create(otp).Error
+}
+
+// GetLatestOTP returns the most recently issued code, earlier codes are superseded by it
+func (r *agreementSignatureRepository) GetLatestOTP(ctx context.Context, documentID uuid.UUID, userID uuid.UUID) (*domain.SignatureOTP, error) {
+	var otp domain.SignatureOTP
+	err := r.db.WithContext(ctx).
+		Where("document_id = ? AND user_id = ?", documentID, userID).
+		Order("created_at DESC").
+		First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func (r *agreementSignatureRepository) CountOTPsSince(ctx context.Context, documentID uuid.UUID, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.SignatureOTP{}).
+		Where("document_id = ? AND user_id = ? AND created_at >= ?", documentID, userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// RecordOTPAttempt counts an attempt against the code, it returns false once the limit is reached
+func (r *agreementSignatureRepository) RecordOTPAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.SignatureOTP{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ConsumeOTP marks the code used, it returns false when it was already used
+func (r *agreementSignatureRepository) ConsumeOTP(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.SignatureOTP{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Signing Code Attempt - Counted In The Database Only While Below The Limit
+func TestAgreementSignatureRepository_RecordOTPAttempt(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewAgreementSignatureRepository(db)
+
+	otpID := uuid.New()
+
+	for _, rows := range []int64{1, 0} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "signature_otps" SET "attempts"=attempts + 1 WHERE id = $1 AND attempts < $2`)).
+			WithArgs(otpID, 3).
+			WillReturnResult(sqlmock.NewResult(0, rows))
+		mock.ExpectCommit()
+	}
+
+	// Act
+	counted, err := repo.RecordOTPAttempt(context.Background(), otpID, 3)
+	require.NoError(t, err)
+	exhausted, err := repo.RecordOTPAttempt(context.Background(), otpID, 3)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, counted)
+	assert.False(t, exhausted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Signing Code Consumption - A Code Used Concurrently Is Consumed Once
+func TestAgreementSignatureRepository_ConsumeOTP(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewAgreementSignatureRepository(db)
+
+	otpID := uuid.New()
+	usedAt := time.Now()
+
+	for _, rows := range []int64{1, 0} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "signature_otps" SET "used_at"=$1 WHERE id = $2 AND used_at IS NULL`)).
+			WithArgs(usedAt, otpID).
+			WillReturnResult(sqlmock.NewResult(0, rows))
+		mock.ExpectCommit()
+	}
+
+	// Act
+	consumed, err := repo.ConsumeOTP(context.Background(), otpID, usedAt)
+	require.NoError(t, err)
+	reused, err := repo.ConsumeOTP(context.Background(), otpID, usedAt)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, consumed)
+	assert.False(t, reused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test API Key Rotation - The Key Is Linked To Its Successor And The Successor Stored In One Transaction
+func TestAPIKeyRepository_Rotate(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewAPIKeyRepository(db)
+
+	keyID := uuid.New()
+	graceUntil := time.Now().Add(24 * time.Hour)
+	successor := &domain.APIKey{ID: uuid.New(), UserID: uuid.New(), Name: "nightly job", Prefix: "amf_fedcba9876543210",
+		Scopes: domain.Permissions{domain.PermissionLoanBrowse}, ExpiresAt: time.Now().Add(30 * 24 * time.Hour), CreatedByID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys" SET "expires_at"=LEAST(expires_at, $1),"replaced_by_id"=$2 WHERE id = $3 AND revoked_at IS NULL AND replaced_by_id IS NULL`)).
+		WithArgs(graceUntil, successor.ID, keyID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(successor.ID))
+	mock.ExpectCommit()
+
+	// Act
+	rotated, err := repo.Rotate(context.Background(), keyID, successor, graceUntil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test API Key Rotation - A Key Rotated Concurrently Gets No Second Successor
+func TestAPIKeyRepository_Rotate_AlreadyRotated(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewAPIKeyRepository(db)
+
+	successor := &domain.APIKey{ID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Act
+	rotated, err := repo.Rotate(context.Background(), uuid.New(), successor, time.Now())
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -31,3 +31,14 @@ func (r *approvalRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID)
 	}
 	return &approval, nil
 }
+
+func (r *approvalRepository) GetNeedingReview(ctx context.Context) ([]domain.Approval, error) {
+	var approvals []domain.Approval
+	err := r.db.WithContext(ctx).
+		Preload("Validator").
+		Preload("PhotoProof").
+		Where("needs_review = ?", true).
+		Order("created_at DESC").
+		Find(&approvals).Error
+	return approvals, err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type approvalStageRepository struct {
+	db *gorm.DB
+}
+
+func NewApprovalStageRepository(db *gorm.DB) domain.ApprovalStageRepository {
+	return &approvalStageRepository{db: db}
+}
+
+func (r *approvalStageRepository) Create(ctx context.Context, stage *domain.ApprovalStage) error {
+	return r.db.WithContext(ctx).Create(stage).Error
+}
+
+func (r *approvalStageRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.ApprovalStage, error) {
+	var stages []domain.ApprovalStage
+	err := r.db.WithContext(ctx).
+		Preload("Actor").
+		Where("loan_id = ?", loanID).
+		Order("decided_at ASC").
+		Find(&stages).Error
+	return stages, err
+}
+
+func (r *approvalStageRepository) RecordDecision(ctx context.Context, stage *domain.ApprovalStage, approval *domain.Approval, required []domain.StageRequirement) (domain.LoanState, error) {
+	var state domain.LoanState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Concurrent decisions on the loan are serialized by its row lock
+		var loan domain.Loan
+		if err := lockLoan(tx, stage.LoanID, &loan); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrLoanNotFound
+			}
+			return err
+		}
+		switch loan.State {
+		case domain.LoanStateProposed:
+		case domain.LoanStateRejected:
+			return domain.ErrLoanRejected
+		default:
+			return domain.ErrLoanAlreadyApproved
+		}
+
+		if approval != nil {
+			var approvals int64
+			if err := tx.Model(&domain.Approval{}).Where("loan_id = ?", loan.ID).Count(&approvals).Error; err != nil {
+				return err
+			}
+			if approvals > 0 {
+				return domain.ErrApprovalStageCompleted
+			}
+			if err := tx.Create(approval).Error; err != nil {
+				return err
+			}
+		}
+
+		var stages []domain.ApprovalStage
+		if err := tx.Where("loan_id = ?", loan.ID).Find(&stages).Error; err != nil {
+			return err
+		}
+		// Committee members sign off individually, every other stage is decided once
+		for _, recorded := range stages {
+			if recorded.Stage == stage.Stage && (stage.Stage != domain.ApprovalStageCommitteeSignoff || recorded.ActorID == stage.ActorID) {
+				return domain.ErrApprovalStageCompleted
+			}
+		}
+		if err := tx.Create(stage).Error; err != nil {
+			return err
+		}
+		stages = append(stages, *stage)
+
+		switch {
+		case stage.Decision == domain.ApprovalDecisionRejected:
+			state = domain.LoanStateRejected
+		case domain.StagesPassed(stages, required):
+			state = domain.LoanStateApproved
+		default:
+			// Still waiting for later stages
+			state = domain.LoanStateProposed
+			return nil
+		}
+
+		result := tx.Model(&domain.Loan{}).
+			Where("id = ? AND state = ?", loan.ID, domain.LoanStateProposed).
+			Updates(map[string]interface{}{"state": state, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrLoanAlreadyApproved
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return state, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stageColumns = []string{"id", "loan_id", "stage", "actor_id", "decision"}
+
+var committeeRequirements = []domain.StageRequirement{
+	{Stage: domain.ApprovalStageFieldVerification, Approvals: 1},
+	{Stage: domain.ApprovalStageCommitteeSignoff, Approvals: 2},
+}
+
+// Test Stage Decision - The Sign-Off Completing The Quorum Approves The Locked Loan
+func TestApprovalStageRepository_RecordDecision_Approves(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewApprovalStageRepository(db)
+
+	loanID := uuid.New()
+	stage := &domain.ApprovalStage{ID: uuid.New(), LoanID: loanID, Stage: domain.ApprovalStageCommitteeSignoff, ActorID: uuid.New(), Decision: domain.ApprovalDecisionApproved, DecidedAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT 1 FOR UPDATE`)).
+		WithArgs(loanID).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 750000.0, 0.0, 750000.0, 0.0, domain.LoanStateProposed))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "approval_stages" WHERE loan_id = $1`)).
+		WithArgs(loanID).
+		WillReturnRows(sqlmock.NewRows(stageColumns).
+			AddRow(uuid.New(), loanID, domain.ApprovalStageFieldVerification, uuid.New(), domain.ApprovalDecisionApproved).
+			AddRow(uuid.New(), loanID, domain.ApprovalStageCommitteeSignoff, uuid.New(), domain.ApprovalDecisionApproved))
+	mock.ExpectQuery(`INSERT INTO "approval_stages"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(stage.ID))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loans" SET "state"=$1,"updated_at"=$2 WHERE id = $3 AND state = $4`)).
+		WithArgs(domain.LoanStateApproved, sqlmock.AnyArg(), loanID, domain.LoanStateProposed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Act
+	state, err := repo.RecordDecision(context.Background(), stage, nil, committeeRequirements)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanStateApproved, state)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Stage Decision - A Member Who Already Signed Off Under The Lock Is Refused
+func TestApprovalStageRepository_RecordDecision_AlreadyDecided(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewApprovalStageRepository(db)
+
+	loanID := uuid.New()
+	memberID := uuid.New()
+	stage := &domain.ApprovalStage{ID: uuid.New(), LoanID: loanID, Stage: domain.ApprovalStageCommitteeSignoff, ActorID: memberID, Decision: domain.ApprovalDecisionApproved}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 750000.0, 0.0, 750000.0, 0.0, domain.LoanStateProposed))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "approval_stages"`).
+		WillReturnRows(sqlmock.NewRows(stageColumns).AddRow(uuid.New(), loanID, domain.ApprovalStageCommitteeSignoff, memberID, domain.ApprovalDecisionApproved))
+	mock.ExpectRollback()
+
+	// Act
+	_, err := repo.RecordDecision(context.Background(), stage, nil, committeeRequirements)
+
+	// Assert
+	assert.Equal(t, domain.ErrApprovalStageCompleted, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Stage Decision - A Loan Decided Concurrently Is Not Touched
+func TestApprovalStageRepository_RecordDecision_LoanRejected(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewApprovalStageRepository(db)
+
+	loanID := uuid.New()
+	stage := &domain.ApprovalStage{ID: uuid.New(), LoanID: loanID, Stage: domain.ApprovalStageFieldVerification, Decision: domain.ApprovalDecisionApproved}
+	approval := &domain.Approval{ID: uuid.New(), LoanID: loanID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 0.0, 100000.0, 0.0, domain.LoanStateRejected))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := repo.RecordDecision(context.Background(), stage, approval, committeeRequirements)
+
+	assert.Equal(t, domain.ErrLoanRejected, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
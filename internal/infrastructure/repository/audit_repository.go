@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) domain.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
@@ -9,14 +9,20 @@ import (
 )
 
 type borrowerRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	cipher domain.FieldCipher
 }
 
-func NewBorrowerRepository(db *gorm.DB) domain.BorrowerRepository {
-	return &borrowerRepository{db: db}
+func NewBorrowerRepository(db *gorm.DB, cipher domain.FieldCipher) domain.BorrowerRepository {
+	return &borrowerRepository{db: db, cipher: cipher}
 }
 
 func (r *borrowerRepository) Create(ctx context.Context, borrower *domain.Borrower) error {
+	// Encrypted fields are bound to the row ID, so it is set before the insert rather than by the database
+	if borrower.ID == uuid.Nil {
+		borrower.ID = uuid.New()
+	}
+	borrower.IdentityNumberIndex = r.cipher.BlindIndex(borrower.IdentityNumber)
 	return r.db.WithContext(ctx).Create(borrower).Error
 }
 
@@ -44,6 +50,30 @@ func (r *borrowerRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return &borrower, nil
 }
 
+func (r *borrowerRepository) GetByIdentityNumber(ctx context.Context, identityNumber string) (*domain.Borrower, error) {
+	var borrower domain.Borrower
+	err := r.db.WithContext(ctx).Where("identity_number_index = ?", r.cipher.BlindIndex(identityNumber)).First(&borrower).Error
+	if err != nil {
+		return nil, err
+	}
+	return &borrower, nil
+}
+
+func (r *borrowerRepository) CreateWithUser(ctx context.Context, borrower *domain.Borrower) error {
+	if borrower.ID == uuid.Nil {
+		borrower.ID = uuid.New()
+	}
+	borrower.IdentityNumberIndex = r.cipher.BlindIndex(borrower.IdentityNumber)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&borrower.User).Error; err != nil {
+			return err
+		}
+		borrower.UserID = borrower.User.ID
+		return tx.Omit("User").Create(borrower).Error
+	})
+}
+
 func (r *borrowerRepository) Update(ctx context.Context, borrower *domain.Borrower) error {
+	borrower.IdentityNumberIndex = r.cipher.BlindIndex(borrower.IdentityNumber)
 	return r.db.WithContext(ctx).Save(borrower).Error
 }
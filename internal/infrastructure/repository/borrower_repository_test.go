@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Borrower Creation - The ID Is Set Before The Insert So Encrypted Fields Can Be Bound To It
+func TestBorrowerRepository_Create_AssignsID(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewBorrowerRepository(db, plainCipher{})
+
+	borrower := &domain.Borrower{UserID: uuid.New(), FullName: "Jane Roe", PhoneNumber: "+1234567893",
+		Address: "12 Hudson St, New York, NY", IdentityNumber: "B001234570"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	// Act
+	err := repo.Create(context.Background(), borrower)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, borrower.ID)
+	assert.Equal(t, "index:B001234570", borrower.IdentityNumberIndex)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type documentRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) domain.DocumentRepository {
+	return &documentRepository{db: db}
+}
+
+func (r *documentRepository) Create(ctx context.Context, document *domain.Document) error {
+	return r.db.WithContext(ctx).Create(document).Error
+}
+
+func (r *documentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Document, error) {
+	var document domain.Document
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *documentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.Document, error) {
+	var documents []domain.Document
+	err := r.db.WithContext(ctx).
+		Where("loan_id = ?", loanID).
+		Order("created_at ASC").
+		Find(&documents).Error
+	return documents, err
+}
+
+func (r *documentRepository) GetLatestByLoanAndType(ctx context.Context, loanID uuid.UUID, docType domain.DocumentType) (*domain.Document, error) {
+	var document domain.Document
+	err := r.db.WithContext(ctx).
+		Where("loan_id = ? AND type = ?", loanID, docType).
+		Order("created_at DESC").
+		First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
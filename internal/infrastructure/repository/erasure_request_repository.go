@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type erasureRequestRepository struct {
+	db     *gorm.DB
+	cipher domain.FieldCipher
+}
+
+func NewErasureRequestRepository(db *gorm.DB, cipher domain.FieldCipher) domain.ErasureRequestRepository {
+	return &erasureRequestRepository{db: db, cipher: cipher}
+}
+
+func (r *erasureRequestRepository) Create(ctx context.Context, request *domain.ErasureRequest) error {
+	return r.db.WithContext(ctx).Omit("User").Create(request).Error
+}
+
+func (r *erasureRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ErasureRequest, error) {
+	var request domain.ErasureRequest
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("id = ?", id).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *erasureRequestRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*domain.ErasureRequest, error) {
+	var request domain.ErasureRequest
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *erasureRequestRepository) ListByStatus(ctx context.Context, status domain.ErasureStatus, limit, offset int) ([]domain.ErasureRequest, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.ErasureRequest{}).Where("status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []domain.ErasureRequest
+	err := query.
+		Preload("User").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&requests).Error
+	return requests, total, err
+}
+
+func (r *erasureRequestRepository) Reject(ctx context.Context, request *domain.ErasureRequest) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.ErasureRequest{}).
+		Where("id = ? AND status = ?", request.ID, domain.ErasureStatusPending).
+		Updates(map[string]interface{}{
+			"status":           domain.ErasureStatusRejected,
+			"processed_by_id":  request.ProcessedByID,
+			"processed_at":     request.ProcessedAt,
+			"rejection_reason": request.RejectionReason,
+			"updated_at":       request.UpdatedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *erasureRequestRepository) Complete(ctx context.Context, erasure *domain.PersonalDataErasure) (bool, error) {
+	request := erasure.Request
+	user := erasure.User
+
+	completed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only a pending request can be completed, a concurrent decision finds it already processed
+		result := tx.Model(&domain.ErasureRequest{}).
+			Where("id = ? AND status = ?", request.ID, domain.ErasureStatusPending).
+			Updates(map[string]interface{}{
+				"status":          domain.ErasureStatusCompleted,
+				"processed_by_id": request.ProcessedByID,
+				"processed_at":    request.ProcessedAt,
+				"updated_at":      request.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		completed = true
+
+		// Failed attempts with the old address are not linked to the user, so the address is read first
+		var current domain.User
+		if err := tx.Select("email").Where("id = ?", user.ID).First(&current).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.LoginEvent{}).
+			Where("user_id = ? OR email = ?", user.ID, current.Email).
+			Updates(map[string]interface{}{"email": user.Email, "ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.TwoFactorChallenge{}).
+			Where("user_id = ?", user.ID).
+			Updates(map[string]interface{}{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		// Signatures keep the signed name, document hash and time as evidence of the contract,
+		// the device details are not needed for that
+		if err := tx.Model(&domain.AgreementSignature{}).
+			Where("signer_user_id = ?", user.ID).
+			Updates(map[string]interface{}{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+
+		// Select writes the cleared fields too, which Updates skips as zero values
+		if err := tx.Model(user).
+			Select("email", "password", "totp_secret", "totp_enabled_at", "totp_last_step", "external_subject",
+				"sessions_revoked_at", "deactivated_at", "erased_at", "updated_at").
+			Updates(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", user.SessionsRevokedAt).Error; err != nil {
+			return err
+		}
+
+		// The identity images go, the submissions stay as the record that the customer was verified
+		submissions := tx.Model(&domain.KYCSubmission{}).Select("id").Where("user_id = ?", user.ID)
+		if err := tx.Where("submission_id IN (?)", submissions).Delete(&domain.KYCDocument{}).Error; err != nil {
+			return err
+		}
+
+		// Profiles are written through the model so the encrypted fields are encrypted
+		if borrower := erasure.Borrower; borrower != nil {
+			borrower.IdentityNumberIndex = r.cipher.BlindIndex(borrower.IdentityNumber)
+			if err := tx.Model(borrower).
+				Select("full_name", "phone_number", "address", "identity_number", "identity_number_index",
+					"latitude", "longitude", "updated_at").
+				Updates(borrower).Error; err != nil {
+				return err
+			}
+		}
+		if investor := erasure.Investor; investor != nil {
+			investor.IdentityNumberIndex = r.cipher.BlindIndex(investor.IdentityNumber)
+			if err := tx.Model(investor).
+				Select("full_name", "phone_number", "address", "identity_number", "identity_number_index", "updated_at").
+				Updates(investor).Error; err != nil {
+				return err
+			}
+
+			// Nobody is left to invest the amounts the investor was waiting for
+			if err := tx.Model(&domain.WaitlistEntry{}).
+				Where("investor_id = ? AND status = ?", investor.ID, domain.WaitlistStatusWaiting).
+				Updates(map[string]interface{}{"status": domain.WaitlistStatusCancelled, "updated_at": investor.UpdatedAt}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return completed, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Erasure Completion - The Challenges Are Anonymized And The Investor's Waitlist Entries Cancelled
+func TestErasureRequestRepository_Complete_Investor(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewErasureRequestRepository(db, plainCipher{})
+
+	now := time.Now()
+	adminID := uuid.New()
+	userID := uuid.New()
+	investorID := uuid.New()
+	erasure := &domain.PersonalDataErasure{
+		Request:  &domain.ErasureRequest{ID: uuid.New(), UserID: userID, ProcessedByID: &adminID, ProcessedAt: &now, UpdatedAt: now},
+		User:     &domain.User{ID: userID, Email: "erased-" + userID.String() + "@erased.invalid", SessionsRevokedAt: &now, UpdatedAt: now},
+		Investor: &domain.Investor{ID: investorID, UserID: userID, FullName: "Erased", IdentityNumber: "ERASED-" + investorID.String(), UpdatedAt: now},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "erasure_requests"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT "email" FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("investor@example.com"))
+	mock.ExpectExec(`UPDATE "login_events"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factor_challenges" SET "ip_address"=$1,"user_agent"=$2 WHERE user_id = $3`)).
+		WithArgs("", "", userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "agreement_signatures" SET "ip_address"=$1,"user_agent"=$2 WHERE signer_user_id = $3`)).
+		WithArgs("", "", userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "recovery_codes"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "refresh_tokens"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "kyc_documents"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "investors"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "waitlist_entries" SET "status"=$1,"updated_at"=$2 WHERE investor_id = $3 AND status = $4`)).
+		WithArgs(domain.WaitlistStatusCancelled, sqlmock.AnyArg(), investorID, domain.WaitlistStatusWaiting).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Act
+	completed, err := repo.Complete(context.Background(), erasure)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Erasure Completion - A Request Processed Concurrently Is Left Alone
+func TestErasureRequestRepository_Complete_AlreadyProcessed(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewErasureRequestRepository(db, plainCipher{})
+
+	now := time.Now()
+	userID := uuid.New()
+	erasure := &domain.PersonalDataErasure{
+		Request: &domain.ErasureRequest{ID: uuid.New(), UserID: userID, ProcessedAt: &now, UpdatedAt: now},
+		User:    &domain.User{ID: userID},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "erasure_requests"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Act
+	completed, err := repo.Complete(context.Background(), erasure)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, completed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
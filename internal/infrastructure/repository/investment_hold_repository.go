@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type investmentHoldRepository struct {
+	db *gorm.DB
+}
+
+func NewInvestmentHoldRepository(db *gorm.DB) domain.InvestmentHoldRepository {
+	return &investmentHoldRepository{db: db}
+}
+
+func (r *investmentHoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.InvestmentHold, error) {
+	var hold domain.InvestmentHold
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// CreateWithLoanLock atomically locks the loan, checks the unreserved capacity and records the hold
+func (r *investmentHoldRepository) CreateWithLoanLock(ctx context.Context, hold *domain.InvestmentHold) (*domain.Loan, error) {
+	var loan domain.Loan
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Lock the loan within the transaction (SELECT FOR UPDATE)
+		if err := lockLoan(tx, hold.LoanID, &loan); err != nil {
+			return err
+		}
+
+		// 2. Only approved loans accept new reservations
+		if loan.State != domain.LoanStateApproved {
+			return domain.ErrLoanNotApproved
+		}
+
+		// 3. Reserved amounts are not available to other investors
+		if hold.Amount > loan.AvailableForInvestment() {
+			return domain.ErrInvestmentExceedsLimit
+		}
+
+		// 4. Reserve the amount on the loan, the condition re-checks the capacity in the same statement
+		result := tx.Model(&domain.Loan{}).
+			Where("id = ? AND state = ? AND remaining_investment - reserved_amount >= ?", loan.ID, domain.LoanStateApproved, hold.Amount).
+			Update("reserved_amount", gorm.Expr("reserved_amount + ?", hold.Amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInvestmentExceedsLimit
+		}
+		loan.ReservedAmount += hold.Amount
+
+		// 5. Create the hold
+		return tx.Create(hold).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// ConvertToInvestment locks the hold and its loan, moves the reserved amount into the invested amount
+// and creates the investment within the same transaction
+func (r *investmentHoldRepository) ConvertToInvestment(ctx context.Context, holdID uuid.UUID, investment *domain.Investment) (*domain.Loan, error) {
+	var loan domain.Loan
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var hold domain.InvestmentHold
+		if err := lockHold(tx, holdID, &hold); err != nil {
+			return err
+		}
+
+		if hold.Status != domain.HoldStatusActive {
+			return domain.ErrHoldNotActive
+		}
+
+		if time.Now().After(hold.ExpiresAt) {
+			return domain.ErrHoldExpired
+		}
+
+		if err := lockLoan(tx, hold.LoanID, &loan); err != nil {
+			return err
+		}
+
+		if loan.State != domain.LoanStateApproved {
+			return domain.ErrInvalidLoanState
+		}
+
+		// Move the reservation into the invested amount
+		investment.Amount = hold.Amount
+		if err := investInLoan(tx, &loan, hold.Amount, hold.Amount); err != nil {
+			return err
+		}
+
+		if err := tx.Create(investment).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Investor{}).
+			Where("id = ?", investment.InvestorID).
+			Update("total_invested", gorm.Expr("total_invested + ?", investment.Amount)).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.InvestmentHold{}).
+			Where("id = ?", hold.ID).
+			Updates(map[string]interface{}{
+				"status":        domain.HoldStatusConverted,
+				"investment_id": investment.ID,
+				"updated_at":    time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		// Announce a fully funded loan through the outbox
+		return enqueueFullyFunded(tx, &loan)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// Release gives the reserved amount of an active hold back to the loan
+func (r *investmentHoldRepository) Release(ctx context.Context, holdID uuid.UUID, status domain.HoldStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var hold domain.InvestmentHold
+		if err := lockHold(tx, holdID, &hold); err != nil {
+			return err
+		}
+
+		// Already converted or released by someone else
+		if hold.Status != domain.HoldStatusActive {
+			return nil
+		}
+
+		if err := tx.Model(&domain.Loan{}).
+			Where("id = ?", hold.LoanID).
+			Update("reserved_amount", gorm.Expr("GREATEST(reserved_amount - ?, 0)", hold.Amount)).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.InvestmentHold{}).
+			Where("id = ?", hold.ID).
+			Updates(map[string]interface{}{
+				"status":     status,
+				"updated_at": time.Now(),
+			}).Error
+	})
+}
+
+func (r *investmentHoldRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]domain.InvestmentHold, error) {
+	var holds []domain.InvestmentHold
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", domain.HoldStatusActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&holds).Error
+	return holds, err
+}
+
+func (r *investmentHoldRepository) GetActiveByInvestorID(ctx context.Context, investorID uuid.UUID) ([]domain.InvestmentHold, error) {
+	var holds []domain.InvestmentHold
+	err := r.db.WithContext(ctx).
+		Where("investor_id = ? AND status = ?", investorID, domain.HoldStatusActive).
+		Find(&holds).Error
+	return holds, err
+}
+
+// lockHold reads the hold with a row lock held until the transaction ends
+func lockHold(tx *gorm.DB, holdID uuid.UUID, hold *domain.InvestmentHold) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", holdID).
+		First(hold).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrHoldNotFound
+	}
+	return err
+}
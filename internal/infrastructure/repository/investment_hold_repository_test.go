@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loanColumns = []string{"id", "borrower_id", "principal_amount", "invested_amount", "remaining_investment", "reserved_amount", "state"}
+
+// Test Reservation - The Loan Row Is Locked And Reserved With A Guarded Relative Update
+func TestInvestmentHoldRepository_CreateWithLoanLock(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentHoldRepository(db)
+
+	loanID := uuid.New()
+	hold := &domain.InvestmentHold{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 30000, Status: domain.HoldStatusActive, ExpiresAt: time.Now().Add(time.Minute)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT 1 FOR UPDATE`)).
+		WithArgs(loanID).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 50000.0, 50000.0, 10000.0, domain.LoanStateApproved))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loans" SET "reserved_amount"=reserved_amount + $1,"updated_at"=$2 WHERE id = $3 AND state = $4 AND remaining_investment - reserved_amount >= $5`)).
+		WithArgs(30000.0, sqlmock.AnyArg(), loanID, domain.LoanStateApproved, 30000.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "investment_holds"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(hold.ID))
+	mock.ExpectCommit()
+
+	// Act
+	loan, err := repo.CreateWithLoanLock(context.Background(), hold)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 40000.0, loan.ReservedAmount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Reservation - A Concurrent Reservation That Took The Room Makes The Guarded Update Miss
+func TestInvestmentHoldRepository_CreateWithLoanLock_RoomTaken(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentHoldRepository(db)
+
+	loanID := uuid.New()
+	hold := &domain.InvestmentHold{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 30000, Status: domain.HoldStatusActive}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 50000.0, 50000.0, 0.0, domain.LoanStateApproved))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`UPDATE "loans" SET "reserved_amount"=reserved_amount \+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Act
+	_, err := repo.CreateWithLoanLock(context.Background(), hold)
+
+	// Assert
+	assert.Equal(t, domain.ErrInvestmentExceedsLimit, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Reservation - The Capacity Is Checked Against The Locked Row Before Writing
+func TestInvestmentHoldRepository_CreateWithLoanLock_ExceedsLimit(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewInvestmentHoldRepository(db)
+
+	loanID := uuid.New()
+	hold := &domain.InvestmentHold{ID: uuid.New(), LoanID: loanID, Amount: 30000}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 50000.0, 50000.0, 25000.0, domain.LoanStateApproved))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := repo.CreateWithLoanLock(context.Background(), hold)
+
+	assert.Equal(t, domain.ErrInvestmentExceedsLimit, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Hold Conversion - Hold And Loan Are Locked And The Amounts Change Relative To The Stored Ones
+func TestInvestmentHoldRepository_ConvertToInvestment(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentHoldRepository(db)
+
+	holdID := uuid.New()
+	loanID := uuid.New()
+	investorID := uuid.New()
+	investment := &domain.Investment{ID: uuid.New(), LoanID: loanID, InvestorID: investorID, Status: "completed"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investment_holds" WHERE id = $1 ORDER BY "investment_holds"."id" LIMIT 1 FOR UPDATE`)).
+		WithArgs(holdID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "status", "expires_at"}).
+			AddRow(holdID, loanID, investorID, 50000.0, domain.HoldStatusActive, time.Now().Add(time.Minute)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT 1 FOR UPDATE`)).
+		WithArgs(loanID).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 50000.0, 50000.0, 50000.0, domain.LoanStateApproved))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// The last 50000 completes the loan
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loans" SET "invested_amount"=invested_amount + $1,"remaining_investment"=GREATEST(remaining_investment - $2, 0),"reserved_amount"=GREATEST(reserved_amount - $3, 0),"state"=$4,"updated_at"=$5 WHERE id = $6 AND state = $7 AND remaining_investment - reserved_amount + $8 >= $9`)).
+		WithArgs(50000.0, 50000.0, 50000.0, domain.LoanStateInvested, sqlmock.AnyArg(), loanID, domain.LoanStateApproved, 50000.0, 50000.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Waiting investors keep their place, a cancelled investment can give the loan capacity back
+	mock.ExpectQuery(`INSERT INTO "investments"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(investment.ID))
+	mock.ExpectExec(`UPDATE "investors" SET "total_invested"=total_invested \+`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "investment_holds" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "outbox_events"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Act
+	loan, err := repo.ConvertToInvestment(context.Background(), holdID, investment)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanStateInvested, loan.State)
+	assert.Equal(t, 100000.0, loan.InvestedAmount)
+	assert.Equal(t, 0.0, loan.RemainingInvestment)
+	assert.Equal(t, 0.0, loan.ReservedAmount)
+	assert.Equal(t, 50000.0, investment.Amount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type investmentRepository struct {
@@ -20,6 +22,15 @@ func (r *investmentRepository) Create(ctx context.Context, investment *domain.In
 	return r.db.WithContext(ctx).Create(investment).Error
 }
 
+func (r *investmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Investment, error) {
+	var investment domain.Investment
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&investment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &investment, nil
+}
+
 func (r *investmentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.Investment, error) {
 	var investments []domain.Investment
 	err := r.db.WithContext(ctx).
@@ -51,6 +62,19 @@ func (r *investmentRepository) GetTotalInvestedAmount(ctx context.Context, loanI
 	return total, err
 }
 
+func (r *investmentRepository) GetLoanIDsPendingAgreementLetter(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	var loanIDs []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Model(&domain.Investment{}).
+		Distinct("investments.loan_id").
+		Joins("JOIN loans ON loans.id = investments.loan_id").
+		Where("investments.status = ? AND investments.agreement_document_id IS NULL", "completed").
+		Where("loans.state IN ?", []domain.LoanState{domain.LoanStateInvested, domain.LoanStateDisbursed}).
+		Limit(limit).
+		Pluck("investments.loan_id", &loanIDs).Error
+	return loanIDs, err
+}
+
 func (r *investmentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	return r.db.WithContext(ctx).
 		Model(&domain.Investment{}).
@@ -58,22 +82,38 @@ func (r *investmentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, s
 		Update("status", status).Error
 }
 
-func (r *investmentRepository) UpdateAgreementLetterURL(ctx context.Context, id uuid.UUID, url string) error {
+func (r *investmentRepository) UpdateAgreementLetter(ctx context.Context, id uuid.UUID, documentID uuid.UUID, url string) error {
 	return r.db.WithContext(ctx).
 		Model(&domain.Investment{}).
 		Where("id = ?", id).
-		Update("agreement_letter_url", url).Error
+		Updates(map[string]interface{}{
+			"agreement_document_id": documentID,
+			"agreement_letter_url":  url,
+		}).Error
 }
 
+// CreateWithTx records the investment against the locked loan. The loan is re-read under the lock, so
+// the amounts and state it ends up with are the committed ones rather than those computed by the caller
 func (r *investmentRepository) CreateWithTx(ctx context.Context, investment *domain.Investment, loan *domain.Loan) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Create the investment
-		if err := tx.Create(investment).Error; err != nil {
+		var locked domain.Loan
+		if err := lockLoan(tx, investment.LoanID, &locked); err != nil {
 			return err
 		}
+		if locked.State != domain.LoanStateApproved {
+			return domain.ErrInvalidLoanState
+		}
+		if investment.Amount > locked.AvailableForInvestment() {
+			return domain.ErrInvestmentExceedsLimit
+		}
 
 		// Update loan amounts and state
-		if err := tx.Save(loan).Error; err != nil {
+		if err := investInLoan(tx, &locked, investment.Amount, 0); err != nil {
+			return err
+		}
+
+		// Create the investment
+		if err := tx.Create(investment).Error; err != nil {
 			return err
 		}
 
@@ -84,7 +124,10 @@ func (r *investmentRepository) CreateWithTx(ctx context.Context, investment *dom
 			return err
 		}
 
-		return nil
+		*loan = locked
+
+		// Announce a fully funded loan through the outbox
+		return enqueueFullyFunded(tx, loan)
 	})
 }
 
@@ -94,10 +137,7 @@ func (r *investmentRepository) CreateInvestmentWithLoanLock(ctx context.Context,
 
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. Lock the loan within the transaction (SELECT FOR UPDATE)
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
-			Preload("Borrower").
-			Where("id = ?", loanID).
-			First(&loan).Error; err != nil {
+		if err := lockLoan(tx, loanID, &loan); err != nil {
 			return err
 		}
 
@@ -106,38 +146,97 @@ func (r *investmentRepository) CreateInvestmentWithLoanLock(ctx context.Context,
 			return domain.ErrInvalidLoanState
 		}
 
-		// 3. Check if investment still fits within remaining amount
-		if investment.Amount > loan.RemainingInvestment {
+		// 3. Check if investment still fits within remaining amount not held by reservations
+		if investment.Amount > loan.AvailableForInvestment() {
 			return domain.ErrInvestmentExceedsLimit
 		}
 
-		// 4. Update loan amounts
-		loan.InvestedAmount += investment.Amount
-		loan.RemainingInvestment -= investment.Amount
-
-		// 5. Check if loan is fully funded
-		if loan.RemainingInvestment <= 0 {
-			loan.State = domain.LoanStateInvested
-			loan.RemainingInvestment = 0 // Ensure it's exactly 0
+		// 4. Update loan amounts and state
+		if err := investInLoan(tx, &loan, investment.Amount, 0); err != nil {
+			return err
 		}
 
-		// 6. Create the investment
+		// 5. Create the investment
 		if err := tx.Create(investment).Error; err != nil {
 			return err
 		}
 
-		// 7. Update the loan
-		if err := tx.Save(&loan).Error; err != nil {
+		// 6. Update investor total invested
+		if err := tx.Model(&domain.Investor{}).
+			Where("id = ?", investment.InvestorID).
+			Update("total_invested", gorm.Expr("total_invested + ?", investment.Amount)).Error; err != nil {
+			return err
+		}
+
+		// 7. Announce a fully funded loan through the outbox
+		return enqueueFullyFunded(tx, &loan)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// CancelWithLoanLock cancels the investment and moves its amount back into the remaining amount of the locked
+// loan. A disbursed loan keeps its investments. An invested loan is approved again, waiting investors get
+// the capacity when the caller processes the waitlist.
+func (r *investmentRepository) CancelWithLoanLock(ctx context.Context, id uuid.UUID) (*domain.Loan, error) {
+	var loan domain.Loan
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var investment domain.Investment
+		if err := tx.Where("id = ?", id).First(&investment).Error; err != nil {
+			return err
+		}
+
+		if err := lockLoan(tx, investment.LoanID, &loan); err != nil {
+			return err
+		}
+		if loan.State != domain.LoanStateApproved && loan.State != domain.LoanStateInvested {
+			return domain.ErrCoolingOffEnded
+		}
+
+		now := time.Now()
+		result := tx.Model(&domain.Investment{}).
+			Where("id = ? AND status = ?", id, "completed").
+			Updates(map[string]interface{}{
+				"status":     "cancelled",
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		// Cancelled concurrently, the amount was already given back
+		if result.RowsAffected == 0 {
+			return domain.ErrCoolingOffEnded
+		}
+
+		if err := tx.Model(&domain.Loan{}).
+			Where("id = ?", loan.ID).
+			Updates(map[string]interface{}{
+				"invested_amount":      gorm.Expr("GREATEST(invested_amount - ?, 0)", investment.Amount),
+				"remaining_investment": gorm.Expr("remaining_investment + ?", investment.Amount),
+				"state":                domain.LoanStateApproved,
+				"updated_at":           now,
+			}).Error; err != nil {
 			return err
 		}
 
-		// 8. Update investor total invested
 		if err := tx.Model(&domain.Investor{}).
 			Where("id = ?", investment.InvestorID).
-			Update("total_invested", gorm.Expr("total_invested + ?", investment.Amount)).Error; err != nil {
+			Update("total_invested", gorm.Expr("GREATEST(total_invested - ?, 0)", investment.Amount)).Error; err != nil {
 			return err
 		}
 
+		loan.InvestedAmount -= investment.Amount
+		if loan.InvestedAmount < 0 {
+			loan.InvestedAmount = 0
+		}
+		loan.RemainingInvestment += investment.Amount
+		loan.State = domain.LoanStateApproved
+		loan.UpdatedAt = now
 		return nil
 	})
 
@@ -147,3 +246,54 @@ func (r *investmentRepository) CreateInvestmentWithLoanLock(ctx context.Context,
 
 	return &loan, nil
 }
+
+// lockLoan reads the loan with its borrower, holding a row lock on the loan until the transaction ends
+func lockLoan(tx *gorm.DB, loanID uuid.UUID, loan *domain.Loan) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Borrower").
+		Where("id = ?", loanID).
+		First(loan).Error
+}
+
+// investInLoan moves amount into the invested amount of the locked loan, of which reserved was held by a
+// reservation. The columns change relative to their stored values and only while the loan still has the
+// room, so a write can never overwrite a concurrent one. The loan struct is updated to match.
+func investInLoan(tx *gorm.DB, loan *domain.Loan, amount float64, reserved float64) error {
+	now := time.Now()
+	fullyFunded := loan.RemainingInvestment-amount <= 0
+
+	updates := map[string]interface{}{
+		"invested_amount":      gorm.Expr("invested_amount + ?", amount),
+		"remaining_investment": gorm.Expr("GREATEST(remaining_investment - ?, 0)", amount),
+		"updated_at":           now,
+	}
+	if reserved > 0 {
+		updates["reserved_amount"] = gorm.Expr("GREATEST(reserved_amount - ?, 0)", reserved)
+	}
+	if fullyFunded {
+		updates["state"] = domain.LoanStateInvested
+	}
+
+	result := tx.Model(&domain.Loan{}).
+		Where("id = ? AND state = ? AND remaining_investment - reserved_amount + ? >= ?", loan.ID, domain.LoanStateApproved, reserved, amount).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvestmentExceedsLimit
+	}
+
+	loan.ReservedAmount -= reserved
+	if loan.ReservedAmount < 0 {
+		loan.ReservedAmount = 0
+	}
+	loan.InvestedAmount += amount
+	loan.RemainingInvestment -= amount
+	loan.UpdatedAt = now
+	if fullyFunded {
+		loan.State = domain.LoanStateInvested
+		loan.RemainingInvestment = 0 // Ensure it's exactly 0
+	}
+	return nil
+}
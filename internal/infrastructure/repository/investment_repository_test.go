@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Investment Cancellation - The Amount Goes Back To The Locked Loan And A Fully Funded Loan Reopens
+func TestInvestmentRepository_CancelWithLoanLock(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentRepository(db)
+
+	investmentID := uuid.New()
+	loanID := uuid.New()
+	investorID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE id = $1`)).
+		WithArgs(investmentID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "status"}).
+			AddRow(investmentID, loanID, investorID, 25000.0, "completed"))
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 100000.0, 0.0, 0.0, domain.LoanStateInvested))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "investments" SET "status"=$1,"updated_at"=$2 WHERE id = $3 AND status = $4`)).
+		WithArgs("cancelled", sqlmock.AnyArg(), investmentID, "completed").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "loans" SET "invested_amount"=GREATEST(invested_amount - $1, 0),"remaining_investment"=remaining_investment + $2,"state"=$3,"updated_at"=$4 WHERE id = $5`)).
+		WithArgs(25000.0, 25000.0, domain.LoanStateApproved, sqlmock.AnyArg(), loanID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "investors" SET "total_invested"=GREATEST(total_invested - $1, 0),"updated_at"=$2 WHERE id = $3`)).
+		WithArgs(25000.0, sqlmock.AnyArg(), investorID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Act
+	loan, err := repo.CancelWithLoanLock(context.Background(), investmentID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.LoanStateApproved, loan.State)
+	assert.Equal(t, 75000.0, loan.InvestedAmount)
+	assert.Equal(t, 25000.0, loan.RemainingInvestment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Investment Cancellation - A Disbursed Loan Keeps Its Investments
+func TestInvestmentRepository_CancelWithLoanLock_Disbursed(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentRepository(db)
+
+	investmentID := uuid.New()
+	loanID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "investments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "status"}).
+			AddRow(investmentID, loanID, uuid.New(), 25000.0, "completed"))
+	mock.ExpectQuery(`SELECT \* FROM "loans" .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(loanColumns).AddRow(loanID, uuid.New(), 100000.0, 100000.0, 0.0, 0.0, domain.LoanStateDisbursed))
+	mock.ExpectQuery(`SELECT \* FROM "borrowers"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	// Act
+	_, err := repo.CancelWithLoanLock(context.Background(), investmentID)
+
+	// Assert
+	assert.Equal(t, domain.ErrCoolingOffEnded, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Pending Agreement Letters - Only Completed Investments Of Funded Loans Without A Letter
+func TestInvestmentRepository_GetLoanIDsPendingAgreementLetter(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewInvestmentRepository(db)
+
+	loanID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT investments.loan_id FROM "investments" JOIN loans ON loans.id = investments.loan_id WHERE (investments.status = $1 AND investments.agreement_document_id IS NULL) AND loans.state IN ($2,$3) LIMIT 50`)).
+		WithArgs("completed", domain.LoanStateInvested, domain.LoanStateDisbursed).
+		WillReturnRows(sqlmock.NewRows([]string{"loan_id"}).AddRow(loanID))
+
+	// Act
+	loanIDs, err := repo.GetLoanIDsPendingAgreementLetter(context.Background(), 50)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{loanID}, loanIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
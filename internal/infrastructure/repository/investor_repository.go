@@ -9,14 +9,20 @@ import (
 )
 
 type investorRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	cipher domain.FieldCipher
 }
 
-func NewInvestorRepository(db *gorm.DB) domain.InvestorRepository {
-	return &investorRepository{db: db}
+func NewInvestorRepository(db *gorm.DB, cipher domain.FieldCipher) domain.InvestorRepository {
+	return &investorRepository{db: db, cipher: cipher}
 }
 
 func (r *investorRepository) Create(ctx context.Context, investor *domain.Investor) error {
+	// Encrypted fields are bound to the row ID, so it is set before the insert rather than by the database
+	if investor.ID == uuid.Nil {
+		investor.ID = uuid.New()
+	}
+	investor.IdentityNumberIndex = r.cipher.BlindIndex(investor.IdentityNumber)
 	return r.db.WithContext(ctx).Create(investor).Error
 }
 
@@ -44,6 +50,30 @@ func (r *investorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return &investor, nil
 }
 
+func (r *investorRepository) GetByIdentityNumber(ctx context.Context, identityNumber string) (*domain.Investor, error) {
+	var investor domain.Investor
+	err := r.db.WithContext(ctx).Where("identity_number_index = ?", r.cipher.BlindIndex(identityNumber)).First(&investor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &investor, nil
+}
+
+func (r *investorRepository) CreateWithUser(ctx context.Context, investor *domain.Investor) error {
+	if investor.ID == uuid.Nil {
+		investor.ID = uuid.New()
+	}
+	investor.IdentityNumberIndex = r.cipher.BlindIndex(investor.IdentityNumber)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&investor.User).Error; err != nil {
+			return err
+		}
+		investor.UserID = investor.User.ID
+		return tx.Omit("User").Create(investor).Error
+	})
+}
+
 func (r *investorRepository) Update(ctx context.Context, investor *domain.Investor) error {
+	investor.IdentityNumberIndex = r.cipher.BlindIndex(investor.IdentityNumber)
 	return r.db.WithContext(ctx).Save(investor).Error
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type kycSubmissionRepository struct {
+	db *gorm.DB
+}
+
+func NewKYCSubmissionRepository(db *gorm.DB) domain.KYCSubmissionRepository {
+	return &kycSubmissionRepository{db: db}
+}
+
+func (r *kycSubmissionRepository) Create(ctx context.Context, submission *domain.KYCSubmission) error {
+	return r.db.WithContext(ctx).Omit("User").Create(submission).Error
+}
+
+func (r *kycSubmissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.KYCSubmission, error) {
+	var submission domain.KYCSubmission
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Documents").
+		Where("id = ?", id).
+		First(&submission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *kycSubmissionRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*domain.KYCSubmission, error) {
+	var submission domain.KYCSubmission
+	err := r.db.WithContext(ctx).
+		Preload("Documents").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&submission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *kycSubmissionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]domain.KYCSubmission, error) {
+	var submissions []domain.KYCSubmission
+	err := r.db.WithContext(ctx).
+		Preload("Documents").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *kycSubmissionRepository) ListByStatus(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.KYCSubmission{}).Where("status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var submissions []domain.KYCSubmission
+	err := query.
+		Preload("User").
+		Preload("Documents").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&submissions).Error
+	return submissions, total, err
+}
+
+func (r *kycSubmissionRepository) Decide(ctx context.Context, submission *domain.KYCSubmission) (bool, error) {
+	decided := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only a pending submission can be decided, a concurrent review finds it already decided
+		result := tx.Model(&domain.KYCSubmission{}).
+			Where("id = ? AND status = ?", submission.ID, domain.KYCStatusPending).
+			Updates(map[string]interface{}{
+				"status":           submission.Status,
+				"reviewer_id":      submission.ReviewerID,
+				"reviewed_at":      submission.ReviewedAt,
+				"rejection_reason": submission.RejectionReason,
+				"updated_at":       submission.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		decided = true
+
+		if submission.Status != domain.KYCStatusVerified {
+			return nil
+		}
+		return tx.Model(&domain.User{}).
+			Where("id = ?", submission.UserID).
+			Updates(map[string]interface{}{
+				"kyc_verified_at": submission.ReviewedAt,
+				"updated_at":      submission.UpdatedAt,
+			}).Error
+	})
+	return decided, err
+}
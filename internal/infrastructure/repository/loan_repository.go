@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type loanRepository struct {
@@ -27,6 +28,10 @@ func (r *loanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Loa
 		Preload("Borrower.User").
 		Preload("Approval").
 		Preload("Approval.Validator").
+		Preload("Approval.PhotoProof").
+		Preload("ApprovalStages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("decided_at ASC")
+		}).
 		Preload("Investments").
 		Preload("Investments.Investor").
 		Preload("Investments.Investor.User").
@@ -52,7 +57,7 @@ func (r *loanRepository) GetByIDWithLock(ctx context.Context, id uuid.UUID) (*do
 		Preload("Investments.Investor.User").
 		Preload("Disbursement").
 		Preload("Disbursement.Officer").
-		Set("gorm:query_option", "FOR UPDATE").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("id = ?", id).
 		First(&loan).Error
 	if err != nil {
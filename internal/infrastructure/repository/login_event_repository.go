@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type loginEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginEventRepository(db *gorm.DB) domain.LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+func (r *loginEventRepository) Create(ctx context.Context, event *domain.LoginEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *loginEventRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]domain.LoginEvent, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var events []domain.LoginEvent
+	err := query.Find(&events).Error
+	return events, err
+}
+
+func (r *loginEventRepository) Search(ctx context.Context, filter domain.LoginEventFilter, limit int) ([]domain.LoginEvent, error) {
+	query := r.db.WithContext(ctx)
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.FailedOnly {
+		query = query.Where("succeeded = ?", false)
+	}
+
+	var events []domain.LoginEvent
+	err := query.Order("created_at DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *loginEventRepository) FailureStatsByIP(ctx context.Context, ipAddress string, reasons []string, since time.Time) (*domain.LoginFailureStats, error) {
+	var row struct {
+		Count  int64
+		LastAt *time.Time
+	}
+	err := r.db.WithContext(ctx).Model(&domain.LoginEvent{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last_at").
+		Where("ip_address = ? AND succeeded = ? AND reason IN ? AND created_at >= ?", ipAddress, false, reasons, since).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginFailureStats{Count: row.Count, LastAt: row.LastAt}, nil
+}
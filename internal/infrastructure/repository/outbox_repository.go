@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Another relay instance skips the rows locked here instead of waiting for them
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].NextAttemptAt = now.Add(lease)
+		}
+		return tx.Model(&domain.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sent_at":    sentAt,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": "",
+		}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&domain.OutboxEvent{}).
+		Where("id = ? AND sent_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+func (r *outboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("sent_at IS NOT NULL AND sent_at < ?", before).
+		Delete(&domain.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// enqueueFullyFunded records the fully funded event in the transaction that invested the loan,
+// so the event exists exactly when the state change was committed
+func enqueueFullyFunded(tx *gorm.DB, loan *domain.Loan) error {
+	if loan.State != domain.LoanStateInvested {
+		return nil
+	}
+
+	event, err := domain.NewFullyFundedLoanEvent(loan, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Create(event).Error
+}
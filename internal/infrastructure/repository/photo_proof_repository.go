@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type photoProofRepository struct {
+	db *gorm.DB
+}
+
+func NewPhotoProofRepository(db *gorm.DB) domain.PhotoProofRepository {
+	return &photoProofRepository{db: db}
+}
+
+func (r *photoProofRepository) Create(ctx context.Context, proof *domain.PhotoProof) error {
+	return r.db.WithContext(ctx).Create(proof).Error
+}
+
+func (r *photoProofRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PhotoProof, error) {
+	var proof domain.PhotoProof
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&proof).Error
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test Refresh Token Rotation - The Token Is Revoked And Its Successor Stored In One Transaction
+func TestRefreshTokenRepository_Rotate(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	tokenID := uuid.New()
+	now := time.Now()
+	successor := &domain.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New(),
+		TokenHash: "successor-hash", ExpiresAt: now.Add(24 * time.Hour)}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "replaced_by_id"=$1,"revoked_at"=$2 WHERE id = $3 AND revoked_at IS NULL`)).
+		WithArgs(successor.ID, now, tokenID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(successor.ID))
+	mock.ExpectCommit()
+
+	// Act
+	rotated, err := repo.Rotate(context.Background(), tokenID, successor, now)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Refresh Token Rotation - A Failed Insert Rolls Back The Revocation
+func TestRefreshTokenRepository_Rotate_CreateFails(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	successor := &domain.RefreshToken{ID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "refresh_tokens"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	// Act
+	rotated, err := repo.Rotate(context.Background(), uuid.New(), successor, time.Now())
+
+	// Assert
+	assert.Error(t, err)
+	assert.False(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Refresh Token Rotation - A Token Rotated Concurrently Gets No Second Successor
+func TestRefreshTokenRepository_Rotate_AlreadyRotated(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	successor := &domain.RefreshToken{ID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "refresh_tokens"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Act
+	rotated, err := repo.Rotate(context.Background(), uuid.New(), successor, time.Now())
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
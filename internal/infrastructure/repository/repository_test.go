@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/database"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/encryption"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// plainCipher stands in for the field cipher so models with encrypted fields can be parsed
+type plainCipher struct{}
+
+func (plainCipher) Encrypt(ctx context.Context, plaintext string, location domain.FieldLocation) (string, error) {
+	return plaintext, nil
+}
+
+func (plainCipher) Decrypt(ctx context.Context, stored string, location domain.FieldLocation) (string, error) {
+	return stored, nil
+}
+
+func (plainCipher) IsCurrent(stored string) bool {
+	return true
+}
+
+func (plainCipher) BlindIndex(value string) string {
+	return "index:" + value
+}
+
+func TestMain(m *testing.M) {
+	encryption.RegisterSerializer(plainCipher{})
+	os.Exit(m.Run())
+}
+
+// newMockDB returns a GORM connection whose SQL is checked by sqlmock
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	return db, mock
+}
+
+// newPostgresDB returns a migrated connection to the database in TEST_DATABASE_DSN, for tests that need the
+// real constraints. The test runs in a transaction that is rolled back, it is skipped without a database.
+func newPostgresDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
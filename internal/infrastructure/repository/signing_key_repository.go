@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) domain.SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *domain.SigningKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *signingKeyRepository) GetRetiringAfter(ctx context.Context, after time.Time) ([]domain.SigningKey, error) {
+	var keys []domain.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("retires_at > ?", after).
+		Order("activates_at ASC, created_at ASC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) DeleteRetiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("retires_at < ?", before).Delete(&domain.SigningKey{})
+	return result.RowsAffected, result.Error
+}
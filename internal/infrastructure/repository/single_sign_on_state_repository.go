@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type singleSignOnStateRepository struct {
+	db *gorm.DB
+}
+
+func NewSingleSignOnStateRepository(db *gorm.DB) domain.SingleSignOnStateRepository {
+	return &singleSignOnStateRepository{db: db}
+}
+
+func (r *singleSignOnStateRepository) Create(ctx context.Context, state *domain.SingleSignOnState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+func (r *singleSignOnStateRepository) GetByStateHash(ctx context.Context, stateHash string) (*domain.SingleSignOnState, error) {
+	var state domain.SingleSignOnState
+	err := r.db.WithContext(ctx).Where("state_hash = ?", stateHash).First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *singleSignOnStateRepository) MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.SingleSignOnState{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type staffRelationshipRepository struct {
+	db *gorm.DB
+}
+
+func NewStaffRelationshipRepository(db *gorm.DB) domain.StaffRelationshipRepository {
+	return &staffRelationshipRepository{db: db}
+}
+
+func (r *staffRelationshipRepository) Create(ctx context.Context, relationship *domain.StaffRelationship) error {
+	return r.db.WithContext(ctx).Create(relationship).Error
+}
+
+func (r *staffRelationshipRepository) Exists(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.StaffRelationship{}).
+		Where("staff_user_id = ? AND borrower_id = ?", staffUserID, borrowerID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *staffRelationshipRepository) GetByStaffUserID(ctx context.Context, staffUserID uuid.UUID) ([]domain.StaffRelationship, error) {
+	var relationships []domain.StaffRelationship
+	err := r.db.WithContext(ctx).
+		Where("staff_user_id = ?", staffUserID).
+		Order("created_at ASC").
+		Find(&relationships).Error
+	return relationships, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type twoFactorChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorChallengeRepository(db *gorm.DB) domain.TwoFactorChallengeRepository {
+	return &twoFactorChallengeRepository{db: db}
+}
+
+func (r *twoFactorChallengeRepository) Create(ctx context.Context, challenge *domain.TwoFactorChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *twoFactorChallengeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.TwoFactorChallenge, error) {
+	var challenge domain.TwoFactorChallenge
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *twoFactorChallengeRepository) RecordFailedAttempt(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.TwoFactorChallenge{}).
+		Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func (r *twoFactorChallengeRepository) MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	return result.RowsAffected > 0, result.Error
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -29,6 +31,15 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	return &user, nil
 }
 
+func (r *userRepository) GetByExternalSubject(ctx context.Context, subject string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Where("external_subject = ?", subject).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	var user domain.User
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
@@ -38,10 +49,64 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	return &user, nil
 }
 
+func (r *userRepository) GetByRole(ctx context.Context, role domain.UserRole) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.WithContext(ctx).
+		Where("role = ? AND deactivated_at IS NULL AND service_account = ?", role, false).
+		Order("created_at ASC").
+		Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) List(ctx context.Context, role domain.UserRole, limit, offset int) ([]domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.User{})
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []domain.User
+	err := query.Order("created_at ASC").Limit(limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepository) GetServiceAccounts(ctx context.Context) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.WithContext(ctx).Where("service_account = ?", true).Order("created_at ASC").Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&domain.User{}, "id = ?", id).Error
+func (r *userRepository) IncrementFailedLogins(ctx context.Context, id uuid.UUID) (int, error) {
+	var user domain.User
+	result := r.db.WithContext(ctx).Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_login_count"}}}).
+		Where("id = ?", id).
+		Update("failed_login_count", gorm.Expr("failed_login_count + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.FailedLoginCount, nil
+}
+
+func (r *userRepository) SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Update("locked_until", until).Error
+}
+
+func (r *userRepository) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"failed_login_count": 0, "locked_until": nil}).Error
 }
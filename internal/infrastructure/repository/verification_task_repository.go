@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type verificationTaskRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationTaskRepository(db *gorm.DB) domain.VerificationTaskRepository {
+	return &verificationTaskRepository{db: db}
+}
+
+func (r *verificationTaskRepository) Create(ctx context.Context, task *domain.VerificationTask) error {
+	return r.db.WithContext(ctx).Create(task).Error
+}
+
+func (r *verificationTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.VerificationTask, error) {
+	var task domain.VerificationTask
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *verificationTaskRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) (*domain.VerificationTask, error) {
+	var task domain.VerificationTask
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *verificationTaskRepository) GetByAssigneeID(ctx context.Context, assigneeID uuid.UUID, status domain.TaskStatus) ([]domain.VerificationTask, error) {
+	var tasks []domain.VerificationTask
+	err := r.db.WithContext(ctx).
+		Preload("Loan").
+		Preload("Loan.Borrower").
+		Where("assignee_id = ? AND status = ?", assigneeID, status).
+		Order("due_at ASC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetOverdue returns open tasks past their due date and open tasks nobody is assigned to
+func (r *verificationTaskRepository) GetOverdue(ctx context.Context, now time.Time, limit int) ([]domain.VerificationTask, error) {
+	var tasks []domain.VerificationTask
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND (due_at < ? OR assignee_id IS NULL)", domain.TaskStatusOpen, now).
+		Order("due_at ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *verificationTaskRepository) CountOpenByAssignee(ctx context.Context, assigneeIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		AssigneeID uuid.UUID
+		Count      int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&domain.VerificationTask{}).
+		Select("assignee_id, COUNT(*) AS count").
+		Where("status = ? AND assignee_id IN ?", domain.TaskStatusOpen, assigneeIDs).
+		Group("assignee_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.AssigneeID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *verificationTaskRepository) Update(ctx context.Context, task *domain.VerificationTask) error {
+	return r.db.WithContext(ctx).Save(task).Error
+}
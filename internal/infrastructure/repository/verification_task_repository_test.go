@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+// Test Overdue Tasks - Open Tasks Without An Assignee Are Returned With The Overdue Ones
+func TestVerificationTaskRepository_GetOverdue(t *testing.T) {
+	// Arrange
+	db, mock := newMockDB(t)
+	repo := NewVerificationTaskRepository(db)
+
+	now := time.Now()
+	taskID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "verification_tasks" WHERE status = $1 AND (due_at < $2 OR assignee_id IS NULL) ORDER BY due_at ASC LIMIT 10`)).
+		WithArgs(domain.TaskStatusOpen, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "assignee_id", "status"}).AddRow(taskID, uuid.New(), nil, domain.TaskStatusOpen))
+
+	// Act
+	tasks, err := repo.GetOverdue(context.Background(), now, 10)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, taskID, tasks[0].ID)
+	assert.Nil(t, tasks[0].AssigneeID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test Task Hand Off - The Schema Takes An Unassigned Task But No Assignee That Is Not A User
+func TestVerificationTaskRepository_Update_Unassigned_Postgres(t *testing.T) {
+	// Arrange
+	db := newPostgresDB(t)
+	repo := NewVerificationTaskRepository(db)
+	ctx := context.Background()
+
+	validator := &domain.User{ID: uuid.New(), Email: "validator-" + uuid.NewString() + "@example.com", Password: "x", Role: domain.RoleFieldValidator}
+	borrowerUser := &domain.User{ID: uuid.New(), Email: "borrower-" + uuid.NewString() + "@example.com", Password: "x", Role: domain.RoleBorrower}
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: borrowerUser.ID, FullName: "Jane Borrower", PhoneNumber: "0800",
+		Address: "Main Street 1", IdentityNumber: uuid.NewString(), IdentityNumberIndex: uuid.NewString()}
+	loan := &domain.Loan{ID: uuid.New(), BorrowerID: borrower.ID, PrincipalAmount: 1000, RemainingInvestment: 1000, Rate: 10, ROI: 8, TotalInterest: 100}
+	for _, record := range []interface{}{validator, borrowerUser, borrower, loan} {
+		require.NoError(t, db.Omit(clause.Associations).Create(record).Error)
+	}
+
+	now := time.Now()
+	task := &domain.VerificationTask{ID: uuid.New(), LoanID: loan.ID, AssigneeID: &validator.ID, Status: domain.TaskStatusOpen,
+		DueAt: now.Add(24 * time.Hour), AssignedAt: now}
+	require.NoError(t, repo.Create(ctx, task))
+
+	// Act
+	db.SavePoint("nil_assignee")
+	task.AssigneeID = &uuid.Nil
+	nilUUIDErr := repo.Update(ctx, task)
+	db.RollbackTo("nil_assignee")
+
+	task.AssigneeID = nil
+	unassignErr := repo.Update(ctx, task)
+	stored, getErr := repo.GetByID(ctx, task.ID)
+	sweepable, overdueErr := repo.GetOverdue(ctx, now, 100)
+
+	// Assert
+	assert.Error(t, nilUUIDErr, "the nil UUID is not a user and must violate the foreign key")
+	require.NoError(t, unassignErr)
+	require.NoError(t, getErr)
+	assert.Nil(t, stored.AssigneeID)
+	require.NoError(t, overdueErr)
+	ids := make([]uuid.UUID, len(sweepable))
+	for i := range sweepable {
+		ids[i] = sweepable[i].ID
+	}
+	assert.Contains(t, ids, task.ID)
+}
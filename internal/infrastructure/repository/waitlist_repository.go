@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type waitlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepository(db *gorm.DB) domain.WaitlistRepository {
+	return &waitlistRepository{db: db}
+}
+
+func (r *waitlistRepository) Create(ctx context.Context, entry *domain.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *waitlistRepository) GetActiveByLoanAndInvestor(ctx context.Context, loanID, investorID uuid.UUID) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("loan_id = ? AND investor_id = ? AND status = ?", loanID, investorID, domain.WaitlistStatusWaiting).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepository) GetWaitingByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("Investor").
+		Preload("Investor.User").
+		Where("loan_id = ? AND status = ?", loanID, domain.WaitlistStatusWaiting).
+		Order("created_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("Loan").
+		Where("investor_id = ?", investorID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepository) CountAhead(ctx context.Context, entry *domain.WaitlistEntry) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.WaitlistEntry{}).
+		Where("loan_id = ? AND status = ? AND created_at < ?", entry.LoanID, domain.WaitlistStatusWaiting, entry.CreatedAt).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *waitlistRepository) MarkConverted(ctx context.Context, id uuid.UUID, holdID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, domain.WaitlistStatusWaiting).
+		Updates(map[string]interface{}{
+			"status":     domain.WaitlistStatusConverted,
+			"hold_id":    holdID,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *waitlistRepository) CancelWaitingByLoanID(ctx context.Context, loanID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.WaitlistEntry{}).
+		Where("loan_id = ? AND status = ?", loanID, domain.WaitlistStatusWaiting).
+		Updates(map[string]interface{}{
+			"status":     domain.WaitlistStatusCancelled,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *waitlistRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.WaitlistStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.WaitlistEntry{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// New returns the file storage backend selected by the configuration
+func New(cfg *config.StorageConfig) (domain.FileStorage, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return NewLocalStorage(cfg.LocalDir)
+	case DriverS3:
+		return NewS3Storage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %q", cfg.Driver)
+	}
+}
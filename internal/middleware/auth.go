@@ -8,7 +8,8 @@ import (
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
-func AuthMiddleware(authService domain.AuthService) gin.HandlerFunc {
+// AuthMiddleware accepts a user's JWT as "Bearer <token>" or a service account's key as "ApiKey <key>"
+func AuthMiddleware(authService domain.AuthService, serviceAccountService domain.ServiceAccountService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -17,14 +18,28 @@ func AuthMiddleware(authService domain.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+		credentials := strings.Split(authHeader, " ")
+		if len(credentials) != 2 || (credentials[0] != "Bearer" && credentials[0] != "ApiKey") {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
 			c.Abort()
 			return
 		}
 
-		user, err := authService.ValidateToken(bearerToken[1])
+		if credentials[0] == "ApiKey" {
+			user, key, err := serviceAccountService.Authenticate(c.Request.Context(), credentials[1])
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+
+			c.Set("user", user)
+			c.Set("api_key", key)
+			c.Next()
+			return
+		}
+
+		user, err := authService.ValidateToken(credentials[1])
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
@@ -32,11 +47,26 @@ func AuthMiddleware(authService domain.AuthService) gin.HandlerFunc {
 		}
 
 		c.Set("user", user)
+		c.Set("access_token", credentials[1]) // Needed to revoke the token on logout
+		c.Next()
+	}
+}
+
+// RequireSession refuses API key requests on endpoints that manage a person's own sign-in
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("api_key"); isAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not available to API keys"})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
-func RoleMiddleware(allowedRoles ...domain.UserRole) gin.HandlerFunc {
+// RequirePermission lets the request through only when the authenticated user's role grants the permission
+func RequirePermission(policy domain.PermissionPolicy, permission domain.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, exists := c.Get("user")
 		if !exists {
@@ -52,14 +82,12 @@ func RoleMiddleware(allowedRoles ...domain.UserRole) gin.HandlerFunc {
 			return
 		}
 
-		for _, role := range allowedRoles {
-			if userObj.Role == role {
-				c.Next()
-				return
-			}
+		if !policy.Allows(userObj, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		c.Abort()
+		c.Next()
 	}
 }
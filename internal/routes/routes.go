@@ -12,49 +12,254 @@ func SetupRoutes(
 	authService domain.AuthService,
 	loanService domain.LoanService,
 	investmentService domain.InvestmentService,
+	waitlistService domain.WaitlistService,
+	photoProofService domain.PhotoProofService,
+	photoProofMaxSize int64,
+	documentService domain.DocumentService,
+	documentMaxSize int64,
+	kycService domain.KYCService,
+	kycMaxSize int64,
+	privacyService domain.PrivacyService,
+	agreementService domain.AgreementService,
+	taskService domain.TaskService,
+	segregationService domain.SegregationService,
+	userAdminService domain.UserAdminService,
+	serviceAccountService domain.ServiceAccountService,
+	tokenKeyService domain.TokenKeyService,
+	policy domain.PermissionPolicy,
 ) {
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	loanHandler := handlers.NewLoanHandler(loanService)
-	investmentHandler := handlers.NewInvestmentHandler(investmentService)
+	loanHandler := handlers.NewLoanHandler(loanService, policy)
+	investmentHandler := handlers.NewInvestmentHandler(investmentService, policy)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService)
+	photoProofHandler := handlers.NewPhotoProofHandler(photoProofService, photoProofMaxSize)
+	documentHandler := handlers.NewDocumentHandler(documentService, documentMaxSize)
+	kycHandler := handlers.NewKYCHandler(kycService, kycMaxSize)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	agreementHandler := handlers.NewAgreementHandler(agreementService)
+	taskHandler := handlers.NewTaskHandler(taskService)
+	relationshipHandler := handlers.NewRelationshipHandler(segregationService)
+	adminHandler := handlers.NewAdminHandler(userAdminService)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountService)
+	jwksHandler := handlers.NewJWKSHandler(tokenKeyService)
 
 	// Public routes
 	auth := r.Group("/api/auth")
 	{
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/register/borrower", authHandler.RegisterBorrower)
+		auth.POST("/register/investor", authHandler.RegisterInvestor)
+		auth.GET("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/verify-email/resend", authHandler.ResendVerification)
+		auth.POST("/password/forgot", authHandler.ForgotPassword)
+		auth.POST("/password/reset", authHandler.ResetPassword)
+		auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)                  // Second step of a login
+		auth.POST("/2fa/enrol", authHandler.EnrolTwoFactor)                    // Mandatory enrolment during a login
+		auth.POST("/2fa/enrol/confirm", authHandler.ConfirmTwoFactorEnrolment) // Completes the login with recovery codes
+		auth.GET("/oidc/login", authHandler.SingleSignOn)                      // Staff sign-on at the identity provider
+		auth.GET("/oidc/callback", authHandler.SingleSignOnCallback)           // Redirect back from the identity provider
 	}
 
+	// Public keys that verify access tokens
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	// Signed document downloads - the link signature authorizes the request
+	r.GET("/api/documents/:id/download", documentHandler.DownloadDocument)
+
 	// Protected routes
 	api := r.Group("/api")
-	api.Use(middleware.AuthMiddleware(authService))
+	api.Use(middleware.AuthMiddleware(authService, serviceAccountService))
 	{
+		// Own sign-in - people only, API keys are managed by administrators
+		session := api.Group("/auth")
+		session.Use(middleware.RequireSession())
+		{
+			session.POST("/logout", authHandler.Logout)                              // All authenticated users
+			session.POST("/password/change", authHandler.ChangePassword)             // All authenticated users
+			session.POST("/2fa/setup", authHandler.SetupTwoFactor)                   // All authenticated users
+			session.POST("/2fa/activate", authHandler.ActivateTwoFactor)             // All authenticated users
+			session.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes) // All authenticated users
+			session.POST("/2fa/disable", authHandler.DisableTwoFactor)               // Roles that do not require it
+		}
+
 		// Loan routes
 		loans := api.Group("/loans")
 		{
-			loans.POST("", loanHandler.CreateLoan)   // Borrowers only
-			loans.GET("", loanHandler.GetLoans)      // All authenticated users
-			loans.GET("/my", loanHandler.GetMyLoans) // Borrowers only - specific endpoint for borrower's loans
-			loans.GET("/:id", loanHandler.GetLoan)   // All authenticated users
+			loans.POST("",
+				middleware.RequirePermission(policy, domain.PermissionLoanCreate),
+				loanHandler.CreateLoan)
+			loans.GET("", loanHandler.GetLoans) // Browsing roles filter by state, borrowers see their own loans
+			loans.GET("/my",
+				middleware.RequirePermission(policy, domain.PermissionLoanViewOwn),
+				loanHandler.GetMyLoans) // Specific endpoint for borrower's loans
+			loans.GET("/:id", loanHandler.GetLoan) // All authenticated users
+
+			// Field verifications with flagged evidence - staff reviewing approvals
+			loans.GET("/flagged-evidence",
+				middleware.RequirePermission(policy, domain.PermissionLoanViewFlagged),
+				loanHandler.GetFlaggedApprovals)
+
+			// Photo proof evidence - uploaded by field validators, visible to staff
+			loans.POST("/:id/photo-proofs",
+				middleware.RequirePermission(policy, domain.PermissionLoanApprove),
+				photoProofHandler.UploadPhotoProof)
+			loans.GET("/:id/photo-proofs/:proofId",
+				middleware.RequirePermission(policy, domain.PermissionLoanViewEvidence),
+				photoProofHandler.DownloadPhotoProof)
 
 			// Approval route - field validators only
 			loans.POST("/:id/approve",
-				middleware.RoleMiddleware(domain.RoleFieldValidator),
+				middleware.RequirePermission(policy, domain.PermissionLoanApprove),
 				loanHandler.ApproveLoan)
 
+			// Later approval stages
+			loans.POST("/:id/credit-review",
+				middleware.RequirePermission(policy, domain.PermissionLoanReview),
+				loanHandler.SubmitCreditReview)
+			loans.POST("/:id/committee-decision",
+				middleware.RequirePermission(policy, domain.PermissionLoanCommitteeSignoff),
+				loanHandler.SubmitCommitteeDecision)
+			loans.GET("/:id/approval-stages",
+				middleware.RequirePermission(policy, domain.PermissionLoanViewEvidence),
+				loanHandler.GetApprovalStages)
+
+			// Loan documents - listing is filtered by access, uploads by field officers only
+			loans.GET("/:id/documents", documentHandler.GetLoanDocuments)
+			loans.POST("/:id/documents",
+				middleware.RequirePermission(policy, domain.PermissionLoanUploadDocument),
+				documentHandler.UploadDocument)
+
+			// Borrower agreement - visible to the borrower and staff, signed by the borrower only
+			loans.GET("/:id/agreement", agreementHandler.GetBorrowerAgreement)
+			loans.POST("/:id/agreement/otp",
+				middleware.RequirePermission(policy, domain.PermissionAgreementSign),
+				agreementHandler.RequestSigningOTP)
+			loans.POST("/:id/agreement/sign",
+				middleware.RequirePermission(policy, domain.PermissionAgreementSign),
+				agreementHandler.SignAgreement)
+
 			// Disbursement route - field officers only
 			loans.POST("/:id/disburse",
-				middleware.RoleMiddleware(domain.RoleFieldOfficer),
+				middleware.RequirePermission(policy, domain.PermissionLoanDisburse),
 				loanHandler.DisburseLoan)
 
 			// Investment routes for loans - using same :id parameter
 			loans.GET("/:id/investments", investmentHandler.GetLoanInvestments)
+
+			// Waitlist routes for fully funded loans - investors only
+			loans.POST("/:id/waitlist",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentCreate),
+				waitlistHandler.JoinWaitlist)
+			loans.DELETE("/:id/waitlist",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentCreate),
+				waitlistHandler.LeaveWaitlist)
 		}
 
 		// Investment routes
 		investments := api.Group("/investments")
 		{
-			investments.POST("", investmentHandler.Invest)             // Investors only
-			investments.GET("/my", investmentHandler.GetMyInvestments) // Investors only
+			investments.POST("",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentCreate),
+				investmentHandler.Invest)
+			investments.GET("/my",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentViewOwn),
+				investmentHandler.GetMyInvestments)
+			investments.POST("/:id/cancel",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentCreate),
+				investmentHandler.CancelInvestment) // Investors only - own investment within the cooling-off period
+			investments.GET("/holds/:id",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentViewOwn),
+				investmentHandler.GetHold) // Investors only - status of own reservation
+			investments.GET("/waitlist",
+				middleware.RequirePermission(policy, domain.PermissionInvestmentViewOwn),
+				waitlistHandler.GetMyWaitlist) // Investors only - own waitlist entries
+		}
+
+		// Identity verification - customers submit, staff review before they can borrow or invest
+		kyc := api.Group("/kyc")
+		{
+			kyc.POST("",
+				middleware.RequirePermission(policy, domain.PermissionKYCSubmit),
+				kycHandler.SubmitKYC) // Multipart id_card and selfie images
+			kyc.GET("/my",
+				middleware.RequirePermission(policy, domain.PermissionKYCSubmit),
+				kycHandler.GetMyKYC) // Status of the latest own submission
+
+			reviews := kyc.Group("/reviews")
+			reviews.Use(middleware.RequirePermission(policy, domain.PermissionKYCReview))
+			{
+				reviews.GET("", kycHandler.GetReviewQueue)                                // Oldest first, filter with ?status=
+				reviews.GET("/:id", kycHandler.GetReview)                                 // With the registered name and identity number
+				reviews.GET("/:id/documents/:documentId", kycHandler.DownloadKYCDocument) // ID card or selfie image
+				reviews.POST("/:id/approve", kycHandler.ApproveKYC)                       // Verifies the customer
+				reviews.POST("/:id/reject", kycHandler.RejectKYC)                         // The reason is shown to the customer
+			}
+		}
+
+		// Data subject requests - people export their own data and ask for its erasure,
+		// staff act on requests received elsewhere and complete or reject the erasures
+		privacy := api.Group("/privacy")
+		{
+			privacy.GET("/export", middleware.RequireSession(), privacyHandler.ExportMyData)         // Zip archive of JSON files and documents
+			privacy.POST("/erasure", middleware.RequireSession(), privacyHandler.RequestMyErasure)   // Borrowers and investors
+			privacy.GET("/erasure", middleware.RequireSession(), privacyHandler.GetMyErasureRequest) // Status of the latest own request
+
+			manage := privacy.Group("")
+			manage.Use(middleware.RequirePermission(policy, domain.PermissionPrivacyManage))
+			{
+				manage.GET("/users/:id/export", privacyHandler.ExportUserData)
+				manage.POST("/users/:id/erasure", privacyHandler.RequestUserErasure)
+				manage.GET("/erasure-requests", privacyHandler.ListErasureRequests)         // Oldest first, filter with ?status=
+				manage.POST("/erasure-requests/:id/approve", privacyHandler.ApproveErasure) // Anonymizes the customer's personal data
+				manage.POST("/erasure-requests/:id/reject", privacyHandler.RejectErasure)   // The reason is shown to the customer
+			}
+		}
+
+		// Verification task routes - field validators only
+		tasks := api.Group("/tasks")
+		tasks.Use(middleware.RequirePermission(policy, domain.PermissionTaskManage))
+		{
+			tasks.GET("/my", taskHandler.GetMyTasks)              // Own inbox
+			tasks.POST("/:id/reassign", taskHandler.ReassignTask) // Hand an own task to another validator
+		}
+
+		// Conflict of interest declarations - staff only
+		staff := api.Group("/staff")
+		staff.Use(middleware.RequirePermission(policy, domain.PermissionStaffDeclareRelationship))
+		{
+			staff.POST("/relationships", relationshipHandler.DeclareRelationship)  // Declare a relationship with a borrower
+			staff.GET("/relationships/my", relationshipHandler.GetMyRelationships) // Own declarations
+		}
+
+		// User administration - administrators only
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequirePermission(policy, domain.PermissionUserManage))
+		{
+			admin.GET("/users", adminHandler.ListUsers)                         // Filter with ?role= and paginate with ?page=&page_size=
+			admin.POST("/users", adminHandler.CreateUser)                       // Staff and administrator accounts
+			admin.POST("/users/:id/deactivate", adminHandler.DeactivateUser)    // Block sign-in and end all sessions
+			admin.PUT("/users/:id/role", adminHandler.ChangeRole)               // Move an employee to another staff role
+			admin.POST("/users/:id/reset-password", adminHandler.ResetPassword) // Issue a temporary password
+			admin.POST("/users/:id/2fa/reset", adminHandler.ResetTwoFactor)     // Remove a lost authenticator
+			admin.POST("/users/:id/unlock", adminHandler.UnlockUser)            // Lift a lockout after failed sign-ins
+			admin.GET("/users/:id/login-history", adminHandler.GetLoginHistory) // Recent sign-in attempts
+			admin.GET("/login-events", adminHandler.SearchLoginEvents)          // Filter with ?ip_address=&email=&failed_only=
+
+			// Service accounts for partner integrations and batch jobs
+			admin.POST("/service-accounts", serviceAccountHandler.CreateServiceAccount)
+			admin.GET("/service-accounts", serviceAccountHandler.ListServiceAccounts)
+			admin.POST("/service-accounts/:id/api-keys", serviceAccountHandler.IssueAPIKey) // The key is shown once
+			admin.GET("/service-accounts/:id/api-keys", serviceAccountHandler.ListAPIKeys)
+			admin.POST("/api-keys/:id/rotate", serviceAccountHandler.RotateAPIKey) // The old key works for the grace period
+			admin.POST("/api-keys/:id/revoke", serviceAccountHandler.RevokeAPIKey)
+		}
+
+		// Document routes - access is checked per document
+		documents := api.Group("/documents")
+		{
+			documents.GET("/:id", documentHandler.GetDocument) // Returns a signed download link
 		}
 	}
 
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// AgreementLetterSweeper periodically retries investor agreement letters that were not delivered
+type AgreementLetterSweeper struct {
+	investmentService domain.InvestmentService
+	interval          time.Duration
+	stop              chan struct{}
+}
+
+func NewAgreementLetterSweeper(investmentService domain.InvestmentService, interval time.Duration) *AgreementLetterSweeper {
+	return &AgreementLetterSweeper{
+		investmentService: investmentService,
+		interval:          interval,
+		stop:              make(chan struct{}),
+	}
+}
+
+func (w *AgreementLetterSweeper) Start(ctx context.Context) {
+	log.Println("Starting agreement letter sweeper...")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			sent, err := w.investmentService.RetryAgreementLetters(ctx)
+			if err != nil {
+				log.Printf("Error retrying agreement letters: %v", err)
+				continue
+			}
+			if sent > 0 {
+				log.Printf("Sent pending agreement letters for %d loans", sent)
+			}
+		}
+	}
+}
+
+func (w *AgreementLetterSweeper) Stop() {
+	log.Println("Stopping agreement letter sweeper...")
+	close(w.stop)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// signingOTPDigits is the length of the code sent to the borrower
+const signingOTPDigits = 6
+
+type agreementService struct {
+	loanRepo            domain.LoanRepository
+	documentRepo        domain.DocumentRepository
+	signatureRepo       domain.AgreementSignatureRepository
+	documentService     domain.DocumentService
+	notificationService domain.NotificationService
+	pdfRenderer         domain.PDFRenderer
+	policy              domain.PermissionPolicy
+	signatureConfig     *config.SignatureConfig
+}
+
+func NewAgreementService(
+	loanRepo domain.LoanRepository,
+	documentRepo domain.DocumentRepository,
+	signatureRepo domain.AgreementSignatureRepository,
+	documentService domain.DocumentService,
+	notificationService domain.NotificationService,
+	pdfRenderer domain.PDFRenderer,
+	policy domain.PermissionPolicy,
+	signatureConfig *config.SignatureConfig,
+) domain.AgreementService {
+	return &agreementService{
+		loanRepo:            loanRepo,
+		documentRepo:        documentRepo,
+		signatureRepo:       signatureRepo,
+		documentService:     documentService,
+		notificationService: notificationService,
+		pdfRenderer:         pdfRenderer,
+		policy:              policy,
+		signatureConfig:     signatureConfig,
+	}
+}
+
+func (s *agreementService) GenerateBorrowerAgreement(ctx context.Context, loanID uuid.UUID) (*domain.Document, error) {
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.State != domain.LoanStateInvested {
+		return nil, domain.ErrLoanNotInvested
+	}
+	return s.getAgreement(ctx, loan)
+}
+
+// generateAgreement renders the borrower agreement of the loan and stores it
+func (s *agreementService) generateAgreement(ctx context.Context, loan *domain.Loan) (*domain.Document, error) {
+	content, err := s.pdfRenderer.Render(borrowerAgreementTemplate(loan, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render borrower agreement: %w", err)
+	}
+
+	// Unowned, so the borrower and staff can see it
+	document := &domain.Document{
+		LoanID:          loan.ID,
+		Type:            domain.DocumentTypeBorrowerAgreement,
+		FileName:        fmt.Sprintf("borrower_agreement_%s.pdf", loan.ID),
+		ContentType:     "application/pdf",
+		TemplateVersion: borrowerAgreementTemplateVersion,
+	}
+	if err := s.documentService.StoreDocument(ctx, document, content); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func (s *agreementService) GetBorrowerAgreement(ctx context.Context, user *domain.User, loanID uuid.UUID) (*domain.BorrowerAgreement, error) {
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.policy.Allows(user, domain.PermissionDocumentViewAll) && loan.Borrower.UserID != user.ID {
+		return nil, domain.ErrInsufficientPermission
+	}
+
+	document, err := s.getAgreement(ctx, loan)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signatureRepo.GetByDocumentID(ctx, document.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return &domain.BorrowerAgreement{Document: document, Signature: signature}, nil
+}
+
+func (s *agreementService) RequestSigningOTP(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) (time.Time, error) {
+	loan, document, err := s.getSignableAgreement(ctx, userID, loanID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Every code comes with fresh attempts, so the number of codes bounds the guesses
+	issued, err := s.signatureRepo.CountOTPsSince(ctx, document.ID, userID, time.Now().Add(-s.signatureConfig.OTPIssueWindow))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if issued >= int64(s.signatureConfig.OTPMaxIssued) {
+		return time.Time{}, domain.ErrOTPRateLimited
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	otp := &domain.SignatureOTP{
+		ID:         uuid.New(),
+		DocumentID: document.ID,
+		UserID:     userID,
+		ExpiresAt:  time.Now().Add(s.signatureConfig.OTPTTL),
+		CreatedAt:  time.Now(),
+	}
+	otp.CodeHash = hashOTP(otp.ID, code)
+
+	if err := s.signatureRepo.CreateOTP(ctx, otp); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.notificationService.SendSigningOTP(ctx, &loan.Borrower, loan.ID, code, otp.ExpiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to send signing code: %w", err)
+	}
+
+	return otp.ExpiresAt, nil
+}
+
+// SignBorrowerAgreement records the borrower's signature once consent, typed name and one-time code check out
+func (s *agreementService) SignBorrowerAgreement(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, input domain.SignAgreementInput) (*domain.AgreementSignature, error) {
+	loan, document, err := s.getSignableAgreement(ctx, userID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !input.Consent {
+		return nil, domain.ErrSignatureConsentRequired
+	}
+	if !strings.EqualFold(normalizeName(input.TypedName), normalizeName(loan.Borrower.FullName)) {
+		return nil, domain.ErrSignatureNameMismatch
+	}
+
+	if err := s.verifyOTP(ctx, document.ID, userID, input.OTP); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	signature := &domain.AgreementSignature{
+		ID:             uuid.New(),
+		LoanID:         loan.ID,
+		DocumentID:     document.ID,
+		SignerUserID:   userID,
+		DocumentSHA256: document.SHA256,
+		SignedName:     strings.TrimSpace(input.TypedName),
+		ConsentText:    borrowerConsentText,
+		IPAddress:      input.IPAddress,
+		UserAgent:      input.UserAgent,
+		SignedAt:       now,
+		CreatedAt:      now,
+	}
+	if err := s.signatureRepo.Create(ctx, signature); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// verifyOTP checks the code against the latest one issued and consumes it on success
+func (s *agreementService) verifyOTP(ctx context.Context, documentID uuid.UUID, userID uuid.UUID, code string) error {
+	otp, err := s.signatureRepo.GetLatestOTP(ctx, documentID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidOTP
+		}
+		return err
+	}
+
+	if otp.UsedAt != nil {
+		return domain.ErrInvalidOTP
+	}
+	if time.Now().After(otp.ExpiresAt) {
+		return domain.ErrOTPExpired
+	}
+	if otp.Attempts >= s.signatureConfig.OTPMaxAttempts {
+		return domain.ErrOTPAttemptsExceeded
+	}
+
+	// The attempt is counted before the code is compared, concurrent guesses cannot get past the limit
+	counted, err := s.signatureRepo.RecordOTPAttempt(ctx, otp.ID, s.signatureConfig.OTPMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !counted {
+		return domain.ErrOTPAttemptsExceeded
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashOTP(otp.ID, strings.TrimSpace(code))), []byte(otp.CodeHash)) != 1 {
+		return domain.ErrInvalidOTP
+	}
+
+	// Only one of concurrent requests with the right code consumes it
+	consumed, err := s.signatureRepo.ConsumeOTP(ctx, otp.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return domain.ErrInvalidOTP
+	}
+	return nil
+}
+
+// getSignableAgreement returns the borrower's unsigned agreement of an invested loan
+func (s *agreementService) getSignableAgreement(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) (*domain.Loan, *domain.Document, error) {
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if loan.Borrower.UserID != userID {
+		return nil, nil, domain.ErrInsufficientPermission
+	}
+	if loan.State != domain.LoanStateInvested {
+		return nil, nil, domain.ErrLoanNotInvested
+	}
+
+	document, err := s.getAgreement(ctx, loan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = s.signatureRepo.GetByDocumentID(ctx, document.ID)
+	if err == nil {
+		return nil, nil, domain.ErrAgreementAlreadySigned
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	return loan, document, nil
+}
+
+// getAgreement returns the borrower agreement of the loan. It is generated once, a stored agreement is
+// never replaced as the borrower may have signed it. An invested loan whose agreement could not be generated
+// when it was funded gets it here, so a failure then does not block signing and disbursement.
+func (s *agreementService) getAgreement(ctx context.Context, loan *domain.Loan) (*domain.Document, error) {
+	document, err := s.documentRepo.GetLatestByLoanAndType(ctx, loan.ID, domain.DocumentTypeBorrowerAgreement)
+	if err == nil {
+		return document, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if loan.State != domain.LoanStateInvested {
+		return nil, domain.ErrDocumentNotFound
+	}
+	return s.generateAgreement(ctx, loan)
+}
+
+func (s *agreementService) getLoan(ctx context.Context, loanID uuid.UUID) (*domain.Loan, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoanNotFound
+		}
+		return nil, err
+	}
+	return loan, nil
+}
+
+// generateOTP returns a random numeric code of signingOTPDigits digits
+func generateOTP() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < signingOTPDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signing code: %w", err)
+	}
+
+	return fmt.Sprintf("%0*d", signingOTPDigits, n), nil
+}
+
+// hashOTP binds the code to its record so stored hashes cannot be reused across codes
+func hashOTP(otpID uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(otpID.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeName collapses whitespace so the typed name only has to match word for word
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Mock Agreement Signature Repository
+type mockAgreementSignatureRepository struct {
+	mock.Mock
+}
+
+func (m *mockAgreementSignatureRepository) Create(ctx context.Context, signature *domain.AgreementSignature) error {
+	args := m.Called(ctx, signature)
+	return args.Error(0)
+}
+
+func (m *mockAgreementSignatureRepository) GetByDocumentID(ctx context.Context, documentID uuid.UUID) (*domain.AgreementSignature, error) {
+	args := m.Called(ctx, documentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AgreementSignature), args.Error(1)
+}
+
+func (m *mockAgreementSignatureRepository) CreateOTP(ctx context.Context, otp *domain.SignatureOTP) error {
+	args := m.Called(ctx, otp)
+	return args.Error(0)
+}
+
+func (m *mockAgreementSignatureRepository) GetLatestOTP(ctx context.Context, documentID uuid.UUID, userID uuid.UUID) (*domain.SignatureOTP, error) {
+	args := m.Called(ctx, documentID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SignatureOTP), args.Error(1)
+}
+
+func (m *mockAgreementSignatureRepository) CountOTPsSince(ctx context.Context, documentID uuid.UUID, userID uuid.UUID, since time.Time) (int64, error) {
+	args := m.Called(ctx, documentID, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockAgreementSignatureRepository) RecordOTPAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (bool, error) {
+	args := m.Called(ctx, id, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockAgreementSignatureRepository) ConsumeOTP(ctx context.Context, id uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+var testSignatureConfig = &config.SignatureConfig{
+	OTPTTL:         10 * time.Minute,
+	OTPMaxAttempts: 3,
+	OTPMaxIssued:   3,
+	OTPIssueWindow: time.Hour,
+}
+
+// Test Borrower Agreement Generation - Stored Once As A PDF
+func TestAgreementService_GenerateBorrowerAgreement(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+	mockRenderer := new(mockPDFRenderer)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, documentService, mockNotificationService, mockRenderer, testPermissionPolicy, testSignatureConfig)
+
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, PrincipalAmount: 1000000, Rate: 0.1, TenorMonths: 12}
+	existingLoan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested}
+	existing := &domain.Document{ID: uuid.New(), LoanID: existingLoan.ID, Type: domain.DocumentTypeBorrowerAgreement}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, existingLoan.ID).Return(existingLoan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loan.ID, domain.DocumentTypeBorrowerAgreement).Return(nil, gorm.ErrRecordNotFound)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, existingLoan.ID, domain.DocumentTypeBorrowerAgreement).Return(existing, nil)
+	mockRenderer.On("Render", mock.AnythingOfType("*domain.TextDocument")).Return([]byte("%PDF-1.4 agreement"), nil)
+	mockStorage.On("Put", mock.Anything, mock.AnythingOfType("string"), mock.Anything, "application/pdf").Return(nil)
+	mockDocumentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Document")).Return(nil)
+
+	// Act
+	document, err := agreementService.GenerateBorrowerAgreement(context.Background(), loan.ID)
+	again, againErr := agreementService.GenerateBorrowerAgreement(context.Background(), existingLoan.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DocumentTypeBorrowerAgreement, document.Type)
+	assert.Nil(t, document.OwnerUserID)
+	assert.Equal(t, borrowerAgreementTemplateVersion, document.TemplateVersion)
+	assert.NotEmpty(t, document.SHA256)
+
+	assert.NoError(t, againErr)
+	assert.Equal(t, existing.ID, again.ID)
+	mockRenderer.AssertNumberOfCalls(t, "Render", 1)
+}
+
+// Test Borrower Agreement - Generated On First Access When It Failed When The Loan Was Funded
+func TestAgreementService_GetBorrowerAgreement_GeneratesMissing(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, documentService, nil, mockRenderer, testPermissionPolicy, testSignatureConfig)
+
+	borrowerUser := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower}
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, Borrower: domain.Borrower{UserID: borrowerUser.ID}}
+	disbursedLoan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateDisbursed, Borrower: domain.Borrower{UserID: borrowerUser.ID}}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, disbursedLoan.ID).Return(disbursedLoan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, mock.Anything, domain.DocumentTypeBorrowerAgreement).Return(nil, gorm.ErrRecordNotFound)
+	mockRenderer.On("Render", mock.AnythingOfType("*domain.TextDocument")).Return([]byte("%PDF-1.4 agreement"), nil)
+	mockStorage.On("Put", mock.Anything, mock.AnythingOfType("string"), mock.Anything, "application/pdf").Return(nil)
+	mockDocumentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Document")).Return(nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(nil, gorm.ErrRecordNotFound)
+
+	// Act
+	agreement, err := agreementService.GetBorrowerAgreement(context.Background(), borrowerUser, loan.ID)
+	_, disbursedErr := agreementService.GetBorrowerAgreement(context.Background(), borrowerUser, disbursedLoan.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.DocumentTypeBorrowerAgreement, agreement.Document.Type)
+	assert.Equal(t, loan.ID, agreement.Document.LoanID)
+	assert.Nil(t, agreement.Signature)
+	// Only invested loans get a new agreement, a loan past funding keeps what it has
+	assert.Equal(t, domain.ErrDocumentNotFound, disbursedErr)
+	mockRenderer.AssertNumberOfCalls(t, "Render", 1)
+}
+
+// Test Borrower Agreement Signing - Code Sent By SMS Signs The Agreement
+func TestAgreementService_SignBorrowerAgreement_Success(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockNotificationService := new(mockNotificationService)
+
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, nil, mockNotificationService, nil, testPermissionPolicy, testSignatureConfig)
+
+	userID := uuid.New()
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, Borrower: domain.Borrower{UserID: userID, FullName: "Jane  Borrower"}}
+	document := &domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement, SHA256: "c0ffee"}
+
+	var issued *domain.SignatureOTP
+	var code string
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loan.ID, domain.DocumentTypeBorrowerAgreement).Return(document, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, document.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockSignatureRepo.On("CountOTPsSince", mock.Anything, document.ID, userID, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	mockSignatureRepo.On("CreateOTP", mock.Anything, mock.AnythingOfType("*domain.SignatureOTP")).Run(func(args mock.Arguments) {
+		issued = args.Get(1).(*domain.SignatureOTP)
+	}).Return(nil)
+	mockNotificationService.On("SendSigningOTP", mock.Anything, &loan.Borrower, loan.ID, mock.AnythingOfType("string"), mock.Anything).Run(func(args mock.Arguments) {
+		code = args.String(3)
+	}).Return(nil)
+	mockSignatureRepo.On("RecordOTPAttempt", mock.Anything, mock.AnythingOfType("uuid.UUID"), testSignatureConfig.OTPMaxAttempts).Return(true, nil)
+	mockSignatureRepo.On("ConsumeOTP", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	mockSignatureRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AgreementSignature")).Return(nil)
+
+	// Act
+	expiresAt, err := agreementService.RequestSigningOTP(context.Background(), userID, loan.ID)
+	assert.NoError(t, err)
+	mockSignatureRepo.On("GetLatestOTP", mock.Anything, document.ID, userID).Return(issued, nil)
+
+	signature, err := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, domain.SignAgreementInput{
+		TypedName: "jane borrower",
+		Consent:   true,
+		OTP:       code,
+		IPAddress: "203.0.113.7",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, code, signingOTPDigits)
+	assert.NotContains(t, issued.CodeHash, code)
+	assert.WithinDuration(t, time.Now().Add(testSignatureConfig.OTPTTL), expiresAt, time.Minute)
+	mockSignatureRepo.AssertCalled(t, "ConsumeOTP", mock.Anything, issued.ID, mock.AnythingOfType("time.Time"))
+	assert.Equal(t, document.ID, signature.DocumentID)
+	assert.Equal(t, "c0ffee", signature.DocumentSHA256)
+	assert.Equal(t, "203.0.113.7", signature.IPAddress)
+	assert.Equal(t, borrowerConsentText, signature.ConsentText)
+	assert.False(t, signature.SignedAt.IsZero())
+}
+
+// Test Borrower Agreement Signing - Consent, Name And Code Are Checked
+func TestAgreementService_SignBorrowerAgreement_Rejected(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockNotificationService := new(mockNotificationService)
+
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, nil, mockNotificationService, nil, testPermissionPolicy, testSignatureConfig)
+
+	userID := uuid.New()
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, Borrower: domain.Borrower{UserID: userID, FullName: "Jane Borrower"}}
+	document := &domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement}
+	otp := &domain.SignatureOTP{ID: uuid.New(), DocumentID: document.ID, UserID: userID, ExpiresAt: time.Now().Add(time.Minute)}
+	otp.CodeHash = hashOTP(otp.ID, "123456")
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loan.ID, domain.DocumentTypeBorrowerAgreement).Return(document, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, document.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockSignatureRepo.On("GetLatestOTP", mock.Anything, document.ID, userID).Return(otp, nil)
+	mockSignatureRepo.On("RecordOTPAttempt", mock.Anything, otp.ID, testSignatureConfig.OTPMaxAttempts).Return(true, nil).Times(testSignatureConfig.OTPMaxAttempts)
+	mockSignatureRepo.On("RecordOTPAttempt", mock.Anything, otp.ID, testSignatureConfig.OTPMaxAttempts).Return(false, nil)
+
+	input := func(name string, consent bool, code string) domain.SignAgreementInput {
+		return domain.SignAgreementInput{TypedName: name, Consent: consent, OTP: code, IPAddress: "203.0.113.7"}
+	}
+
+	// Act
+	_, strangerErr := agreementService.SignBorrowerAgreement(context.Background(), uuid.New(), loan.ID, input("Jane Borrower", true, "123456"))
+	_, consentErr := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, input("Jane Borrower", false, "123456"))
+	_, nameErr := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, input("John Doe", true, "123456"))
+	var codeErrs []error
+	for i := 0; i < testSignatureConfig.OTPMaxAttempts+1; i++ {
+		_, err := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, input("Jane Borrower", true, "000000"))
+		codeErrs = append(codeErrs, err)
+	}
+	_, lockedErr := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, input("Jane Borrower", true, "123456"))
+
+	// Assert
+	assert.Equal(t, domain.ErrInsufficientPermission, strangerErr)
+	assert.Equal(t, domain.ErrSignatureConsentRequired, consentErr)
+	assert.Equal(t, domain.ErrSignatureNameMismatch, nameErr)
+	assert.Equal(t, domain.ErrInvalidOTP, codeErrs[0])
+	assert.Equal(t, domain.ErrOTPAttemptsExceeded, codeErrs[testSignatureConfig.OTPMaxAttempts])
+	assert.Equal(t, domain.ErrOTPAttemptsExceeded, lockedErr)
+	mockSignatureRepo.AssertNotCalled(t, "ConsumeOTP", mock.Anything, mock.Anything, mock.Anything)
+	mockSignatureRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Borrower Agreement Signing - A Code Consumed By A Concurrent Request Does Not Sign Twice
+func TestAgreementService_SignBorrowerAgreement_CodeAlreadyConsumed(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, nil, nil, nil, testPermissionPolicy, testSignatureConfig)
+
+	userID := uuid.New()
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, Borrower: domain.Borrower{UserID: userID, FullName: "Jane Borrower"}}
+	document := &domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement}
+	otp := &domain.SignatureOTP{ID: uuid.New(), DocumentID: document.ID, UserID: userID, ExpiresAt: time.Now().Add(time.Minute)}
+	otp.CodeHash = hashOTP(otp.ID, "123456")
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loan.ID, domain.DocumentTypeBorrowerAgreement).Return(document, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, document.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockSignatureRepo.On("GetLatestOTP", mock.Anything, document.ID, userID).Return(otp, nil)
+	mockSignatureRepo.On("RecordOTPAttempt", mock.Anything, otp.ID, testSignatureConfig.OTPMaxAttempts).Return(true, nil)
+	mockSignatureRepo.On("ConsumeOTP", mock.Anything, otp.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	// Act
+	_, err := agreementService.SignBorrowerAgreement(context.Background(), userID, loan.ID, domain.SignAgreementInput{
+		TypedName: "Jane Borrower",
+		Consent:   true,
+		OTP:       "123456",
+	})
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidOTP, err)
+	mockSignatureRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Signing Code Request - Refused Once The Borrower Requested Too Many Codes In The Window
+func TestAgreementService_RequestSigningOTP_RateLimited(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockNotificationService := new(mockNotificationService)
+
+	agreementService := NewAgreementService(mockLoanRepo, mockDocumentRepo, mockSignatureRepo, nil, mockNotificationService, nil, testPermissionPolicy, testSignatureConfig)
+
+	userID := uuid.New()
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested, Borrower: domain.Borrower{UserID: userID, FullName: "Jane Borrower"}}
+	document := &domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement}
+
+	var since time.Time
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loan.ID, domain.DocumentTypeBorrowerAgreement).Return(document, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, document.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockSignatureRepo.On("CountOTPsSince", mock.Anything, document.ID, userID, mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		since = args.Get(3).(time.Time)
+	}).Return(int64(testSignatureConfig.OTPMaxIssued), nil)
+
+	// Act
+	_, err := agreementService.RequestSigningOTP(context.Background(), userID, loan.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrOTPRateLimited, err)
+	assert.WithinDuration(t, time.Now().Add(-testSignatureConfig.OTPIssueWindow), since, time.Minute)
+	mockSignatureRepo.AssertNotCalled(t, "CreateOTP", mock.Anything, mock.Anything)
+	mockNotificationService.AssertNotCalled(t, "SendSigningOTP", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Loan Disbursement - Refused Until The Borrower Signed The Stored Agreement
+func TestLoanService_DisburseLoan_RequiresBorrowerSignature(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+	mockStorage := new(mockFileStorage)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, mockStorage, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
+
+	unsignedLoanID := uuid.New()
+	tamperedLoanID := uuid.New()
+	replacedLoanID := uuid.New()
+	signedSum := sha256.Sum256([]byte("%PDF signed agreement"))
+	unsignedAgreement := &domain.Document{ID: uuid.New(), LoanID: unsignedLoanID, Type: domain.DocumentTypeBorrowerAgreement, SHA256: "aaa"}
+	tamperedAgreement := &domain.Document{ID: uuid.New(), LoanID: tamperedLoanID, Type: domain.DocumentTypeBorrowerAgreement, SHA256: "bbb"}
+	replacedAgreement := &domain.Document{ID: uuid.New(), LoanID: replacedLoanID, Type: domain.DocumentTypeBorrowerAgreement,
+		SHA256: hex.EncodeToString(signedSum[:]), StorageKey: "documents/replaced.pdf"}
+	unsignedUpload := &domain.Document{ID: uuid.New(), LoanID: unsignedLoanID, Type: domain.DocumentTypeDisbursementAgreement}
+	tamperedUpload := &domain.Document{ID: uuid.New(), LoanID: tamperedLoanID, Type: domain.DocumentTypeDisbursementAgreement}
+	replacedUpload := &domain.Document{ID: uuid.New(), LoanID: replacedLoanID, Type: domain.DocumentTypeDisbursementAgreement}
+
+	for _, id := range []uuid.UUID{unsignedLoanID, tamperedLoanID, replacedLoanID} {
+		mockLoanRepo.On("GetByID", mock.Anything, id).Return(&domain.Loan{ID: id, State: domain.LoanStateInvested}, nil)
+	}
+	mockDocumentRepo.On("GetByID", mock.Anything, unsignedUpload.ID).Return(unsignedUpload, nil)
+	mockDocumentRepo.On("GetByID", mock.Anything, tamperedUpload.ID).Return(tamperedUpload, nil)
+	mockDocumentRepo.On("GetByID", mock.Anything, replacedUpload.ID).Return(replacedUpload, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, unsignedLoanID, domain.DocumentTypeBorrowerAgreement).Return(unsignedAgreement, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, tamperedLoanID, domain.DocumentTypeBorrowerAgreement).Return(tamperedAgreement, nil)
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, replacedLoanID, domain.DocumentTypeBorrowerAgreement).Return(replacedAgreement, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, unsignedAgreement.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, tamperedAgreement.ID).Return(&domain.AgreementSignature{
+		LoanID: tamperedLoanID, DocumentID: tamperedAgreement.ID, DocumentSHA256: "aaa",
+	}, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, replacedAgreement.ID).Return(&domain.AgreementSignature{
+		LoanID: replacedLoanID, DocumentID: replacedAgreement.ID, DocumentSHA256: replacedAgreement.SHA256,
+	}, nil)
+	// The file in storage was swapped after signing, the document metadata was left alone
+	mockStorage.On("Get", mock.Anything, replacedAgreement.StorageKey).Return(io.NopCloser(strings.NewReader("%PDF other agreement")), nil)
+	mockSegregationService.On("CheckActor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	unsignedErr := loanService.DisburseLoan(context.Background(), unsignedLoanID, uuid.New(), unsignedUpload.ID, time.Now())
+	tamperedErr := loanService.DisburseLoan(context.Background(), tamperedLoanID, uuid.New(), tamperedUpload.ID, time.Now())
+	replacedErr := loanService.DisburseLoan(context.Background(), replacedLoanID, uuid.New(), replacedUpload.ID, time.Now())
+
+	// Assert
+	assert.Equal(t, domain.ErrAgreementNotSigned, unsignedErr)
+	assert.Equal(t, domain.ErrAgreementNotSigned, tamperedErr)
+	assert.Equal(t, domain.ErrAgreementNotSigned, replacedErr)
+	mockDisbursementRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockLoanRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// investorAgreementTemplateVersion is stored with every generated letter. Bump it whenever the
+// wording or the terms shown change so earlier letters can still be told apart.
+const investorAgreementTemplateVersion = "investor-agreement/v1"
+
+// investorAgreementTemplate builds the investor agreement letter for one investment
+func investorAgreementTemplate(loan *domain.Loan, investment *domain.Investment, issuedAt time.Time) *domain.TextDocument {
+	expectedReturn := investment.Amount * loan.ROI
+
+	return &domain.TextDocument{
+		Title: "Investment Agreement Letter",
+		Sections: []domain.TextSection{
+			{
+				Lines: []string{
+					fmt.Sprintf("Agreement reference: %s", investment.ID),
+					fmt.Sprintf("Issued at: %s", issuedAt.Format("2 January 2006 15:04 MST")),
+					fmt.Sprintf("Template: %s", investorAgreementTemplateVersion),
+				},
+			},
+			{
+				Heading: "Parties",
+				Lines: []string{
+					fmt.Sprintf("Investor: %s, identity number %s", investment.Investor.FullName, investment.Investor.IdentityNumber),
+					fmt.Sprintf("Borrower: %s, identity number %s", loan.Borrower.FullName, loan.Borrower.IdentityNumber),
+					"Platform: AMF Loan Service, acting as the arranger of this loan.",
+				},
+			},
+			{
+				Heading: "Loan Terms",
+				Lines: []string{
+					fmt.Sprintf("Loan ID: %s", loan.ID),
+					fmt.Sprintf("Principal amount: %s", formatAmount(loan.PrincipalAmount)),
+					fmt.Sprintf("Tenor: %d months", loan.TenorMonths),
+					fmt.Sprintf("Borrower interest rate: %.2f%%", loan.Rate*100),
+					fmt.Sprintf("Investor return on investment (ROI): %.2f%%", loan.ROI*100),
+				},
+			},
+			{
+				Heading: "Investment",
+				Lines: []string{
+					fmt.Sprintf("Investment amount: %s", formatAmount(investment.Amount)),
+					fmt.Sprintf("Share of principal: %.2f%%", investment.Amount/loan.PrincipalAmount*100),
+					fmt.Sprintf("Expected return at maturity: %s", formatAmount(expectedReturn)),
+					"The investor funds the amount above and receives principal and return pro rata to the borrower's repayments. " +
+						"Returns are not guaranteed and depend on the borrower repaying the loan.",
+				},
+			},
+			{
+				Heading: "Signatures",
+				Lines: []string{
+					"Investor: ______________________________",
+					fmt.Sprintf("Name: %s", investment.Investor.FullName),
+					"",
+					"For AMF Loan Service: ______________________________",
+					"Date: ______________________________",
+				},
+			},
+		},
+	}
+}
+
+// borrowerAgreementTemplateVersion is stored with every generated borrower agreement
+const borrowerAgreementTemplateVersion = "borrower-agreement/v1"
+
+// borrowerConsentText is the statement the borrower accepts when signing electronically. It is
+// stored with every signature, so changing it only affects new signatures.
+const borrowerConsentText = "I have read the borrower agreement and agree to be bound by its terms. " +
+	"I sign it electronically by typing my full name and confirming the one-time code sent to me."
+
+// borrowerAgreementTemplate builds the agreement the borrower signs before the loan is disbursed
+func borrowerAgreementTemplate(loan *domain.Loan, issuedAt time.Time) *domain.TextDocument {
+	return &domain.TextDocument{
+		Title: "Borrower Loan Agreement",
+		Sections: []domain.TextSection{
+			{
+				Lines: []string{
+					fmt.Sprintf("Agreement reference: %s", loan.ID),
+					fmt.Sprintf("Issued at: %s", issuedAt.Format("2 January 2006 15:04 MST")),
+					fmt.Sprintf("Template: %s", borrowerAgreementTemplateVersion),
+				},
+			},
+			{
+				Heading: "Parties",
+				Lines: []string{
+					fmt.Sprintf("Borrower: %s, identity number %s", loan.Borrower.FullName, loan.Borrower.IdentityNumber),
+					"Lenders: the investors who funded this loan through AMF Loan Service.",
+					"Platform: AMF Loan Service, acting as the arranger of this loan.",
+				},
+			},
+			{
+				Heading: "Loan Terms",
+				Lines: []string{
+					fmt.Sprintf("Principal amount: %s", formatAmount(loan.PrincipalAmount)),
+					fmt.Sprintf("Tenor: %d months", loan.TenorMonths),
+					fmt.Sprintf("Interest rate: %.2f%%", loan.Rate*100),
+					fmt.Sprintf("Total interest: %s", formatAmount(loan.TotalInterest)),
+					fmt.Sprintf("Total amount repayable: %s", formatAmount(loan.PrincipalAmount+loan.TotalInterest)),
+				},
+			},
+			{
+				Heading: "Obligations",
+				Lines: []string{
+					"The principal is paid out to the borrower once this agreement is signed and the field officer completes the disbursement.",
+					"The borrower repays the total amount above within the tenor. Repayments are passed on to the lenders pro rata to their investments.",
+				},
+			},
+			{
+				Heading: "Electronic Signature",
+				Lines: []string{
+					borrowerConsentText,
+					"The signature records the typed name, the time of signing, the IP address used and the SHA-256 fingerprint of this document.",
+				},
+			},
+		},
+	}
+}
+
+// formatAmount renders money with thousands separators and two decimals
+func formatAmount(amount float64) string {
+	raw := fmt.Sprintf("%.2f", amount)
+	whole, decimals := raw[:len(raw)-3], raw[len(raw)-3:]
+
+	sign := ""
+	if whole[0] == '-' {
+		sign, whole = "-", whole[1:]
+	}
+
+	var out []byte
+	for i := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, whole[i])
+	}
+
+	return sign + string(out) + decimals
+}
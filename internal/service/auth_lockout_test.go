@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Test Account Lockout - The Threshold Locks The Account, Even The Right Password Is Refused
+func TestAuthService_Login_AccountLockout(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := &domain.User{
+		ID:               uuid.New(),
+		Email:            "borrower@example.com",
+		Password:         string(hashedPassword),
+		Role:             domain.RoleBorrower,
+		FailedLoginCount: testLockoutConfig.Threshold - 1,
+	}
+
+	mockUserRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockUserRepo.On("IncrementFailedLogins", mock.Anything, user.ID).Return(testLockoutConfig.Threshold, nil)
+	mockUserRepo.On("SetLockedUntil", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, wrongErr := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "wrong-password"})
+	response, lockedErr := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "password"})
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidCredentials, wrongErr)
+	assert.Nil(t, response)
+	assert.ErrorIs(t, lockedErr, domain.ErrLoginThrottled)
+
+	var throttled *domain.LoginThrottledError
+	assert.True(t, errors.As(lockedErr, &throttled))
+	assert.True(t, throttled.AccountLocked)
+	assert.WithinDuration(t, time.Now().Add(testLockoutConfig.LockoutDuration), throttled.RetryAt, 5*time.Second)
+
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureAccountLocked
+	}))
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Progressive Delay - Failures Past The Free Attempts Double The Wait Up To The Maximum
+func TestAuthService_Login_ProgressiveDelay(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := &domain.User{ID: uuid.New(), Email: "investor@example.com", Password: string(hashedPassword), Role: domain.RoleInvestor}
+
+	var lockedUntil time.Time
+	mockUserRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockUserRepo.On("IncrementFailedLogins", mock.Anything, user.ID).Return(testLockoutConfig.FreeAttempts, nil).Once()
+	mockUserRepo.On("IncrementFailedLogins", mock.Anything, user.ID).Return(testLockoutConfig.FreeAttempts+3, nil).Once()
+	mockUserRepo.On("SetLockedUntil", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		lockedUntil = args.Get(2).(time.Time)
+	}).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, freeErr := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "wrong-password"})
+	_, delayedErr := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "wrong-password"})
+	_, waitErr := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "password"})
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidCredentials, freeErr)
+	assert.Equal(t, domain.ErrInvalidCredentials, delayedErr)
+	assert.WithinDuration(t, time.Now().Add(4*time.Second), lockedUntil, time.Second)
+	mockUserRepo.AssertNumberOfCalls(t, "SetLockedUntil", 1)
+
+	var throttled *domain.LoginThrottledError
+	assert.True(t, errors.As(waitErr, &throttled))
+	assert.False(t, throttled.AccountLocked)
+}
+
+// Test Login Delay - Doubles From The Base Delay And Stops At The Maximum
+func TestAuthService_LoginDelay(t *testing.T) {
+	// Arrange
+	service := &authService{lockoutConfig: testLockoutConfig}
+
+	// Act & Assert
+	assert.Equal(t, time.Second, service.loginDelay(1))
+	assert.Equal(t, 2*time.Second, service.loginDelay(2))
+	assert.Equal(t, 8*time.Second, service.loginDelay(4))
+	assert.Equal(t, testLockoutConfig.MaxDelay, service.loginDelay(7))
+	assert.Equal(t, testLockoutConfig.MaxDelay, service.loginDelay(100))
+}
+
+// Test Client Throttling - An Address With Many Failures Is Refused Before Any Account Lookup
+func TestAuthService_Login_ClientThrottled(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	lastFailure := time.Now().Add(-time.Minute)
+	mockLoginEventRepo.On("FailureStatsByIP", mock.Anything, "198.51.100.9", ipCountedFailures, mock.AnythingOfType("time.Time")).
+		Return(&domain.LoginFailureStats{Count: int64(testLockoutConfig.IPThreshold), LastAt: &lastFailure}, nil)
+	mockLoginEventRepo.On("FailureStatsByIP", mock.Anything, "198.51.100.10", ipCountedFailures, mock.AnythingOfType("time.Time")).
+		Return(&domain.LoginFailureStats{Count: int64(testLockoutConfig.IPFreeAttempts), LastAt: &lastFailure}, nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	// Act
+	_, blockedErr := authService.Login(context.Background(), domain.LoginInput{Email: "someone@example.com", Password: "password", IPAddress: "198.51.100.9"})
+	_, delayPassedErr := authService.Login(context.Background(), domain.LoginInput{Email: "nobody@example.com", Password: "password", IPAddress: "198.51.100.10"})
+
+	// Assert
+	var throttled *domain.LoginThrottledError
+	assert.True(t, errors.As(blockedErr, &throttled))
+	assert.WithinDuration(t, lastFailure.Add(testLockoutConfig.IPWindow), throttled.RetryAt, time.Millisecond)
+	assert.Equal(t, domain.ErrInvalidCredentials, delayPassedErr)
+	mockUserRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, "someone@example.com")
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureTooManyAttempts && event.IPAddress == "198.51.100.9"
+	}))
+}
+
+// Test Successful Login - Earlier Failures Are Cleared
+func TestAuthService_Login_ClearsFailures(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	expiredDelay := time.Now().Add(-time.Second)
+	user := &domain.User{
+		ID:               uuid.New(),
+		Email:            "borrower@example.com",
+		Password:         string(hashedPassword),
+		Role:             domain.RoleBorrower,
+		FailedLoginCount: 5,
+		LockedUntil:      &expiredDelay,
+	}
+
+	mockUserRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockUserRepo.On("ResetFailedLogins", mock.Anything, user.ID).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	response, err := authService.Login(context.Background(), domain.LoginInput{Email: user.Email, Password: "password"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Zero(t, user.FailedLoginCount)
+	assert.Nil(t, user.LockedUntil)
+	mockUserRepo.AssertExpectations(t)
+}
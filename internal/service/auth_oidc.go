@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// Reasons recorded for refused single sign-ons and password logins of staff who must use it
+const (
+	loginFailureSingleSignOnRequired = "single_sign_on_required"
+	loginFailureSingleSignOnFailed   = "single_sign_on_failed"
+	loginFailureNoRoleForGroups      = "no_role_for_groups"
+	loginFailureSingleSignOnConflict = "single_sign_on_conflict"
+)
+
+func (s *authService) StartSingleSignOn(ctx context.Context) (*domain.SingleSignOnStart, error) {
+	if !s.oidcConfig.Enabled {
+		return nil, domain.ErrSingleSignOnDisabled
+	}
+
+	state, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	// 64 characters, within the 43 to 128 RFC 7636 allows
+	codeVerifier, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationURL, err := s.identityProvider.AuthorizationURL(ctx, state, nonce, pkceChallenge(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the hash of the state is stored, the nonce and verifier never leave the service
+	now := time.Now()
+	signOn := &domain.SingleSignOnState{
+		ID:           uuid.New(),
+		StateHash:    hashSecureToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    now.Add(s.oidcConfig.StateTTL),
+		CreatedAt:    now,
+	}
+	if err := s.ssoStateRepo.Create(ctx, signOn); err != nil {
+		return nil, err
+	}
+
+	return &domain.SingleSignOnStart{
+		AuthorizationURL: authorizationURL,
+		State:            state,
+		ExpiresAt:        signOn.ExpiresAt,
+	}, nil
+}
+
+func (s *authService) CompleteSingleSignOn(ctx context.Context, callback domain.SingleSignOnCallback) (*domain.LoginResponse, error) {
+	if !s.oidcConfig.Enabled {
+		return nil, domain.ErrSingleSignOnDisabled
+	}
+
+	signOn, err := s.ssoStateRepo.GetByStateHash(ctx, hashSecureToken(strings.TrimSpace(callback.State)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidSingleSignOn
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if signOn.UsedAt != nil || now.After(signOn.ExpiresAt) {
+		return nil, domain.ErrInvalidSingleSignOn
+	}
+	// A replayed callback must not redeem the code a second time
+	used, err := s.ssoStateRepo.MarkUsed(ctx, signOn.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, domain.ErrInvalidSingleSignOn
+	}
+
+	event := &domain.LoginEvent{
+		IPAddress: callback.IPAddress,
+		UserAgent: callback.UserAgent,
+	}
+
+	if callback.Error != "" {
+		log.Printf("Identity provider refused the sign-on: %s", callback.Error)
+		s.recordLogin(ctx, event, loginFailureSingleSignOnFailed)
+		return nil, fmt.Errorf("%w: %s", domain.ErrSingleSignOnFailed, callback.Error)
+	}
+	if callback.Code == "" {
+		return nil, domain.ErrInvalidSingleSignOn
+	}
+
+	identity, err := s.identityProvider.Exchange(ctx, callback.Code, signOn.CodeVerifier, signOn.Nonce)
+	if err != nil {
+		log.Printf("Single sign-on failed: %v", err)
+		s.recordLogin(ctx, event, loginFailureSingleSignOnFailed)
+		return nil, err
+	}
+	event.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+
+	role := s.roleForGroups(identity.Groups)
+	if role == "" {
+		s.recordLogin(ctx, event, loginFailureNoRoleForGroups)
+		return nil, domain.ErrNoRoleForGroups
+	}
+
+	user, err := s.ssoUser(ctx, identity, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrSingleSignOnConflict):
+			s.recordLogin(ctx, event, loginFailureSingleSignOnConflict)
+		case errors.Is(err, domain.ErrSingleSignOnFailed):
+			log.Printf("Single sign-on failed: %v", err)
+			s.recordLogin(ctx, event, loginFailureSingleSignOnFailed)
+		}
+		return nil, err
+	}
+	event.UserID = &user.ID
+	event.Email = user.Email
+
+	if !user.IsActive() {
+		s.recordLogin(ctx, event, loginFailureAccountInactive)
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	// The identity provider owns the role, a change of groups applies at the next sign-on
+	if user.Role != role {
+		log.Printf("Single sign-on changed the role of user %s from %s to %s", user.ID, user.Role, role)
+		user.Role = role
+		user.UpdatedAt = now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.clearFailures(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// The second factor is the identity provider's concern, a local one is not asked for
+	response, err := s.issueTokens(ctx, user, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordLogin(ctx, event, "")
+	return response, nil
+}
+
+// ssoUser finds the account of an external identity. An unknown subject is linked to the
+// staff account with the same verified email, or gets a new staff account.
+func (s *authService) ssoUser(ctx context.Context, identity *domain.ExternalIdentity, role domain.UserRole) (*domain.User, error) {
+	user, err := s.userRepo.GetByExternalSubject(ctx, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// An unverified address could claim somebody else's account
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		return nil, fmt.Errorf("%w: the identity provider has not verified the email address", domain.ErrSingleSignOnFailed)
+	}
+
+	now := time.Now()
+	subject := identity.Subject
+	user, err = s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		if !user.Role.IsStaff() || user.ServiceAccount || user.ExternalSubject != nil {
+			return nil, domain.ErrSingleSignOnConflict
+		}
+		log.Printf("Linked user %s to identity provider subject %s", user.ID, subject)
+		user.ExternalSubject = &subject
+		user.UpdatedAt = now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = &domain.User{
+		ID:              uuid.New(),
+		Email:           email,
+		Password:        unusablePassword,
+		Role:            role,
+		EmailVerifiedAt: &now,
+		ExternalSubject: &subject,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	log.Printf("Provisioned %s user %s through single sign-on", role, user.ID)
+	return user, nil
+}
+
+// roleForGroups returns the staff role of the first configured group the user belongs to
+func (s *authService) roleForGroups(groups []string) domain.UserRole {
+	for _, mapping := range s.oidcConfig.GroupRoles {
+		role := domain.UserRole(mapping.Role)
+		if !role.IsStaff() {
+			continue
+		}
+		for _, group := range groups {
+			if group == mapping.Group {
+				return role
+			}
+		}
+	}
+	return ""
+}
+
+// singleSignOnRequired tells whether the user must sign in at the identity provider instead of with a password
+func (s *authService) singleSignOnRequired(user *domain.User) bool {
+	return s.oidcConfig.Enabled && s.oidcConfig.RequiredForStaff && user.Role.IsStaff() && !user.ServiceAccount
+}
+
+// signsInWithIdentityProvider tells whether the identity provider vouches for the user's second factor
+func (s *authService) signsInWithIdentityProvider(user *domain.User) bool {
+	return s.oidcConfig.Enabled && user.ExternalSubject != nil
+}
+
+// pkceChallenge derives the S256 code challenge of a code verifier (RFC 7636 section 4.2)
+func pkceChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var testOIDCConfig = &config.OIDCConfig{
+	Enabled:     true,
+	ClientID:    "amf-loan-service",
+	GroupsClaim: "groups",
+	GroupRoles: []config.OIDCGroupRole{
+		{Group: "amf-admins", Role: "admin"},
+		{Group: "amf-credit-committee", Role: "credit_committee"},
+		{Group: "amf-credit-analysts", Role: "credit_analyst"},
+		{Group: "amf-customers", Role: "borrower"},
+	},
+	StateTTL:         10 * time.Minute,
+	RequiredForStaff: true,
+}
+
+// openSignOn is a sign-on started a minute ago that the callback can still complete
+func openSignOn() *domain.SingleSignOnState {
+	return &domain.SingleSignOnState{
+		ID:           uuid.New(),
+		StateHash:    hashSecureToken("state"),
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(9 * time.Minute),
+		CreatedAt:    time.Now().Add(-time.Minute),
+	}
+}
+
+// Test Single Sign-On Start - The State Is Stored Hashed And The Challenge Matches The Verifier
+func TestAuthService_StartSingleSignOn(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+	disabledService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	var challenge, nonce string
+	var stored *domain.SingleSignOnState
+	mockIdentityProvider.On("AuthorizationURL", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		nonce = args.String(2)
+		challenge = args.String(3)
+	}).Return("https://idp.example.com/authorize?client_id=amf-loan-service", nil)
+	mockSSOStateRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.SingleSignOnState")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.SingleSignOnState)
+	}).Return(nil)
+
+	// Act
+	start, err := authService.StartSingleSignOn(context.Background())
+	_, disabledErr := disabledService.StartSingleSignOn(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/authorize?client_id=amf-loan-service", start.AuthorizationURL)
+	assert.Len(t, start.State, 64)
+	assert.Equal(t, hashSecureToken(start.State), stored.StateHash)
+	assert.Equal(t, nonce, stored.Nonce)
+	assert.Equal(t, pkceChallenge(stored.CodeVerifier), challenge)
+	assert.NotEqual(t, stored.CodeVerifier, challenge)
+	assert.WithinDuration(t, time.Now().Add(testOIDCConfig.StateTTL), start.ExpiresAt, 5*time.Second)
+	assert.Equal(t, domain.ErrSingleSignOnDisabled, disabledErr)
+}
+
+// Test Single Sign-On - An Unknown Staff Member Is Provisioned With The Role Of Their First Mapped Group
+func TestAuthService_CompleteSingleSignOn_Provision(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+
+	signOn := openSignOn()
+	identity := &domain.ExternalIdentity{
+		Subject:       "idp-subject-1",
+		Email:         "New.Analyst@AMF.com",
+		EmailVerified: true,
+		Groups:        []string{"amf-everyone", "amf-credit-analysts", "amf-credit-committee"},
+	}
+
+	var created *domain.User
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, signOn.StateHash).Return(signOn, nil)
+	mockSSOStateRepo.On("MarkUsed", mock.Anything, signOn.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockIdentityProvider.On("Exchange", mock.Anything, "code", "verifier", "nonce").Return(identity, nil)
+	mockUserRepo.On("GetByExternalSubject", mock.Anything, "idp-subject-1").Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "new.analyst@amf.com").Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.User)
+	}).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	response, err := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "code", State: "state", IPAddress: "203.0.113.7"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, "new.analyst@amf.com", created.Email)
+	assert.Equal(t, domain.RoleCreditCommittee, created.Role)
+	assert.Equal(t, "idp-subject-1", *created.ExternalSubject)
+	assert.True(t, created.IsEmailVerified())
+	assert.Equal(t, unusablePassword, created.Password)
+	assert.Equal(t, created.ID, response.UserID)
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return event.Succeeded && *event.UserID == created.ID && event.IPAddress == "203.0.113.7"
+	}))
+	mockChallengeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Single Sign-On - A Staff Account Is Linked By Its Verified Email And Takes The Role From The Groups
+func TestAuthService_CompleteSingleSignOn_LinkExistingStaff(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+
+	signOn := openSignOn()
+	staff := &domain.User{
+		ID:       uuid.New(),
+		Email:    "analyst@amf.com",
+		Password: "$2a$10$legacy",
+		Role:     domain.RoleCreditAnalyst,
+	}
+	identity := &domain.ExternalIdentity{
+		Subject:       "idp-subject-2",
+		Email:         "analyst@amf.com",
+		EmailVerified: true,
+		Groups:        []string{"amf-admins"},
+	}
+
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, signOn.StateHash).Return(signOn, nil)
+	mockSSOStateRepo.On("MarkUsed", mock.Anything, signOn.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockIdentityProvider.On("Exchange", mock.Anything, "code", "verifier", "nonce").Return(identity, nil)
+	mockUserRepo.On("GetByExternalSubject", mock.Anything, "idp-subject-2").Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "analyst@amf.com").Return(staff, nil)
+	mockUserRepo.On("Update", mock.Anything, staff).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	response, err := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "code", State: "state"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, staff.ID, response.UserID)
+	assert.Equal(t, "idp-subject-2", *staff.ExternalSubject)
+	assert.Equal(t, domain.RoleAdmin, staff.Role)
+	mockUserRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Single Sign-On - Customer Accounts, Unverified Emails And Unmapped Groups Are Refused
+func TestAuthService_CompleteSingleSignOn_Refused(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+
+	borrower := &domain.User{ID: uuid.New(), Email: "john.doe@example.com", Role: domain.RoleBorrower}
+
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, mock.AnythingOfType("string")).Return(openSignOn(), nil)
+	mockSSOStateRepo.On("MarkUsed", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockIdentityProvider.On("Exchange", mock.Anything, "customer", "verifier", "nonce").Return(&domain.ExternalIdentity{
+		Subject: "idp-customer", Email: "john.doe@example.com", EmailVerified: true, Groups: []string{"amf-credit-analysts"},
+	}, nil)
+	mockIdentityProvider.On("Exchange", mock.Anything, "unverified", "verifier", "nonce").Return(&domain.ExternalIdentity{
+		Subject: "idp-unverified", Email: "analyst@amf.com", EmailVerified: false, Groups: []string{"amf-credit-analysts"},
+	}, nil)
+	mockIdentityProvider.On("Exchange", mock.Anything, "contractor", "verifier", "nonce").Return(&domain.ExternalIdentity{
+		Subject: "idp-contractor", Email: "contractor@amf.com", EmailVerified: true, Groups: []string{"amf-contractors", "amf-customers"},
+	}, nil)
+	mockUserRepo.On("GetByExternalSubject", mock.Anything, mock.AnythingOfType("string")).Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "john.doe@example.com").Return(borrower, nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, customerErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "customer", State: "state"})
+	_, unverifiedErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "unverified", State: "state"})
+	_, contractorErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "contractor", State: "state"})
+	_, deniedErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Error: "access_denied", State: "state"})
+
+	// Assert
+	assert.Equal(t, domain.ErrSingleSignOnConflict, customerErr)
+	assert.Nil(t, borrower.ExternalSubject)
+	assert.ErrorIs(t, unverifiedErr, domain.ErrSingleSignOnFailed)
+	assert.Equal(t, domain.ErrNoRoleForGroups, contractorErr)
+	assert.ErrorIs(t, deniedErr, domain.ErrSingleSignOnFailed)
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureSingleSignOnConflict
+	}))
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureNoRoleForGroups && event.Email == "contractor@amf.com"
+	}))
+	mockUserRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Single Sign-On - Unknown, Expired And Replayed States Are Rejected Before The Code Is Redeemed
+func TestAuthService_CompleteSingleSignOn_InvalidState(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+
+	expired := openSignOn()
+	expired.StateHash = hashSecureToken("expired")
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	replayed := openSignOn()
+	replayed.StateHash = hashSecureToken("replayed")
+
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, hashSecureToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, expired.StateHash).Return(expired, nil)
+	mockSSOStateRepo.On("GetByStateHash", mock.Anything, replayed.StateHash).Return(replayed, nil)
+	mockSSOStateRepo.On("MarkUsed", mock.Anything, replayed.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	// Act
+	_, unknownErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "code", State: "unknown"})
+	_, expiredErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "code", State: "expired"})
+	_, replayedErr := authService.CompleteSingleSignOn(context.Background(), domain.SingleSignOnCallback{Code: "code", State: "replayed"})
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidSingleSignOn, unknownErr)
+	assert.Equal(t, domain.ErrInvalidSingleSignOn, expiredErr)
+	assert.Equal(t, domain.ErrInvalidSingleSignOn, replayedErr)
+	mockIdentityProvider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Password Login - Staff Must Use Single Sign-On, Customers Keep Their Passwords
+func TestAuthService_Login_SingleSignOnRequired(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, testOIDCConfig)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	staff := &domain.User{ID: uuid.New(), Email: "analyst@amf.com", Password: string(hashedPassword), Role: domain.RoleCreditAnalyst}
+	borrower := &domain.User{ID: uuid.New(), Email: "john.doe@example.com", Password: string(hashedPassword), Role: domain.RoleBorrower}
+
+	mockUserRepo.On("GetByEmail", mock.Anything, staff.Email).Return(staff, nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, borrower.Email).Return(borrower, nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, staffErr := authService.Login(context.Background(), domain.LoginInput{Email: staff.Email, Password: "password"})
+	resetErr := authService.ForgotPassword(context.Background(), staff.Email)
+	response, borrowerErr := authService.Login(context.Background(), domain.LoginInput{Email: borrower.Email, Password: "password"})
+
+	// Assert
+	assert.Equal(t, domain.ErrSingleSignOnRequired, staffErr)
+	assert.NoError(t, resetErr)
+	assert.NoError(t, borrowerErr)
+	assert.Equal(t, borrower.ID, response.UserID)
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureSingleSignOnRequired && *event.UserID == staff.ID
+	}))
+	mockPasswordResetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockEmailSender.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// maxPasswordBytes is the longest password bcrypt hashes without truncating it
+const maxPasswordBytes = 72
+
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		// Unknown addresses get the same answer so accounts cannot be discovered
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// Staff who sign in at the identity provider have no password to reset here
+	if !user.IsActive() || user.ServiceAccount || s.singleSignOnRequired(user) {
+		return nil
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	reset := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashSecureToken(token),
+		ExpiresAt: time.Now().Add(s.passwordConfig.ResetTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := s.passwordResetRepo.Create(ctx, reset); err != nil {
+		return err
+	}
+
+	link := s.passwordConfig.ResetURL + "?token=" + url.QueryEscape(token)
+	if err := s.emailSender.SendPasswordReset(ctx, user.Email, link, reset.ExpiresAt); err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *authService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	reset, err := s.passwordResetRepo.GetByTokenHash(ctx, hashSecureToken(strings.TrimSpace(token)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidPasswordResetToken
+		}
+		return err
+	}
+
+	if reset.UsedAt != nil {
+		return domain.ErrInvalidPasswordResetToken
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		return domain.ErrPasswordResetTokenExpired
+	}
+	if err := checkPasswordPolicy(s.passwordConfig, newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidPasswordResetToken
+		}
+		return err
+	}
+	if !user.IsActive() {
+		return domain.ErrAccountDeactivated
+	}
+
+	// Only one of two concurrent resets with the same token may win
+	now := time.Now()
+	used, err := s.passwordResetRepo.MarkUsed(ctx, reset.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidPasswordResetToken
+	}
+
+	return s.replacePassword(ctx, user, newPassword)
+}
+
+func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword string, newPassword string) (*domain.LoginResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if currentPassword == newPassword {
+		return nil, domain.ErrPasswordUnchanged
+	}
+	if err := checkPasswordPolicy(s.passwordConfig, newPassword); err != nil {
+		return nil, err
+	}
+
+	if err := s.replacePassword(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	// The caller stays signed in on a new session, every other session ended with the old password
+	return s.issueTokens(ctx, user, uuid.New())
+}
+
+// replacePassword stores the new password, consumes outstanding reset links and ends every session
+func (s *authService) replacePassword(ctx context.Context, user *domain.User, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.Password = string(hashedPassword)
+	// Whoever proved they own the account is not held back by failures before the new password
+	user.FailedLoginCount = 0
+	user.LockedUntil = nil
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.passwordResetRepo.InvalidateByUserID(ctx, user.ID, now); err != nil {
+		return err
+	}
+
+	return s.RevokeAllSessions(ctx, user.ID)
+}
+
+// checkPasswordPolicy returns ErrWeakPassword, wrapped with the first rule the password breaks
+func checkPasswordPolicy(passwordConfig *config.PasswordConfig, password string) error {
+	if len([]rune(password)) < passwordConfig.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", domain.ErrWeakPassword, passwordConfig.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: use at most %d bytes", domain.ErrWeakPassword, maxPasswordBytes)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case passwordConfig.RequireUpper && !hasUpper:
+		return fmt.Errorf("%w: include an upper case letter", domain.ErrWeakPassword)
+	case passwordConfig.RequireLower && !hasLower:
+		return fmt.Errorf("%w: include a lower case letter", domain.ErrWeakPassword)
+	case passwordConfig.RequireDigit && !hasDigit:
+		return fmt.Errorf("%w: include a digit", domain.ErrWeakPassword)
+	case passwordConfig.RequireSymbol && !hasSymbol:
+		return fmt.Errorf("%w: include a symbol", domain.ErrWeakPassword)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Test Forgot Password - A Hashed Token Is Stored And Unknown Emails Get The Same Answer
+func TestAuthService_ForgotPassword(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	user := &domain.User{ID: uuid.New(), Email: "borrower1@example.com", Role: domain.RoleBorrower}
+
+	var stored *domain.PasswordResetToken
+	var link string
+	mockUserRepo.On("GetByEmail", mock.Anything, "borrower1@example.com").Return(user, nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockPasswordResetRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PasswordResetToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.PasswordResetToken)
+	}).Return(nil)
+	mockEmailSender.On("SendPasswordReset", mock.Anything, user.Email, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		link = args.String(2)
+	}).Return(nil)
+
+	// Act
+	err := authService.ForgotPassword(context.Background(), " Borrower1@Example.com ")
+	unknownErr := authService.ForgotPassword(context.Background(), "nobody@example.com")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, unknownErr)
+	assert.True(t, strings.HasPrefix(link, testPasswordConfig.ResetURL+"?token="))
+	token := strings.TrimPrefix(link, testPasswordConfig.ResetURL+"?token=")
+	assert.Equal(t, hashSecureToken(token), stored.TokenHash)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.WithinDuration(t, time.Now().Add(testPasswordConfig.ResetTTL), stored.ExpiresAt, time.Minute)
+	mockEmailSender.AssertNumberOfCalls(t, "SendPasswordReset", 1)
+}
+
+// Test Reset Password - The Token Is Consumed And Every Session Ends
+func TestAuthService_ResetPassword_Success(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	user := &domain.User{ID: uuid.New(), Email: "borrower1@example.com", Role: domain.RoleBorrower}
+	reset := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashSecureToken("reset-token"),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+
+	mockPasswordResetRepo.On("GetByTokenHash", mock.Anything, reset.TokenHash).Return(reset, nil)
+	mockPasswordResetRepo.On("MarkUsed", mock.Anything, reset.ID, mock.Anything).Return(true, nil)
+	mockPasswordResetRepo.On("InvalidateByUserID", mock.Anything, user.ID, mock.Anything).Return(nil)
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", mock.Anything, user).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	// Act
+	weakErr := authService.ResetPassword(context.Background(), "reset-token", "short")
+	err := authService.ResetPassword(context.Background(), "reset-token", "new-secret-42")
+
+	// Assert
+	assert.ErrorIs(t, weakErr, domain.ErrWeakPassword)
+	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-secret-42")))
+	assert.NotNil(t, user.SessionsRevokedAt)
+	mockPasswordResetRepo.AssertNumberOfCalls(t, "MarkUsed", 1)
+	mockRefreshTokenRepo.AssertExpectations(t)
+}
+
+// Test Reset Password - Used And Expired Tokens Are Refused
+func TestAuthService_ResetPassword_InvalidToken(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	usedAt := time.Now().Add(-time.Minute)
+	used := &domain.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), TokenHash: hashSecureToken("used-token"), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+	expired := &domain.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), TokenHash: hashSecureToken("expired-token"), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	mockPasswordResetRepo.On("GetByTokenHash", mock.Anything, used.TokenHash).Return(used, nil)
+	mockPasswordResetRepo.On("GetByTokenHash", mock.Anything, expired.TokenHash).Return(expired, nil)
+	mockPasswordResetRepo.On("GetByTokenHash", mock.Anything, hashSecureToken("unknown-token")).Return(nil, gorm.ErrRecordNotFound)
+
+	// Act
+	usedErr := authService.ResetPassword(context.Background(), "used-token", "new-secret-42")
+	expiredErr := authService.ResetPassword(context.Background(), "expired-token", "new-secret-42")
+	unknownErr := authService.ResetPassword(context.Background(), "unknown-token", "new-secret-42")
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidPasswordResetToken, usedErr)
+	assert.Equal(t, domain.ErrPasswordResetTokenExpired, expiredErr)
+	assert.Equal(t, domain.ErrInvalidPasswordResetToken, unknownErr)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// Test Change Password - The Current Password Is Required And A New Session Is Issued
+func TestAuthService_ChangePassword(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old-secret-1"), bcrypt.DefaultCost)
+	user := &domain.User{ID: uuid.New(), Email: "investor1@example.com", Password: string(hashedPassword), Role: domain.RoleInvestor}
+
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", mock.Anything, user).Return(nil)
+	mockPasswordResetRepo.On("InvalidateByUserID", mock.Anything, user.ID, mock.Anything).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, user.ID, mock.Anything).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+
+	// Act
+	_, wrongErr := authService.ChangePassword(context.Background(), user.ID, "not-my-password", "new-secret-42")
+	_, unchangedErr := authService.ChangePassword(context.Background(), user.ID, "old-secret-1", "old-secret-1")
+	response, err := authService.ChangePassword(context.Background(), user.ID, "old-secret-1", "new-secret-42")
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidCredentials, wrongErr)
+	assert.Equal(t, domain.ErrPasswordUnchanged, unchangedErr)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-secret-42")))
+	mockUserRepo.AssertNumberOfCalls(t, "Update", 2)
+	mockRefreshTokenRepo.AssertCalled(t, "RevokeByUserID", mock.Anything, user.ID, mock.Anything)
+}
+
+// Test Password Policy - Each Configured Rule Is Enforced
+func TestCheckPasswordPolicy(t *testing.T) {
+	strict := &config.PasswordConfig{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	assert.NoError(t, checkPasswordPolicy(strict, "Sup3r-secret"))
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "Sh0rt!"), domain.ErrWeakPassword)
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "sup3r-secret"), domain.ErrWeakPassword)
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "SUP3R-SECRET"), domain.ErrWeakPassword)
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "Super-secret"), domain.ErrWeakPassword)
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "Sup3rsecret"), domain.ErrWeakPassword)
+	assert.ErrorIs(t, checkPasswordPolicy(strict, "Sup3r-"+strings.Repeat("x", maxPasswordBytes)), domain.ErrWeakPassword)
+}
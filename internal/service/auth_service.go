@@ -2,7 +2,13 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,67 +20,347 @@ import (
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
+// secureTokenBytes is the amount of randomness in refresh and email verification tokens
+const secureTokenBytes = 32
+
 type authService struct {
-	userRepo     domain.UserRepository
-	borrowerRepo domain.BorrowerRepository
-	investorRepo domain.InvestorRepository
-	jwtConfig    *config.JWTConfig
+	userRepo            domain.UserRepository
+	borrowerRepo        domain.BorrowerRepository
+	investorRepo        domain.InvestorRepository
+	verificationRepo    domain.EmailVerificationRepository
+	refreshTokenRepo    domain.RefreshTokenRepository
+	revokedTokenRepo    domain.RevokedTokenRepository
+	loginEventRepo      domain.LoginEventRepository
+	passwordResetRepo   domain.PasswordResetRepository
+	challengeRepo       domain.TwoFactorChallengeRepository
+	recoveryCodeRepo    domain.RecoveryCodeRepository
+	ssoStateRepo        domain.SingleSignOnStateRepository
+	notificationService domain.NotificationService
+	emailSender         domain.EmailSender
+	identityProvider    domain.IdentityProvider
+	policy              domain.PermissionPolicy
+	tokenKeys           domain.TokenKeyService
+	jwtConfig           *config.JWTConfig
+	registrationConfig  *config.RegistrationConfig
+	passwordConfig      *config.PasswordConfig
+	twoFactorConfig     *config.TwoFactorConfig
+	lockoutConfig       *config.LockoutConfig
+	oidcConfig          *config.OIDCConfig
 }
 
 func NewAuthService(
 	userRepo domain.UserRepository,
 	borrowerRepo domain.BorrowerRepository,
 	investorRepo domain.InvestorRepository,
+	verificationRepo domain.EmailVerificationRepository,
+	refreshTokenRepo domain.RefreshTokenRepository,
+	revokedTokenRepo domain.RevokedTokenRepository,
+	loginEventRepo domain.LoginEventRepository,
+	passwordResetRepo domain.PasswordResetRepository,
+	challengeRepo domain.TwoFactorChallengeRepository,
+	recoveryCodeRepo domain.RecoveryCodeRepository,
+	ssoStateRepo domain.SingleSignOnStateRepository,
+	notificationService domain.NotificationService,
+	emailSender domain.EmailSender,
+	identityProvider domain.IdentityProvider,
+	policy domain.PermissionPolicy,
+	tokenKeys domain.TokenKeyService,
 	jwtConfig *config.JWTConfig,
+	registrationConfig *config.RegistrationConfig,
+	passwordConfig *config.PasswordConfig,
+	twoFactorConfig *config.TwoFactorConfig,
+	lockoutConfig *config.LockoutConfig,
+	oidcConfig *config.OIDCConfig,
 ) domain.AuthService {
 	return &authService{
-		userRepo:     userRepo,
-		borrowerRepo: borrowerRepo,
-		investorRepo: investorRepo,
-		jwtConfig:    jwtConfig,
+		userRepo:            userRepo,
+		borrowerRepo:        borrowerRepo,
+		investorRepo:        investorRepo,
+		verificationRepo:    verificationRepo,
+		refreshTokenRepo:    refreshTokenRepo,
+		revokedTokenRepo:    revokedTokenRepo,
+		loginEventRepo:      loginEventRepo,
+		passwordResetRepo:   passwordResetRepo,
+		challengeRepo:       challengeRepo,
+		recoveryCodeRepo:    recoveryCodeRepo,
+		ssoStateRepo:        ssoStateRepo,
+		notificationService: notificationService,
+		emailSender:         emailSender,
+		identityProvider:    identityProvider,
+		policy:              policy,
+		tokenKeys:           tokenKeys,
+		jwtConfig:           jwtConfig,
+		registrationConfig:  registrationConfig,
+		passwordConfig:      passwordConfig,
+		twoFactorConfig:     twoFactorConfig,
+		lockoutConfig:       lockoutConfig,
+		oidcConfig:          oidcConfig,
 	}
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (*domain.LoginResponse, error) {
-	user, err := s.userRepo.GetByEmail(ctx, email)
+// Reasons recorded for refused sign-in attempts
+const (
+	loginFailureUnknownEmail    = "unknown_email"
+	loginFailureInvalidPassword = "invalid_password"
+	loginFailureAccountInactive = "account_deactivated"
+	loginFailureTwoFactorCode   = "invalid_two_factor_code"
+)
+
+func (s *authService) Login(ctx context.Context, input domain.LoginInput) (*domain.LoginResponse, error) {
+	event := &domain.LoginEvent{
+		Email:     strings.ToLower(strings.TrimSpace(input.Email)),
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+	}
+
+	now := time.Now()
+	if err := s.checkClientThrottle(ctx, event.IPAddress, now); err != nil {
+		if errors.Is(err, domain.ErrLoginThrottled) {
+			s.recordLogin(ctx, event, loginFailureTooManyAttempts)
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, event.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordLogin(ctx, event, loginFailureUnknownEmail)
 			return nil, domain.ErrInvalidCredentials
 		}
 		return nil, err
 	}
+	event.UserID = &user.ID
+
+	// A locked account is refused before the password is checked, so guessing gains nothing
+	if err := checkAccountLock(user, s.lockoutConfig.Threshold, now); err != nil {
+		s.recordLogin(ctx, event, loginFailureAccountLocked)
+		return nil, err
+	}
+
+	// Staff sign in at the identity provider, their local password is never checked
+	if s.singleSignOnRequired(user) {
+		s.recordLogin(ctx, event, loginFailureSingleSignOnRequired)
+		return nil, domain.ErrSingleSignOnRequired
+	}
 
 	// Check password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
+		s.recordLogin(ctx, event, loginFailureInvalidPassword)
+		if err := s.registerFailure(ctx, user); err != nil {
+			return nil, err
+		}
 		return nil, domain.ErrInvalidCredentials
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user)
+	if !user.IsActive() {
+		s.recordLogin(ctx, event, loginFailureAccountInactive)
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	// The attempt is recorded once the second step completes
+	if user.TwoFactorEnabled() || s.requiresTwoFactor(user) {
+		return s.startChallenge(ctx, user, input)
+	}
+
+	if err := s.clearFailures(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Every login starts a new session
+	response, err := s.issueTokens(ctx, user, uuid.New())
 	if err != nil {
 		return nil, err
 	}
 
-	response := &domain.LoginResponse{
-		Token:     token,
-		UserID:    user.ID,
-		Email:     user.Email,
-		ExpiresAt: time.Now().Add(s.jwtConfig.Expiry),
+	s.recordLogin(ctx, event, "")
+	return response, nil
+}
+
+// recordLogin adds the attempt to the login history, an empty reason marks a successful login.
+// A failure is logged since the outcome of the attempt already stands.
+func (s *authService) recordLogin(ctx context.Context, event *domain.LoginEvent, failureReason string) {
+	event.ID = uuid.New()
+	event.Succeeded = failureReason == ""
+	event.Reason = failureReason
+	event.CreatedAt = time.Now()
+	if err := s.loginEventRepo.Create(ctx, event); err != nil {
+		log.Printf("Failed to record login attempt for %s: %v", event.Email, err)
+	}
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*domain.LoginResponse, error) {
+	current, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashSecureToken(strings.TrimSpace(refreshToken)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if current.RevokedAt != nil {
+		// A rotated token coming back means it was copied, end the session for both holders
+		if current.ReplacedByID != nil {
+			log.Printf("Refresh token reuse detected for user %s, revoking session %s", current.UserID, current.FamilyID)
+			if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID, now); err != nil {
+				return nil, err
+			}
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if now.After(current.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
 	}
 
-	// Borrower and Investor fields removed from LoginResponse, so skip setting them
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrAccountDeactivated
+	}
+	// Sessions from before two-factor authentication became mandatory end here
+	if s.requiresTwoFactor(user) && !user.TwoFactorEnabled() && !s.signsInWithIdentityProvider(user) {
+		return nil, domain.ErrTwoFactorEnrolmentRequired
+	}
+
+	response, next, err := s.newTokens(ctx, user, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one of two concurrent refreshes with the same token may win, the session never ends up without a token
+	rotated, err := s.refreshTokenRepo.Rotate(ctx, current.ID, next, now)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, domain.ErrInvalidRefreshToken
+	}
 
 	return response, nil
 }
 
+func (s *authService) Logout(ctx context.Context, accessToken string, refreshToken string) error {
+	claims, err := s.parseToken(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.revokedTokenRepo.Create(ctx, &domain.RevokedToken{
+		JTI:       claims.jti,
+		UserID:    claims.userID,
+		ExpiresAt: claims.expiresAt,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	session, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashSecureToken(strings.TrimSpace(refreshToken)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidRefreshToken
+		}
+		return err
+	}
+	if session.UserID != claims.userID {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, session.FamilyID, time.Now())
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+
+	now := time.Now()
+	if err := s.refreshTokenRepo.RevokeByUserID(ctx, userID, now); err != nil {
+		return err
+	}
+
+	user.SessionsRevokedAt = &now
+	user.UpdatedAt = now
+	return s.userRepo.Update(ctx, user)
+}
+
 func (s *authService) ValidateToken(tokenString string) (*domain.User, error) {
+	ctx := context.Background()
+	claims, err := s.parseToken(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(ctx, claims.jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if !user.IsActive() {
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	// iat has second precision, a token from the same second as the revocation is still accepted
+	if user.SessionsRevokedAt != nil && claims.issuedAt.Unix() < user.SessionsRevokedAt.Unix() {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return user, nil
+}
+
+// accessClaims are the claims of a verified access token the service relies on
+type accessClaims struct {
+	jti       uuid.UUID
+	userID    uuid.UUID
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// parseToken verifies the signature and registered claims of an access token and extracts its claims
+func (s *authService) parseToken(ctx context.Context, tokenString string) (*accessClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, domain.ErrInvalidToken
+		}
+		key, err := s.tokenKeys.VerificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		// The key decides the algorithm, not the token header
+		if token.Method.Alg() != key.Algorithm {
 			return nil, domain.ErrInvalidToken
 		}
-		return []byte(s.jwtConfig.Secret), nil
-	})
+		return key.Signer.Public(), nil
+	},
+		jwt.WithValidMethods([]string{domain.SigningAlgorithmRS256, domain.SigningAlgorithmEdDSA}),
+		jwt.WithIssuer(s.jwtConfig.Issuer),
+		jwt.WithAudience(s.jwtConfig.Audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(s.jwtConfig.Leeway),
+	)
 
 	if err != nil {
 		return nil, domain.ErrInvalidToken
@@ -95,22 +381,331 @@ func (s *authService) ValidateToken(tokenString string) (*domain.User, error) {
 		return nil, domain.ErrInvalidToken
 	}
 
-	user, err := s.userRepo.GetByID(context.Background(), userID)
+	// Tokens without a jti cannot be revoked and are refused
+	jtiStr, ok := claims["jti"].(string)
+	if !ok {
+		return nil, domain.ErrInvalidToken
+	}
+
+	jti, err := uuid.Parse(jtiStr)
 	if err != nil {
-		return nil, domain.ErrUserNotFound
+		return nil, domain.ErrInvalidToken
 	}
 
-	return user, nil
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// The parser only checks nbf when it is present
+	notBefore, err := claims.GetNotBefore()
+	if err != nil || notBefore == nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return &accessClaims{
+		jti:       jti,
+		userID:    userID,
+		issuedAt:  issuedAt.Time,
+		expiresAt: expiresAt.Time,
+	}, nil
 }
 
-func (s *authService) generateToken(user *domain.User) (string, error) {
+func (s *authService) RegisterBorrower(ctx context.Context, input domain.RegistrationInput) (*domain.Borrower, error) {
+	input = normalizeRegistration(input)
+
+	if err := checkPasswordPolicy(s.passwordConfig, input.Password); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkEmailAvailable(ctx, input.Email); err != nil {
+		return nil, err
+	}
+	if _, err := s.borrowerRepo.GetByIdentityNumber(ctx, input.IdentityNumber); err == nil {
+		return nil, domain.ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user, err := newCustomerUser(input, domain.RoleBorrower)
+	if err != nil {
+		return nil, err
+	}
+
+	borrower := &domain.Borrower{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		FullName:       input.FullName,
+		PhoneNumber:    input.PhoneNumber,
+		Address:        input.Address,
+		IdentityNumber: input.IdentityNumber,
+		Region:         input.Region,
+		Latitude:       input.Latitude,
+		Longitude:      input.Longitude,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.CreatedAt,
+		User:           *user,
+	}
+	if err := s.borrowerRepo.CreateWithUser(ctx, borrower); err != nil {
+		return nil, err
+	}
+
+	s.sendVerification(ctx, &borrower.User)
+
+	return borrower, nil
+}
+
+func (s *authService) RegisterInvestor(ctx context.Context, input domain.RegistrationInput) (*domain.Investor, error) {
+	input = normalizeRegistration(input)
+
+	if err := checkPasswordPolicy(s.passwordConfig, input.Password); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkEmailAvailable(ctx, input.Email); err != nil {
+		return nil, err
+	}
+	if _, err := s.investorRepo.GetByIdentityNumber(ctx, input.IdentityNumber); err == nil {
+		return nil, domain.ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user, err := newCustomerUser(input, domain.RoleInvestor)
+	if err != nil {
+		return nil, err
+	}
+
+	investor := &domain.Investor{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		FullName:       input.FullName,
+		PhoneNumber:    input.PhoneNumber,
+		Address:        input.Address,
+		IdentityNumber: input.IdentityNumber,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.CreatedAt,
+		User:           *user,
+	}
+	if err := s.investorRepo.CreateWithUser(ctx, investor); err != nil {
+		return nil, err
+	}
+
+	s.sendVerification(ctx, &investor.User)
+
+	return investor, nil
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	verification, err := s.verificationRepo.GetByTokenHash(ctx, hashSecureToken(strings.TrimSpace(token)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidVerificationToken
+		}
+		return err
+	}
+
+	if verification.UsedAt != nil {
+		return domain.ErrInvalidVerificationToken
+	}
+	if time.Now().After(verification.ExpiresAt) {
+		return domain.ErrVerificationTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, verification.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+
+	now := time.Now()
+	if !user.IsEmailVerified() {
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	verification.UsedAt = &now
+	return s.verificationRepo.Update(ctx, verification)
+}
+
+func (s *authService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		// Unknown addresses are not revealed to the caller
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	return s.issueVerification(ctx, user)
+}
+
+func (s *authService) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return domain.ErrEmailExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// sendVerification issues the first verification link of a new account, a failure is logged
+// since the account exists and the customer can ask for a new link
+func (s *authService) sendVerification(ctx context.Context, user *domain.User) {
+	if err := s.issueVerification(ctx, user); err != nil {
+		log.Printf("Failed to send email verification to user %s: %v", user.ID, err)
+	}
+}
+
+func (s *authService) issueVerification(ctx context.Context, user *domain.User) error {
+	token, err := generateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	verification := &domain.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashSecureToken(token),
+		ExpiresAt: time.Now().Add(s.registrationConfig.VerificationTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return err
+	}
+
+	link := s.registrationConfig.VerificationURL + "?token=" + url.QueryEscape(token)
+	return s.notificationService.SendEmailVerification(ctx, user, link, verification.ExpiresAt)
+}
+
+// newCustomerUser builds the unverified login of a self-registered customer
+func newCustomerUser(input domain.RegistrationInput, role domain.UserRole) (*domain.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &domain.User{
+		ID:        uuid.New(),
+		Email:     input.Email,
+		Password:  string(hashedPassword),
+		Role:      role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func normalizeRegistration(input domain.RegistrationInput) domain.RegistrationInput {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.FullName = strings.TrimSpace(input.FullName)
+	input.PhoneNumber = strings.TrimSpace(input.PhoneNumber)
+	input.Address = strings.TrimSpace(input.Address)
+	input.IdentityNumber = strings.TrimSpace(input.IdentityNumber)
+	input.Region = strings.TrimSpace(input.Region)
+	return input
+}
+
+// generateSecureToken returns a random URL safe token
+func generateSecureToken() (string, error) {
+	buf := make([]byte, secureTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashSecureToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens stores a new refresh token in the session family and returns it with a fresh access token
+func (s *authService) issueTokens(ctx context.Context, user *domain.User, familyID uuid.UUID) (*domain.LoginResponse, error) {
+	response, refresh, err := s.newTokens(ctx, user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// newTokens generates an access token and an unsaved refresh token for the session family
+func (s *authService) newTokens(ctx context.Context, user *domain.User, familyID uuid.UUID) (*domain.LoginResponse, *domain.RefreshToken, error) {
+	now := time.Now()
+
+	accessToken, err := s.generateToken(ctx, user, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := generateSecureToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh := &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashSecureToken(refreshToken),
+		ExpiresAt: now.Add(s.jwtConfig.RefreshTTL),
+		CreatedAt: now,
+	}
+
+	response := &domain.LoginResponse{
+		Token:            accessToken,
+		UserID:           user.ID,
+		Email:            user.Email,
+		ExpiresAt:        now.Add(s.jwtConfig.Expiry),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}
+
+	return response, refresh, nil
+}
+
+// generateToken signs an access token with the active key, its kid lets verifiers pick the key from the JWKS
+func (s *authService) generateToken(ctx context.Context, user *domain.User, now time.Time) (string, error) {
+	key, err := s.tokenKeys.SigningKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
 	claims := jwt.MapClaims{
+		"jti":     uuid.New().String(),
+		"iss":     s.jwtConfig.Issuer,
+		"aud":     s.jwtConfig.Audience,
 		"user_id": user.ID.String(),
 		"email":   user.Email,
 		"role":    user.Role,
-		"exp":     time.Now().Add(s.jwtConfig.Expiry).Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     now.Add(s.jwtConfig.Expiry).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtConfig.Secret))
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Signer)
 }
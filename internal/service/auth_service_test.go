@@ -2,15 +2,19 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // Mock repositories
@@ -31,6 +35,14 @@ func (m *mockUserRepository) GetByEmail(ctx context.Context, email string) (*dom
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *mockUserRepository) GetByExternalSubject(ctx context.Context, subject string) (*domain.User, error) {
+	args := m.Called(ctx, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
 func (m *mockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -39,12 +51,37 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *mockUserRepository) GetByRole(ctx context.Context, role domain.UserRole) ([]domain.User, error) {
+	args := m.Called(ctx, role)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *mockUserRepository) List(ctx context.Context, role domain.UserRole, limit, offset int) ([]domain.User, int64, error) {
+	args := m.Called(ctx, role, limit, offset)
+	return args.Get(0).([]domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockUserRepository) GetServiceAccounts(ctx context.Context) ([]domain.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
 func (m *mockUserRepository) Update(ctx context.Context, user *domain.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *mockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *mockUserRepository) IncrementFailedLogins(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockUserRepository) SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	args := m.Called(ctx, id, until)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
@@ -74,6 +111,19 @@ func (m *mockBorrowerRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.Borrower), args.Error(1)
 }
 
+func (m *mockBorrowerRepository) GetByIdentityNumber(ctx context.Context, identityNumber string) (*domain.Borrower, error) {
+	args := m.Called(ctx, identityNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Borrower), args.Error(1)
+}
+
+func (m *mockBorrowerRepository) CreateWithUser(ctx context.Context, borrower *domain.Borrower) error {
+	args := m.Called(ctx, borrower)
+	return args.Error(0)
+}
+
 func (m *mockBorrowerRepository) Update(ctx context.Context, borrower *domain.Borrower) error {
 	args := m.Called(ctx, borrower)
 	return args.Error(0)
@@ -104,24 +154,301 @@ func (m *mockInvestorRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 	return args.Get(0).(*domain.Investor), args.Error(1)
 }
 
+func (m *mockInvestorRepository) GetByIdentityNumber(ctx context.Context, identityNumber string) (*domain.Investor, error) {
+	args := m.Called(ctx, identityNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Investor), args.Error(1)
+}
+
+func (m *mockInvestorRepository) CreateWithUser(ctx context.Context, investor *domain.Investor) error {
+	args := m.Called(ctx, investor)
+	return args.Error(0)
+}
+
 func (m *mockInvestorRepository) Update(ctx context.Context, investor *domain.Investor) error {
 	args := m.Called(ctx, investor)
 	return args.Error(0)
 }
 
+type mockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *mockEmailVerificationRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockEmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailVerificationToken), args.Error(1)
+}
+
+func (m *mockEmailVerificationRepository) Update(ctx context.Context, token *domain.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+type mockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *mockRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *mockRefreshTokenRepository) Rotate(ctx context.Context, id uuid.UUID, successor *domain.RefreshToken, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, successor, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error {
+	args := m.Called(ctx, familyID, now)
+	return args.Error(0)
+}
+
+func (m *mockRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+type mockRevokedTokenRepository struct {
+	mock.Mock
+}
+
+func (m *mockRevokedTokenRepository) Create(ctx context.Context, token *domain.RevokedToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockRevokedTokenRepository) IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockLoginEventRepository struct {
+	mock.Mock
+}
+
+func (m *mockLoginEventRepository) Create(ctx context.Context, event *domain.LoginEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *mockLoginEventRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]domain.LoginEvent, error) {
+	args := m.Called(ctx, userID, limit)
+	return args.Get(0).([]domain.LoginEvent), args.Error(1)
+}
+
+func (m *mockLoginEventRepository) Search(ctx context.Context, filter domain.LoginEventFilter, limit int) ([]domain.LoginEvent, error) {
+	args := m.Called(ctx, filter, limit)
+	return args.Get(0).([]domain.LoginEvent), args.Error(1)
+}
+
+func (m *mockLoginEventRepository) FailureStatsByIP(ctx context.Context, ipAddress string, reasons []string, since time.Time) (*domain.LoginFailureStats, error) {
+	args := m.Called(ctx, ipAddress, reasons, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginFailureStats), args.Error(1)
+}
+
+type mockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *mockPasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockPasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PasswordResetToken), args.Error(1)
+}
+
+func (m *mockPasswordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockPasswordResetRepository) InvalidateByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+type mockEmailSender struct {
+	mock.Mock
+}
+
+func (m *mockEmailSender) SendPasswordReset(ctx context.Context, to string, link string, expiresAt time.Time) error {
+	args := m.Called(ctx, to, link, expiresAt)
+	return args.Error(0)
+}
+
+type mockTwoFactorChallengeRepository struct {
+	mock.Mock
+}
+
+func (m *mockTwoFactorChallengeRepository) Create(ctx context.Context, challenge *domain.TwoFactorChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *mockTwoFactorChallengeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.TwoFactorChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *mockTwoFactorChallengeRepository) RecordFailedAttempt(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockTwoFactorChallengeRepository) MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *mockRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error {
+	args := m.Called(ctx, userID, codes)
+	return args.Error(0)
+}
+
+func (m *mockRecoveryCodeRepository) MarkUsed(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	args := m.Called(ctx, userID, codeHash, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type mockSingleSignOnStateRepository struct {
+	mock.Mock
+}
+
+func (m *mockSingleSignOnStateRepository) Create(ctx context.Context, state *domain.SingleSignOnState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *mockSingleSignOnStateRepository) GetByStateHash(ctx context.Context, stateHash string) (*domain.SingleSignOnState, error) {
+	args := m.Called(ctx, stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SingleSignOnState), args.Error(1)
+}
+
+func (m *mockSingleSignOnStateRepository) MarkUsed(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockIdentityProvider struct {
+	mock.Mock
+}
+
+func (m *mockIdentityProvider) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	args := m.Called(ctx, state, nonce, codeChallenge)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockIdentityProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalIdentity, error) {
+	args := m.Called(ctx, code, codeVerifier, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ExternalIdentity), args.Error(1)
+}
+
+var testTwoFactorConfig = &config.TwoFactorConfig{
+	Issuer:        "AMF Loan Service",
+	RequiredRoles: []string{"field_officer", "field_validator", "admin"},
+	ChallengeTTL:  5 * time.Minute,
+	MaxAttempts:   5,
+	RecoveryCodes: 10,
+}
+
+var testLockoutConfig = &config.LockoutConfig{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	Threshold:       10,
+	LockoutDuration: 15 * time.Minute,
+	IPFreeAttempts:  10,
+	IPThreshold:     30,
+	IPWindow:        15 * time.Minute,
+}
+
+var testPasswordConfig = &config.PasswordConfig{
+	MinLength:    10,
+	RequireLower: true,
+	RequireDigit: true,
+	ResetTTL:     30 * time.Minute,
+	ResetURL:     "http://localhost:3000/reset-password",
+}
+
+var testRegistrationConfig = &config.RegistrationConfig{
+	VerificationTTL: 24 * time.Hour,
+	VerificationURL: "http://localhost:8080/api/auth/verify-email",
+}
+
+// verifiedUser is the login of a customer who already confirmed their email address and passed KYC
+func verifiedUser(id uuid.UUID) domain.User {
+	verifiedAt := time.Now().Add(-time.Hour)
+	return domain.User{ID: id, EmailVerifiedAt: &verifiedAt, KYCVerifiedAt: &verifiedAt}
+}
+
 // Test AuthService Login - Happy Flow
 func TestAuthService_Login_Success(t *testing.T) {
 	// Arrange
 	mockUserRepo := new(mockUserRepository)
 	mockBorrowerRepo := new(mockBorrowerRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
 
 	jwtConfig := &config.JWTConfig{
-		Secret: "test-secret",
 		Expiry: time.Hour,
 	}
 
-	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, jwtConfig)
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, jwtConfig, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
 
 	userID := uuid.New()
 	email := "test@example.com"
@@ -136,12 +463,17 @@ func TestAuthService_Login_Success(t *testing.T) {
 	}
 
 	mockUserRepo.On("GetByEmail", mock.Anything, email).Return(user, nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return event.Succeeded && event.UserID != nil && *event.UserID == userID
+	})).Return(nil)
 
 	// Act
-	response, err := authService.Login(context.Background(), email, "password")
+	response, err := authService.Login(context.Background(), domain.LoginInput{Email: email, Password: "password"})
 
 	// Assert
 	assert.NoError(t, err)
+	assert.NotEmpty(t, response.RefreshToken)
 	assert.NotNil(t, response)
 	assert.Equal(t, userID, response.UserID)
 	assert.Equal(t, email, response.Email)
@@ -149,6 +481,7 @@ func TestAuthService_Login_Success(t *testing.T) {
 	assert.True(t, response.ExpiresAt.After(time.Now()))
 
 	mockUserRepo.AssertExpectations(t)
+	mockLoginEventRepo.AssertExpectations(t)
 }
 
 func TestAuthService_Login_UserNotFound(t *testing.T) {
@@ -156,20 +489,30 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 	mockUserRepo := new(mockUserRepository)
 	mockBorrowerRepo := new(mockBorrowerRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
 
 	jwtConfig := &config.JWTConfig{
-		Secret: "test-secret",
 		Expiry: time.Hour,
 	}
 
-	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, jwtConfig)
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, jwtConfig, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
 
 	email := "nonexistent@example.com"
 
 	mockUserRepo.On("GetByEmail", mock.Anything, email).Return(nil, domain.ErrInvalidCredentials)
 
 	// Act
-	response, err := authService.Login(context.Background(), email, "password")
+	response, err := authService.Login(context.Background(), domain.LoginInput{Email: email, Password: "password"})
 
 	// Assert
 	assert.Error(t, err)
@@ -178,3 +521,424 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 
 	mockUserRepo.AssertExpectations(t)
 }
+
+// Test AuthService Login - Deactivated Accounts Are Refused And Recorded
+func TestAuthService_Login_Deactivated(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	deactivatedAt := time.Now().Add(-time.Hour)
+	user := &domain.User{
+		ID:            uuid.New(),
+		Email:         "officer@amf.com",
+		Password:      string(hashedPassword),
+		Role:          domain.RoleFieldOfficer,
+		DeactivatedAt: &deactivatedAt,
+	}
+
+	mockLoginEventRepo.On("FailureStatsByIP", mock.Anything, "203.0.113.7", mock.Anything, mock.Anything).Return(&domain.LoginFailureStats{}, nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == "account_deactivated" && event.IPAddress == "203.0.113.7"
+	})).Return(nil)
+
+	// Act
+	response, err := authService.Login(context.Background(), domain.LoginInput{
+		Email:     "Officer@AMF.com",
+		Password:  "password",
+		IPAddress: "203.0.113.7",
+	})
+
+	// Assert
+	assert.Nil(t, response)
+	assert.Equal(t, domain.ErrAccountDeactivated, err)
+	mockLoginEventRepo.AssertExpectations(t)
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Borrower Registration - Unverified Account And Verification Email
+func TestAuthService_RegisterBorrower_Success(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	input := domain.RegistrationInput{
+		Email:          " New.Borrower@Example.com ",
+		Password:       "s3cret-pass",
+		FullName:       "New Borrower",
+		PhoneNumber:    "+1234567899",
+		Address:        "1 Market St, New York, NY",
+		IdentityNumber: "B009999999",
+		Region:         "NY",
+	}
+
+	var stored *domain.EmailVerificationToken
+	var link string
+	mockUserRepo.On("GetByEmail", mock.Anything, "new.borrower@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockBorrowerRepo.On("GetByIdentityNumber", mock.Anything, "B009999999").Return(nil, gorm.ErrRecordNotFound)
+	mockBorrowerRepo.On("CreateWithUser", mock.Anything, mock.AnythingOfType("*domain.Borrower")).Return(nil)
+	mockVerificationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.EmailVerificationToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.EmailVerificationToken)
+	}).Return(nil)
+	mockNotificationService.On("SendEmailVerification", mock.Anything, mock.AnythingOfType("*domain.User"), mock.AnythingOfType("string"), mock.Anything).Run(func(args mock.Arguments) {
+		link = args.String(2)
+	}).Return(nil)
+
+	// Act
+	borrower, err := authService.RegisterBorrower(context.Background(), input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "new.borrower@example.com", borrower.User.Email)
+	assert.Equal(t, domain.RoleBorrower, borrower.User.Role)
+	assert.Equal(t, borrower.User.ID, borrower.UserID)
+	assert.False(t, borrower.User.IsEmailVerified())
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(borrower.User.Password), []byte("s3cret-pass")))
+
+	// Only the hash of the emailed token is stored
+	assert.Equal(t, borrower.User.ID, stored.UserID)
+	token := strings.TrimPrefix(link, testRegistrationConfig.VerificationURL+"?token=")
+	assert.NotEqual(t, link, token)
+	assert.NotEqual(t, token, stored.TokenHash)
+	assert.Equal(t, hashSecureToken(token), stored.TokenHash)
+}
+
+// Test Investor Registration - Taken Email Or Identity Number Is Rejected
+func TestAuthService_RegisterInvestor_Duplicate(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	takenEmail := domain.RegistrationInput{Email: "investor1@example.com", Password: "s3cret-pass", IdentityNumber: "I000000001"}
+	takenIdentity := domain.RegistrationInput{Email: "fresh@example.com", Password: "s3cret-pass", IdentityNumber: "I001234567"}
+
+	mockUserRepo.On("GetByEmail", mock.Anything, "investor1@example.com").Return(&domain.User{ID: uuid.New()}, nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, "fresh@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockInvestorRepo.On("GetByIdentityNumber", mock.Anything, "I001234567").Return(&domain.Investor{ID: uuid.New()}, nil)
+
+	// Act
+	_, emailErr := authService.RegisterInvestor(context.Background(), takenEmail)
+	_, identityErr := authService.RegisterInvestor(context.Background(), takenIdentity)
+
+	// Assert
+	assert.Equal(t, domain.ErrEmailExists, emailErr)
+	assert.Equal(t, domain.ErrEmailExists, identityErr)
+	mockInvestorRepo.AssertNotCalled(t, "CreateWithUser", mock.Anything, mock.Anything)
+	mockNotificationService.AssertNotCalled(t, "SendEmailVerification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Email Verification - Valid Links Verify Once, Expired Links Are Refused
+func TestAuthService_VerifyEmail(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	user := &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}
+	valid := &domain.EmailVerificationToken{ID: uuid.New(), UserID: user.ID, TokenHash: hashSecureToken("valid-token"), ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &domain.EmailVerificationToken{ID: uuid.New(), UserID: user.ID, TokenHash: hashSecureToken("old-token"), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	mockVerificationRepo.On("GetByTokenHash", mock.Anything, valid.TokenHash).Return(valid, nil)
+	mockVerificationRepo.On("GetByTokenHash", mock.Anything, expired.TokenHash).Return(expired, nil)
+	mockVerificationRepo.On("Update", mock.Anything, valid).Return(nil)
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", mock.Anything, user).Return(nil)
+
+	// Act
+	expiredErr := authService.VerifyEmail(context.Background(), "old-token")
+	err := authService.VerifyEmail(context.Background(), "valid-token")
+	reusedErr := authService.VerifyEmail(context.Background(), "valid-token")
+
+	// Assert
+	assert.Equal(t, domain.ErrVerificationTokenExpired, expiredErr)
+	assert.NoError(t, err)
+	assert.True(t, user.IsEmailVerified())
+	assert.NotNil(t, valid.UsedAt)
+	assert.Equal(t, domain.ErrInvalidVerificationToken, reusedErr)
+	mockUserRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+// Test Token Refresh - The Refresh Token Is Rotated Within Its Session
+func TestAuthService_Refresh_RotatesToken(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	user := &domain.User{ID: uuid.New(), Email: "investor1@example.com", Role: domain.RoleInvestor}
+	current := &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashSecureToken("current-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	var next *domain.RefreshToken
+	mockRefreshTokenRepo.On("GetByTokenHash", mock.Anything, current.TokenHash).Return(current, nil)
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockRefreshTokenRepo.On("Rotate", mock.Anything, current.ID, mock.AnythingOfType("*domain.RefreshToken"), mock.Anything).Run(func(args mock.Arguments) {
+		next = args.Get(2).(*domain.RefreshToken)
+	}).Return(true, nil)
+	mockRevokedTokenRepo.On("IsRevoked", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(false, nil)
+
+	// Act
+	response, err := authService.Refresh(context.Background(), "current-token")
+	validated, validateErr := authService.ValidateToken(response.Token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEqual(t, "current-token", response.RefreshToken)
+	assert.Equal(t, hashSecureToken(response.RefreshToken), next.TokenHash)
+	assert.Equal(t, current.FamilyID, next.FamilyID)
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.NoError(t, validateErr)
+	assert.Equal(t, user.ID, validated.ID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), response.ExpiresAt, time.Minute)
+}
+
+// Test Token Refresh - Replaying A Rotated Token Revokes The Whole Session
+func TestAuthService_Refresh_ReuseRevokesSession(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	rotatedAt := time.Now().Add(-time.Minute)
+	successorID := uuid.New()
+	stolen := &domain.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		FamilyID:     uuid.New(),
+		TokenHash:    hashSecureToken("stolen-token"),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		RevokedAt:    &rotatedAt,
+		ReplacedByID: &successorID,
+	}
+
+	mockRefreshTokenRepo.On("GetByTokenHash", mock.Anything, stolen.TokenHash).Return(stolen, nil)
+	mockRefreshTokenRepo.On("RevokeFamily", mock.Anything, stolen.FamilyID, mock.Anything).Return(nil)
+
+	// Act
+	response, err := authService.Refresh(context.Background(), "stolen-token")
+
+	// Assert
+	assert.Nil(t, response)
+	assert.Equal(t, domain.ErrInvalidRefreshToken, err)
+	mockRefreshTokenRepo.AssertCalled(t, "RevokeFamily", mock.Anything, stolen.FamilyID, mock.Anything)
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Token Revocation - Logged Out And Pre-Revocation Tokens Are Refused
+func TestAuthService_ValidateToken_Revoked(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	jwtConfig := &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}
+	issuer := &authService{tokenKeys: testTokenKeys, jwtConfig: jwtConfig}
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, jwtConfig, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	user := &domain.User{ID: uuid.New(), Email: "borrower1@example.com", Role: domain.RoleBorrower}
+	loggedOut, err := issuer.generateToken(context.Background(), user, time.Now())
+	assert.NoError(t, err)
+	beforeRevocation, err := issuer.generateToken(context.Background(), user, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+
+	var revoked *domain.RevokedToken
+	mockRevokedTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RevokedToken")).Run(func(args mock.Arguments) {
+		revoked = args.Get(1).(*domain.RevokedToken)
+	}).Return(nil)
+	mockRevokedTokenRepo.On("IsRevoked", mock.Anything, mock.MatchedBy(func(jti uuid.UUID) bool {
+		return revoked != nil && revoked.JTI == jti
+	})).Return(true, nil)
+	mockRevokedTokenRepo.On("IsRevoked", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(false, nil)
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockUserRepo.On("Update", mock.Anything, user).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	// Act
+	logoutErr := authService.Logout(context.Background(), loggedOut, "")
+	_, loggedOutErr := authService.ValidateToken(loggedOut)
+	_, activeErr := authService.ValidateToken(beforeRevocation)
+	revokeAllErr := authService.RevokeAllSessions(context.Background(), user.ID)
+	_, revokedAllErr := authService.ValidateToken(beforeRevocation)
+
+	// Assert
+	assert.NoError(t, logoutErr)
+	assert.Equal(t, user.ID, revoked.UserID)
+	assert.Equal(t, domain.ErrInvalidToken, loggedOutErr)
+	assert.NoError(t, activeErr)
+	assert.NoError(t, revokeAllErr)
+	assert.Equal(t, domain.ErrInvalidToken, revokedAllErr)
+	mockRefreshTokenRepo.AssertCalled(t, "RevokeByUserID", mock.Anything, user.ID, mock.Anything)
+}
+
+// Test Token Validation - Signature, Key And Registered Claims Are Checked
+func TestAuthService_ValidateToken_Claims(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	jwtConfig := &config.JWTConfig{Issuer: "amf-loan-service", Audience: "amf-loan-service", Expiry: 15 * time.Minute}
+	rsaKeys := newStaticTokenKeys(domain.SigningAlgorithmRS256)
+	user := &domain.User{ID: uuid.New(), Email: "investor1@example.com", Role: domain.RoleInvestor}
+	now := time.Now()
+	issue := func(tokenKeys domain.TokenKeyService, issuer string, audience string) string {
+		token, err := (&authService{tokenKeys: tokenKeys, jwtConfig: &config.JWTConfig{Issuer: issuer, Audience: audience, Expiry: 15 * time.Minute}}).generateToken(context.Background(), user, now)
+		assert.NoError(t, err)
+		return token
+	}
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, jwtConfig, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+	rsaAuthService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, rsaKeys, jwtConfig, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	signingKey, err := testTokenKeys.SigningKey(context.Background())
+	assert.NoError(t, err)
+
+	// An HMAC token keyed with the published public key must not pass as EdDSA
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti": uuid.New().String(), "iss": "amf-loan-service", "aud": "amf-loan-service", "user_id": user.ID.String(),
+		"iat": now.Unix(), "nbf": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+	})
+	forged.Header["kid"] = signingKey.ID
+	forgedToken, err := forged.SignedString([]byte(signingKey.Signer.Public().(ed25519.PublicKey)))
+	assert.NoError(t, err)
+
+	withoutNotBefore := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"jti": uuid.New().String(), "iss": "amf-loan-service", "aud": "amf-loan-service", "user_id": user.ID.String(),
+		"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+	})
+	withoutNotBefore.Header["kid"] = signingKey.ID
+	withoutNotBeforeToken, err := withoutNotBefore.SignedString(signingKey.Signer)
+	assert.NoError(t, err)
+
+	mockUserRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockRevokedTokenRepo.On("IsRevoked", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(false, nil)
+
+	// Act
+	_, validErr := authService.ValidateToken(issue(testTokenKeys, "amf-loan-service", "amf-loan-service"))
+	_, rsaErr := rsaAuthService.ValidateToken(issue(rsaKeys, "amf-loan-service", "amf-loan-service"))
+	_, otherKeysErr := authService.ValidateToken(issue(rsaKeys, "amf-loan-service", "amf-loan-service"))
+	_, issuerErr := authService.ValidateToken(issue(testTokenKeys, "someone-else", "amf-loan-service"))
+	_, audienceErr := authService.ValidateToken(issue(testTokenKeys, "amf-loan-service", "reporting-service"))
+	_, forgedErr := authService.ValidateToken(forgedToken)
+	_, notBeforeErr := authService.ValidateToken(withoutNotBeforeToken)
+
+	// Assert
+	assert.NoError(t, validErr)
+	assert.NoError(t, rsaErr)
+	assert.Equal(t, domain.ErrInvalidToken, otherKeysErr)
+	assert.Equal(t, domain.ErrInvalidToken, issuerErr)
+	assert.Equal(t, domain.ErrInvalidToken, audienceErr)
+	assert.Equal(t, domain.ErrInvalidToken, forgedErr)
+	assert.Equal(t, domain.ErrInvalidToken, notBeforeErr)
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/totp"
+)
+
+const (
+	// totpSkew is how many 30 second steps a code may be early or late, allowing for clock drift
+	totpSkew = 1
+	// recoveryCodeLength is the number of characters in a recovery code, shown in two groups
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet has 32 characters so every random byte maps onto it without bias
+	recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+func (s *authService) VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (*domain.LoginResponse, error) {
+	challenge, user, err := s.openChallenge(ctx, challengeToken, domain.TwoFactorChallengeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		if err == domain.ErrInvalidTwoFactorCode {
+			if failErr := s.failChallenge(ctx, challenge, user); failErr != nil {
+				return nil, failErr
+			}
+		}
+		return nil, err
+	}
+
+	return s.completeChallenge(ctx, challenge, user)
+}
+
+func (s *authService) SetupTwoFactor(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorEnrolment, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.setupTwoFactor(ctx, user)
+}
+
+func (s *authService) ActivateTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.activateTwoFactor(ctx, user, code)
+}
+
+func (s *authService) SetupTwoFactorWithChallenge(ctx context.Context, challengeToken string) (*domain.TwoFactorEnrolment, error) {
+	_, user, err := s.openChallenge(ctx, challengeToken, domain.TwoFactorChallengeEnrolment)
+	if err != nil {
+		return nil, err
+	}
+	return s.setupTwoFactor(ctx, user)
+}
+
+func (s *authService) ActivateTwoFactorWithChallenge(ctx context.Context, challengeToken string, code string) (*domain.LoginResponse, error) {
+	challenge, user, err := s.openChallenge(ctx, challengeToken, domain.TwoFactorChallengeEnrolment)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.activateTwoFactor(ctx, user, code)
+	if err != nil {
+		if err == domain.ErrInvalidTwoFactorCode {
+			if failErr := s.failChallenge(ctx, challenge, user); failErr != nil {
+				return nil, failErr
+			}
+		}
+		return nil, err
+	}
+
+	response, err := s.completeChallenge(ctx, challenge, user)
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = recoveryCodes
+	return response, nil
+}
+
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, domain.ErrTwoFactorNotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+func (s *authService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.requiresTwoFactor(user) {
+		return domain.ErrTwoFactorMandatory
+	}
+	if !user.TwoFactorEnabled() {
+		return domain.ErrTwoFactorNotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.clearTwoFactor(ctx, user)
+}
+
+func (s *authService) ResetTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.clearTwoFactor(ctx, user); err != nil {
+		return err
+	}
+
+	// Whoever holds the lost device must not keep a session
+	return s.RevokeAllSessions(ctx, user.ID)
+}
+
+// requiresTwoFactor tells whether the user's role must sign in with a second factor.
+// Roles allowed to manage users always do, whatever the configured list says.
+func (s *authService) requiresTwoFactor(user *domain.User) bool {
+	for _, role := range s.twoFactorConfig.RequiredRoles {
+		if string(user.Role) == role {
+			return true
+		}
+	}
+	return s.policy.Can(user.Role, domain.PermissionUserManage)
+}
+
+// startChallenge answers a login whose password was accepted with the second step instead of tokens
+func (s *authService) startChallenge(ctx context.Context, user *domain.User, input domain.LoginInput) (*domain.LoginResponse, error) {
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	purpose := domain.TwoFactorChallengeLogin
+	if !user.TwoFactorEnabled() {
+		purpose = domain.TwoFactorChallengeEnrolment
+	}
+
+	now := time.Now()
+	challenge := &domain.TwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashSecureToken(token),
+		Purpose:   purpose,
+		IPAddress: input.IPAddress,
+		UserAgent: input.UserAgent,
+		ExpiresAt: now.Add(s.twoFactorConfig.ChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := s.challengeRepo.Create(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginResponse{
+		UserID: user.ID,
+		Email:  user.Email,
+		TwoFactor: &domain.TwoFactorPrompt{
+			ChallengeToken:    token,
+			EnrolmentRequired: purpose == domain.TwoFactorChallengeEnrolment,
+			ExpiresAt:         challenge.ExpiresAt,
+		},
+	}, nil
+}
+
+// openChallenge returns a challenge that can still be completed and the user it belongs to
+func (s *authService) openChallenge(ctx context.Context, challengeToken string, purpose domain.TwoFactorChallengePurpose) (*domain.TwoFactorChallenge, *domain.User, error) {
+	challenge, err := s.challengeRepo.GetByTokenHash(ctx, hashSecureToken(strings.TrimSpace(challengeToken)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidTwoFactorChallenge
+		}
+		return nil, nil, err
+	}
+
+	if challenge.Purpose != purpose ||
+		challenge.UsedAt != nil ||
+		time.Now().After(challenge.ExpiresAt) ||
+		challenge.Attempts >= s.twoFactorConfig.MaxAttempts {
+		return nil, nil, domain.ErrInvalidTwoFactorChallenge
+	}
+
+	user, err := s.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidTwoFactorChallenge
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive() {
+		return nil, nil, domain.ErrAccountDeactivated
+	}
+	if err := checkAccountLock(user, s.lockoutConfig.Threshold, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	return challenge, user, nil
+}
+
+// completeChallenge consumes the challenge and starts the session of the login
+func (s *authService) completeChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge, user *domain.User) (*domain.LoginResponse, error) {
+	// Only one of two concurrent completions of the same challenge may win
+	used, err := s.challengeRepo.MarkUsed(ctx, challenge.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, domain.ErrInvalidTwoFactorChallenge
+	}
+
+	if err := s.clearFailures(ctx, user); err != nil {
+		return nil, err
+	}
+
+	response, err := s.issueTokens(ctx, user, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordLogin(ctx, challengeLoginEvent(challenge, user), "")
+	return response, nil
+}
+
+// failChallenge counts a wrong code towards the attempt limit and records it in the login history
+func (s *authService) failChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge, user *domain.User) error {
+	if err := s.challengeRepo.RecordFailedAttempt(ctx, challenge.ID); err != nil {
+		return err
+	}
+	s.recordLogin(ctx, challengeLoginEvent(challenge, user), loginFailureTwoFactorCode)
+	return s.registerFailure(ctx, user)
+}
+
+func challengeLoginEvent(challenge *domain.TwoFactorChallenge, user *domain.User) *domain.LoginEvent {
+	return &domain.LoginEvent{
+		UserID:    &user.ID,
+		Email:     user.Email,
+		IPAddress: challenge.IPAddress,
+		UserAgent: challenge.UserAgent,
+	}
+}
+
+func (s *authService) setupTwoFactor(ctx context.Context, user *domain.User) (*domain.TwoFactorEnrolment, error) {
+	if user.TwoFactorEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	// A secret that was never confirmed is simply replaced
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.twoFactorConfig.Issuer, user.Email, secret),
+	}, nil
+}
+
+// activateTwoFactor enables the pending secret once the user proves their app generates its codes
+func (s *authService) activateTwoFactor(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrTwoFactorNotSetUp
+	}
+
+	now := time.Now()
+	step, ok := totp.Validate(user.TOTPSecret, normalizeTwoFactorCode(code), now, totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	user.TOTPEnabledAt = &now
+	user.TOTPLastStep = step
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+// checkSecondFactor accepts a code from the authenticator, or else consumes a recovery code
+func (s *authService) checkSecondFactor(ctx context.Context, user *domain.User, code string) error {
+	code = normalizeTwoFactorCode(code)
+	now := time.Now()
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TOTPSecret, code, now, totpSkew)
+		// A code seen once, even within its validity window, could have been observed
+		if !ok || step <= user.TOTPLastStep {
+			return domain.ErrInvalidTwoFactorCode
+		}
+
+		user.TOTPLastStep = step
+		user.UpdatedAt = now
+		return s.userRepo.Update(ctx, user)
+	}
+
+	used, err := s.recoveryCodeRepo.MarkUsed(ctx, user.ID, hashSecureToken(code), now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *authService) clearTwoFactor(ctx context.Context, user *domain.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// issueRecoveryCodes replaces the user's recovery codes and returns them, they are never shown again
+func (s *authService) issueRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	now := time.Now()
+	codes := make([]string, 0, s.twoFactorConfig.RecoveryCodes)
+	records := make([]domain.RecoveryCode, 0, s.twoFactorConfig.RecoveryCodes)
+	for i := 0; i < s.twoFactorConfig.RecoveryCodes; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, domain.RecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  hashSecureToken(normalizeTwoFactorCode(code)),
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *authService) getUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// generateRecoveryCode returns a code such as "k7dq2-mx4pa"
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+	}
+	half := recoveryCodeLength / 2
+	return string(buf[:half]) + "-" + string(buf[half:]), nil
+}
+
+// normalizeTwoFactorCode drops the separators and case users may type differently
+func normalizeTwoFactorCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/sigitisme/amf-loan-service/internal/infrastructure/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Test Login - Users With Two-Factor Authentication Get A Challenge Instead Of Tokens
+func TestAuthService_Login_TwoFactorChallenge(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	enabledAt := time.Now().Add(-24 * time.Hour)
+	officer := &domain.User{ID: uuid.New(), Email: "officer@amf.com", Password: string(hashedPassword), Role: domain.RoleFieldOfficer, TOTPEnabledAt: &enabledAt}
+	validator := &domain.User{ID: uuid.New(), Email: "validator@amf.com", Password: string(hashedPassword), Role: domain.RoleFieldValidator}
+
+	var challenges []*domain.TwoFactorChallenge
+	mockUserRepo.On("GetByEmail", mock.Anything, officer.Email).Return(officer, nil)
+	mockUserRepo.On("GetByEmail", mock.Anything, validator.Email).Return(validator, nil)
+	mockLoginEventRepo.On("FailureStatsByIP", mock.Anything, "10.0.0.7", mock.Anything, mock.Anything).Return(&domain.LoginFailureStats{}, nil)
+	mockChallengeRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.TwoFactorChallenge")).Run(func(args mock.Arguments) {
+		challenges = append(challenges, args.Get(1).(*domain.TwoFactorChallenge))
+	}).Return(nil)
+
+	// Act
+	officerResponse, officerErr := authService.Login(context.Background(), domain.LoginInput{Email: officer.Email, Password: "password", IPAddress: "10.0.0.7"})
+	validatorResponse, validatorErr := authService.Login(context.Background(), domain.LoginInput{Email: validator.Email, Password: "password"})
+
+	// Assert
+	assert.NoError(t, officerErr)
+	assert.Empty(t, officerResponse.Token)
+	assert.False(t, officerResponse.TwoFactor.EnrolmentRequired)
+	assert.Equal(t, hashSecureToken(officerResponse.TwoFactor.ChallengeToken), challenges[0].TokenHash)
+	assert.Equal(t, domain.TwoFactorChallengeLogin, challenges[0].Purpose)
+	assert.Equal(t, "10.0.0.7", challenges[0].IPAddress)
+
+	assert.NoError(t, validatorErr)
+	assert.Empty(t, validatorResponse.Token)
+	assert.True(t, validatorResponse.TwoFactor.EnrolmentRequired)
+	assert.Equal(t, domain.TwoFactorChallengeEnrolment, challenges[1].Purpose)
+
+	mockRefreshTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockLoginEventRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Two-Factor Verification - Wrong And Replayed Codes Are Refused, Recovery Codes Work Once
+func TestAuthService_VerifyTwoFactor(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now().Add(-24 * time.Hour)
+	officer := &domain.User{ID: uuid.New(), Email: "officer@amf.com", Role: domain.RoleFieldOfficer, TOTPSecret: secret, TOTPEnabledAt: &enabledAt}
+	challenge := &domain.TwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    officer.ID,
+		TokenHash: hashSecureToken("challenge-token"),
+		Purpose:   domain.TwoFactorChallengeLogin,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	code, _ := totp.Code(secret, time.Now())
+
+	mockChallengeRepo.On("GetByTokenHash", mock.Anything, challenge.TokenHash).Return(challenge, nil)
+	mockChallengeRepo.On("RecordFailedAttempt", mock.Anything, challenge.ID).Return(nil)
+	mockChallengeRepo.On("MarkUsed", mock.Anything, challenge.ID, mock.Anything).Return(true, nil)
+	mockUserRepo.On("GetByID", mock.Anything, officer.ID).Return(officer, nil)
+	mockUserRepo.On("Update", mock.Anything, officer).Return(nil)
+	mockUserRepo.On("IncrementFailedLogins", mock.Anything, officer.ID).Return(1, nil)
+	mockUserRepo.On("ResetFailedLogins", mock.Anything, officer.ID).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockRecoveryCodeRepo.On("MarkUsed", mock.Anything, officer.ID, hashSecureToken("abcde23456"), mock.Anything).Return(true, nil)
+	mockRecoveryCodeRepo.On("MarkUsed", mock.Anything, officer.ID, mock.Anything, mock.Anything).Return(false, nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, wrongErr := authService.VerifyTwoFactor(context.Background(), "challenge-token", "not-a-code")
+	response, err := authService.VerifyTwoFactor(context.Background(), "challenge-token", code)
+	_, replayErr := authService.VerifyTwoFactor(context.Background(), "challenge-token", code)
+	recovered, recoveryErr := authService.VerifyTwoFactor(context.Background(), "challenge-token", "ABCDE-23456")
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidTwoFactorCode, wrongErr)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, domain.ErrInvalidTwoFactorCode, replayErr)
+	assert.NoError(t, recoveryErr)
+	assert.NotEmpty(t, recovered.Token)
+	mockChallengeRepo.AssertNumberOfCalls(t, "RecordFailedAttempt", 2)
+	mockUserRepo.AssertNumberOfCalls(t, "IncrementFailedLogins", 2)
+	mockLoginEventRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(event *domain.LoginEvent) bool {
+		return !event.Succeeded && event.Reason == loginFailureTwoFactorCode
+	}))
+}
+
+// Test Mandatory Enrolment - A Validator Without 2FA Sets It Up To Complete The Login
+func TestAuthService_ActivateTwoFactorWithChallenge(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	validator := &domain.User{ID: uuid.New(), Email: "validator@amf.com", Role: domain.RoleFieldValidator}
+	challenge := &domain.TwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    validator.ID,
+		TokenHash: hashSecureToken("enrolment-token"),
+		Purpose:   domain.TwoFactorChallengeEnrolment,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	var stored []domain.RecoveryCode
+	mockChallengeRepo.On("GetByTokenHash", mock.Anything, challenge.TokenHash).Return(challenge, nil)
+	mockChallengeRepo.On("MarkUsed", mock.Anything, challenge.ID, mock.Anything).Return(true, nil)
+	mockUserRepo.On("GetByID", mock.Anything, validator.ID).Return(validator, nil)
+	mockUserRepo.On("Update", mock.Anything, validator).Return(nil)
+	mockRecoveryCodeRepo.On("ReplaceForUser", mock.Anything, validator.ID, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(2).([]domain.RecoveryCode)
+	}).Return(nil)
+	mockRefreshTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+	mockLoginEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.LoginEvent")).Return(nil)
+
+	// Act
+	_, notSetUpErr := authService.ActivateTwoFactorWithChallenge(context.Background(), "enrolment-token", "123456")
+	enrolment, setupErr := authService.SetupTwoFactorWithChallenge(context.Background(), "enrolment-token")
+	code, _ := totp.Code(enrolment.Secret, time.Now())
+	response, err := authService.ActivateTwoFactorWithChallenge(context.Background(), "enrolment-token", code)
+	_, loginErr := authService.VerifyTwoFactor(context.Background(), "enrolment-token", code)
+
+	// Assert
+	assert.Equal(t, domain.ErrTwoFactorNotSetUp, notSetUpErr)
+	assert.NoError(t, setupErr)
+	assert.Contains(t, enrolment.ProvisioningURI, "otpauth://totp/AMF%20Loan%20Service:validator@amf.com?")
+	assert.Contains(t, enrolment.ProvisioningURI, "secret="+enrolment.Secret)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.True(t, validator.TwoFactorEnabled())
+	assert.Len(t, response.RecoveryCodes, testTwoFactorConfig.RecoveryCodes)
+	assert.Len(t, stored, testTwoFactorConfig.RecoveryCodes)
+	assert.Equal(t, hashSecureToken(normalizeTwoFactorCode(response.RecoveryCodes[0])), stored[0].CodeHash)
+	assert.Equal(t, domain.ErrInvalidTwoFactorChallenge, loginErr)
+}
+
+// Test Disabling 2FA - Refused For Roles That Require It
+func TestAuthService_DisableTwoFactor(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now().Add(-time.Hour)
+	admin := &domain.User{ID: uuid.New(), Role: domain.RoleAdmin, TOTPSecret: secret, TOTPEnabledAt: &enabledAt}
+	borrower := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower, TOTPSecret: secret, TOTPEnabledAt: &enabledAt}
+	code, _ := totp.Code(secret, time.Now())
+
+	mockUserRepo.On("GetByID", mock.Anything, admin.ID).Return(admin, nil)
+	mockUserRepo.On("GetByID", mock.Anything, borrower.ID).Return(borrower, nil)
+	mockUserRepo.On("Update", mock.Anything, borrower).Return(nil)
+	mockRecoveryCodeRepo.On("DeleteByUserID", mock.Anything, borrower.ID).Return(nil)
+
+	// Act
+	mandatoryErr := authService.DisableTwoFactor(context.Background(), admin.ID, code)
+	err := authService.DisableTwoFactor(context.Background(), borrower.ID, code)
+
+	// Assert
+	assert.Equal(t, domain.ErrTwoFactorMandatory, mandatoryErr)
+	assert.True(t, admin.TwoFactorEnabled())
+	assert.NoError(t, err)
+	assert.False(t, borrower.TwoFactorEnabled())
+	assert.Empty(t, borrower.TOTPSecret)
+	mockRecoveryCodeRepo.AssertExpectations(t)
+}
+
+// Test Token Refresh - Sessions Of Staff Who Must Enrol In 2FA End
+func TestAuthService_Refresh_TwoFactorEnrolmentRequired(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	officer := &domain.User{ID: uuid.New(), Email: "officer@amf.com", Role: domain.RoleFieldOfficer}
+	current := &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    officer.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashSecureToken("officer-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockRefreshTokenRepo.On("GetByTokenHash", mock.Anything, current.TokenHash).Return(current, nil)
+	mockUserRepo.On("GetByID", mock.Anything, officer.ID).Return(officer, nil)
+
+	// Act
+	response, err := authService.Refresh(context.Background(), "officer-token")
+
+	// Assert
+	assert.Nil(t, response)
+	assert.Equal(t, domain.ErrTwoFactorEnrolmentRequired, err)
+	mockRefreshTokenRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -19,11 +19,17 @@ func TestLoanService_BusinessLogic_CreateLoan(t *testing.T) {
 	// Arrange
 	mockLoanRepo := new(mockLoanRepository)
 	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
 	mockDisbursementRepo := new(mockDisbursementRepository)
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
 
-	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockDisbursementRepo, mockInvestmentRepo, mockBorrowerRepo)
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
 
 	userID := uuid.New()
 	borrowerID := uuid.New()
@@ -34,13 +40,15 @@ func TestLoanService_BusinessLogic_CreateLoan(t *testing.T) {
 		ID:       borrowerID,
 		UserID:   userID,
 		FullName: "Test Borrower",
+		User:     verifiedUser(userID),
 	}
 
 	mockBorrowerRepo.On("GetByUserID", mock.Anything, userID).Return(borrower, nil)
 	mockLoanRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	mockTaskService.On("CreateVerificationTask", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.Anything).Return(&domain.VerificationTask{}, nil)
 
 	// Act
-	loan, err := loanService.CreateLoan(context.Background(), userID, principalAmount, rate)
+	loan, err := loanService.CreateLoan(context.Background(), userID, principalAmount, rate, 0)
 
 	// Assert - Test Business Logic
 	assert.NoError(t, err)
@@ -68,10 +76,12 @@ func TestInvestmentService_BusinessLogic_ProcessInvestment(t *testing.T) {
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	loanID := uuid.New()
 	investorID := uuid.New()
@@ -136,10 +146,12 @@ func TestInvestmentService_BusinessLogic_FullyFunded(t *testing.T) {
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	loanID := uuid.New()
 	investorID := uuid.New()
@@ -171,8 +183,7 @@ func TestInvestmentService_BusinessLogic_FullyFunded(t *testing.T) {
 			capturedLoan = args.Get(2).(*domain.Loan)
 		}).Return(nil)
 
-	// Mock the fully funded flow
-	mockKafkaProducer.On("PublishFullyFundedLoan", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	// Mock the fully funded flow, the event itself is recorded by the repository transaction
 	mockNotificationService.On("SendAgreementLetters", mock.Anything, loanID).Return(nil)
 
 	// Act
@@ -186,41 +197,50 @@ func TestInvestmentService_BusinessLogic_FullyFunded(t *testing.T) {
 	assert.Equal(t, 0.0, capturedLoan.RemainingInvestment)        // No remaining investment
 	assert.Equal(t, domain.LoanStateInvested, capturedLoan.State) // Changed to invested state
 
-	// Verify fully funded events were triggered
-	mockKafkaProducer.AssertExpectations(t)
+	// Verify fully funded follow-ups were triggered without publishing directly
+	mockKafkaProducer.AssertNotCalled(t, "PublishOutboxEvent", mock.Anything, mock.Anything)
 	mockNotificationService.AssertExpectations(t)
 	mockLoanRepo.AssertExpectations(t)
 	mockInvestmentRepo.AssertExpectations(t)
 }
 
-// Test Notification Service Business Logic
-func TestNotificationService_BusinessLogic_GenerateURLs(t *testing.T) {
+// Test Agreement Template Business Logic
+func TestAgreementTemplate_BusinessLogic_InvestorAgreement(t *testing.T) {
 	// Arrange
-	mockLoanRepo := new(mockLoanRepository)
-	mockInvestmentRepo := new(mockInvestmentRepository)
+	loan := &domain.Loan{
+		ID:              uuid.New(),
+		PrincipalAmount: 100000,
+		Rate:            0.12,
+		ROI:             0.096,
+		TenorMonths:     18,
+		Borrower:        domain.Borrower{FullName: "Budi Borrower", IdentityNumber: "3171000000000001"},
+	}
+	investment := &domain.Investment{
+		ID:       uuid.New(),
+		Amount:   25000,
+		Investor: domain.Investor{FullName: "John Investor", IdentityNumber: "3171000000000002"},
+	}
 
-	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo).(*notificationService)
+	// Act - Test Template Business Logic
+	doc := investorAgreementTemplate(loan, investment, time.Now())
 
-	loanID := uuid.New()
-	investorID := uuid.New()
-	investmentID := uuid.New()
-
-	// Act - Test URL Generation Business Logic
-	url := notificationService.generateAgreementLetterURL(loanID, investorID, investmentID)
-
-	// Assert - Test URL Format
-	expectedPrefix := "https://amf-documents.s3.amazonaws.com/agreements"
-	expectedSuffix := ".pdf"
-
-	assert.Contains(t, url, expectedPrefix)
-	assert.Contains(t, url, loanID.String())
-	assert.Contains(t, url, investorID.String())
-	assert.Contains(t, url, investmentID.String())
-	assert.True(t, strings.HasSuffix(url, expectedSuffix))
-
-	// Verify URL structure follows expected pattern
-	expectedURL := expectedPrefix + "/loan_" + loanID.String() +
-		"/investor_" + investorID.String() +
-		"/agreement_" + investmentID.String() + expectedSuffix
-	assert.Equal(t, expectedURL, url)
+	var text strings.Builder
+	for _, section := range doc.Sections {
+		text.WriteString(section.Heading + "\n")
+		for _, line := range section.Lines {
+			text.WriteString(line + "\n")
+		}
+	}
+	letter := text.String()
+
+	// Assert - Letter carries the loan terms, both parties and a signature block
+	assert.Contains(t, letter, investorAgreementTemplateVersion)
+	assert.Contains(t, letter, "Budi Borrower")
+	assert.Contains(t, letter, "John Investor")
+	assert.Contains(t, letter, "Principal amount: 100,000.00")
+	assert.Contains(t, letter, "Tenor: 18 months")
+	assert.Contains(t, letter, "(ROI): 9.60%")
+	assert.Contains(t, letter, "Investment amount: 25,000.00")
+	assert.Contains(t, letter, "Expected return at maturity: 2,400.00")
+	assert.Contains(t, letter, "Signatures")
 }
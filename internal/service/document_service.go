@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// documentExtensions maps the content types of stored documents to the extension of their storage key
+var documentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// uploadableDocumentTypes are the content types staff may upload
+var uploadableDocumentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type documentService struct {
+	documentRepo   domain.DocumentRepository
+	loanRepo       domain.LoanRepository
+	storage        domain.FileStorage
+	policy         domain.PermissionPolicy
+	documentConfig *config.DocumentConfig
+}
+
+func NewDocumentService(
+	documentRepo domain.DocumentRepository,
+	loanRepo domain.LoanRepository,
+	storage domain.FileStorage,
+	policy domain.PermissionPolicy,
+	documentConfig *config.DocumentConfig,
+) domain.DocumentService {
+	return &documentService{
+		documentRepo:   documentRepo,
+		loanRepo:       loanRepo,
+		storage:        storage,
+		policy:         policy,
+		documentConfig: documentConfig,
+	}
+}
+
+func (s *documentService) StoreDocument(ctx context.Context, document *domain.Document, data []byte) error {
+	if len(data) == 0 {
+		return domain.ErrEmptyFile
+	}
+
+	if document.ID == uuid.Nil {
+		document.ID = uuid.New()
+	}
+	if document.CreatedAt.IsZero() {
+		document.CreatedAt = time.Now()
+	}
+
+	sum := sha256.Sum256(data)
+	document.SHA256 = hex.EncodeToString(sum[:])
+	document.Size = int64(len(data))
+	document.StorageKey = fmt.Sprintf("documents/%s/%s%s", document.LoanID, document.ID, documentExtensions[document.ContentType])
+
+	if err := s.storage.Put(ctx, document.StorageKey, data, document.ContentType); err != nil {
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+
+	if err := s.documentRepo.Create(ctx, document); err != nil {
+		// Do not leave orphaned objects behind
+		_ = s.storage.Delete(ctx, document.StorageKey)
+		return err
+	}
+
+	return nil
+}
+
+// UploadDocument stores a staff supplied document, currently only signed disbursement agreements
+func (s *documentService) UploadDocument(ctx context.Context, loanID uuid.UUID, uploaderID uuid.UUID, docType domain.DocumentType, filename string, data []byte) (*domain.Document, error) {
+	if docType != domain.DocumentTypeDisbursementAgreement {
+		return nil, domain.ErrInvalidDocumentType
+	}
+	if len(data) == 0 {
+		return nil, domain.ErrEmptyFile
+	}
+	if int64(len(data)) > s.documentConfig.MaxUploadSize {
+		return nil, domain.ErrFileTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !uploadableDocumentTypes[contentType] {
+		return nil, domain.ErrUnsupportedFileType
+	}
+
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.State != domain.LoanStateInvested {
+		return nil, domain.ErrLoanNotInvested
+	}
+
+	document := &domain.Document{
+		LoanID:      loanID,
+		Type:        docType,
+		FileName:    filename,
+		ContentType: contentType,
+		CreatedBy:   &uploaderID,
+	}
+	if err := s.StoreDocument(ctx, document, data); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func (s *documentService) GetDocument(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.Document, error) {
+	document, err := s.documentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDocumentNotFound
+		}
+		return nil, err
+	}
+
+	loan, err := s.getLoan(ctx, document.LoanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.canAccessDocument(user, document, loan) {
+		return nil, domain.ErrInsufficientPermission
+	}
+
+	return document, nil
+}
+
+// GetLoanDocuments returns the documents of a loan that the user is allowed to see
+func (s *documentService) GetLoanDocuments(ctx context.Context, user *domain.User, loanID uuid.UUID) ([]domain.Document, error) {
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.documentRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]domain.Document, 0, len(documents))
+	for _, document := range documents {
+		if s.canAccessDocument(user, &document, loan) {
+			visible = append(visible, document)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *documentService) IssueDownloadURL(ctx context.Context, user *domain.User, id uuid.UUID) (*domain.DocumentDownload, error) {
+	document, err := s.GetDocument(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.documentConfig.URLTTL).Truncate(time.Second)
+	expires := expiresAt.Unix()
+
+	return &domain.DocumentDownload{
+		Document: document,
+		URL: fmt.Sprintf("%s/download?expires=%d&signature=%s",
+			s.DocumentURL(document.ID), expires, s.sign(document.ID, expires)),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *documentService) OpenSignedDownload(ctx context.Context, id uuid.UUID, expires int64, signature string) (*domain.Document, io.ReadCloser, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) {
+		return nil, nil, domain.ErrInvalidDownloadSignature
+	}
+	if time.Now().Unix() > expires {
+		return nil, nil, domain.ErrDownloadURLExpired
+	}
+
+	document, err := s.documentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Get(ctx, document.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return document, content, nil
+}
+
+func (s *documentService) DocumentURL(id uuid.UUID) string {
+	return strings.TrimSuffix(s.documentConfig.PublicBaseURL, "/") + documentPath(id)
+}
+
+// documentPath is the API path a document can be requested from
+func documentPath(id uuid.UUID) string {
+	return fmt.Sprintf("/api/documents/%s", id)
+}
+
+// sign returns the HMAC that binds a download link to a document and expiry time
+func (s *documentService) sign(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.documentConfig.URLSigningKey))
+	mac.Write([]byte(id.String() + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *documentService) getLoan(ctx context.Context, loanID uuid.UUID) (*domain.Loan, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoanNotFound
+		}
+		return nil, err
+	}
+	return loan, nil
+}
+
+// canAccessDocument lets roles granted document:view_all see everything, owners see their own
+// documents and borrowers see the unowned documents of their loans
+func (s *documentService) canAccessDocument(user *domain.User, document *domain.Document, loan *domain.Loan) bool {
+	if s.policy.Allows(user, domain.PermissionDocumentViewAll) {
+		return true
+	}
+
+	if document.OwnerUserID != nil {
+		return *document.OwnerUserID == user.ID
+	}
+
+	return user.Role == domain.RoleBorrower && loan.Borrower.UserID == user.ID
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Document Repository
+type mockDocumentRepository struct {
+	mock.Mock
+}
+
+func (m *mockDocumentRepository) Create(ctx context.Context, document *domain.Document) error {
+	args := m.Called(ctx, document)
+	return args.Error(0)
+}
+
+func (m *mockDocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Document, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Document), args.Error(1)
+}
+
+func (m *mockDocumentRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.Document, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).([]domain.Document), args.Error(1)
+}
+
+func (m *mockDocumentRepository) GetLatestByLoanAndType(ctx context.Context, loanID uuid.UUID, docType domain.DocumentType) (*domain.Document, error) {
+	args := m.Called(ctx, loanID, docType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Document), args.Error(1)
+}
+
+var testDocumentConfig = &config.DocumentConfig{
+	PublicBaseURL: "https://loans.example.com",
+	URLSigningKey: "test-signing-key-of-thirty-two-bytes",
+	URLTTL:        15 * time.Minute,
+	MaxUploadSize: 1024,
+}
+
+// Test Signed Download URL - Issued Link Opens The Document
+func TestDocumentService_SignedDownload_RoundTrip(t *testing.T) {
+	// Arrange
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+
+	investorUser := &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}
+	loan := &domain.Loan{ID: uuid.New(), Borrower: domain.Borrower{UserID: uuid.New()}}
+	document := &domain.Document{ID: uuid.New(), LoanID: loan.ID, OwnerUserID: &investorUser.ID, StorageKey: "documents/a.txt"}
+
+	mockDocumentRepo.On("GetByID", mock.Anything, document.ID).Return(document, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockStorage.On("Get", mock.Anything, document.StorageKey).Return(io.NopCloser(strings.NewReader("letter")), nil)
+
+	// Act
+	download, err := documentService.IssueDownloadURL(context.Background(), investorUser, document.ID)
+	assert.NoError(t, err)
+
+	parsed, _ := url.Parse(download.URL)
+	expires, _ := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	opened, content, err := documentService.OpenSignedDownload(context.Background(), document.ID, expires, parsed.Query().Get("signature"))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(download.URL, "https://loans.example.com/api/documents/"+document.ID.String()+"/download?"))
+	assert.Equal(t, document.ID, opened.ID)
+	assert.NotNil(t, content)
+}
+
+// Test Signed Download URL - Tampered And Expired Links Are Refused
+func TestDocumentService_OpenSignedDownload_Rejected(t *testing.T) {
+	// Arrange
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig).(*documentService)
+
+	documentID := uuid.New()
+	expired := time.Now().Add(-time.Minute).Unix()
+	valid := time.Now().Add(time.Minute).Unix()
+
+	// Act
+	_, _, tamperedErr := documentService.OpenSignedDownload(context.Background(), documentID, valid, documentService.sign(uuid.New(), valid))
+	_, _, expiredErr := documentService.OpenSignedDownload(context.Background(), documentID, expired, documentService.sign(documentID, expired))
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidDownloadSignature, tamperedErr)
+	assert.Equal(t, domain.ErrDownloadURLExpired, expiredErr)
+	mockDocumentRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+// Test Document Access - Owners, Borrowers And Staff
+func TestDocumentService_GetDocument_AccessChecks(t *testing.T) {
+	// Arrange
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+
+	borrowerUser := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower}
+	ownerInvestor := &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}
+	otherInvestor := &domain.User{ID: uuid.New(), Role: domain.RoleInvestor}
+	officer := &domain.User{ID: uuid.New(), Role: domain.RoleFieldOfficer}
+
+	loan := &domain.Loan{ID: uuid.New(), Borrower: domain.Borrower{UserID: borrowerUser.ID}}
+	investorLetter := &domain.Document{ID: uuid.New(), LoanID: loan.ID, OwnerUserID: &ownerInvestor.ID}
+	loanAgreement := &domain.Document{ID: uuid.New(), LoanID: loan.ID}
+
+	mockDocumentRepo.On("GetByID", mock.Anything, investorLetter.ID).Return(investorLetter, nil)
+	mockDocumentRepo.On("GetByID", mock.Anything, loanAgreement.ID).Return(loanAgreement, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+
+	// Act & Assert
+	_, err := documentService.GetDocument(context.Background(), ownerInvestor, investorLetter.ID)
+	assert.NoError(t, err)
+	_, err = documentService.GetDocument(context.Background(), otherInvestor, investorLetter.ID)
+	assert.Equal(t, domain.ErrInsufficientPermission, err)
+	_, err = documentService.GetDocument(context.Background(), borrowerUser, investorLetter.ID)
+	assert.Equal(t, domain.ErrInsufficientPermission, err)
+	_, err = documentService.GetDocument(context.Background(), officer, investorLetter.ID)
+	assert.NoError(t, err)
+
+	_, err = documentService.GetDocument(context.Background(), borrowerUser, loanAgreement.ID)
+	assert.NoError(t, err)
+	_, err = documentService.GetDocument(context.Background(), ownerInvestor, loanAgreement.ID)
+	assert.Equal(t, domain.ErrInsufficientPermission, err)
+}
+
+// Test Loan Disbursement - Requires A Disbursement Agreement Of The Loan
+func TestLoanService_DisburseLoan_RequiresAgreementDocument(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+	mockStorage := new(mockFileStorage)
+	mockWaitlistRepo := new(mockWaitlistRepository)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, mockStorage, mockDisbursementRepo, mockInvestmentRepo, mockWaitlistRepo, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
+
+	loanID := uuid.New()
+	investorLetter := &domain.Document{ID: uuid.New(), LoanID: loanID, Type: domain.DocumentTypeInvestorAgreement}
+	agreement := &domain.Document{ID: uuid.New(), LoanID: loanID, Type: domain.DocumentTypeDisbursementAgreement}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID, State: domain.LoanStateInvested}, nil)
+	mockDocumentRepo.On("GetByID", mock.Anything, investorLetter.ID).Return(investorLetter, nil)
+	mockDocumentRepo.On("GetByID", mock.Anything, agreement.ID).Return(agreement, nil)
+	sum := sha256.Sum256([]byte("%PDF agreement"))
+	borrowerAgreement := &domain.Document{ID: uuid.New(), LoanID: loanID, Type: domain.DocumentTypeBorrowerAgreement, SHA256: hex.EncodeToString(sum[:]), StorageKey: "documents/agreement.pdf"}
+	mockDocumentRepo.On("GetLatestByLoanAndType", mock.Anything, loanID, domain.DocumentTypeBorrowerAgreement).Return(borrowerAgreement, nil)
+	mockSignatureRepo.On("GetByDocumentID", mock.Anything, borrowerAgreement.ID).Return(&domain.AgreementSignature{LoanID: loanID, DocumentID: borrowerAgreement.ID, DocumentSHA256: borrowerAgreement.SHA256}, nil)
+	mockStorage.On("Get", mock.Anything, borrowerAgreement.StorageKey).Return(io.NopCloser(strings.NewReader("%PDF agreement")), nil)
+	mockDisbursementRepo.On("Create", mock.Anything, mock.MatchedBy(func(disbursement *domain.Disbursement) bool {
+		return *disbursement.AgreementDocumentID == agreement.ID && disbursement.AgreementFileURL == "/api/documents/"+agreement.ID.String()
+	})).Return(nil)
+	mockLoanRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
+	mockWaitlistRepo.On("CancelWaitingByLoanID", mock.Anything, loanID).Return(int64(1), nil)
+	mockSegregationService.On("CheckActor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	wrongTypeErr := loanService.DisburseLoan(context.Background(), loanID, uuid.New(), investorLetter.ID, time.Now())
+	err := loanService.DisburseLoan(context.Background(), loanID, uuid.New(), agreement.ID, time.Now())
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidDocumentType, wrongTypeErr)
+	assert.NoError(t, err)
+	mockDisbursementRepo.AssertNumberOfCalls(t, "Create", 1)
+	// Nobody can be served from the waitlist of a disbursed loan
+	mockWaitlistRepo.AssertExpectations(t)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// earthRadiusMeters is the mean earth radius used for great-circle distances
+const earthRadiusMeters = 6371000.0
+
+// assessFieldEvidence compares the reported capture location and time with the borrower's registered
+// address and the photo's EXIF metadata, returning the distance to the borrower and any flags raised
+func (s *loanService) assessFieldEvidence(borrower *domain.Borrower, proof *domain.PhotoProof, evidence domain.FieldEvidence, now time.Time) (*float64, domain.EvidenceFlags) {
+	cfg := s.approvalConfig
+	flags := domain.EvidenceFlags{}
+
+	var distance *float64
+	if borrower.Latitude != nil && borrower.Longitude != nil {
+		d := distanceMeters(evidence.Latitude, evidence.Longitude, *borrower.Latitude, *borrower.Longitude)
+		distance = &d
+	}
+
+	if cfg.EvidenceMaxDistance > 0 {
+		switch {
+		case distance == nil:
+			flags = append(flags, domain.EvidenceFlagBorrowerLocation)
+		case *distance > cfg.EvidenceMaxDistance:
+			flags = append(flags, domain.EvidenceFlagTooFar)
+		}
+	}
+
+	if cfg.EvidenceMaxAge > 0 && now.Sub(evidence.CapturedAt) > cfg.EvidenceMaxAge {
+		flags = append(flags, domain.EvidenceFlagTooOld)
+	}
+
+	// Device clocks drift a little, so the EXIF tolerance doubles as the allowed skew
+	if evidence.CapturedAt.After(now.Add(cfg.EvidenceExifTimeLimit)) {
+		flags = append(flags, domain.EvidenceFlagCapturedInFuture)
+	}
+
+	if cfg.EvidenceExifDistanceLimit > 0 || cfg.EvidenceExifTimeLimit > 0 {
+		flags = append(flags, exifFlags(proof, evidence, cfg.EvidenceExifDistanceLimit, cfg.EvidenceExifTimeLimit)...)
+	}
+
+	return distance, flags
+}
+
+// exifFlags checks that the metadata embedded in the photo agrees with what the device reported
+func exifFlags(proof *domain.PhotoProof, evidence domain.FieldEvidence, distanceLimit float64, timeLimit time.Duration) domain.EvidenceFlags {
+	var flags domain.EvidenceFlags
+
+	hasLocation := proof.ExifLatitude != nil && proof.ExifLongitude != nil
+	if (distanceLimit > 0 && !hasLocation) || (timeLimit > 0 && proof.ExifCapturedAt == nil) {
+		flags = append(flags, domain.EvidenceFlagExifMissing)
+	}
+
+	if distanceLimit > 0 && hasLocation {
+		if distanceMeters(evidence.Latitude, evidence.Longitude, *proof.ExifLatitude, *proof.ExifLongitude) > distanceLimit {
+			flags = append(flags, domain.EvidenceFlagExifLocationMismatch)
+		}
+	}
+
+	if timeLimit > 0 && proof.ExifCapturedAt != nil {
+		exifTime := *proof.ExifCapturedAt
+		if !proof.ExifTimeZoned {
+			// The camera wrote local wall clock time, read it in the zone the device reported
+			exifTime = time.Date(exifTime.Year(), exifTime.Month(), exifTime.Day(),
+				exifTime.Hour(), exifTime.Minute(), exifTime.Second(), 0, evidence.CapturedAt.Location())
+		}
+
+		diff := exifTime.Sub(evidence.CapturedAt)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > timeLimit {
+			flags = append(flags, domain.EvidenceFlagExifTimeMismatch)
+		}
+	}
+
+	return flags
+}
+
+// distanceMeters returns the haversine distance between two coordinates in decimal degrees
+func distanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// testFieldEvidence is a visit reported just before the tests run, all evidence checks are off in the shared configs
+var testFieldEvidence = domain.FieldEvidence{
+	Latitude:   40.7128,
+	Longitude:  -74.0060,
+	CapturedAt: time.Now(),
+}
+
+// Single stage workflow with every evidence check enabled
+var evidenceApprovalConfig = &config.ApprovalConfig{
+	EvidenceMaxDistance:       500,
+	EvidenceMaxAge:            48 * time.Hour,
+	EvidenceExifDistanceLimit: 100,
+	EvidenceExifTimeLimit:     15 * time.Minute,
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// Test Loan Approval - Evidence Near The Borrower Approves Without Review
+func TestLoanService_ApproveLoan_EvidenceWithinLimits(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, evidenceApprovalConfig)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	capturedAt := time.Now().Add(-time.Hour)
+	exifTime := capturedAt.Add(-2 * time.Minute)
+	proof := &domain.PhotoProof{
+		ID:             uuid.New(),
+		LoanID:         loanID,
+		UploaderID:     validatorID,
+		ExifLatitude:   float64Ptr(40.7129),
+		ExifLongitude:  float64Ptr(-74.0061),
+		ExifCapturedAt: &exifTime,
+		ExifTimeZoned:  true,
+	}
+	loan := &domain.Loan{
+		ID:       loanID,
+		State:    domain.LoanStateProposed,
+		Borrower: domain.Borrower{Latitude: float64Ptr(40.7130), Longitude: float64Ptr(-74.0050)},
+	}
+
+	var recorded *domain.Approval
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockPhotoProofRepo.On("GetByID", mock.Anything, proof.ID).Return(proof, nil)
+	mockTaskService.On("EnsureAssignee", mock.Anything, loanID, validatorID).Return(nil)
+	mockTaskService.On("CompleteVerificationTask", mock.Anything, loanID).Return(nil)
+	mockApprovalStageRepo.On("RecordDecision", mock.Anything, mock.AnythingOfType("*domain.ApprovalStage"), mock.AnythingOfType("*domain.Approval"), mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(2).(*domain.Approval)
+	}).Return(domain.LoanStateApproved, nil)
+	mockSegregationService.On("CheckActor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	err := loanService.ApproveLoan(context.Background(), loanID, validatorID, proof.ID, time.Now(), domain.FieldEvidence{
+		Latitude:   40.7128,
+		Longitude:  -74.0060,
+		CapturedAt: capturedAt,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, recorded.EvidenceFlags)
+	assert.False(t, recorded.NeedsReview)
+	assert.InDelta(t, 86, *recorded.DistanceMeters, 5)
+	assert.Equal(t, domain.LoanStateApproved, loan.State)
+}
+
+// Test Loan Approval - Far, Old And Inconsistent Evidence Is Flagged And Sent To Credit Review
+func TestLoanService_ApproveLoan_EvidenceFlagged(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, evidenceApprovalConfig)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	capturedAt := time.Now().Add(-72 * time.Hour)
+	// Photo taken in Jersey City while the device reported Manhattan
+	proof := &domain.PhotoProof{
+		ID:            uuid.New(),
+		LoanID:        loanID,
+		UploaderID:    validatorID,
+		ExifLatitude:  float64Ptr(40.7178),
+		ExifLongitude: float64Ptr(-74.0431),
+	}
+	// Borrower registered in Brooklyn
+	loan := &domain.Loan{
+		ID:       loanID,
+		State:    domain.LoanStateProposed,
+		Borrower: domain.Borrower{Latitude: float64Ptr(40.6782), Longitude: float64Ptr(-73.9442)},
+	}
+
+	var recorded *domain.Approval
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockPhotoProofRepo.On("GetByID", mock.Anything, proof.ID).Return(proof, nil)
+	mockTaskService.On("EnsureAssignee", mock.Anything, loanID, validatorID).Return(nil)
+	mockTaskService.On("CompleteVerificationTask", mock.Anything, loanID).Return(nil)
+	var required []domain.StageRequirement
+	mockApprovalStageRepo.On("RecordDecision", mock.Anything, mock.AnythingOfType("*domain.ApprovalStage"), mock.AnythingOfType("*domain.Approval"), mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(2).(*domain.Approval)
+		required = args.Get(3).([]domain.StageRequirement)
+	}).Return(domain.LoanStateProposed, nil)
+	mockSegregationService.On("CheckActor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	err := loanService.ApproveLoan(context.Background(), loanID, validatorID, proof.ID, time.Now(), domain.FieldEvidence{
+		Latitude:   40.7128,
+		Longitude:  -74.0060,
+		CapturedAt: capturedAt,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, recorded.NeedsReview)
+	assert.ElementsMatch(t, domain.EvidenceFlags{
+		domain.EvidenceFlagTooFar,
+		domain.EvidenceFlagTooOld,
+		domain.EvidenceFlagExifMissing,
+		domain.EvidenceFlagExifLocationMismatch,
+	}, recorded.EvidenceFlags)
+
+	// Credit review is not configured, yet the loan must wait for it
+	assert.Contains(t, required, domain.StageRequirement{Stage: domain.ApprovalStageCreditReview, Approvals: 1})
+	assert.Equal(t, domain.LoanStateProposed, loan.State)
+}
+
+// Test Evidence Checks - Camera Local Time Is Read In The Device Time Zone
+func TestExifFlags_LocalCameraTime(t *testing.T) {
+	// Arrange
+	newYork := time.FixedZone("EDT", -4*60*60)
+	capturedAt := time.Date(2025, 8, 13, 10, 30, 0, 0, newYork)
+	// The camera wrote 10:25 wall clock without an offset
+	localWallClock := time.Date(2025, 8, 13, 10, 25, 0, 0, time.UTC)
+	proof := &domain.PhotoProof{
+		ExifLatitude:   float64Ptr(40.7128),
+		ExifLongitude:  float64Ptr(-74.0060),
+		ExifCapturedAt: &localWallClock,
+	}
+	evidence := domain.FieldEvidence{Latitude: 40.7128, Longitude: -74.0060, CapturedAt: capturedAt}
+
+	// Act
+	unzoned := exifFlags(proof, evidence, 100, 15*time.Minute)
+	proof.ExifTimeZoned = true
+	zoned := exifFlags(proof, evidence, 100, 15*time.Minute)
+
+	// Assert
+	assert.Empty(t, unzoned)
+	// Taken literally as UTC the times are four hours apart
+	assert.Equal(t, domain.EvidenceFlags{domain.EvidenceFlagExifTimeMismatch}, zoned)
+}
+
+// Test Loan Approval - Coordinates Captured At Registration Are Used For The Distance Check
+func TestLoanService_ApproveLoan_RegisteredCoordinates(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockNotificationService := new(mockNotificationService)
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, new(mockInvestorRepository), mockVerificationRepo, new(mockRefreshTokenRepository), new(mockRevokedTokenRepository), new(mockLoginEventRepository), new(mockPasswordResetRepository), new(mockTwoFactorChallengeRepository), new(mockRecoveryCodeRepository), new(mockSingleSignOnStateRepository), mockNotificationService, new(mockEmailSender), new(mockIdentityProvider), testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+	loanService := NewLoanService(mockLoanRepo, new(mockApprovalRepository), mockApprovalStageRepo, mockPhotoProofRepo, new(mockDocumentRepository), new(mockAgreementSignatureRepository), nil, new(mockDisbursementRepository), new(mockInvestmentRepository), nil, mockBorrowerRepo, mockTaskService, mockSegregationService, evidenceApprovalConfig)
+
+	// Borrower registers with the geocoded address in Brooklyn
+	var registered *domain.Borrower
+	mockUserRepo.On("GetByEmail", mock.Anything, "brooklyn@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockBorrowerRepo.On("GetByIdentityNumber", mock.Anything, "B007777777").Return(nil, gorm.ErrRecordNotFound)
+	mockBorrowerRepo.On("CreateWithUser", mock.Anything, mock.AnythingOfType("*domain.Borrower")).Run(func(args mock.Arguments) {
+		registered = args.Get(1).(*domain.Borrower)
+	}).Return(nil)
+	mockVerificationRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockNotificationService.On("SendEmailVerification", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	capturedAt := time.Now().Add(-time.Hour)
+	exifTime := capturedAt.Add(-time.Minute)
+	// Photo and device both report Manhattan
+	proof := &domain.PhotoProof{
+		ID:             uuid.New(),
+		LoanID:         loanID,
+		UploaderID:     validatorID,
+		ExifLatitude:   float64Ptr(40.7128),
+		ExifLongitude:  float64Ptr(-74.0060),
+		ExifCapturedAt: &exifTime,
+		ExifTimeZoned:  true,
+	}
+
+	var recorded *domain.Approval
+	mockPhotoProofRepo.On("GetByID", mock.Anything, proof.ID).Return(proof, nil)
+	mockTaskService.On("EnsureAssignee", mock.Anything, loanID, validatorID).Return(nil)
+	mockTaskService.On("CompleteVerificationTask", mock.Anything, loanID).Return(nil)
+	mockApprovalStageRepo.On("RecordDecision", mock.Anything, mock.AnythingOfType("*domain.ApprovalStage"), mock.AnythingOfType("*domain.Approval"), mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(2).(*domain.Approval)
+	}).Return(domain.LoanStateProposed, nil)
+	mockSegregationService.On("CheckActor", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	_, err := authService.RegisterBorrower(context.Background(), domain.RegistrationInput{
+		Email:          "brooklyn@example.com",
+		Password:       "s3cret-pass",
+		FullName:       "Brooklyn Borrower",
+		PhoneNumber:    "+1234567800",
+		Address:        "1 Eastern Pkwy, Brooklyn, NY",
+		IdentityNumber: "B007777777",
+		Region:         "NY",
+		Latitude:       float64Ptr(40.6782),
+		Longitude:      float64Ptr(-73.9442),
+	})
+	assert.NoError(t, err)
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{
+		ID:         loanID,
+		BorrowerID: registered.ID,
+		State:      domain.LoanStateProposed,
+		Borrower:   *registered,
+	}, nil)
+	err = loanService.ApproveLoan(context.Background(), loanID, validatorID, proof.ID, time.Now(), domain.FieldEvidence{
+		Latitude:   40.7128,
+		Longitude:  -74.0060,
+		CapturedAt: capturedAt,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 40.6782, *registered.Latitude)
+	assert.Equal(t, -73.9442, *registered.Longitude)
+	if assert.NotNil(t, recorded.DistanceMeters) {
+		assert.InDelta(t, 6480, *recorded.DistanceMeters, 50)
+	}
+	assert.Equal(t, domain.EvidenceFlags{domain.EvidenceFlagTooFar}, recorded.EvidenceFlags)
+	assert.True(t, recorded.NeedsReview)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// HoldSweeper periodically releases investment holds whose TTL has passed
+type HoldSweeper struct {
+	investmentService domain.InvestmentService
+	interval          time.Duration
+	stop              chan struct{}
+}
+
+func NewHoldSweeper(investmentService domain.InvestmentService, interval time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		investmentService: investmentService,
+		interval:          interval,
+		stop:              make(chan struct{}),
+	}
+}
+
+func (w *HoldSweeper) Start(ctx context.Context) {
+	log.Println("Starting investment hold sweeper...")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			released, err := w.investmentService.ReleaseExpiredHolds(ctx)
+			if err != nil {
+				log.Printf("Error releasing expired holds: %v", err)
+				continue
+			}
+			if released > 0 {
+				log.Printf("Released %d expired investment holds", released)
+			}
+		}
+	}
+}
+
+func (w *HoldSweeper) Stop() {
+	log.Println("Stopping investment hold sweeper...")
+	close(w.stop)
+}
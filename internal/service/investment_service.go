@@ -4,97 +4,171 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
+// expiredHoldBatchSize bounds how many holds a single sweep releases
+const expiredHoldBatchSize = 100
+
+// agreementLetterBatchSize bounds how many loans a single retry sweep sends letters for
+const agreementLetterBatchSize = 50
+
 type investmentService struct {
 	investmentRepo      domain.InvestmentRepository
 	loanRepo            domain.LoanRepository
 	investorRepo        domain.InvestorRepository
+	holdRepo            domain.InvestmentHoldRepository
 	kafkaProducer       domain.KafkaProducer
 	notificationService domain.NotificationService
+	waitlistService     domain.WaitlistService
+	agreementService    domain.AgreementService
+	investmentConfig    *config.InvestmentConfig
 }
 
 func NewInvestmentService(
 	investmentRepo domain.InvestmentRepository,
 	loanRepo domain.LoanRepository,
 	investorRepo domain.InvestorRepository,
+	holdRepo domain.InvestmentHoldRepository,
 	kafkaProducer domain.KafkaProducer,
 	notificationService domain.NotificationService,
+	waitlistService domain.WaitlistService,
+	agreementService domain.AgreementService,
+	investmentConfig *config.InvestmentConfig,
 ) domain.InvestmentService {
 	return &investmentService{
 		investmentRepo:      investmentRepo,
 		loanRepo:            loanRepo,
 		investorRepo:        investorRepo,
+		holdRepo:            holdRepo,
 		kafkaProducer:       kafkaProducer,
 		notificationService: notificationService,
+		waitlistService:     waitlistService,
+		agreementService:    agreementService,
+		investmentConfig:    investmentConfig,
 	}
 }
 
-// RequestInvestment validates the request and publishes to Kafka
-func (s *investmentService) RequestInvestment(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, amount float64) error {
+// RequestInvestment validates the request, reserves the amount against the loan and publishes to Kafka
+func (s *investmentService) RequestInvestment(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, amount float64) (*domain.InvestmentHold, error) {
 	// Get investor to validate existence (userID is actually userID from the handler)
 	investor, err := s.investorRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return domain.ErrUserNotFound
+			return nil, domain.ErrUserNotFound
 		}
-		return err
+		return nil, err
+	}
+
+	if !investor.User.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
+	}
+	if !investor.User.IsKYCVerified() {
+		return nil, domain.ErrKYCNotVerified
 	}
 
-	// Get loan to validate (without lock, just for validation)
+	// Get loan to validate (without lock, the reservation below re-checks under lock)
 	loan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return domain.ErrLoanNotFound
+			return nil, domain.ErrLoanNotFound
 		}
-		return err
+		return nil, err
 	}
 
 	// Check if loan is approved (allow investment only for approved)
 	if loan.State != domain.LoanStateApproved {
-		return domain.ErrLoanNotApproved
+		return nil, domain.ErrLoanNotApproved
 	}
 
 	// Check if investor is trying to invest in their own loan (compare user IDs)
 	if loan.Borrower.UserID == userID {
-		return domain.ErrSelfInvestment
+		return nil, domain.ErrSelfInvestment
 	}
 
 	// Validate investment amount
 	if amount <= 0 {
-		return domain.ErrInvalidInvestmentAmount
+		return nil, domain.ErrInvalidInvestmentAmount
 	}
 
-	// Check if investment would exceed remaining amount (basic check, final check in consumer)
-	if amount > loan.RemainingInvestment {
-		return domain.ErrInvestmentExceedsLimit
+	// Reserve the amount so the consumer cannot lose this request to a concurrent one
+	hold, err := reserveInvestment(ctx, s.holdRepo, s.kafkaProducer, s.investmentConfig.HoldTTL, loanID, investor.ID, amount)
+	if err != nil {
+		// The released reservation may be what a waiting investor needs
+		if errors.Is(err, errInvestmentNotPublished) {
+			s.processWaitlist(ctx, loanID)
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// errInvestmentNotPublished reports a reservation that was released again because its event could not be published
+var errInvestmentNotPublished = errors.New("failed to publish investment event")
+
+// reserveInvestment creates an active hold for the investor and publishes the investment event
+// referencing it; the hold is released again if the event cannot be published
+func reserveInvestment(
+	ctx context.Context,
+	holdRepo domain.InvestmentHoldRepository,
+	kafkaProducer domain.KafkaProducer,
+	ttl time.Duration,
+	loanID uuid.UUID,
+	investorID uuid.UUID,
+	amount float64,
+) (*domain.InvestmentHold, error) {
+	now := time.Now()
+	hold := &domain.InvestmentHold{
+		ID:         uuid.New(),
+		LoanID:     loanID,
+		InvestorID: investorID,
+		Amount:     amount,
+		Status:     domain.HoldStatusActive,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if _, err := holdRepo.CreateWithLoanLock(ctx, hold); err != nil {
+		return nil, err
 	}
 
-	// Create investment event using the actual investor ID
+	// Create investment event referencing the hold
 	event := domain.InvestmentEvent{
 		ID:         uuid.New(),
+		HoldID:     hold.ID,
 		LoanID:     loanID,
-		InvestorID: investor.ID, // Use the actual investor ID, not user ID
+		InvestorID: investorID,
 		Amount:     amount,
-		Timestamp:  time.Now(),
+		Timestamp:  now,
 	}
 
-	// Publish to Kafka for processing
-	if err := s.kafkaProducer.PublishInvestmentEvent(ctx, event); err != nil {
-		return fmt.Errorf("failed to publish investment event: %w", err)
+	// Publish to Kafka for processing, giving the reservation back if that fails
+	if err := kafkaProducer.PublishInvestmentEvent(ctx, event); err != nil {
+		if releaseErr := holdRepo.Release(ctx, hold.ID, domain.HoldStatusReleased); releaseErr != nil {
+			log.Printf("Failed to release investment hold %s: %v", hold.ID, releaseErr)
+		}
+		return nil, fmt.Errorf("%w: %w", errInvestmentNotPublished, err)
 	}
 
-	return nil
+	return hold, nil
 }
 
 // ProcessInvestment handles the actual investment processing with transaction and locking
 func (s *investmentService) ProcessInvestment(ctx context.Context, event domain.InvestmentEvent) error {
+	// Events created from a reservation are converted atomically by the hold repository
+	if event.HoldID != uuid.Nil {
+		return s.processHeldInvestment(ctx, event)
+	}
+
 	// Get loan with pessimistic lock
 	loan, err := s.loanRepo.GetByIDWithLock(ctx, event.LoanID)
 	if err != nil {
@@ -106,8 +180,8 @@ func (s *investmentService) ProcessInvestment(ctx context.Context, event domain.
 		return fmt.Errorf("loan is no longer in approved state: %s", loan.State)
 	}
 
-	// Check if investment still fits within remaining amount
-	if event.Amount > loan.RemainingInvestment {
+	// Check if investment still fits within remaining amount not held by reservations
+	if event.Amount > loan.AvailableForInvestment() {
 		return domain.ErrInvestmentExceedsLimit
 	}
 
@@ -138,25 +212,188 @@ func (s *investmentService) ProcessInvestment(ctx context.Context, event domain.
 		return fmt.Errorf("failed to create investment with transaction: %w", err)
 	}
 
-	// If loan is fully funded, publish fully funded event and send agreement letters
-	if loan.State == domain.LoanStateInvested {
-		if s.kafkaProducer != nil {
-			if err := s.kafkaProducer.PublishFullyFundedLoan(ctx, loan); err != nil {
-				// Log error but don't fail the investment
-				fmt.Printf("Failed to publish fully funded loan event: %v\n", err)
+	s.handleFullyFunded(ctx, loan)
+
+	return nil
+}
+
+// processHeldInvestment converts the reservation referenced by the event into an investment
+func (s *investmentService) processHeldInvestment(ctx context.Context, event domain.InvestmentEvent) error {
+	investment := &domain.Investment{
+		ID:         event.ID,
+		LoanID:     event.LoanID,
+		InvestorID: event.InvestorID,
+		Amount:     event.Amount,
+		Status:     "completed",
+		CreatedAt:  event.Timestamp,
+		UpdatedAt:  time.Now(),
+	}
+
+	loan, err := s.holdRepo.ConvertToInvestment(ctx, event.HoldID, investment)
+	if err != nil {
+		// An expired hold will never convert, so hand its capacity to the waitlist right away
+		if errors.Is(err, domain.ErrHoldExpired) {
+			if releaseErr := s.holdRepo.Release(ctx, event.HoldID, domain.HoldStatusExpired); releaseErr != nil {
+				log.Printf("Failed to release expired hold %s: %v", event.HoldID, releaseErr)
+			} else {
+				s.processWaitlist(ctx, event.LoanID)
 			}
 		}
+		return fmt.Errorf("failed to convert investment hold %s: %w", event.HoldID, err)
+	}
 
+	s.handleFullyFunded(ctx, loan)
+
+	return nil
+}
+
+// handleFullyFunded sends agreement letters and prepares the borrower agreement once a loan is invested.
+// The fully funded event was recorded in the outbox with the investment and is published by the relay.
+func (s *investmentService) handleFullyFunded(ctx context.Context, loan *domain.Loan) {
+	if loan.State == domain.LoanStateInvested {
 		// Send agreement letters to all investors
 		if s.notificationService != nil {
 			if err := s.notificationService.SendAgreementLetters(ctx, loan.ID); err != nil {
 				// Log error but don't fail the investment
-				fmt.Printf("Failed to send agreement letters: %v\n", err)
+				log.Printf("Failed to send agreement letters for loan %s: %v", loan.ID, err)
+			}
+		}
+
+		// Generate the agreement the borrower has to sign before disbursement
+		if s.agreementService != nil {
+			if _, err := s.agreementService.GenerateBorrowerAgreement(ctx, loan.ID); err != nil {
+				// The borrower agreement is generated on first access when this fails
+				log.Printf("Failed to generate borrower agreement for loan %s: %v", loan.ID, err)
 			}
 		}
 	}
+}
 
-	return nil
+// GetHold returns a reservation owned by the investor behind userID
+func (s *investmentService) GetHold(ctx context.Context, userID uuid.UUID, holdID uuid.UUID) (*domain.InvestmentHold, error) {
+	investor, err := s.investorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.GetByID(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrHoldNotFound
+		}
+		return nil, err
+	}
+
+	// Do not reveal holds of other investors
+	if hold.InvestorID != investor.ID {
+		return nil, domain.ErrHoldNotFound
+	}
+
+	return hold, nil
+}
+
+// CancelInvestment withdraws an investment of the investor behind userID while the cooling-off period runs.
+// A fully funded loan opens for funding again and the freed amount is offered to the waitlist first.
+func (s *investmentService) CancelInvestment(ctx context.Context, userID uuid.UUID, investmentID uuid.UUID) (*domain.Investment, error) {
+	investor, err := s.investorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	investment, err := s.investmentRepo.GetByID(ctx, investmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvestmentNotFound
+		}
+		return nil, err
+	}
+
+	// Do not reveal investments of other investors
+	if investment.InvestorID != investor.ID {
+		return nil, domain.ErrInvestmentNotFound
+	}
+
+	if investment.Status != "completed" || time.Since(investment.CreatedAt) > s.investmentConfig.CoolingOffPeriod {
+		return nil, domain.ErrCoolingOffEnded
+	}
+
+	loan, err := s.investmentRepo.CancelWithLoanLock(ctx, investment.ID)
+	if err != nil {
+		return nil, err
+	}
+	investment.Status = "cancelled"
+
+	s.processWaitlist(ctx, loan.ID)
+
+	return investment, nil
+}
+
+// ReleaseExpiredHolds returns the amount of expired reservations to their loans
+func (s *investmentService) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	holds, err := s.holdRepo.GetExpired(ctx, time.Now(), expiredHoldBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired holds: %w", err)
+	}
+
+	released := 0
+	loanIDs := make(map[uuid.UUID]struct{})
+	for _, hold := range holds {
+		if err := s.holdRepo.Release(ctx, hold.ID, domain.HoldStatusExpired); err != nil {
+			log.Printf("Failed to release expired hold %s: %v", hold.ID, err)
+			continue
+		}
+		released++
+		loanIDs[hold.LoanID] = struct{}{}
+	}
+
+	// Released capacity goes to waiting investors first
+	for loanID := range loanIDs {
+		s.processWaitlist(ctx, loanID)
+	}
+
+	return released, nil
+}
+
+// RetryAgreementLetters sends the agreement letters still missing on funded loans, returning how many
+// loans were completed. An investment has no letter linked until one was stored for it, so a failure when
+// the loan was fully funded stays pending until a sweep succeeds.
+func (s *investmentService) RetryAgreementLetters(ctx context.Context) (int, error) {
+	if s.notificationService == nil {
+		return 0, nil
+	}
+
+	loanIDs, err := s.investmentRepo.GetLoanIDsPendingAgreementLetter(ctx, agreementLetterBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get loans pending agreement letters: %w", err)
+	}
+
+	sent := 0
+	for _, loanID := range loanIDs {
+		if err := s.notificationService.SendAgreementLetters(ctx, loanID); err != nil {
+			log.Printf("Failed to retry agreement letters for loan %s: %v", loanID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// processWaitlist offers freed capacity of a loan to its waitlist, logging failures
+func (s *investmentService) processWaitlist(ctx context.Context, loanID uuid.UUID) {
+	if s.waitlistService == nil {
+		return
+	}
+
+	if err := s.waitlistService.ProcessWaitlist(ctx, loanID); err != nil {
+		log.Printf("Failed to process waitlist for loan %s: %v", loanID, err)
+	}
 }
 
 func (s *investmentService) GetInvestorInvestments(ctx context.Context, investorID uuid.UUID) ([]domain.Investment, error) {
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -21,11 +23,61 @@ func (m *mockKafkaProducer) PublishInvestmentEvent(ctx context.Context, event do
 	return args.Error(0)
 }
 
-func (m *mockKafkaProducer) PublishFullyFundedLoan(ctx context.Context, loan *domain.Loan) error {
-	args := m.Called(ctx, loan)
+func (m *mockKafkaProducer) PublishOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
 	return args.Error(0)
 }
 
+// Mock Investment Hold Repository
+type mockInvestmentHoldRepository struct {
+	mock.Mock
+}
+
+func (m *mockInvestmentHoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.InvestmentHold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.InvestmentHold), args.Error(1)
+}
+
+func (m *mockInvestmentHoldRepository) CreateWithLoanLock(ctx context.Context, hold *domain.InvestmentHold) (*domain.Loan, error) {
+	args := m.Called(ctx, hold)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Loan), args.Error(1)
+}
+
+func (m *mockInvestmentHoldRepository) ConvertToInvestment(ctx context.Context, holdID uuid.UUID, investment *domain.Investment) (*domain.Loan, error) {
+	args := m.Called(ctx, holdID, investment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Loan), args.Error(1)
+}
+
+func (m *mockInvestmentHoldRepository) Release(ctx context.Context, holdID uuid.UUID, status domain.HoldStatus) error {
+	args := m.Called(ctx, holdID, status)
+	return args.Error(0)
+}
+
+func (m *mockInvestmentHoldRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]domain.InvestmentHold, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.InvestmentHold), args.Error(1)
+}
+
+func (m *mockInvestmentHoldRepository) GetActiveByInvestorID(ctx context.Context, investorID uuid.UUID) ([]domain.InvestmentHold, error) {
+	args := m.Called(ctx, investorID)
+	return args.Get(0).([]domain.InvestmentHold), args.Error(1)
+}
+
+var testInvestmentConfig = &config.InvestmentConfig{
+	HoldTTL:           5 * time.Minute,
+	HoldSweepInterval: time.Minute,
+	CoolingOffPeriod:  48 * time.Hour,
+}
+
 // Mock Notification Service
 type mockNotificationService struct {
 	mock.Mock
@@ -36,16 +88,38 @@ func (m *mockNotificationService) SendAgreementLetters(ctx context.Context, loan
 	return args.Error(0)
 }
 
+func (m *mockNotificationService) NotifyWaitlistPromoted(ctx context.Context, entry *domain.WaitlistEntry, hold *domain.InvestmentHold) error {
+	args := m.Called(ctx, entry, hold)
+	return args.Error(0)
+}
+
+func (m *mockNotificationService) SendSigningOTP(ctx context.Context, borrower *domain.Borrower, loanID uuid.UUID, code string, expiresAt time.Time) error {
+	args := m.Called(ctx, borrower, loanID, code, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockNotificationService) SendEmailVerification(ctx context.Context, user *domain.User, link string, expiresAt time.Time) error {
+	args := m.Called(ctx, user, link, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockNotificationService) NotifyKYCDecision(ctx context.Context, submission *domain.KYCSubmission) error {
+	args := m.Called(ctx, submission)
+	return args.Error(0)
+}
+
 // Test Investment Request - Happy Flow
 func TestInvestmentService_RequestInvestment_Success(t *testing.T) {
 	// Arrange
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	userID := uuid.New()
 	loanID := uuid.New()
@@ -56,6 +130,7 @@ func TestInvestmentService_RequestInvestment_Success(t *testing.T) {
 	investor := &domain.Investor{
 		ID:     investorID,
 		UserID: userID,
+		User:   verifiedUser(userID),
 	}
 
 	loan := &domain.Loan{
@@ -69,29 +144,263 @@ func TestInvestmentService_RequestInvestment_Success(t *testing.T) {
 
 	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
 	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
-	mockKafkaProducer.On("PublishInvestmentEvent", mock.Anything, mock.AnythingOfType("domain.InvestmentEvent")).Return(nil)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.AnythingOfType("*domain.InvestmentHold")).Return(loan, nil)
+	mockKafkaProducer.On("PublishInvestmentEvent", mock.Anything, mock.MatchedBy(func(event domain.InvestmentEvent) bool {
+		return event.HoldID != uuid.Nil && event.InvestorID == investorID
+	})).Return(nil)
 
 	// Act
-	err := investmentService.RequestInvestment(context.Background(), userID, loanID, amount)
+	hold, err := investmentService.RequestInvestment(context.Background(), userID, loanID, amount)
 
 	// Assert
 	assert.NoError(t, err)
+	assert.NotNil(t, hold)
+	assert.Equal(t, domain.HoldStatusActive, hold.Status)
+	assert.Equal(t, amount, hold.Amount)
+	assert.True(t, hold.ExpiresAt.After(time.Now()))
 
 	mockInvestorRepo.AssertExpectations(t)
 	mockLoanRepo.AssertExpectations(t)
+	mockHoldRepo.AssertExpectations(t)
 	mockKafkaProducer.AssertExpectations(t)
 }
 
+// Test Investment Request - Investors Without A Verified Identity Cannot Invest
+func TestInvestmentService_RequestInvestment_KYCNotVerified(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
+
+	userID := uuid.New()
+	user := verifiedUser(userID)
+	user.KYCVerifiedAt = nil
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: user}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+
+	// Act
+	hold, err := investmentService.RequestInvestment(context.Background(), userID, uuid.New(), 50000)
+
+	// Assert
+	assert.Equal(t, domain.ErrKYCNotVerified, err)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertNotCalled(t, "CreateWithLoanLock", mock.Anything, mock.Anything)
+	mockKafkaProducer.AssertNotCalled(t, "PublishInvestmentEvent", mock.Anything, mock.Anything)
+}
+
+// Test Investment Request - Reservation Rejected When Capacity Is Held
+func TestInvestmentService_RequestInvestment_ExceedsAvailable(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
+
+	userID := uuid.New()
+	loanID := uuid.New()
+
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: verifiedUser(userID)}
+	loan := &domain.Loan{
+		ID:                  loanID,
+		State:               domain.LoanStateApproved,
+		RemainingInvestment: 100000.0,
+		ReservedAmount:      80000.0,
+		Borrower:            domain.Borrower{UserID: uuid.New()},
+	}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.AnythingOfType("*domain.InvestmentHold")).Return(nil, domain.ErrInvestmentExceedsLimit)
+
+	// Act
+	hold, err := investmentService.RequestInvestment(context.Background(), userID, loanID, 50000.0)
+
+	// Assert
+	assert.Nil(t, hold)
+	assert.Equal(t, domain.ErrInvestmentExceedsLimit, err)
+	mockKafkaProducer.AssertNotCalled(t, "PublishInvestmentEvent", mock.Anything, mock.Anything)
+}
+
+// Test Investment Request - Hold Released When Publishing Fails
+func TestInvestmentService_RequestInvestment_PublishFailureReleasesHold(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
+
+	userID := uuid.New()
+	loanID := uuid.New()
+
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: verifiedUser(userID)}
+	loan := &domain.Loan{
+		ID:                  loanID,
+		State:               domain.LoanStateApproved,
+		RemainingInvestment: 100000.0,
+		Borrower:            domain.Borrower{UserID: uuid.New()},
+	}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.AnythingOfType("*domain.InvestmentHold")).Return(loan, nil)
+	mockKafkaProducer.On("PublishInvestmentEvent", mock.Anything, mock.AnythingOfType("domain.InvestmentEvent")).Return(assert.AnError)
+	mockHoldRepo.On("Release", mock.Anything, mock.AnythingOfType("uuid.UUID"), domain.HoldStatusReleased).Return(nil)
+	mockWaitlistService.On("ProcessWaitlist", mock.Anything, loanID).Return(nil)
+
+	// Act
+	hold, err := investmentService.RequestInvestment(context.Background(), userID, loanID, 50000.0)
+
+	// Assert - the released reservation is offered to the waitlist
+	assert.Error(t, err)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertExpectations(t)
+	mockWaitlistService.AssertExpectations(t)
+}
+
+// Test Investment Processing - Hold Converted To Investment
+func TestInvestmentService_ProcessInvestment_ConvertsHold(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
+
+	holdID := uuid.New()
+	loanID := uuid.New()
+	event := domain.InvestmentEvent{
+		ID:         uuid.New(),
+		HoldID:     holdID,
+		LoanID:     loanID,
+		InvestorID: uuid.New(),
+		Amount:     50000.0,
+		Timestamp:  time.Now(),
+	}
+
+	loan := &domain.Loan{
+		ID:                  loanID,
+		State:               domain.LoanStateApproved,
+		InvestedAmount:      50000.0,
+		RemainingInvestment: 50000.0,
+	}
+
+	mockHoldRepo.On("ConvertToInvestment", mock.Anything, holdID, mock.AnythingOfType("*domain.Investment")).Return(loan, nil)
+
+	// Act
+	err := investmentService.ProcessInvestment(context.Background(), event)
+
+	// Assert
+	assert.NoError(t, err)
+	mockHoldRepo.AssertExpectations(t)
+	mockLoanRepo.AssertNotCalled(t, "GetByIDWithLock", mock.Anything, mock.Anything)
+	mockKafkaProducer.AssertNotCalled(t, "PublishOutboxEvent", mock.Anything, mock.Anything)
+}
+
+// Test Release Expired Holds
+func TestInvestmentService_ReleaseExpiredHolds(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
+
+	loanID := uuid.New()
+	expired := []domain.InvestmentHold{
+		{ID: uuid.New(), LoanID: loanID, Amount: 10000, Status: domain.HoldStatusActive},
+		{ID: uuid.New(), LoanID: loanID, Amount: 20000, Status: domain.HoldStatusActive},
+	}
+
+	mockHoldRepo.On("GetExpired", mock.Anything, mock.AnythingOfType("time.Time"), expiredHoldBatchSize).Return(expired, nil)
+	mockHoldRepo.On("Release", mock.Anything, expired[0].ID, domain.HoldStatusExpired).Return(nil)
+	mockHoldRepo.On("Release", mock.Anything, expired[1].ID, domain.HoldStatusExpired).Return(nil)
+	mockWaitlistService.On("ProcessWaitlist", mock.Anything, loanID).Return(nil).Once()
+
+	// Act
+	released, err := investmentService.ReleaseExpiredHolds(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, released)
+	mockHoldRepo.AssertExpectations(t)
+	mockWaitlistService.AssertExpectations(t)
+}
+
+// Test Investment Cancellation - Within The Cooling-Off Period The Amount Goes To The Waitlist
+func TestInvestmentService_CancelInvestment(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockWaitlistService := new(mockWaitlistService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, new(mockLoanRepository), mockInvestorRepo, new(mockInvestmentHoldRepository), new(mockKafkaProducer), nil, mockWaitlistService, nil, testInvestmentConfig)
+
+	userID := uuid.New()
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID}
+	loanID := uuid.New()
+	recent := &domain.Investment{ID: uuid.New(), LoanID: loanID, InvestorID: investor.ID, Amount: 25000, Status: "completed", CreatedAt: time.Now().Add(-time.Hour)}
+	old := &domain.Investment{ID: uuid.New(), LoanID: loanID, InvestorID: investor.ID, Amount: 25000, Status: "completed", CreatedAt: time.Now().Add(-72 * time.Hour)}
+	othersInvestment := &domain.Investment{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 25000, Status: "completed", CreatedAt: time.Now()}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockInvestmentRepo.On("GetByID", mock.Anything, recent.ID).Return(recent, nil)
+	mockInvestmentRepo.On("GetByID", mock.Anything, old.ID).Return(old, nil)
+	mockInvestmentRepo.On("GetByID", mock.Anything, othersInvestment.ID).Return(othersInvestment, nil)
+	// The loan was fully funded and opens again
+	mockInvestmentRepo.On("CancelWithLoanLock", mock.Anything, recent.ID).Return(&domain.Loan{ID: loanID, State: domain.LoanStateApproved, RemainingInvestment: 25000}, nil)
+	mockWaitlistService.On("ProcessWaitlist", mock.Anything, loanID).Return(nil).Once()
+
+	// Act
+	cancelled, err := investmentService.CancelInvestment(context.Background(), userID, recent.ID)
+	_, lateErr := investmentService.CancelInvestment(context.Background(), userID, old.ID)
+	_, strangerErr := investmentService.CancelInvestment(context.Background(), userID, othersInvestment.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, domain.ErrCoolingOffEnded, lateErr)
+	assert.Equal(t, domain.ErrInvestmentNotFound, strangerErr)
+	mockInvestmentRepo.AssertNumberOfCalls(t, "CancelWithLoanLock", 1)
+	mockWaitlistService.AssertExpectations(t)
+}
+
 // Test Investment Processing - Happy Flow
 func TestInvestmentService_ProcessInvestment_Success(t *testing.T) {
 	// Arrange
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	eventID := uuid.New()
 	loanID := uuid.New()
@@ -132,10 +441,12 @@ func TestInvestmentService_ProcessInvestment_FullyFunded(t *testing.T) {
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	eventID := uuid.New()
 	loanID := uuid.New()
@@ -159,7 +470,6 @@ func TestInvestmentService_ProcessInvestment_FullyFunded(t *testing.T) {
 
 	mockLoanRepo.On("GetByIDWithLock", mock.Anything, loanID).Return(loan, nil)
 	mockInvestmentRepo.On("CreateWithTx", mock.Anything, mock.AnythingOfType("*domain.Investment"), mock.AnythingOfType("*domain.Loan")).Return(nil)
-	mockKafkaProducer.On("PublishFullyFundedLoan", mock.Anything, mock.AnythingOfType("*domain.Loan")).Return(nil)
 	mockNotificationService.On("SendAgreementLetters", mock.Anything, loanID).Return(nil)
 
 	// Act
@@ -170,7 +480,7 @@ func TestInvestmentService_ProcessInvestment_FullyFunded(t *testing.T) {
 
 	mockLoanRepo.AssertExpectations(t)
 	mockInvestmentRepo.AssertExpectations(t)
-	mockKafkaProducer.AssertExpectations(t)
+	mockKafkaProducer.AssertNotCalled(t, "PublishOutboxEvent", mock.Anything, mock.Anything) // Left to the outbox relay
 	mockNotificationService.AssertExpectations(t)
 }
 
@@ -180,10 +490,12 @@ func TestInvestmentService_GetInvestorInvestments_Success(t *testing.T) {
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	investorID := uuid.New()
 
@@ -211,10 +523,12 @@ func TestInvestmentService_RequestInvestment_SelfInvestmentError(t *testing.T) {
 	mockInvestmentRepo := new(mockInvestmentRepository)
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
 	mockKafkaProducer := new(mockKafkaProducer)
 	mockNotificationService := new(mockNotificationService)
+	mockWaitlistService := new(mockWaitlistService)
 
-	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockKafkaProducer, mockNotificationService)
+	investmentService := NewInvestmentService(mockInvestmentRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, mockWaitlistService, nil, testInvestmentConfig)
 
 	userID := uuid.New() // Same user ID for both investor and borrower
 	loanID := uuid.New()
@@ -224,6 +538,7 @@ func TestInvestmentService_RequestInvestment_SelfInvestmentError(t *testing.T) {
 	investor := &domain.Investor{
 		ID:     investorID,
 		UserID: userID,
+		User:   verifiedUser(userID),
 	}
 
 	loan := &domain.Loan{
@@ -239,12 +554,37 @@ func TestInvestmentService_RequestInvestment_SelfInvestmentError(t *testing.T) {
 	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
 
 	// Act
-	err := investmentService.RequestInvestment(context.Background(), userID, loanID, amount)
+	hold, err := investmentService.RequestInvestment(context.Background(), userID, loanID, amount)
 
 	// Assert
 	assert.Error(t, err)
+	assert.Nil(t, hold)
 	assert.Equal(t, domain.ErrSelfInvestment, err)
 
 	mockInvestorRepo.AssertExpectations(t)
 	mockLoanRepo.AssertExpectations(t)
 }
+
+// Test Agreement Letter Retry - Loans Still Missing Letters Are Sent Again, Failures Stay Pending
+func TestInvestmentService_RetryAgreementLetters(t *testing.T) {
+	// Arrange
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockNotificationService := new(mockNotificationService)
+
+	investmentService := NewInvestmentService(mockInvestmentRepo, new(mockLoanRepository), new(mockInvestorRepository), new(mockInvestmentHoldRepository), new(mockKafkaProducer), mockNotificationService, nil, nil, testInvestmentConfig)
+
+	deliveredLoanID := uuid.New()
+	failingLoanID := uuid.New()
+
+	mockInvestmentRepo.On("GetLoanIDsPendingAgreementLetter", mock.Anything, agreementLetterBatchSize).Return([]uuid.UUID{deliveredLoanID, failingLoanID}, nil)
+	mockNotificationService.On("SendAgreementLetters", mock.Anything, deliveredLoanID).Return(nil)
+	mockNotificationService.On("SendAgreementLetters", mock.Anything, failingLoanID).Return(errors.New("storage unavailable"))
+
+	// Act
+	sent, err := investmentService.RetryAgreementLetters(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	mockNotificationService.AssertExpectations(t)
+}
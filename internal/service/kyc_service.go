@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type kycService struct {
+	submissionRepo      domain.KYCSubmissionRepository
+	userRepo            domain.UserRepository
+	borrowerRepo        domain.BorrowerRepository
+	investorRepo        domain.InvestorRepository
+	auditRepo           domain.AuditRepository
+	storage             domain.FileStorage
+	notificationService domain.NotificationService
+	kycConfig           *config.KYCConfig
+}
+
+func NewKYCService(
+	submissionRepo domain.KYCSubmissionRepository,
+	userRepo domain.UserRepository,
+	borrowerRepo domain.BorrowerRepository,
+	investorRepo domain.InvestorRepository,
+	auditRepo domain.AuditRepository,
+	storage domain.FileStorage,
+	notificationService domain.NotificationService,
+	kycConfig *config.KYCConfig,
+) domain.KYCService {
+	return &kycService{
+		submissionRepo:      submissionRepo,
+		userRepo:            userRepo,
+		borrowerRepo:        borrowerRepo,
+		investorRepo:        investorRepo,
+		auditRepo:           auditRepo,
+		storage:             storage,
+		notificationService: notificationService,
+		kycConfig:           kycConfig,
+	}
+}
+
+func (s *kycService) SubmitKYC(ctx context.Context, userID uuid.UUID, idCard domain.KYCUpload, selfie domain.KYCUpload) (*domain.KYCSubmission, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.Role != domain.RoleBorrower && user.Role != domain.RoleInvestor {
+		return nil, domain.ErrInvalidRole
+	}
+	if user.IsKYCVerified() {
+		return nil, domain.ErrKYCAlreadyVerified
+	}
+
+	latest, err := s.submissionRepo.GetLatestByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Status == domain.KYCStatusPending {
+		return nil, domain.ErrKYCPending
+	}
+
+	now := time.Now()
+	submission := &domain.KYCSubmission{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    domain.KYCStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	uploads := []struct {
+		docType domain.KYCDocumentType
+		upload  domain.KYCUpload
+	}{
+		{domain.KYCDocumentIDCard, idCard},
+		{domain.KYCDocumentSelfie, selfie},
+	}
+	// Check both images before anything is stored
+	for _, u := range uploads {
+		document, err := s.newDocument(submission, u.docType, u.upload, now)
+		if err != nil {
+			return nil, err
+		}
+		submission.Documents = append(submission.Documents, *document)
+	}
+
+	for i, document := range submission.Documents {
+		if err := s.storage.Put(ctx, document.StorageKey, uploads[i].upload.Data, document.ContentType); err != nil {
+			s.deleteStored(ctx, submission.Documents[:i])
+			return nil, fmt.Errorf("failed to store KYC document: %w", err)
+		}
+	}
+
+	if err := s.submissionRepo.Create(ctx, submission); err != nil {
+		// Do not leave orphaned identity documents behind
+		s.deleteStored(ctx, submission.Documents)
+		return nil, err
+	}
+
+	log.Printf("User %s submitted KYC %s for review", userID, submission.ID)
+	return submission, nil
+}
+
+// newDocument validates an uploaded image and describes where it is stored
+func (s *kycService) newDocument(submission *domain.KYCSubmission, docType domain.KYCDocumentType, upload domain.KYCUpload, now time.Time) (*domain.KYCDocument, error) {
+	if len(upload.Data) == 0 {
+		return nil, domain.ErrEmptyFile
+	}
+	if int64(len(upload.Data)) > s.kycConfig.MaxUploadSize {
+		return nil, domain.ErrFileTooLarge
+	}
+
+	// Trust the file content, not the client supplied name or header
+	contentType := http.DetectContentType(upload.Data)
+	ext, ok := allowedPhotoTypes[contentType]
+	if !ok {
+		return nil, domain.ErrUnsupportedFileType
+	}
+
+	sum := sha256.Sum256(upload.Data)
+	document := &domain.KYCDocument{
+		ID:           uuid.New(),
+		SubmissionID: submission.ID,
+		Type:         docType,
+		ContentType:  contentType,
+		Size:         int64(len(upload.Data)),
+		SHA256:       hex.EncodeToString(sum[:]),
+		OriginalName: upload.FileName,
+		CreatedAt:    now,
+	}
+	document.StorageKey = fmt.Sprintf("kyc/%s/%s/%s.%s", submission.UserID, submission.ID, document.ID, ext)
+	return document, nil
+}
+
+func (s *kycService) deleteStored(ctx context.Context, documents []domain.KYCDocument) {
+	for _, document := range documents {
+		if err := s.storage.Delete(ctx, document.StorageKey); err != nil {
+			log.Printf("Failed to delete KYC document %s: %v", document.StorageKey, err)
+		}
+	}
+}
+
+func (s *kycService) GetMyKYC(ctx context.Context, userID uuid.UUID) (*domain.KYCSubmission, error) {
+	submission, err := s.submissionRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrKYCSubmissionNotFound
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+func (s *kycService) GetReviewQueue(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, int64, error) {
+	if status == "" {
+		status = domain.KYCStatusPending
+	}
+	return s.submissionRepo.ListByStatus(ctx, status, limit, offset)
+}
+
+// GetReview returns the submission with the name and identity number the customer registered,
+// which the reviewer compares with the ID card
+func (s *kycService) GetReview(ctx context.Context, submissionID uuid.UUID) (*domain.KYCReview, error) {
+	submission, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &domain.KYCReview{Submission: submission}
+	switch submission.User.Role {
+	case domain.RoleBorrower:
+		borrower, err := s.borrowerRepo.GetByUserID(ctx, submission.UserID)
+		if err != nil {
+			return nil, err
+		}
+		review.FullName = borrower.FullName
+		review.IdentityNumber = borrower.IdentityNumber
+	case domain.RoleInvestor:
+		investor, err := s.investorRepo.GetByUserID(ctx, submission.UserID)
+		if err != nil {
+			return nil, err
+		}
+		review.FullName = investor.FullName
+		review.IdentityNumber = investor.IdentityNumber
+	}
+	return review, nil
+}
+
+// GetKYCDocument returns the document metadata and its content, the caller must close the reader
+func (s *kycService) GetKYCDocument(ctx context.Context, submissionID uuid.UUID, documentID uuid.UUID) (*domain.KYCDocument, io.ReadCloser, error) {
+	submission, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range submission.Documents {
+		document := &submission.Documents[i]
+		if document.ID != documentID {
+			continue
+		}
+		content, err := s.storage.Get(ctx, document.StorageKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return document, content, nil
+	}
+	return nil, nil, domain.ErrKYCDocumentNotFound
+}
+
+func (s *kycService) ApproveKYC(ctx context.Context, reviewerID uuid.UUID, submissionID uuid.UUID) (*domain.KYCSubmission, error) {
+	return s.decide(ctx, reviewerID, submissionID, domain.KYCStatusVerified, "")
+}
+
+func (s *kycService) RejectKYC(ctx context.Context, reviewerID uuid.UUID, submissionID uuid.UUID, reason string) (*domain.KYCSubmission, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrRejectionReasonMissing
+	}
+	return s.decide(ctx, reviewerID, submissionID, domain.KYCStatusRejected, reason)
+}
+
+func (s *kycService) decide(ctx context.Context, reviewerID uuid.UUID, submissionID uuid.UUID, status domain.KYCStatus, reason string) (*domain.KYCSubmission, error) {
+	submission, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if submission.Status != domain.KYCStatusPending {
+		return nil, domain.ErrKYCAlreadyReviewed
+	}
+	// Nobody verifies their own identity
+	if submission.UserID == reviewerID {
+		s.audit(ctx, reviewerID, "kyc."+string(status), submission.ID, domain.AuditOutcomeDenied, domain.ErrSegregationOfDuties.Error())
+		return nil, domain.ErrSegregationOfDuties
+	}
+
+	now := time.Now()
+	submission.Status = status
+	submission.ReviewerID = &reviewerID
+	submission.ReviewedAt = &now
+	submission.RejectionReason = reason
+	submission.UpdatedAt = now
+
+	decided, err := s.submissionRepo.Decide(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, domain.ErrKYCAlreadyReviewed
+	}
+	if status == domain.KYCStatusVerified {
+		submission.User.KYCVerifiedAt = &now
+	}
+
+	s.audit(ctx, reviewerID, "kyc."+string(status), submission.ID, domain.AuditOutcomeAllowed, reason)
+
+	// The decision stands even if the customer could not be told about it
+	if err := s.notificationService.NotifyKYCDecision(ctx, submission); err != nil {
+		log.Printf("Failed to notify user %s of KYC decision: %v", submission.UserID, err)
+	}
+
+	return submission, nil
+}
+
+func (s *kycService) getSubmission(ctx context.Context, submissionID uuid.UUID) (*domain.KYCSubmission, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrKYCSubmissionNotFound
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+func (s *kycService) audit(ctx context.Context, actorID uuid.UUID, action string, submissionID uuid.UUID, outcome domain.AuditOutcome, reason string) {
+	writeAudit(ctx, s.auditRepo, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: "kyc_submission",
+		EntityID:   &submissionID,
+		Outcome:    outcome,
+		Reason:     reason,
+	})
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock KYC Submission Repository
+type mockKYCSubmissionRepository struct {
+	mock.Mock
+}
+
+func (m *mockKYCSubmissionRepository) Create(ctx context.Context, submission *domain.KYCSubmission) error {
+	args := m.Called(ctx, submission)
+	return args.Error(0)
+}
+
+func (m *mockKYCSubmissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.KYCSubmission, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.KYCSubmission), args.Error(1)
+}
+
+func (m *mockKYCSubmissionRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*domain.KYCSubmission, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.KYCSubmission), args.Error(1)
+}
+
+func (m *mockKYCSubmissionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]domain.KYCSubmission, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.KYCSubmission), args.Error(1)
+}
+
+func (m *mockKYCSubmissionRepository) ListByStatus(ctx context.Context, status domain.KYCStatus, limit, offset int) ([]domain.KYCSubmission, int64, error) {
+	args := m.Called(ctx, status, limit, offset)
+	return args.Get(0).([]domain.KYCSubmission), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockKYCSubmissionRepository) Decide(ctx context.Context, submission *domain.KYCSubmission) (bool, error) {
+	args := m.Called(ctx, submission)
+	return args.Bool(0), args.Error(1)
+}
+
+var testKYCConfig = &config.KYCConfig{
+	MaxUploadSize: 1024,
+}
+
+// jpegHeader is enough for content sniffing to detect a JPEG image
+var jpegHeader = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+
+// pendingSubmission is a submission of a borrower awaiting review
+func pendingSubmission(userID uuid.UUID) *domain.KYCSubmission {
+	submissionID := uuid.New()
+	return &domain.KYCSubmission{
+		ID:     submissionID,
+		UserID: userID,
+		Status: domain.KYCStatusPending,
+		User:   domain.User{ID: userID, Email: "borrower@example.com", Role: domain.RoleBorrower},
+		Documents: []domain.KYCDocument{
+			{ID: uuid.New(), SubmissionID: submissionID, Type: domain.KYCDocumentIDCard, StorageKey: "kyc/id-card.jpg"},
+			{ID: uuid.New(), SubmissionID: submissionID, Type: domain.KYCDocumentSelfie, StorageKey: "kyc/selfie.png"},
+		},
+	}
+}
+
+// Test KYC Submission - Happy Flow
+func TestKYCService_SubmitKYC_Success(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Role: domain.RoleInvestor}
+	rejected := &domain.KYCSubmission{ID: uuid.New(), UserID: userID, Status: domain.KYCStatusRejected}
+
+	mockUserRepo.On("GetByID", mock.Anything, userID).Return(user, nil)
+	mockSubmissionRepo.On("GetLatestByUserID", mock.Anything, userID).Return(rejected, nil)
+	mockStorage.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "kyc/"+userID.String()+"/") && strings.HasSuffix(key, ".jpg")
+	}), jpegHeader, "image/jpeg").Return(nil).Once()
+	mockStorage.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "kyc/"+userID.String()+"/") && strings.HasSuffix(key, ".png")
+	}), pngHeader, "image/png").Return(nil).Once()
+	mockSubmissionRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.KYCSubmission")).Return(nil)
+
+	// Act
+	submission, err := kycService.SubmitKYC(context.Background(), userID,
+		domain.KYCUpload{FileName: "ktp.jpg", Data: jpegHeader},
+		domain.KYCUpload{FileName: "selfie.png", Data: pngHeader})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.KYCStatusPending, submission.Status)
+	assert.Equal(t, userID, submission.UserID)
+	assert.Len(t, submission.Documents, 2)
+	assert.Equal(t, domain.KYCDocumentIDCard, submission.Documents[0].Type)
+	assert.Equal(t, "image/jpeg", submission.Documents[0].ContentType)
+	assert.Equal(t, domain.KYCDocumentSelfie, submission.Documents[1].Type)
+	assert.Equal(t, submission.ID, submission.Documents[1].SubmissionID)
+	mockStorage.AssertExpectations(t)
+	mockSubmissionRepo.AssertExpectations(t)
+}
+
+// Test KYC Submission - Refused While The Previous One Awaits Review
+func TestKYCService_SubmitKYC_Pending(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID, Role: domain.RoleBorrower}, nil)
+	mockSubmissionRepo.On("GetLatestByUserID", mock.Anything, userID).Return(pendingSubmission(userID), nil)
+
+	// Act
+	submission, err := kycService.SubmitKYC(context.Background(), userID,
+		domain.KYCUpload{Data: jpegHeader}, domain.KYCUpload{Data: pngHeader})
+
+	// Assert
+	assert.Equal(t, domain.ErrKYCPending, err)
+	assert.Nil(t, submission)
+	mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test KYC Submission - Nothing Is Stored When One Image Is Not A JPEG Or PNG
+func TestKYCService_SubmitKYC_UnsupportedType(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID, Role: domain.RoleBorrower}, nil)
+	mockSubmissionRepo.On("GetLatestByUserID", mock.Anything, userID).Return(nil, gorm.ErrRecordNotFound)
+
+	// Act
+	submission, err := kycService.SubmitKYC(context.Background(), userID,
+		domain.KYCUpload{Data: jpegHeader}, domain.KYCUpload{Data: []byte("%PDF-1.4 not a selfie")})
+
+	// Assert
+	assert.Equal(t, domain.ErrUnsupportedFileType, err)
+	assert.Nil(t, submission)
+	mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test KYC Submission - Stored Images Are Removed When The Submission Cannot Be Saved
+func TestKYCService_SubmitKYC_CleansUpOnFailure(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID, Role: domain.RoleBorrower}, nil)
+	mockSubmissionRepo.On("GetLatestByUserID", mock.Anything, userID).Return(nil, gorm.ErrRecordNotFound)
+	mockStorage.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockSubmissionRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("database unavailable"))
+	mockStorage.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+	// Act
+	submission, err := kycService.SubmitKYC(context.Background(), userID,
+		domain.KYCUpload{Data: jpegHeader}, domain.KYCUpload{Data: pngHeader})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, submission)
+	mockStorage.AssertNumberOfCalls(t, "Put", 2)
+	mockStorage.AssertNumberOfCalls(t, "Delete", 2)
+}
+
+// Test KYC Approval - Happy Flow
+func TestKYCService_ApproveKYC_Success(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	reviewerID := uuid.New()
+	submission := pendingSubmission(uuid.New())
+
+	mockSubmissionRepo.On("GetByID", mock.Anything, submission.ID).Return(submission, nil)
+	mockSubmissionRepo.On("Decide", mock.Anything, mock.MatchedBy(func(s *domain.KYCSubmission) bool {
+		return s.Status == domain.KYCStatusVerified && *s.ReviewerID == reviewerID && s.ReviewedAt != nil
+	})).Return(true, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "kyc.verified" && *entry.EntityID == submission.ID && entry.Outcome == domain.AuditOutcomeAllowed
+	})).Return(nil)
+	mockNotificationService.On("NotifyKYCDecision", mock.Anything, submission).Return(nil)
+
+	// Act
+	decided, err := kycService.ApproveKYC(context.Background(), reviewerID, submission.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.KYCStatusVerified, decided.Status)
+	assert.True(t, decided.User.IsKYCVerified())
+	mockSubmissionRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+	mockNotificationService.AssertExpectations(t)
+}
+
+// Test KYC Approval - A Concurrent Review Decided First
+func TestKYCService_ApproveKYC_AlreadyReviewed(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	submission := pendingSubmission(uuid.New())
+	mockSubmissionRepo.On("GetByID", mock.Anything, submission.ID).Return(submission, nil)
+	mockSubmissionRepo.On("Decide", mock.Anything, mock.Anything).Return(false, nil)
+
+	// Act
+	decided, err := kycService.ApproveKYC(context.Background(), uuid.New(), submission.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrKYCAlreadyReviewed, err)
+	assert.Nil(t, decided)
+	mockNotificationService.AssertNotCalled(t, "NotifyKYCDecision", mock.Anything, mock.Anything)
+}
+
+// Test KYC Rejection - A Reason Is Required
+func TestKYCService_RejectKYC_ReasonRequired(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	// Act
+	decided, err := kycService.RejectKYC(context.Background(), uuid.New(), uuid.New(), "  ")
+
+	// Assert
+	assert.Equal(t, domain.ErrRejectionReasonMissing, err)
+	assert.Nil(t, decided)
+	mockSubmissionRepo.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything)
+}
+
+// Test KYC Rejection - The Customer Is Told Why
+func TestKYCService_RejectKYC_Success(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	submission := pendingSubmission(uuid.New())
+	mockSubmissionRepo.On("GetByID", mock.Anything, submission.ID).Return(submission, nil)
+	mockSubmissionRepo.On("Decide", mock.Anything, mock.Anything).Return(true, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockNotificationService.On("NotifyKYCDecision", mock.Anything, submission).Return(nil)
+
+	// Act
+	decided, err := kycService.RejectKYC(context.Background(), uuid.New(), submission.ID, "ID card photo is blurred")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.KYCStatusRejected, decided.Status)
+	assert.Equal(t, "ID card photo is blurred", decided.RejectionReason)
+	assert.False(t, decided.User.IsKYCVerified())
+	mockNotificationService.AssertExpectations(t)
+}
+
+// Test KYC Review - The Registered Details Are Shown Next To The Documents
+func TestKYCService_GetReview_Borrower(t *testing.T) {
+	// Arrange
+	mockSubmissionRepo := new(mockKYCSubmissionRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockStorage := new(mockFileStorage)
+	mockNotificationService := new(mockNotificationService)
+
+	kycService := NewKYCService(mockSubmissionRepo, mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockAuditRepo, mockStorage, mockNotificationService, testKYCConfig)
+
+	userID := uuid.New()
+	submission := pendingSubmission(userID)
+	submission.CreatedAt = time.Now()
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: userID, FullName: "Jane Borrower", IdentityNumber: "3171234567890001"}
+
+	mockSubmissionRepo.On("GetByID", mock.Anything, submission.ID).Return(submission, nil)
+	mockBorrowerRepo.On("GetByUserID", mock.Anything, userID).Return(borrower, nil)
+
+	// Act
+	review, err := kycService.GetReview(context.Background(), submission.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, submission, review.Submission)
+	assert.Equal(t, "Jane Borrower", review.FullName)
+	assert.Equal(t, "3171234567890001", review.IdentityNumber)
+	mockInvestorRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything)
+}
@@ -2,46 +2,93 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/sigitisme/amf-loan-service/internal/config"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
 type loanService struct {
-	loanRepo         domain.LoanRepository
-	approvalRepo     domain.ApprovalRepository
-	disbursementRepo domain.DisbursementRepository
-	investmentRepo   domain.InvestmentRepository
-	borrowerRepo     domain.BorrowerRepository
+	loanRepo           domain.LoanRepository
+	approvalRepo       domain.ApprovalRepository
+	approvalStageRepo  domain.ApprovalStageRepository
+	photoProofRepo     domain.PhotoProofRepository
+	documentRepo       domain.DocumentRepository
+	signatureRepo      domain.AgreementSignatureRepository
+	storage            domain.FileStorage
+	disbursementRepo   domain.DisbursementRepository
+	investmentRepo     domain.InvestmentRepository
+	waitlistRepo       domain.WaitlistRepository
+	borrowerRepo       domain.BorrowerRepository
+	taskService        domain.TaskService
+	segregationService domain.SegregationService
+	approvalConfig     *config.ApprovalConfig
 }
 
 func NewLoanService(
 	loanRepo domain.LoanRepository,
 	approvalRepo domain.ApprovalRepository,
+	approvalStageRepo domain.ApprovalStageRepository,
+	photoProofRepo domain.PhotoProofRepository,
+	documentRepo domain.DocumentRepository,
+	signatureRepo domain.AgreementSignatureRepository,
+	storage domain.FileStorage,
 	disbursementRepo domain.DisbursementRepository,
 	investmentRepo domain.InvestmentRepository,
+	waitlistRepo domain.WaitlistRepository,
 	borrowerRepo domain.BorrowerRepository,
+	taskService domain.TaskService,
+	segregationService domain.SegregationService,
+	approvalConfig *config.ApprovalConfig,
 ) domain.LoanService {
 	return &loanService{
-		loanRepo:         loanRepo,
-		approvalRepo:     approvalRepo,
-		disbursementRepo: disbursementRepo,
-		investmentRepo:   investmentRepo,
-		borrowerRepo:     borrowerRepo,
+		loanRepo:           loanRepo,
+		approvalRepo:       approvalRepo,
+		approvalStageRepo:  approvalStageRepo,
+		photoProofRepo:     photoProofRepo,
+		documentRepo:       documentRepo,
+		signatureRepo:      signatureRepo,
+		storage:            storage,
+		disbursementRepo:   disbursementRepo,
+		investmentRepo:     investmentRepo,
+		waitlistRepo:       waitlistRepo,
+		borrowerRepo:       borrowerRepo,
+		taskService:        taskService,
+		segregationService: segregationService,
+		approvalConfig:     approvalConfig,
 	}
 }
 
-func (s *loanService) CreateLoan(ctx context.Context, userID uuid.UUID, principalAmount, rate float64) (*domain.Loan, error) {
+// defaultTenorMonths is used when the borrower does not ask for a specific loan term
+const defaultTenorMonths = 12
+
+func (s *loanService) CreateLoan(ctx context.Context, userID uuid.UUID, principalAmount, rate float64, tenorMonths int) (*domain.Loan, error) {
 	// Get borrower by user ID
 	borrower, err := s.borrowerRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if !borrower.User.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
+	}
+	if !borrower.User.IsKYCVerified() {
+		return nil, domain.ErrKYCNotVerified
+	}
+
+	if tenorMonths <= 0 {
+		tenorMonths = defaultTenorMonths
+	}
+
 	// Calculate total interest that borrower must pay
 	totalInterest := principalAmount * rate
 
@@ -57,6 +104,7 @@ func (s *loanService) CreateLoan(ctx context.Context, userID uuid.UUID, principa
 		Rate:                rate,
 		ROI:                 roi,
 		TotalInterest:       totalInterest,
+		TenorMonths:         tenorMonths,
 		State:               domain.LoanStateProposed,
 		CreatedAt:           time.Now(),
 		UpdatedAt:           time.Now(),
@@ -67,10 +115,15 @@ func (s *loanService) CreateLoan(ctx context.Context, userID uuid.UUID, principa
 		return nil, err
 	}
 
+	// Route the field verification to a validator, the task sweeper retries loans left unassigned
+	if _, err := s.taskService.CreateVerificationTask(ctx, loan.ID, borrower.Region); err != nil {
+		log.Printf("Failed to assign verification task for loan %s: %v", loan.ID, err)
+	}
+
 	return loan, nil
 }
 
-func (s *loanService) ApproveLoan(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID, photoProofURL string, approvalDate time.Time) error {
+func (s *loanService) ApproveLoan(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID, photoProofID uuid.UUID, approvalDate time.Time, evidence domain.FieldEvidence) error {
 	// Get loan
 	loan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
@@ -81,30 +134,264 @@ func (s *loanService) ApproveLoan(ctx context.Context, loanID uuid.UUID, validat
 	}
 
 	// Check if loan is in proposed state
+	if loan.State == domain.LoanStateRejected {
+		return domain.ErrLoanRejected
+	}
 	if loan.State != domain.LoanStateProposed {
 		return domain.ErrLoanAlreadyApproved
 	}
 
+	// Field verification happens once per loan
+	if loan.Approval != nil {
+		return domain.ErrApprovalStageCompleted
+	}
+
+	// The photo proof must have been uploaded for this loan by the approving validator
+	proof, err := s.photoProofRepo.GetByID(ctx, photoProofID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrPhotoProofNotFound
+		}
+		return err
+	}
+	if proof.LoanID != loanID || proof.UploaderID != validatorID {
+		return domain.ErrPhotoProofNotFound
+	}
+
+	// Only the validator the verification task is assigned to may approve
+	if err := s.taskService.EnsureAssignee(ctx, loanID, validatorID); err != nil {
+		return err
+	}
+
+	if err := s.segregationService.CheckActor(ctx, loan, domain.LoanActionFieldVerification, validatorID); err != nil {
+		return err
+	}
+
+	// Evidence that is too far away, too old or inconsistent is accepted but flagged for credit review
+	now := time.Now()
+	distance, flags := s.assessFieldEvidence(&loan.Borrower, proof, evidence, now)
+
 	// Create approval record
 	approval := &domain.Approval{
-		ID:            uuid.New(),
-		LoanID:        loanID,
-		ValidatorID:   validatorID,
-		PhotoProofURL: photoProofURL,
-		ApprovalDate:  approvalDate,
-		CreatedAt:     time.Now(),
+		ID:             uuid.New(),
+		LoanID:         loanID,
+		ValidatorID:    validatorID,
+		PhotoProofID:   &proof.ID,
+		PhotoProofURL:  photoProofPath(proof),
+		ApprovalDate:   approvalDate,
+		Latitude:       evidence.Latitude,
+		Longitude:      evidence.Longitude,
+		CapturedAt:     evidence.CapturedAt,
+		DistanceMeters: distance,
+		EvidenceFlags:  flags,
+		NeedsReview:    len(flags) > 0,
+		CreatedAt:      now,
 	}
 
-	err = s.approvalRepo.Create(ctx, approval)
+	// The approval decides whether a credit review is required, so it is set before the stages are evaluated
+	loan.Approval = approval
+
+	// Record the field verification as the first approval stage, together with the approval
+	err = s.recordStageDecision(ctx, loan, approval, domain.ApprovalStageFieldVerification, validatorID, domain.RoleFieldValidator, domain.ApprovalDecisionApproved, "", approvalDate)
 	if err != nil {
+		loan.Approval = nil
 		return err
 	}
 
-	// Update loan state
-	loan.State = domain.LoanStateApproved
-	loan.UpdatedAt = time.Now()
+	if err := s.taskService.CompleteVerificationTask(ctx, loanID); err != nil {
+		// Log error but don't fail the approval, it is already recorded
+		log.Printf("Failed to complete verification task of loan %s: %v", loanID, err)
+	}
+
+	return nil
+}
+
+// GetFlaggedApprovals returns field verifications whose evidence needs a closer look
+func (s *loanService) GetFlaggedApprovals(ctx context.Context) ([]domain.Approval, error) {
+	return s.approvalRepo.GetNeedingReview(ctx)
+}
+
+// SubmitCreditReview records the credit analyst's decision after field verification
+func (s *loanService) SubmitCreditReview(ctx context.Context, loanID uuid.UUID, analystID uuid.UUID, decision domain.ApprovalDecision, comment string) error {
+	loan, err := s.getLoanInReview(ctx, loanID, decision)
+	if err != nil {
+		return err
+	}
+
+	if !s.isStageRequired(loan, domain.ApprovalStageCreditReview) {
+		return domain.ErrApprovalStageNotRequired
+	}
+
+	if !s.stagePassed(loan.ApprovalStages, domain.ApprovalStageFieldVerification) {
+		return domain.ErrApprovalStageOutOfOrder
+	}
+
+	if hasStageDecision(loan.ApprovalStages, domain.ApprovalStageCreditReview, uuid.Nil) {
+		return domain.ErrApprovalStageCompleted
+	}
+
+	if err := s.segregationService.CheckActor(ctx, loan, domain.LoanActionCreditReview, analystID); err != nil {
+		return err
+	}
+
+	return s.recordStageDecision(ctx, loan, nil, domain.ApprovalStageCreditReview, analystID, domain.RoleCreditAnalyst, decision, comment, time.Now())
+}
+
+// SubmitCommitteeDecision records a committee member's sign-off for loans above the committee threshold
+func (s *loanService) SubmitCommitteeDecision(ctx context.Context, loanID uuid.UUID, memberID uuid.UUID, decision domain.ApprovalDecision, comment string) error {
+	loan, err := s.getLoanInReview(ctx, loanID, decision)
+	if err != nil {
+		return err
+	}
+
+	if !s.isStageRequired(loan, domain.ApprovalStageCommitteeSignoff) {
+		return domain.ErrApprovalStageNotRequired
+	}
+
+	// The committee only sees loans that passed every earlier stage
+	previousStage := domain.ApprovalStageFieldVerification
+	if s.isStageRequired(loan, domain.ApprovalStageCreditReview) {
+		previousStage = domain.ApprovalStageCreditReview
+	}
+	if !s.stagePassed(loan.ApprovalStages, previousStage) {
+		return domain.ErrApprovalStageOutOfOrder
+	}
+
+	// Each member signs off once
+	if hasStageDecision(loan.ApprovalStages, domain.ApprovalStageCommitteeSignoff, memberID) {
+		return domain.ErrApprovalStageCompleted
+	}
+
+	if err := s.segregationService.CheckActor(ctx, loan, domain.LoanActionCommitteeSignoff, memberID); err != nil {
+		return err
+	}
+
+	return s.recordStageDecision(ctx, loan, nil, domain.ApprovalStageCommitteeSignoff, memberID, domain.RoleCreditCommittee, decision, comment, time.Now())
+}
+
+func (s *loanService) GetApprovalStages(ctx context.Context, loanID uuid.UUID) ([]domain.ApprovalStage, error) {
+	if _, err := s.GetLoanByID(ctx, loanID); err != nil {
+		return nil, err
+	}
+	return s.approvalStageRepo.GetByLoanID(ctx, loanID)
+}
+
+// getLoanInReview loads a loan that is still going through the approval workflow
+func (s *loanService) getLoanInReview(ctx context.Context, loanID uuid.UUID, decision domain.ApprovalDecision) (*domain.Loan, error) {
+	if decision != domain.ApprovalDecisionApproved && decision != domain.ApprovalDecisionRejected {
+		return nil, domain.ErrInvalidApprovalDecision
+	}
+
+	loan, err := s.GetLoanByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch loan.State {
+	case domain.LoanStateProposed:
+		return loan, nil
+	case domain.LoanStateRejected:
+		return nil, domain.ErrLoanRejected
+	default:
+		return nil, domain.ErrLoanAlreadyApproved
+	}
+}
+
+// recordStageDecision stores the decision and moves the loan to approved once every required stage
+// has passed, or to rejected as soon as any stage rejects it. The repository does all of it in one
+// transaction against the locked loan, so concurrent decisions cannot lose a state change.
+func (s *loanService) recordStageDecision(
+	ctx context.Context,
+	loan *domain.Loan,
+	approval *domain.Approval,
+	stageType domain.ApprovalStageType,
+	actorID uuid.UUID,
+	actorRole domain.UserRole,
+	decision domain.ApprovalDecision,
+	comment string,
+	decidedAt time.Time,
+) error {
+	stage := domain.ApprovalStage{
+		ID:        uuid.New(),
+		LoanID:    loan.ID,
+		Stage:     stageType,
+		ActorID:   actorID,
+		ActorRole: actorRole,
+		Decision:  decision,
+		Comment:   comment,
+		DecidedAt: decidedAt,
+		CreatedAt: time.Now(),
+	}
+
+	state, err := s.approvalStageRepo.RecordDecision(ctx, &stage, approval, s.stageRequirements(loan))
+	if err != nil {
+		return err
+	}
+	loan.ApprovalStages = append(loan.ApprovalStages, stage)
+
+	if state != loan.State {
+		loan.State = state
+		loan.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// requiredStages returns the approval stages configured for the loan, in order
+func (s *loanService) requiredStages(loan *domain.Loan) []domain.ApprovalStageType {
+	stages := []domain.ApprovalStageType{domain.ApprovalStageFieldVerification}
+
+	// Flagged field visit evidence is always reviewed by a credit analyst
+	if s.approvalConfig.CreditReviewRequired || (loan.Approval != nil && loan.Approval.NeedsReview) {
+		stages = append(stages, domain.ApprovalStageCreditReview)
+	}
+
+	if s.approvalConfig.CommitteeThreshold > 0 && loan.PrincipalAmount > s.approvalConfig.CommitteeThreshold {
+		stages = append(stages, domain.ApprovalStageCommitteeSignoff)
+	}
+
+	return stages
+}
+
+func (s *loanService) isStageRequired(loan *domain.Loan, stageType domain.ApprovalStageType) bool {
+	for _, required := range s.requiredStages(loan) {
+		if required == stageType {
+			return true
+		}
+	}
+	return false
+}
+
+// stageRequirements returns the required stages of the loan with the approvals each of them needs
+func (s *loanService) stageRequirements(loan *domain.Loan) []domain.StageRequirement {
+	stages := s.requiredStages(loan)
+	required := make([]domain.StageRequirement, 0, len(stages))
+	for _, stageType := range stages {
+		required = append(required, domain.StageRequirement{Stage: stageType, Approvals: s.approvalsNeeded(stageType)})
+	}
+	return required
+}
 
-	return s.loanRepo.Update(ctx, loan)
+// approvalsNeeded returns how many distinct approvers a stage needs; the committee needs a quorum of members
+func (s *loanService) approvalsNeeded(stageType domain.ApprovalStageType) int {
+	if stageType == domain.ApprovalStageCommitteeSignoff && s.approvalConfig.CommitteeQuorum > 1 {
+		return s.approvalConfig.CommitteeQuorum
+	}
+	return 1
+}
+
+// stagePassed reports whether a stage has enough approvals
+func (s *loanService) stagePassed(stages []domain.ApprovalStage, stageType domain.ApprovalStageType) bool {
+	return domain.StageApprovers(stages, stageType) >= s.approvalsNeeded(stageType)
+}
+
+// hasStageDecision reports whether the stage was already decided, by actorID when it is not uuid.Nil
+func hasStageDecision(stages []domain.ApprovalStage, stageType domain.ApprovalStageType, actorID uuid.UUID) bool {
+	for _, stage := range stages {
+		if stage.Stage == stageType && (actorID == uuid.Nil || stage.ActorID == actorID) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *loanService) GetLoansByState(ctx context.Context, state domain.LoanState) ([]domain.Loan, error) {
@@ -137,7 +424,7 @@ func (s *loanService) GetBorrowerLoansByUserID(ctx context.Context, userID uuid.
 	return s.loanRepo.GetByBorrowerID(ctx, borrower.ID)
 }
 
-func (s *loanService) DisburseLoan(ctx context.Context, loanID uuid.UUID, officerID uuid.UUID, agreementFileURL string, disbursementDate time.Time) error {
+func (s *loanService) DisburseLoan(ctx context.Context, loanID uuid.UUID, officerID uuid.UUID, agreementDocumentID uuid.UUID, disbursementDate time.Time) error {
 	// Get loan
 	loan, err := s.loanRepo.GetByID(ctx, loanID)
 	if err != nil {
@@ -152,14 +439,39 @@ func (s *loanService) DisburseLoan(ctx context.Context, loanID uuid.UUID, office
 		return domain.ErrLoanNotInvested
 	}
 
+	if err := s.segregationService.CheckActor(ctx, loan, domain.LoanActionDisbursement, officerID); err != nil {
+		return err
+	}
+
+	// The signed agreement must be a stored disbursement agreement of this loan
+	document, err := s.documentRepo.GetByID(ctx, agreementDocumentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrDocumentNotFound
+		}
+		return err
+	}
+	if document.LoanID != loanID {
+		return domain.ErrDocumentNotFound
+	}
+	if document.Type != domain.DocumentTypeDisbursementAgreement {
+		return domain.ErrInvalidDocumentType
+	}
+
+	// The borrower must have signed the agreement as it is stored now
+	if err := s.checkBorrowerSignature(ctx, loanID); err != nil {
+		return err
+	}
+
 	// Create disbursement record
 	disbursement := &domain.Disbursement{
-		ID:               uuid.New(),
-		LoanID:           loanID,
-		OfficerID:        officerID,
-		AgreementFileURL: agreementFileURL,
-		DisbursementDate: disbursementDate,
-		CreatedAt:        time.Now(),
+		ID:                  uuid.New(),
+		LoanID:              loanID,
+		OfficerID:           officerID,
+		AgreementDocumentID: &document.ID,
+		AgreementFileURL:    documentPath(document.ID),
+		DisbursementDate:    disbursementDate,
+		CreatedAt:           time.Now(),
 	}
 
 	err = s.disbursementRepo.Create(ctx, disbursement)
@@ -171,5 +483,55 @@ func (s *loanService) DisburseLoan(ctx context.Context, loanID uuid.UUID, office
 	loan.State = domain.LoanStateDisbursed
 	loan.UpdatedAt = time.Now()
 
-	return s.loanRepo.Update(ctx, loan)
+	if err := s.loanRepo.Update(ctx, loan); err != nil {
+		return err
+	}
+
+	// Investments can no longer be cancelled, so whoever still waits for the loan cannot be served anymore
+	if _, err := s.waitlistRepo.CancelWaitingByLoanID(ctx, loanID); err != nil {
+		log.Printf("Failed to cancel waitlist of disbursed loan %s: %v", loanID, err)
+	}
+
+	return nil
+}
+
+// checkBorrowerSignature requires a signature over the latest borrower agreement of the loan whose
+// recorded document hash still matches the document and the file in storage
+func (s *loanService) checkBorrowerSignature(ctx context.Context, loanID uuid.UUID) error {
+	agreement, err := s.documentRepo.GetLatestByLoanAndType(ctx, loanID, domain.DocumentTypeBorrowerAgreement)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrAgreementNotSigned
+		}
+		return err
+	}
+
+	signature, err := s.signatureRepo.GetByDocumentID(ctx, agreement.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrAgreementNotSigned
+		}
+		return err
+	}
+
+	if signature.LoanID != loanID || signature.DocumentSHA256 != agreement.SHA256 {
+		return domain.ErrAgreementNotSigned
+	}
+
+	// The stored file is hashed again, a file replaced in storage was not what the borrower signed
+	content, err := s.storage.Get(ctx, agreement.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to read borrower agreement: %w", err)
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return fmt.Errorf("failed to read borrower agreement: %w", err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != signature.DocumentSHA256 {
+		return domain.ErrAgreementNotSigned
+	}
+
+	return nil
 }
@@ -4,23 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sigitisme/amf-loan-service/internal/domain"
 )
 
 type notificationService struct {
-	loanRepo       domain.LoanRepository
-	investmentRepo domain.InvestmentRepository
+	loanRepo        domain.LoanRepository
+	investmentRepo  domain.InvestmentRepository
+	documentService domain.DocumentService
+	pdfRenderer     domain.PDFRenderer
 }
 
 func NewNotificationService(
 	loanRepo domain.LoanRepository,
 	investmentRepo domain.InvestmentRepository,
+	documentService domain.DocumentService,
+	pdfRenderer domain.PDFRenderer,
 ) domain.NotificationService {
 	return &notificationService{
-		loanRepo:       loanRepo,
-		investmentRepo: investmentRepo,
+		loanRepo:        loanRepo,
+		investmentRepo:  investmentRepo,
+		documentService: documentService,
+		pdfRenderer:     pdfRenderer,
 	}
 }
 
@@ -31,15 +38,36 @@ func (s *notificationService) SendAgreementLetters(ctx context.Context, loanID u
 		return fmt.Errorf("failed to get investments: %w", err)
 	}
 
-	// Generate agreement letter URL for each investment and simulate email sending
+	if len(investments) == 0 {
+		return nil
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return fmt.Errorf("failed to get loan: %w", err)
+	}
+
+	// Store an agreement letter for each investment and simulate email sending
+	failed := 0
 	for _, investment := range investments {
-		// Generate dummy PDF URL for each investor's agreement letter
-		agreementURL := s.generateAgreementLetterURL(loanID, investment.InvestorID, investment.ID)
+		// Letters are generated once, a repeated fully funded event must not issue new ones
+		if investment.AgreementDocumentID != nil || investment.Status == "cancelled" {
+			continue
+		}
+
+		document, err := s.storeAgreementLetter(ctx, loan, &investment)
+		if err != nil {
+			log.Printf("Failed to store agreement letter for investment %s: %v", investment.ID, err)
+			failed++
+			continue
+		}
+		agreementURL := s.documentService.DocumentURL(document.ID)
 
-		// Update investment with agreement letter URL
-		err := s.investmentRepo.UpdateAgreementLetterURL(ctx, investment.ID, agreementURL)
+		// Link the stored letter to the investment, an unlinked investment is picked up again by the retry sweeper
+		err = s.investmentRepo.UpdateAgreementLetter(ctx, investment.ID, document.ID, agreementURL)
 		if err != nil {
-			log.Printf("Failed to update agreement letter URL for investment %s: %v", investment.ID, err)
+			log.Printf("Failed to update agreement letter for investment %s: %v", investment.ID, err)
+			failed++
 			continue
 		}
 
@@ -47,13 +75,36 @@ func (s *notificationService) SendAgreementLetters(ctx context.Context, loanID u
 		s.simulateEmailSending(investment.Investor.User.Email, investment.Investor.FullName, agreementURL, loanID)
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("failed to send %d agreement letters", failed)
+	}
+
 	return nil
 }
 
-// generateAgreementLetterURL generates a dummy PDF URL for the agreement letter
-func (s *notificationService) generateAgreementLetterURL(loanID, investorID, investmentID uuid.UUID) string {
-	return fmt.Sprintf("https://amf-documents.s3.amazonaws.com/agreements/loan_%s/investor_%s/agreement_%s.pdf",
-		loanID.String(), investorID.String(), investmentID.String())
+// storeAgreementLetter renders the investor's agreement letter as a PDF and keeps it in document
+// storage, visible only to that investor and staff
+func (s *notificationService) storeAgreementLetter(ctx context.Context, loan *domain.Loan, investment *domain.Investment) (*domain.Document, error) {
+	content, err := s.pdfRenderer.Render(investorAgreementTemplate(loan, investment, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render agreement letter: %w", err)
+	}
+
+	ownerID := investment.Investor.UserID
+	document := &domain.Document{
+		LoanID:          loan.ID,
+		Type:            domain.DocumentTypeInvestorAgreement,
+		OwnerUserID:     &ownerID,
+		FileName:        fmt.Sprintf("agreement_%s.pdf", investment.ID),
+		ContentType:     "application/pdf",
+		TemplateVersion: investorAgreementTemplateVersion,
+	}
+
+	if err := s.documentService.StoreDocument(ctx, document, content); err != nil {
+		return nil, err
+	}
+
+	return document, nil
 }
 
 // simulateEmailSending logs the email that would be sent to the investor
@@ -67,3 +118,53 @@ func (s *notificationService) simulateEmailSending(email, fullName, agreementURL
 	log.Printf("Thank you for investing with AMF Loan Service!")
 	log.Printf("---")
 }
+
+// NotifyWaitlistPromoted tells the investor their waitlist entry was turned into an investment request
+func (s *notificationService) NotifyWaitlistPromoted(ctx context.Context, entry *domain.WaitlistEntry, hold *domain.InvestmentHold) error {
+	log.Printf("SIMULATED EMAIL SENT")
+	log.Printf("To: %s (%s)", entry.Investor.User.Email, entry.Investor.FullName)
+	log.Printf("Subject: Waitlist Update - Loan %s", entry.LoanID.String())
+	log.Printf("Body: Dear %s,", entry.Investor.FullName)
+	log.Printf("Capacity became available and your waitlisted investment of %.2f has been submitted for processing.", entry.Amount)
+	log.Printf("Investment Hold: %s (expires %s)", hold.ID.String(), hold.ExpiresAt.Format(time.RFC3339))
+	log.Printf("Thank you for investing with AMF Loan Service!")
+	log.Printf("---")
+	return nil
+}
+
+// SendSigningOTP texts the borrower the one-time code that confirms their agreement signature
+func (s *notificationService) SendSigningOTP(ctx context.Context, borrower *domain.Borrower, loanID uuid.UUID, code string, expiresAt time.Time) error {
+	log.Printf("SIMULATED SMS SENT")
+	log.Printf("To: %s (%s)", borrower.PhoneNumber, borrower.FullName)
+	log.Printf("Body: Your AMF Loan Service code to sign the agreement for loan %s is %s. It expires at %s. Do not share it with anyone.",
+		loanID.String(), code, expiresAt.Format(time.RFC3339))
+	log.Printf("---")
+	return nil
+}
+
+// SendEmailVerification emails a newly registered customer the link that verifies their address
+func (s *notificationService) SendEmailVerification(ctx context.Context, user *domain.User, link string, expiresAt time.Time) error {
+	log.Printf("SIMULATED EMAIL SENT")
+	log.Printf("To: %s", user.Email)
+	log.Printf("Subject: Verify your AMF Loan Service email address")
+	log.Printf("Body: Welcome to AMF Loan Service!")
+	log.Printf("Please verify your email address before creating loans or investing:")
+	log.Printf("Verification Link: %s (expires %s)", link, expiresAt.Format(time.RFC3339))
+	log.Printf("---")
+	return nil
+}
+
+// NotifyKYCDecision emails the customer the outcome of their identity verification
+func (s *notificationService) NotifyKYCDecision(ctx context.Context, submission *domain.KYCSubmission) error {
+	log.Printf("SIMULATED EMAIL SENT")
+	log.Printf("To: %s", submission.User.Email)
+	log.Printf("Subject: Your AMF Loan Service identity verification")
+	if submission.Status == domain.KYCStatusVerified {
+		log.Printf("Body: Your identity has been verified, you can now create loans or invest.")
+	} else {
+		log.Printf("Body: We could not verify your identity: %s", submission.RejectionReason)
+		log.Printf("Please submit a new ID card photo and selfie.")
+	}
+	log.Printf("---")
+	return nil
+}
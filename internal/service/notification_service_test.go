@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -10,13 +12,30 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// Mock PDF Renderer
+type mockPDFRenderer struct {
+	mock.Mock
+}
+
+func (m *mockPDFRenderer) Render(doc *domain.TextDocument) ([]byte, error) {
+	args := m.Called(doc)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
 // Test Notification Service - Happy Flow
 func TestNotificationService_SendAgreementLetters_Success(t *testing.T) {
 	// Arrange
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
 
-	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo)
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo, documentService, mockRenderer)
 
 	loanID := uuid.New()
 
@@ -49,9 +68,15 @@ func TestNotificationService_SendAgreementLetters_Success(t *testing.T) {
 	}
 
 	mockInvestmentRepo.On("GetByLoanID", mock.Anything, loanID).Return(investments, nil)
-	// Mock UpdateAgreementLetterURL for each investment
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID, PrincipalAmount: 55000}, nil)
+	mockRenderer.On("Render", mock.AnythingOfType("*domain.TextDocument")).Return([]byte("%PDF-1.4"), nil)
+	mockStorage.On("Put", mock.Anything, mock.AnythingOfType("string"), mock.Anything, "application/pdf").Return(nil)
+	mockDocumentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Document")).Return(nil)
+	// Mock UpdateAgreementLetter for each investment
 	for _, investment := range investments {
-		mockInvestmentRepo.On("UpdateAgreementLetterURL", mock.Anything, investment.ID, mock.AnythingOfType("string")).Return(nil)
+		mockInvestmentRepo.On("UpdateAgreementLetter", mock.Anything, investment.ID, mock.AnythingOfType("uuid.UUID"), mock.MatchedBy(func(url string) bool {
+			return strings.HasPrefix(url, "https://loans.example.com/api/documents/")
+		})).Return(nil)
 	}
 
 	// Act
@@ -63,30 +88,40 @@ func TestNotificationService_SendAgreementLetters_Success(t *testing.T) {
 	mockInvestmentRepo.AssertExpectations(t)
 }
 
-// Test Notification Service - Generate Agreement Letter URL
-func TestNotificationService_GenerateAgreementLetterURL(t *testing.T) {
+// Test Notification Service - Agreement Letter Is Owned By The Investor
+func TestNotificationService_StoreAgreementLetter(t *testing.T) {
 	// Arrange
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo, documentService, mockRenderer).(*notificationService)
+
+	loan := &domain.Loan{ID: uuid.New(), PrincipalAmount: 100000}
+	investment := &domain.Investment{
+		ID:       uuid.New(),
+		Amount:   25000,
+		Investor: domain.Investor{UserID: uuid.New(), FullName: "John Investor"},
+	}
 
-	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo).(*notificationService)
-
-	loanID := uuid.New()
-	investorID := uuid.New()
-	investmentID := uuid.New()
+	mockRenderer.On("Render", mock.AnythingOfType("*domain.TextDocument")).Return([]byte("%PDF-1.4"), nil)
+	mockStorage.On("Put", mock.Anything, mock.AnythingOfType("string"), []byte("%PDF-1.4"), "application/pdf").Return(nil)
+	mockDocumentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Document")).Return(nil)
 
 	// Act
-	url := notificationService.generateAgreementLetterURL(loanID, investorID, investmentID)
+	document, err := notificationService.storeAgreementLetter(context.Background(), loan, investment)
 
 	// Assert
-	expectedURL := "https://amf-documents.s3.amazonaws.com/agreements/loan_" +
-		loanID.String() + "/investor_" + investorID.String() + "/agreement_" + investmentID.String() + ".pdf"
-	assert.Equal(t, expectedURL, url)
-	assert.Contains(t, url, "https://amf-documents.s3.amazonaws.com/agreements")
-	assert.Contains(t, url, loanID.String())
-	assert.Contains(t, url, investorID.String())
-	assert.Contains(t, url, investmentID.String())
-	assert.Contains(t, url, ".pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DocumentTypeInvestorAgreement, document.Type)
+	assert.Equal(t, investment.Investor.UserID, *document.OwnerUserID)
+	assert.Equal(t, loan.ID, document.LoanID)
+	assert.Equal(t, investorAgreementTemplateVersion, document.TemplateVersion)
+	assert.Len(t, document.SHA256, 64)
+	assert.Contains(t, document.StorageKey, loan.ID.String())
 }
 
 // Test Notification Service - No Investments
@@ -94,8 +129,12 @@ func TestNotificationService_SendAgreementLetters_NoInvestments(t *testing.T) {
 	// Arrange
 	mockLoanRepo := new(mockLoanRepository)
 	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
 
-	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo)
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo, documentService, mockRenderer)
 
 	loanID := uuid.New()
 
@@ -112,3 +151,64 @@ func TestNotificationService_SendAgreementLetters_NoInvestments(t *testing.T) {
 
 	mockInvestmentRepo.AssertExpectations(t)
 }
+
+// Test Notification Service - Letters Are Not Generated Twice
+func TestNotificationService_SendAgreementLetters_SkipsExistingLetters(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo, documentService, mockRenderer)
+
+	loanID := uuid.New()
+	existingDocumentID := uuid.New()
+	investments := []domain.Investment{
+		{ID: uuid.New(), LoanID: loanID, Amount: 25000, AgreementDocumentID: &existingDocumentID},
+	}
+
+	mockInvestmentRepo.On("GetByLoanID", mock.Anything, loanID).Return(investments, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID}, nil)
+
+	// Act
+	err := notificationService.SendAgreementLetters(context.Background(), loanID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRenderer.AssertNotCalled(t, "Render", mock.Anything)
+	mockInvestmentRepo.AssertNotCalled(t, "UpdateAgreementLetter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Notification Service - A Letter That Fails To Store Is Reported And Left Pending
+func TestNotificationService_SendAgreementLetters_StoreFailure(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockStorage := new(mockFileStorage)
+	mockRenderer := new(mockPDFRenderer)
+
+	documentService := NewDocumentService(mockDocumentRepo, mockLoanRepo, mockStorage, testPermissionPolicy, testDocumentConfig)
+	notificationService := NewNotificationService(mockLoanRepo, mockInvestmentRepo, documentService, mockRenderer)
+
+	loanID := uuid.New()
+	investments := []domain.Investment{
+		{ID: uuid.New(), LoanID: loanID, Amount: 25000, Status: "completed"},
+		{ID: uuid.New(), LoanID: loanID, Amount: 10000, Status: "cancelled"},
+	}
+
+	mockInvestmentRepo.On("GetByLoanID", mock.Anything, loanID).Return(investments, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID}, nil)
+	mockRenderer.On("Render", mock.AnythingOfType("*domain.TextDocument")).Return(nil, errors.New("renderer unavailable")).Once()
+
+	// Act
+	err := notificationService.SendAgreementLetters(context.Background(), loanID)
+
+	// Assert
+	assert.Error(t, err)
+	mockRenderer.AssertNumberOfCalls(t, "Render", 1)
+	mockInvestmentRepo.AssertNotCalled(t, "UpdateAgreementLetter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// outboxPurgeInterval is how often sent events past their retention are removed
+const outboxPurgeInterval = time.Hour
+
+// OutboxRelay periodically publishes the events of the transactional outbox to Kafka
+type OutboxRelay struct {
+	outboxService domain.OutboxService
+	interval      time.Duration
+	stop          chan struct{}
+}
+
+func NewOutboxRelay(outboxService domain.OutboxService, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outboxService: outboxService,
+		interval:      interval,
+		stop:          make(chan struct{}),
+	}
+}
+
+func (w *OutboxRelay) Start(ctx context.Context) {
+	log.Println("Starting outbox relay...")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(outboxPurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			sent, err := w.outboxService.RelayDue(ctx)
+			if err != nil {
+				log.Printf("Error relaying outbox events: %v", err)
+				continue
+			}
+			if sent > 0 {
+				log.Printf("Published %d outbox events", sent)
+			}
+		case <-purgeTicker.C:
+			purged, err := w.outboxService.PurgeSent(ctx)
+			if err != nil {
+				log.Printf("Error purging sent outbox events: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d sent outbox events", purged)
+			}
+		}
+	}
+}
+
+func (w *OutboxRelay) Stop() {
+	log.Println("Stopping outbox relay...")
+	close(w.stop)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type outboxService struct {
+	outboxRepo    domain.OutboxRepository
+	kafkaProducer domain.KafkaProducer
+	outboxConfig  *config.OutboxConfig
+}
+
+func NewOutboxService(
+	outboxRepo domain.OutboxRepository,
+	kafkaProducer domain.KafkaProducer,
+	outboxConfig *config.OutboxConfig,
+) domain.OutboxService {
+	return &outboxService{
+		outboxRepo:    outboxRepo,
+		kafkaProducer: kafkaProducer,
+		outboxConfig:  outboxConfig,
+	}
+}
+
+// RelayDue publishes the due events of the outbox. An event is marked sent only after the broker
+// accepted it, so a crash in between sends it again once its claim runs out.
+func (s *outboxService) RelayDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	events, err := s.outboxRepo.ClaimDue(ctx, now, s.outboxConfig.PublishLease, s.outboxConfig.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	// Publishing stops before the claim runs out, the rest is picked up again by the next run
+	publishCtx, cancel := context.WithTimeout(ctx, s.outboxConfig.PublishLease)
+	defer cancel()
+
+	sent := 0
+	for i := range events {
+		if publishCtx.Err() != nil {
+			break
+		}
+
+		event := &events[i]
+		if err := s.kafkaProducer.PublishOutboxEvent(publishCtx, event); err != nil {
+			attempts := event.Attempts + 1
+			nextAttemptAt := time.Now().Add(s.retryDelay(attempts))
+			if markErr := s.outboxRepo.MarkFailed(ctx, event.ID, attempts, nextAttemptAt, err.Error()); markErr != nil {
+				log.Printf("Failed to record failed attempt of outbox event %s: %v", event.ID, markErr)
+			}
+			continue
+		}
+
+		if err := s.outboxRepo.MarkSent(ctx, event.ID, time.Now()); err != nil {
+			// Published but still pending, it is sent again once the claim runs out
+			log.Printf("Failed to mark outbox event %s as sent: %v", event.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s *outboxService) PurgeSent(ctx context.Context) (int64, error) {
+	return s.outboxRepo.DeleteSentBefore(ctx, time.Now().Add(-s.outboxConfig.Retention))
+}
+
+// retryDelay doubles the backoff with every failed attempt up to the maximum
+func (s *outboxService) retryDelay(attempts int) time.Duration {
+	delay := s.outboxConfig.RetryBackoff
+	for i := 1; i < attempts && delay < s.outboxConfig.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.outboxConfig.MaxBackoff {
+		delay = s.outboxConfig.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Outbox Repository
+type mockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *mockOutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.OutboxEvent, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]domain.OutboxEvent), args.Error(1)
+}
+
+func (m *mockOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(ctx, id, sentAt)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(ctx, id, attempts, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var testOutboxConfig = &config.OutboxConfig{
+	RelayInterval: time.Second,
+	BatchSize:     50,
+	PublishLease:  time.Minute,
+	RetryBackoff:  5 * time.Second,
+	MaxBackoff:    time.Minute,
+	Retention:     24 * time.Hour,
+}
+
+// Test Outbox Relay - Published Events Are Marked Sent
+func TestOutboxService_RelayDue_Success(t *testing.T) {
+	// Arrange
+	mockOutboxRepo := new(mockOutboxRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+
+	outboxService := NewOutboxService(mockOutboxRepo, mockKafkaProducer, testOutboxConfig)
+
+	events := []domain.OutboxEvent{
+		{ID: uuid.New(), Type: domain.OutboxEventLoanFullyFunded, Key: uuid.New().String(), Payload: "{}"},
+		{ID: uuid.New(), Type: domain.OutboxEventLoanFullyFunded, Key: uuid.New().String(), Payload: "{}"},
+	}
+
+	mockOutboxRepo.On("ClaimDue", mock.Anything, mock.AnythingOfType("time.Time"), time.Minute, 50).Return(events, nil)
+	mockKafkaProducer.On("PublishOutboxEvent", mock.Anything, mock.AnythingOfType("*domain.OutboxEvent")).Return(nil)
+	mockOutboxRepo.On("MarkSent", mock.Anything, events[0].ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockOutboxRepo.On("MarkSent", mock.Anything, events[1].ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	sent, err := outboxService.RelayDue(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	mockOutboxRepo.AssertExpectations(t)
+	mockKafkaProducer.AssertNumberOfCalls(t, "PublishOutboxEvent", 2)
+	mockOutboxRepo.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Outbox Relay - A Broker Outage Keeps The Event For A Later Attempt
+func TestOutboxService_RelayDue_PublishFails(t *testing.T) {
+	// Arrange
+	mockOutboxRepo := new(mockOutboxRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+
+	outboxService := NewOutboxService(mockOutboxRepo, mockKafkaProducer, testOutboxConfig)
+
+	failing := domain.OutboxEvent{ID: uuid.New(), Type: domain.OutboxEventLoanFullyFunded, Attempts: 2}
+	succeeding := domain.OutboxEvent{ID: uuid.New(), Type: domain.OutboxEventLoanFullyFunded}
+
+	mockOutboxRepo.On("ClaimDue", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]domain.OutboxEvent{failing, succeeding}, nil)
+	mockKafkaProducer.On("PublishOutboxEvent", mock.Anything, mock.MatchedBy(func(event *domain.OutboxEvent) bool {
+		return event.ID == failing.ID
+	})).Return(assert.AnError)
+	mockKafkaProducer.On("PublishOutboxEvent", mock.Anything, mock.MatchedBy(func(event *domain.OutboxEvent) bool {
+		return event.ID == succeeding.ID
+	})).Return(nil)
+
+	before := time.Now()
+	var nextAttemptAt time.Time
+	mockOutboxRepo.On("MarkFailed", mock.Anything, failing.ID, 3, mock.AnythingOfType("time.Time"), assert.AnError.Error()).
+		Run(func(args mock.Arguments) {
+			nextAttemptAt = args.Get(3).(time.Time)
+		}).Return(nil)
+	mockOutboxRepo.On("MarkSent", mock.Anything, succeeding.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	sent, err := outboxService.RelayDue(context.Background())
+
+	// Assert - the third attempt waits twice the doubled backoff, the other event is still sent
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.WithinDuration(t, before.Add(20*time.Second), nextAttemptAt, 2*time.Second)
+	mockOutboxRepo.AssertExpectations(t)
+	mockOutboxRepo.AssertNotCalled(t, "MarkSent", mock.Anything, failing.ID, mock.Anything)
+}
+
+// Test Outbox Relay - The Delay Between Attempts Doubles Up To The Maximum
+func TestOutboxService_RetryDelay(t *testing.T) {
+	service := &outboxService{outboxConfig: testOutboxConfig}
+
+	assert.Equal(t, 5*time.Second, service.retryDelay(1))
+	assert.Equal(t, 10*time.Second, service.retryDelay(2))
+	assert.Equal(t, 40*time.Second, service.retryDelay(4))
+	assert.Equal(t, time.Minute, service.retryDelay(5))
+	assert.Equal(t, time.Minute, service.retryDelay(100))
+}
+
+// Test Outbox Purge - Sent Events Past The Retention Are Removed
+func TestOutboxService_PurgeSent(t *testing.T) {
+	mockOutboxRepo := new(mockOutboxRepository)
+	outboxService := NewOutboxService(mockOutboxRepo, new(mockKafkaProducer), testOutboxConfig)
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	mockOutboxRepo.On("DeleteSentBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return before.Sub(cutoff).Abs() < time.Second
+	})).Return(int64(3), nil)
+
+	purged, err := outboxService.PurgeSent(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockOutboxRepo.AssertExpectations(t)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type permissionPolicy struct {
+	grants map[domain.UserRole]map[domain.Permission]bool
+}
+
+// NewPermissionPolicy builds the policy from the configured role to permission mapping.
+// Roles missing from the mapping are granted nothing.
+func NewPermissionPolicy(authorizationConfig *config.AuthorizationConfig) domain.PermissionPolicy {
+	grants := make(map[domain.UserRole]map[domain.Permission]bool, len(authorizationConfig.RolePermissions))
+	for role, permissions := range authorizationConfig.RolePermissions {
+		granted := make(map[domain.Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			granted[domain.Permission(permission)] = true
+		}
+		grants[domain.UserRole(role)] = granted
+	}
+
+	return &permissionPolicy{grants: grants}
+}
+
+func (p *permissionPolicy) Can(role domain.UserRole, permission domain.Permission) bool {
+	return p.grants[role][permission]
+}
+
+func (p *permissionPolicy) Allows(user *domain.User, permission domain.Permission) bool {
+	if user == nil || !p.Can(user.Role, permission) {
+		return false
+	}
+	// An API key narrows what its service account may do, it never widens it
+	if user.Scopes != nil {
+		return user.Scopes.Contains(permission)
+	}
+	return true
+}
+
+func (p *permissionPolicy) Require(user *domain.User, permission domain.Permission) error {
+	if !p.Allows(user, permission) {
+		return domain.ErrInsufficientPermission
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPermissionPolicy = NewPermissionPolicy(&config.AuthorizationConfig{
+	RolePermissions: config.DefaultRolePermissions(),
+})
+
+func TestPermissionPolicy_DefaultRoles(t *testing.T) {
+	// Arrange
+	policy := testPermissionPolicy
+
+	// Act & Assert
+	assert.True(t, policy.Can(domain.RoleBorrower, domain.PermissionLoanCreate))
+	assert.False(t, policy.Can(domain.RoleBorrower, domain.PermissionLoanBrowse))
+	assert.True(t, policy.Can(domain.RoleInvestor, domain.PermissionInvestmentCreate))
+	assert.False(t, policy.Can(domain.RoleInvestor, domain.PermissionLoanCreate))
+	assert.True(t, policy.Can(domain.RoleFieldValidator, domain.PermissionLoanApprove))
+	assert.False(t, policy.Can(domain.RoleFieldValidator, domain.PermissionLoanDisburse))
+	assert.True(t, policy.Can(domain.RoleFieldOfficer, domain.PermissionLoanDisburse))
+	assert.True(t, policy.Can(domain.RoleCreditAnalyst, domain.PermissionLoanViewPII))
+	assert.False(t, policy.Can(domain.RoleInvestor, domain.PermissionLoanViewPII))
+	assert.False(t, policy.Can(domain.UserRole("auditor"), domain.PermissionLoanBrowse))
+}
+
+func TestPermissionPolicy_ConfiguredMapping(t *testing.T) {
+	// Arrange
+	policy := NewPermissionPolicy(&config.AuthorizationConfig{
+		RolePermissions: map[string][]string{
+			"credit_analyst": {"loan:review", "loan:approve"},
+			"auditor":        {"loan:browse", "document:view_all"},
+		},
+	})
+
+	// Act & Assert
+	assert.True(t, policy.Can(domain.RoleCreditAnalyst, domain.PermissionLoanApprove))
+	assert.False(t, policy.Can(domain.RoleCreditAnalyst, domain.PermissionLoanViewPII))
+	assert.True(t, policy.Can(domain.UserRole("auditor"), domain.PermissionDocumentViewAll))
+	assert.False(t, policy.Can(domain.RoleBorrower, domain.PermissionLoanCreate))
+}
+
+func TestPermissionPolicy_Require(t *testing.T) {
+	// Arrange
+	policy := testPermissionPolicy
+	borrower := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower}
+
+	// Act & Assert
+	assert.NoError(t, policy.Require(borrower, domain.PermissionAgreementSign))
+	assert.Equal(t, domain.ErrInsufficientPermission, policy.Require(borrower, domain.PermissionLoanDisburse))
+	assert.Equal(t, domain.ErrInsufficientPermission, policy.Require(nil, domain.PermissionLoanCreate))
+}
+
+func TestPermissionPolicy_Allows_APIKeyScopes(t *testing.T) {
+	// Arrange
+	policy := testPermissionPolicy
+	analyst := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst}
+	scoped := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst, ServiceAccount: true, Scopes: domain.Permissions{domain.PermissionLoanBrowse, domain.PermissionLoanDisburse}}
+
+	// Act & Assert
+	assert.True(t, policy.Allows(analyst, domain.PermissionLoanReview))
+	assert.True(t, policy.Allows(scoped, domain.PermissionLoanBrowse))
+	assert.False(t, policy.Allows(scoped, domain.PermissionLoanReview))   // Granted by the role but not the key
+	assert.False(t, policy.Allows(scoped, domain.PermissionLoanDisburse)) // In the key but not granted by the role
+	assert.Equal(t, domain.ErrInsufficientPermission, policy.Require(scoped, domain.PermissionLoanReview))
+}
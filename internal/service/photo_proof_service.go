@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// allowedPhotoTypes maps accepted content types to the extension used for the stored object
+var allowedPhotoTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+type photoProofService struct {
+	photoProofRepo domain.PhotoProofRepository
+	loanRepo       domain.LoanRepository
+	storage        domain.FileStorage
+	metadataReader domain.ImageMetadataReader
+	storageConfig  *config.StorageConfig
+}
+
+func NewPhotoProofService(
+	photoProofRepo domain.PhotoProofRepository,
+	loanRepo domain.LoanRepository,
+	storage domain.FileStorage,
+	metadataReader domain.ImageMetadataReader,
+	storageConfig *config.StorageConfig,
+) domain.PhotoProofService {
+	return &photoProofService{
+		photoProofRepo: photoProofRepo,
+		loanRepo:       loanRepo,
+		storage:        storage,
+		metadataReader: metadataReader,
+		storageConfig:  storageConfig,
+	}
+}
+
+func (s *photoProofService) UploadPhotoProof(ctx context.Context, loanID uuid.UUID, uploaderID uuid.UUID, filename string, data []byte) (*domain.PhotoProof, error) {
+	if len(data) == 0 {
+		return nil, domain.ErrEmptyFile
+	}
+	if int64(len(data)) > s.storageConfig.PhotoProofMaxSize {
+		return nil, domain.ErrFileTooLarge
+	}
+
+	// Trust the file content, not the client supplied name or header
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedPhotoTypes[contentType]
+	if !ok {
+		return nil, domain.ErrUnsupportedFileType
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoanNotFound
+		}
+		return nil, err
+	}
+
+	// Proofs are only collected while the loan awaits field verification
+	if loan.State != domain.LoanStateProposed || loan.Approval != nil {
+		return nil, domain.ErrInvalidLoanState
+	}
+
+	sum := sha256.Sum256(data)
+	proof := &domain.PhotoProof{
+		ID:           uuid.New(),
+		LoanID:       loanID,
+		UploaderID:   uploaderID,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		SHA256:       hex.EncodeToString(sum[:]),
+		OriginalName: filename,
+		CreatedAt:    time.Now(),
+	}
+	proof.StorageKey = fmt.Sprintf("photo-proofs/%s/%s.%s", loanID, proof.ID, ext)
+
+	// Missing or unreadable metadata does not reject the upload, the approval is flagged instead
+	metadata, err := s.metadataReader.Read(data)
+	if err != nil {
+		log.Printf("Failed to read metadata of photo proof %s: %v", proof.ID, err)
+	} else {
+		proof.ExifLatitude = metadata.Latitude
+		proof.ExifLongitude = metadata.Longitude
+		proof.ExifCapturedAt = metadata.CapturedAt
+		proof.ExifTimeZoned = metadata.CapturedAtZoned
+	}
+
+	if err := s.storage.Put(ctx, proof.StorageKey, data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store photo proof: %w", err)
+	}
+
+	if err := s.photoProofRepo.Create(ctx, proof); err != nil {
+		// Do not leave orphaned objects behind
+		_ = s.storage.Delete(ctx, proof.StorageKey)
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// GetPhotoProof returns the proof metadata and its content, the caller must close the reader
+func (s *photoProofService) GetPhotoProof(ctx context.Context, loanID uuid.UUID, proofID uuid.UUID) (*domain.PhotoProof, io.ReadCloser, error) {
+	proof, err := s.photoProofRepo.GetByID(ctx, proofID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrPhotoProofNotFound
+		}
+		return nil, nil, err
+	}
+
+	if proof.LoanID != loanID {
+		return nil, nil, domain.ErrPhotoProofNotFound
+	}
+
+	content, err := s.storage.Get(ctx, proof.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proof, content, nil
+}
+
+// photoProofPath is the API path a stored photo proof can be downloaded from
+func photoProofPath(proof *domain.PhotoProof) string {
+	return fmt.Sprintf("/api/loans/%s/photo-proofs/%s", proof.LoanID, proof.ID)
+}
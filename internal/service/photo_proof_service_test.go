@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Photo Proof Repository
+type mockPhotoProofRepository struct {
+	mock.Mock
+}
+
+func (m *mockPhotoProofRepository) Create(ctx context.Context, proof *domain.PhotoProof) error {
+	args := m.Called(ctx, proof)
+	return args.Error(0)
+}
+
+func (m *mockPhotoProofRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PhotoProof, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PhotoProof), args.Error(1)
+}
+
+// Mock File Storage
+type mockFileStorage struct {
+	mock.Mock
+}
+
+func (m *mockFileStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	args := m.Called(ctx, key, data, contentType)
+	return args.Error(0)
+}
+
+func (m *mockFileStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *mockFileStorage) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// Mock Image Metadata Reader
+type mockImageMetadataReader struct {
+	mock.Mock
+}
+
+func (m *mockImageMetadataReader) Read(data []byte) (*domain.ImageMetadata, error) {
+	args := m.Called(data)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImageMetadata), args.Error(1)
+}
+
+var testStorageConfig = &config.StorageConfig{
+	Driver:            "local",
+	PhotoProofMaxSize: 1024,
+}
+
+// pngHeader is enough for content sniffing to detect a PNG image
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// Test Photo Proof Upload - Happy Flow
+func TestPhotoProofService_UploadPhotoProof_Success(t *testing.T) {
+	// Arrange
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+	mockMetadataReader := new(mockImageMetadataReader)
+
+	photoProofService := NewPhotoProofService(mockPhotoProofRepo, mockLoanRepo, mockStorage, mockMetadataReader, testStorageConfig)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	loan := &domain.Loan{ID: loanID, State: domain.LoanStateProposed}
+	sum := sha256.Sum256(pngHeader)
+	latitude, longitude := 40.7128, -74.0060
+	capturedAt := time.Date(2025, 8, 13, 10, 15, 0, 0, time.UTC)
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockStorage.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "photo-proofs/"+loanID.String()+"/") && strings.HasSuffix(key, ".png")
+	}), pngHeader, "image/png").Return(nil)
+	mockPhotoProofRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PhotoProof")).Return(nil)
+	mockMetadataReader.On("Read", pngHeader).Return(&domain.ImageMetadata{
+		Latitude:        &latitude,
+		Longitude:       &longitude,
+		CapturedAt:      &capturedAt,
+		CapturedAtZoned: true,
+	}, nil)
+
+	// Act
+	proof, err := photoProofService.UploadPhotoProof(context.Background(), loanID, validatorID, "visit.png", pngHeader)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, proof)
+	assert.Equal(t, "image/png", proof.ContentType)
+	assert.Equal(t, hex.EncodeToString(sum[:]), proof.SHA256)
+	assert.Equal(t, int64(len(pngHeader)), proof.Size)
+	assert.Equal(t, validatorID, proof.UploaderID)
+	assert.Equal(t, latitude, *proof.ExifLatitude)
+	assert.Equal(t, longitude, *proof.ExifLongitude)
+	assert.Equal(t, capturedAt, *proof.ExifCapturedAt)
+	assert.True(t, proof.ExifTimeZoned)
+
+	mockStorage.AssertExpectations(t)
+	mockPhotoProofRepo.AssertExpectations(t)
+}
+
+// Test Photo Proof Upload - Rejects Non Image Content
+func TestPhotoProofService_UploadPhotoProof_UnsupportedType(t *testing.T) {
+	// Arrange
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+	mockMetadataReader := new(mockImageMetadataReader)
+
+	photoProofService := NewPhotoProofService(mockPhotoProofRepo, mockLoanRepo, mockStorage, mockMetadataReader, testStorageConfig)
+
+	// Act
+	proof, err := photoProofService.UploadPhotoProof(context.Background(), uuid.New(), uuid.New(), "proof.jpg", []byte("%PDF-1.4 not an image"))
+
+	// Assert
+	assert.Nil(t, proof)
+	assert.Equal(t, domain.ErrUnsupportedFileType, err)
+	mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Photo Proof Upload - Rejects Oversized Files
+func TestPhotoProofService_UploadPhotoProof_TooLarge(t *testing.T) {
+	// Arrange
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+	mockMetadataReader := new(mockImageMetadataReader)
+
+	photoProofService := NewPhotoProofService(mockPhotoProofRepo, mockLoanRepo, mockStorage, mockMetadataReader, testStorageConfig)
+
+	data := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 2048)...)
+
+	// Act
+	proof, err := photoProofService.UploadPhotoProof(context.Background(), uuid.New(), uuid.New(), "big.png", data)
+
+	// Assert
+	assert.Nil(t, proof)
+	assert.Equal(t, domain.ErrFileTooLarge, err)
+	mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test Photo Proof Upload - Stored Object Removed When Metadata Cannot Be Saved
+func TestPhotoProofService_UploadPhotoProof_CleansUpOnFailure(t *testing.T) {
+	// Arrange
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockStorage := new(mockFileStorage)
+	mockMetadataReader := new(mockImageMetadataReader)
+
+	photoProofService := NewPhotoProofService(mockPhotoProofRepo, mockLoanRepo, mockStorage, mockMetadataReader, testStorageConfig)
+
+	loanID := uuid.New()
+	loan := &domain.Loan{ID: loanID, State: domain.LoanStateProposed}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockStorage.On("Put", mock.Anything, mock.AnythingOfType("string"), pngHeader, "image/png").Return(nil)
+	mockMetadataReader.On("Read", pngHeader).Return(nil, assert.AnError)
+	mockPhotoProofRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PhotoProof")).Return(assert.AnError)
+	mockStorage.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+	// Act
+	proof, err := photoProofService.UploadPhotoProof(context.Background(), loanID, uuid.New(), "visit.png", pngHeader)
+
+	// Assert
+	assert.Nil(t, proof)
+	assert.Error(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+// Test Loan Approval - Photo Proof Must Belong To The Loan
+func TestLoanService_ApproveLoan_PhotoProofFromOtherLoan(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	proof := &domain.PhotoProof{ID: uuid.New(), LoanID: uuid.New(), UploaderID: validatorID}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID, State: domain.LoanStateProposed}, nil)
+	mockPhotoProofRepo.On("GetByID", mock.Anything, proof.ID).Return(proof, nil)
+
+	// Act
+	err := loanService.ApproveLoan(context.Background(), loanID, validatorID, proof.ID, time.Now(), testFieldEvidence)
+
+	// Assert
+	assert.Equal(t, domain.ErrPhotoProofNotFound, err)
+	mockApprovalRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
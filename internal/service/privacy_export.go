@@ -0,0 +1,459 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// The export only describes the user's own data. Loans are exported without their investors and
+// investments without the borrower, so nobody receives someone else's personal data.
+
+type exportProfile struct {
+	Account  *domain.User  `json:"account"`
+	Borrower *exportPerson `json:"borrower_profile,omitempty"`
+	Investor *exportPerson `json:"investor_profile,omitempty"`
+}
+
+type exportPerson struct {
+	ID             uuid.UUID `json:"id"`
+	FullName       string    `json:"full_name"`
+	PhoneNumber    string    `json:"phone_number"`
+	Address        string    `json:"address"`
+	IdentityNumber string    `json:"identity_number"`
+	Region         string    `json:"region,omitempty"`
+	Branch         string    `json:"branch,omitempty"`
+	Latitude       *float64  `json:"latitude,omitempty"`
+	Longitude      *float64  `json:"longitude,omitempty"`
+	TotalInvested  *float64  `json:"total_invested,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type exportLoan struct {
+	ID                uuid.UUID                `json:"id"`
+	PrincipalAmount   float64                  `json:"principal_amount"`
+	InvestedAmount    float64                  `json:"invested_amount"`
+	Rate              float64                  `json:"rate"`
+	TotalInterest     float64                  `json:"total_interest"`
+	TenorMonths       int                      `json:"tenor_months"`
+	State             domain.LoanState         `json:"state"`
+	FieldVerification *exportFieldVerification `json:"field_verification,omitempty"`
+	ApprovalStages    []exportApprovalStage    `json:"approval_stages"`
+	Investments       []exportFunding          `json:"investments"`
+	DisbursementDate  *time.Time               `json:"disbursement_date,omitempty"`
+	CreatedAt         time.Time                `json:"created_at"`
+	UpdatedAt         time.Time                `json:"updated_at"`
+}
+
+type exportFieldVerification struct {
+	ApprovalDate   time.Time `json:"approval_date"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	CapturedAt     time.Time `json:"captured_at"`
+	DistanceMeters *float64  `json:"distance_meters,omitempty"`
+}
+
+type exportApprovalStage struct {
+	Stage     domain.ApprovalStageType `json:"stage"`
+	Decision  domain.ApprovalDecision  `json:"decision"`
+	Comment   string                   `json:"comment,omitempty"`
+	DecidedAt time.Time                `json:"decided_at"`
+}
+
+// exportFunding is an investment in the borrower's loan, without the investor
+type exportFunding struct {
+	Amount    float64   `json:"amount"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportInvestment struct {
+	ID                  uuid.UUID        `json:"id"`
+	LoanID              uuid.UUID        `json:"loan_id"`
+	Amount              float64          `json:"amount"`
+	Status              string           `json:"status"`
+	AgreementDocumentID *uuid.UUID       `json:"agreement_document_id,omitempty"`
+	LoanPrincipal       float64          `json:"loan_principal_amount"`
+	LoanROI             float64          `json:"loan_roi"`
+	LoanTenorMonths     int              `json:"loan_tenor_months"`
+	LoanState           domain.LoanState `json:"loan_state"`
+	CreatedAt           time.Time        `json:"created_at"`
+}
+
+type exportDocument struct {
+	ID          uuid.UUID           `json:"id"`
+	LoanID      uuid.UUID           `json:"loan_id"`
+	Type        domain.DocumentType `json:"type"`
+	FileName    string              `json:"file_name"`
+	ContentType string              `json:"content_type"`
+	SHA256      string              `json:"sha256"`
+	File        string              `json:"file,omitempty"` // Path in the archive, empty when the file is missing
+	Signature   *exportSignature    `json:"signature,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type exportSignature struct {
+	SignedName string    `json:"signed_name"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	SignedAt   time.Time `json:"signed_at"`
+}
+
+type exportKYCSubmission struct {
+	ID              uuid.UUID           `json:"id"`
+	Status          domain.KYCStatus    `json:"status"`
+	ReviewedAt      *time.Time          `json:"reviewed_at,omitempty"`
+	RejectionReason string              `json:"rejection_reason,omitempty"`
+	Documents       []exportKYCDocument `json:"documents"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+type exportKYCDocument struct {
+	ID           uuid.UUID              `json:"id"`
+	Type         domain.KYCDocumentType `json:"type"`
+	ContentType  string                 `json:"content_type"`
+	SHA256       string                 `json:"sha256"`
+	OriginalName string                 `json:"original_name,omitempty"`
+	File         string                 `json:"file,omitempty"`
+}
+
+// personalDataExport collects the content of an archive
+type personalDataExport struct {
+	profile     exportProfile
+	loans       []exportLoan
+	investments []exportInvestment
+	documents   []domain.Document
+	kyc         []domain.KYCSubmission
+	logins      []domain.LoginEvent
+}
+
+func (s *privacyService) buildArchive(ctx context.Context, user *domain.User) (*domain.PersonalDataArchive, error) {
+	export := &personalDataExport{
+		profile:     exportProfile{Account: user},
+		loans:       []exportLoan{},
+		investments: []exportInvestment{},
+	}
+
+	if err := s.collectBorrowerData(ctx, user, export); err != nil {
+		return nil, err
+	}
+	if err := s.collectInvestorData(ctx, user, export); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if export.kyc, err = s.kycRepo.ListByUserID(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if export.logins, err = s.loginEventRepo.GetByUserID(ctx, user.ID, 0); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	documents, err := s.writeDocuments(ctx, zw, user.ID, export.documents)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.writeKYCDocuments(ctx, zw, export.kyc)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", export.profile},
+		{"loans.json", export.loans},
+		{"investments.json", export.investments},
+		{"documents.json", documents},
+		{"kyc_submissions.json", submissions},
+		{"login_history.json", export.logins},
+	}
+	for _, file := range files {
+		if err := writeJSONFile(zw, file.name, file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &domain.PersonalDataArchive{
+		FileName: fmt.Sprintf("personal-data-%s-%s.zip", user.ID, time.Now().Format("20060102")),
+		Data:     buf.Bytes(),
+	}, nil
+}
+
+// collectBorrowerData adds the borrower profile, loans and the documents the borrower can see
+func (s *privacyService) collectBorrowerData(ctx context.Context, user *domain.User, export *personalDataExport) error {
+	borrower, err := s.borrowerRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	export.profile.Borrower = &exportPerson{
+		ID:             borrower.ID,
+		FullName:       borrower.FullName,
+		PhoneNumber:    borrower.PhoneNumber,
+		Address:        borrower.Address,
+		IdentityNumber: borrower.IdentityNumber,
+		Region:         borrower.Region,
+		Branch:         borrower.Branch,
+		Latitude:       borrower.Latitude,
+		Longitude:      borrower.Longitude,
+		CreatedAt:      borrower.CreatedAt,
+		UpdatedAt:      borrower.UpdatedAt,
+	}
+
+	loans, err := s.loanRepo.GetByBorrowerID(ctx, borrower.ID)
+	if err != nil {
+		return err
+	}
+	for _, loan := range loans {
+		stages, err := s.approvalStageRepo.GetByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		export.loans = append(export.loans, newExportLoan(&loan, stages))
+
+		documents, err := s.documentRepo.GetByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		// Investor agreements belong to their investors
+		for _, document := range documents {
+			if document.OwnerUserID == nil || *document.OwnerUserID == user.ID {
+				export.documents = append(export.documents, document)
+			}
+		}
+	}
+	return nil
+}
+
+// collectInvestorData adds the investor profile, investments and the investor's agreements
+func (s *privacyService) collectInvestorData(ctx context.Context, user *domain.User, export *personalDataExport) error {
+	investor, err := s.investorRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	totalInvested := investor.TotalInvested
+	export.profile.Investor = &exportPerson{
+		ID:             investor.ID,
+		FullName:       investor.FullName,
+		PhoneNumber:    investor.PhoneNumber,
+		Address:        investor.Address,
+		IdentityNumber: investor.IdentityNumber,
+		TotalInvested:  &totalInvested,
+		CreatedAt:      investor.CreatedAt,
+		UpdatedAt:      investor.UpdatedAt,
+	}
+
+	investments, err := s.investmentRepo.GetByInvestorID(ctx, investor.ID)
+	if err != nil {
+		return err
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, investment := range investments {
+		export.investments = append(export.investments, exportInvestment{
+			ID:                  investment.ID,
+			LoanID:              investment.LoanID,
+			Amount:              investment.Amount,
+			Status:              investment.Status,
+			AgreementDocumentID: investment.AgreementDocumentID,
+			LoanPrincipal:       investment.Loan.PrincipalAmount,
+			LoanROI:             investment.Loan.ROI,
+			LoanTenorMonths:     investment.Loan.TenorMonths,
+			LoanState:           investment.Loan.State,
+			CreatedAt:           investment.CreatedAt,
+		})
+
+		if seen[investment.LoanID] {
+			continue
+		}
+		seen[investment.LoanID] = true
+		documents, err := s.documentRepo.GetByLoanID(ctx, investment.LoanID)
+		if err != nil {
+			return err
+		}
+		for _, document := range documents {
+			if document.OwnerUserID != nil && *document.OwnerUserID == user.ID {
+				export.documents = append(export.documents, document)
+			}
+		}
+	}
+	return nil
+}
+
+func newExportLoan(loan *domain.Loan, stages []domain.ApprovalStage) exportLoan {
+	exported := exportLoan{
+		ID:              loan.ID,
+		PrincipalAmount: loan.PrincipalAmount,
+		InvestedAmount:  loan.InvestedAmount,
+		Rate:            loan.Rate,
+		TotalInterest:   loan.TotalInterest,
+		TenorMonths:     loan.TenorMonths,
+		State:           loan.State,
+		ApprovalStages:  []exportApprovalStage{},
+		Investments:     []exportFunding{},
+		CreatedAt:       loan.CreatedAt,
+		UpdatedAt:       loan.UpdatedAt,
+	}
+	if approval := loan.Approval; approval != nil {
+		exported.FieldVerification = &exportFieldVerification{
+			ApprovalDate:   approval.ApprovalDate,
+			Latitude:       approval.Latitude,
+			Longitude:      approval.Longitude,
+			CapturedAt:     approval.CapturedAt,
+			DistanceMeters: approval.DistanceMeters,
+		}
+	}
+	for _, stage := range stages {
+		exported.ApprovalStages = append(exported.ApprovalStages, exportApprovalStage{
+			Stage:     stage.Stage,
+			Decision:  stage.Decision,
+			Comment:   stage.Comment,
+			DecidedAt: stage.DecidedAt,
+		})
+	}
+	for _, investment := range loan.Investments {
+		exported.Investments = append(exported.Investments, exportFunding{
+			Amount:    investment.Amount,
+			Status:    investment.Status,
+			CreatedAt: investment.CreatedAt,
+		})
+	}
+	if loan.Disbursement != nil {
+		exported.DisbursementDate = &loan.Disbursement.DisbursementDate
+	}
+	return exported
+}
+
+// writeDocuments adds the stored documents to the archive and describes them with the user's signatures
+func (s *privacyService) writeDocuments(ctx context.Context, zw *zip.Writer, userID uuid.UUID, documents []domain.Document) ([]exportDocument, error) {
+	exported := []exportDocument{}
+	for _, document := range documents {
+		entry := exportDocument{
+			ID:          document.ID,
+			LoanID:      document.LoanID,
+			Type:        document.Type,
+			FileName:    document.FileName,
+			ContentType: document.ContentType,
+			SHA256:      document.SHA256,
+			CreatedAt:   document.CreatedAt,
+		}
+
+		if document.Type == domain.DocumentTypeBorrowerAgreement {
+			signature, err := s.signatureRepo.GetByDocumentID(ctx, document.ID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if signature != nil && signature.SignerUserID == userID {
+				entry.Signature = &exportSignature{
+					SignedName: signature.SignedName,
+					IPAddress:  signature.IPAddress,
+					UserAgent:  signature.UserAgent,
+					SignedAt:   signature.SignedAt,
+				}
+			}
+		}
+
+		name := "files/documents/" + path.Base(document.StorageKey)
+		written, err := s.writeStoredFile(ctx, zw, name, document.StorageKey)
+		if err != nil {
+			return nil, err
+		}
+		if written {
+			entry.File = name
+		}
+		exported = append(exported, entry)
+	}
+	return exported, nil
+}
+
+// writeKYCDocuments adds the identity images to the archive and describes the submissions
+func (s *privacyService) writeKYCDocuments(ctx context.Context, zw *zip.Writer, submissions []domain.KYCSubmission) ([]exportKYCSubmission, error) {
+	exported := []exportKYCSubmission{}
+	for _, submission := range submissions {
+		entry := exportKYCSubmission{
+			ID:              submission.ID,
+			Status:          submission.Status,
+			ReviewedAt:      submission.ReviewedAt,
+			RejectionReason: submission.RejectionReason,
+			Documents:       []exportKYCDocument{},
+			CreatedAt:       submission.CreatedAt,
+		}
+		for _, document := range submission.Documents {
+			documentEntry := exportKYCDocument{
+				ID:           document.ID,
+				Type:         document.Type,
+				ContentType:  document.ContentType,
+				SHA256:       document.SHA256,
+				OriginalName: document.OriginalName,
+			}
+			name := "files/kyc/" + path.Base(document.StorageKey)
+			written, err := s.writeStoredFile(ctx, zw, name, document.StorageKey)
+			if err != nil {
+				return nil, err
+			}
+			if written {
+				documentEntry.File = name
+			}
+			entry.Documents = append(entry.Documents, documentEntry)
+		}
+		exported = append(exported, entry)
+	}
+	return exported, nil
+}
+
+// writeStoredFile copies a stored file into the archive, it returns false if the file is missing
+func (s *privacyService) writeStoredFile(ctx context.Context, zw *zip.Writer, name string, storageKey string) (bool, error) {
+	content, err := s.storage.Get(ctx, storageKey)
+	if err != nil {
+		if errors.Is(err, domain.ErrStoredObjectNotFound) {
+			log.Printf("Stored file %s is missing from a personal data export", storageKey)
+			return false, nil
+		}
+		return false, err
+	}
+	defer content.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(w, content); err != nil {
+		return false, fmt.Errorf("failed to add %s to the export: %w", storageKey, err)
+	}
+	return true, nil
+}
+
+func writeJSONFile(zw *zip.Writer, name string, content interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(content)
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// erasedFullName replaces the name of a borrower or investor whose personal data was erased
+const erasedFullName = "Erased"
+
+type privacyService struct {
+	erasureRepo       domain.ErasureRequestRepository
+	userRepo          domain.UserRepository
+	borrowerRepo      domain.BorrowerRepository
+	investorRepo      domain.InvestorRepository
+	loanRepo          domain.LoanRepository
+	approvalStageRepo domain.ApprovalStageRepository
+	investmentRepo    domain.InvestmentRepository
+	holdRepo          domain.InvestmentHoldRepository
+	documentRepo      domain.DocumentRepository
+	signatureRepo     domain.AgreementSignatureRepository
+	kycRepo           domain.KYCSubmissionRepository
+	loginEventRepo    domain.LoginEventRepository
+	auditRepo         domain.AuditRepository
+	storage           domain.FileStorage
+}
+
+func NewPrivacyService(
+	erasureRepo domain.ErasureRequestRepository,
+	userRepo domain.UserRepository,
+	borrowerRepo domain.BorrowerRepository,
+	investorRepo domain.InvestorRepository,
+	loanRepo domain.LoanRepository,
+	approvalStageRepo domain.ApprovalStageRepository,
+	investmentRepo domain.InvestmentRepository,
+	holdRepo domain.InvestmentHoldRepository,
+	documentRepo domain.DocumentRepository,
+	signatureRepo domain.AgreementSignatureRepository,
+	kycRepo domain.KYCSubmissionRepository,
+	loginEventRepo domain.LoginEventRepository,
+	auditRepo domain.AuditRepository,
+	storage domain.FileStorage,
+) domain.PrivacyService {
+	return &privacyService{
+		erasureRepo:       erasureRepo,
+		userRepo:          userRepo,
+		borrowerRepo:      borrowerRepo,
+		investorRepo:      investorRepo,
+		loanRepo:          loanRepo,
+		approvalStageRepo: approvalStageRepo,
+		investmentRepo:    investmentRepo,
+		holdRepo:          holdRepo,
+		documentRepo:      documentRepo,
+		signatureRepo:     signatureRepo,
+		kycRepo:           kycRepo,
+		loginEventRepo:    loginEventRepo,
+		auditRepo:         auditRepo,
+		storage:           storage,
+	}
+}
+
+func (s *privacyService) ExportPersonalData(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*domain.PersonalDataArchive, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := s.buildArchive(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "privacy.exported", "user", user.ID, domain.AuditOutcomeAllowed, "")
+	return archive, nil
+}
+
+func (s *privacyService) RequestErasure(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*domain.ErasureRequest, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Employee accounts are kept with the employment records
+	if user.Role != domain.RoleBorrower && user.Role != domain.RoleInvestor {
+		return nil, domain.ErrInvalidRole
+	}
+	if user.IsErased() {
+		return nil, domain.ErrAlreadyErased
+	}
+
+	latest, err := s.erasureRepo.GetLatestByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Status == domain.ErasureStatusPending {
+		return nil, domain.ErrErasurePending
+	}
+
+	now := time.Now()
+	request := &domain.ErasureRequest{
+		ID:            uuid.New(),
+		UserID:        userID,
+		RequestedByID: actorID,
+		Status:        domain.ErasureStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.erasureRepo.Create(ctx, request); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "privacy.erasure_requested", "erasure_request", request.ID, domain.AuditOutcomeAllowed, "")
+	return request, nil
+}
+
+func (s *privacyService) GetMyErasureRequest(ctx context.Context, userID uuid.UUID) (*domain.ErasureRequest, error) {
+	request, err := s.erasureRepo.GetLatestByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrErasureRequestNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *privacyService) ListErasureRequests(ctx context.Context, status domain.ErasureStatus, limit, offset int) ([]domain.ErasureRequest, int64, error) {
+	if status == "" {
+		status = domain.ErasureStatusPending
+	}
+	return s.erasureRepo.ListByStatus(ctx, status, limit, offset)
+}
+
+func (s *privacyService) ApproveErasure(ctx context.Context, actorID uuid.UUID, requestID uuid.UUID) (*domain.ErasureRequest, error) {
+	request, err := s.getPendingRequest(ctx, actorID, requestID, "privacy.erasure_completed")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	erasure := &domain.PersonalDataErasure{Request: request, User: anonymizedUser(&request.User, now)}
+
+	// Loans and investments in progress still need to reach the customer
+	switch request.User.Role {
+	case domain.RoleBorrower:
+		borrower, err := s.borrowerRepo.GetByUserID(ctx, request.UserID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if borrower != nil {
+			loans, err := s.loanRepo.GetByBorrowerID(ctx, borrower.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, loan := range loans {
+				if loan.State != domain.LoanStateDisbursed && loan.State != domain.LoanStateRejected {
+					return nil, s.blocked(ctx, actorID, request, fmt.Sprintf("loan %s is %s", loan.ID, loan.State))
+				}
+			}
+			erasure.Borrower = anonymizedBorrower(borrower, now)
+		}
+	case domain.RoleInvestor:
+		investor, err := s.investorRepo.GetByUserID(ctx, request.UserID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if investor != nil {
+			investments, err := s.investmentRepo.GetByInvestorID(ctx, investor.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, investment := range investments {
+				if investment.Loan.State != domain.LoanStateDisbursed {
+					return nil, s.blocked(ctx, actorID, request, fmt.Sprintf("loan %s is %s", investment.LoanID, investment.Loan.State))
+				}
+			}
+			// A reservation still turns into an investment once the payment completes
+			holds, err := s.holdRepo.GetActiveByInvestorID(ctx, investor.ID)
+			if err != nil {
+				return nil, err
+			}
+			if len(holds) > 0 {
+				return nil, s.blocked(ctx, actorID, request, fmt.Sprintf("hold %s on loan %s is active", holds[0].ID, holds[0].LoanID))
+			}
+			erasure.Investor = anonymizedInvestor(investor, now)
+		}
+	}
+
+	// The identity images are removed from storage once their records are gone
+	submissions, err := s.kycRepo.ListByUserID(ctx, request.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = domain.ErasureStatusCompleted
+	request.ProcessedByID = &actorID
+	request.ProcessedAt = &now
+	request.UpdatedAt = now
+
+	completed, err := s.erasureRepo.Complete(ctx, erasure)
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		return nil, domain.ErrErasureProcessed
+	}
+	request.User = *erasure.User
+
+	for _, submission := range submissions {
+		for _, document := range submission.Documents {
+			if err := s.storage.Delete(ctx, document.StorageKey); err != nil {
+				log.Printf("Failed to delete KYC document %s of erased user %s: %v", document.StorageKey, request.UserID, err)
+			}
+		}
+	}
+
+	s.audit(ctx, actorID, "privacy.erasure_completed", "erasure_request", request.ID, domain.AuditOutcomeAllowed, "")
+	return request, nil
+}
+
+func (s *privacyService) RejectErasure(ctx context.Context, actorID uuid.UUID, requestID uuid.UUID, reason string) (*domain.ErasureRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrErasureReasonMissing
+	}
+
+	request, err := s.getPendingRequest(ctx, actorID, requestID, "privacy.erasure_rejected")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = domain.ErasureStatusRejected
+	request.ProcessedByID = &actorID
+	request.ProcessedAt = &now
+	request.RejectionReason = reason
+	request.UpdatedAt = now
+
+	rejected, err := s.erasureRepo.Reject(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if !rejected {
+		return nil, domain.ErrErasureProcessed
+	}
+
+	s.audit(ctx, actorID, "privacy.erasure_rejected", "erasure_request", request.ID, domain.AuditOutcomeAllowed, reason)
+	return request, nil
+}
+
+// getPendingRequest loads a request the actor may still decide
+func (s *privacyService) getPendingRequest(ctx context.Context, actorID uuid.UUID, requestID uuid.UUID, action string) (*domain.ErasureRequest, error) {
+	request, err := s.erasureRepo.GetByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrErasureRequestNotFound
+		}
+		return nil, err
+	}
+	if request.Status != domain.ErasureStatusPending {
+		return nil, domain.ErrErasureProcessed
+	}
+	// Nobody decides on their own data
+	if request.UserID == actorID {
+		s.audit(ctx, actorID, action, "erasure_request", request.ID, domain.AuditOutcomeDenied, domain.ErrSegregationOfDuties.Error())
+		return nil, domain.ErrSegregationOfDuties
+	}
+	return request, nil
+}
+
+// blocked records why an erasure cannot be completed yet and returns ErrErasureBlocked
+func (s *privacyService) blocked(ctx context.Context, actorID uuid.UUID, request *domain.ErasureRequest, reason string) error {
+	s.audit(ctx, actorID, "privacy.erasure_completed", "erasure_request", request.ID, domain.AuditOutcomeDenied, reason)
+	return domain.ErrErasureBlocked
+}
+
+// anonymizedUser returns the account without personal data or credentials, it can never sign in again
+func anonymizedUser(user *domain.User, now time.Time) *domain.User {
+	erased := *user
+	erased.Email = fmt.Sprintf("erased-%s@erased.invalid", user.ID)
+	erased.Password = ""
+	erased.TOTPSecret = ""
+	erased.TOTPEnabledAt = nil
+	erased.TOTPLastStep = 0
+	erased.ExternalSubject = nil
+	erased.SessionsRevokedAt = &now
+	if erased.DeactivatedAt == nil {
+		erased.DeactivatedAt = &now
+	}
+	erased.ErasedAt = &now
+	erased.UpdatedAt = now
+	return &erased
+}
+
+// anonymizedBorrower keeps the region and branch, which are needed to report on the loan portfolio.
+// The identity number becomes a placeholder unique to the profile, so it no longer blocks a new
+// registration with the real number.
+func anonymizedBorrower(borrower *domain.Borrower, now time.Time) *domain.Borrower {
+	erased := *borrower
+	erased.FullName = erasedFullName
+	erased.PhoneNumber = ""
+	erased.Address = ""
+	erased.IdentityNumber = "ERASED-" + borrower.ID.String()
+	erased.Latitude = nil
+	erased.Longitude = nil
+	erased.UpdatedAt = now
+	return &erased
+}
+
+func anonymizedInvestor(investor *domain.Investor, now time.Time) *domain.Investor {
+	erased := *investor
+	erased.FullName = erasedFullName
+	erased.PhoneNumber = ""
+	erased.Address = ""
+	erased.IdentityNumber = "ERASED-" + investor.ID.String()
+	erased.UpdatedAt = now
+	return &erased
+}
+
+func (s *privacyService) getUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *privacyService) audit(ctx context.Context, actorID uuid.UUID, action string, entityType string, entityID uuid.UUID, outcome domain.AuditOutcome, reason string) {
+	writeAudit(ctx, s.auditRepo, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   &entityID,
+		Outcome:    outcome,
+		Reason:     reason,
+	})
+}
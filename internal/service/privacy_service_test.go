@@ -0,0 +1,473 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Mock Erasure Request Repository
+type mockErasureRequestRepository struct {
+	mock.Mock
+}
+
+func (m *mockErasureRequestRepository) Create(ctx context.Context, request *domain.ErasureRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *mockErasureRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *mockErasureRequestRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *mockErasureRequestRepository) ListByStatus(ctx context.Context, status domain.ErasureStatus, limit, offset int) ([]domain.ErasureRequest, int64, error) {
+	args := m.Called(ctx, status, limit, offset)
+	return args.Get(0).([]domain.ErasureRequest), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockErasureRequestRepository) Reject(ctx context.Context, request *domain.ErasureRequest) (bool, error) {
+	args := m.Called(ctx, request)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockErasureRequestRepository) Complete(ctx context.Context, erasure *domain.PersonalDataErasure) (bool, error) {
+	args := m.Called(ctx, erasure)
+	return args.Bool(0), args.Error(1)
+}
+
+// privacyMocks holds the dependencies of a privacy service under test
+type privacyMocks struct {
+	erasureRepo       *mockErasureRequestRepository
+	userRepo          *mockUserRepository
+	borrowerRepo      *mockBorrowerRepository
+	investorRepo      *mockInvestorRepository
+	loanRepo          *mockLoanRepository
+	approvalStageRepo *mockApprovalStageRepository
+	investmentRepo    *mockInvestmentRepository
+	holdRepo          *mockInvestmentHoldRepository
+	documentRepo      *mockDocumentRepository
+	signatureRepo     *mockAgreementSignatureRepository
+	kycRepo           *mockKYCSubmissionRepository
+	loginEventRepo    *mockLoginEventRepository
+	auditRepo         *mockAuditRepository
+	storage           *mockFileStorage
+}
+
+func newPrivacyTestService() (domain.PrivacyService, *privacyMocks) {
+	m := &privacyMocks{
+		erasureRepo:       new(mockErasureRequestRepository),
+		userRepo:          new(mockUserRepository),
+		borrowerRepo:      new(mockBorrowerRepository),
+		investorRepo:      new(mockInvestorRepository),
+		loanRepo:          new(mockLoanRepository),
+		approvalStageRepo: new(mockApprovalStageRepository),
+		investmentRepo:    new(mockInvestmentRepository),
+		holdRepo:          new(mockInvestmentHoldRepository),
+		documentRepo:      new(mockDocumentRepository),
+		signatureRepo:     new(mockAgreementSignatureRepository),
+		kycRepo:           new(mockKYCSubmissionRepository),
+		loginEventRepo:    new(mockLoginEventRepository),
+		auditRepo:         new(mockAuditRepository),
+		storage:           new(mockFileStorage),
+	}
+	m.auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	privacyService := NewPrivacyService(m.erasureRepo, m.userRepo, m.borrowerRepo, m.investorRepo, m.loanRepo, m.approvalStageRepo,
+		m.investmentRepo, m.holdRepo, m.documentRepo, m.signatureRepo, m.kycRepo, m.loginEventRepo, m.auditRepo, m.storage)
+	return privacyService, m
+}
+
+// readArchive returns the files of a zip archive by name
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[file.Name] = content
+	}
+	return files
+}
+
+// Test Personal Data Export - A Borrower Gets Their Loans And Documents Without Investor Data
+func TestPrivacyService_ExportPersonalData_Borrower(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Email: "borrower@example.com", Role: domain.RoleBorrower}
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: userID, FullName: "John Doe", IdentityNumber: "3171234567890001", Address: "Jl. Sudirman No. 1"}
+	investorUserID := uuid.New()
+	loan := domain.Loan{
+		ID:         uuid.New(),
+		BorrowerID: borrower.ID,
+		State:      domain.LoanStateInvested,
+		Investments: []domain.Investment{{
+			ID:       uuid.New(),
+			Amount:   50000,
+			Investor: domain.Investor{FullName: "Alice Investor", IdentityNumber: "3179876543210002"},
+		}},
+	}
+	agreement := domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement, StorageKey: "documents/a/agreement.pdf"}
+	investorAgreement := domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeInvestorAgreement, OwnerUserID: &investorUserID, StorageKey: "documents/a/investor.pdf"}
+	submission := domain.KYCSubmission{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    domain.KYCStatusVerified,
+		Documents: []domain.KYCDocument{{ID: uuid.New(), Type: domain.KYCDocumentIDCard, StorageKey: "kyc/u/s/id-card.jpg"}},
+	}
+
+	m.userRepo.On("GetByID", mock.Anything, userID).Return(user, nil)
+	m.borrowerRepo.On("GetByUserID", mock.Anything, userID).Return(borrower, nil)
+	m.investorRepo.On("GetByUserID", mock.Anything, userID).Return(nil, gorm.ErrRecordNotFound)
+	m.loanRepo.On("GetByBorrowerID", mock.Anything, borrower.ID).Return([]domain.Loan{loan}, nil)
+	m.approvalStageRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]domain.ApprovalStage{
+		{Stage: domain.ApprovalStageCreditReview, Decision: domain.ApprovalDecisionApproved, Comment: "Stable income"},
+	}, nil)
+	m.documentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]domain.Document{agreement, investorAgreement}, nil)
+	m.signatureRepo.On("GetByDocumentID", mock.Anything, agreement.ID).Return(&domain.AgreementSignature{SignerUserID: userID, SignedName: "John Doe"}, nil)
+	m.kycRepo.On("ListByUserID", mock.Anything, userID).Return([]domain.KYCSubmission{submission}, nil)
+	m.loginEventRepo.On("GetByUserID", mock.Anything, userID, 0).Return([]domain.LoginEvent{{Email: user.Email, Succeeded: true}}, nil)
+	m.storage.On("Get", mock.Anything, "documents/a/agreement.pdf").Return(io.NopCloser(strings.NewReader("%PDF agreement")), nil)
+	m.storage.On("Get", mock.Anything, "kyc/u/s/id-card.jpg").Return(io.NopCloser(strings.NewReader("id card image")), nil)
+
+	// Act
+	archive, err := privacyService.ExportPersonalData(context.Background(), userID, userID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(archive.FileName, ".zip"))
+	files := readArchive(t, archive.Data)
+
+	assert.Contains(t, string(files["profile.json"]), "3171234567890001")
+	assert.Contains(t, string(files["loans.json"]), "Stable income")
+	assert.Equal(t, "%PDF agreement", string(files["files/documents/agreement.pdf"]))
+	assert.Equal(t, "id card image", string(files["files/kyc/id-card.jpg"]))
+	assert.Contains(t, files, "login_history.json")
+
+	var documents []exportDocument
+	require.NoError(t, json.Unmarshal(files["documents.json"], &documents))
+	require.Len(t, documents, 1, "the investor's agreement is not the borrower's")
+	assert.Equal(t, agreement.ID, documents[0].ID)
+	require.NotNil(t, documents[0].Signature)
+	assert.Equal(t, "John Doe", documents[0].Signature.SignedName)
+
+	// Nothing about the investors of the loan
+	for name, content := range files {
+		assert.NotContains(t, string(content), "Alice Investor", name)
+		assert.NotContains(t, string(content), "3179876543210002", name)
+	}
+	m.storage.AssertNotCalled(t, "Get", mock.Anything, "documents/a/investor.pdf")
+}
+
+// Test Personal Data Export - An Investor Gets Their Investments Without The Borrower
+func TestPrivacyService_ExportPersonalData_Investor(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	userID := uuid.New()
+	user := &domain.User{ID: userID, Email: "investor@example.com", Role: domain.RoleInvestor}
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, FullName: "Alice Investor", TotalInvested: 50000}
+	loan := domain.Loan{ID: uuid.New(), State: domain.LoanStateDisbursed, Borrower: domain.Borrower{FullName: "John Doe", IdentityNumber: "3171234567890001"}}
+	investments := []domain.Investment{
+		{ID: uuid.New(), LoanID: loan.ID, Amount: 20000, Loan: loan},
+		{ID: uuid.New(), LoanID: loan.ID, Amount: 30000, Loan: loan},
+	}
+	ownAgreement := domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeInvestorAgreement, OwnerUserID: &userID, StorageKey: "documents/l/own.pdf"}
+	borrowerAgreement := domain.Document{ID: uuid.New(), LoanID: loan.ID, Type: domain.DocumentTypeBorrowerAgreement, StorageKey: "documents/l/borrower.pdf"}
+
+	m.userRepo.On("GetByID", mock.Anything, userID).Return(user, nil)
+	m.borrowerRepo.On("GetByUserID", mock.Anything, userID).Return(nil, gorm.ErrRecordNotFound)
+	m.investorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	m.investmentRepo.On("GetByInvestorID", mock.Anything, investor.ID).Return(investments, nil)
+	m.documentRepo.On("GetByLoanID", mock.Anything, loan.ID).Return([]domain.Document{ownAgreement, borrowerAgreement}, nil).Once()
+	m.kycRepo.On("ListByUserID", mock.Anything, userID).Return([]domain.KYCSubmission{}, nil)
+	m.loginEventRepo.On("GetByUserID", mock.Anything, userID, 0).Return([]domain.LoginEvent{}, nil)
+	// The file went missing, the export still describes the document
+	m.storage.On("Get", mock.Anything, "documents/l/own.pdf").Return(nil, domain.ErrStoredObjectNotFound)
+
+	// Act
+	archive, err := privacyService.ExportPersonalData(context.Background(), userID, userID)
+
+	// Assert
+	require.NoError(t, err)
+	files := readArchive(t, archive.Data)
+
+	var exported []exportInvestment
+	require.NoError(t, json.Unmarshal(files["investments.json"], &exported))
+	assert.Len(t, exported, 2)
+
+	var documents []exportDocument
+	require.NoError(t, json.Unmarshal(files["documents.json"], &documents))
+	require.Len(t, documents, 1)
+	assert.Equal(t, ownAgreement.ID, documents[0].ID)
+	assert.Empty(t, documents[0].File)
+
+	for name, content := range files {
+		assert.NotContains(t, string(content), "John Doe", name)
+		assert.NotContains(t, string(content), "3171234567890001", name)
+	}
+	m.documentRepo.AssertExpectations(t)
+}
+
+// Test Erasure Request - Happy Flow
+func TestPrivacyService_RequestErasure_Success(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	userID := uuid.New()
+	m.userRepo.On("GetByID", mock.Anything, userID).Return(&domain.User{ID: userID, Role: domain.RoleBorrower}, nil)
+	m.erasureRepo.On("GetLatestByUserID", mock.Anything, userID).Return(&domain.ErasureRequest{Status: domain.ErasureStatusRejected}, nil)
+	m.erasureRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ErasureRequest")).Return(nil)
+
+	// Act
+	request, err := privacyService.RequestErasure(context.Background(), userID, userID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ErasureStatusPending, request.Status)
+	assert.Equal(t, userID, request.UserID)
+	assert.Equal(t, userID, request.RequestedByID)
+	m.erasureRepo.AssertExpectations(t)
+}
+
+// Test Erasure Request - Refused For Staff, Erased Users And Pending Requests
+func TestPrivacyService_RequestErasure_Refused(t *testing.T) {
+	erasedAt := time.Now()
+	tests := []struct {
+		name    string
+		user    *domain.User
+		latest  *domain.ErasureRequest
+		wantErr error
+	}{
+		{"staff account", &domain.User{Role: domain.RoleCreditAnalyst}, nil, domain.ErrInvalidRole},
+		{"already erased", &domain.User{Role: domain.RoleInvestor, ErasedAt: &erasedAt}, nil, domain.ErrAlreadyErased},
+		{"pending request", &domain.User{Role: domain.RoleInvestor}, &domain.ErasureRequest{Status: domain.ErasureStatusPending}, domain.ErrErasurePending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privacyService, m := newPrivacyTestService()
+			tt.user.ID = uuid.New()
+			m.userRepo.On("GetByID", mock.Anything, tt.user.ID).Return(tt.user, nil)
+			if tt.latest != nil {
+				m.erasureRepo.On("GetLatestByUserID", mock.Anything, tt.user.ID).Return(tt.latest, nil)
+			} else {
+				m.erasureRepo.On("GetLatestByUserID", mock.Anything, tt.user.ID).Return(nil, gorm.ErrRecordNotFound)
+			}
+
+			_, err := privacyService.RequestErasure(context.Background(), tt.user.ID, tt.user.ID)
+
+			assert.Equal(t, tt.wantErr, err)
+			m.erasureRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// pendingErasure is a pending erasure request of a customer in the role
+func pendingErasure(role domain.UserRole) *domain.ErasureRequest {
+	userID := uuid.New()
+	return &domain.ErasureRequest{
+		ID:            uuid.New(),
+		UserID:        userID,
+		RequestedByID: userID,
+		Status:        domain.ErasureStatusPending,
+		User:          domain.User{ID: userID, Email: "customer@example.com", Role: role, TOTPSecret: "SECRET"},
+	}
+}
+
+// Test Erasure Approval - The Borrower Is Anonymized And The Identity Images Deleted
+func TestPrivacyService_ApproveErasure_Success(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleBorrower)
+	adminID := uuid.New()
+	latitude := -6.2
+	borrower := &domain.Borrower{ID: uuid.New(), UserID: request.UserID, FullName: "John Doe", PhoneNumber: "+6281234567890",
+		Address: "Jl. Sudirman No. 1", IdentityNumber: "3171234567890001", Region: "jakarta", Latitude: &latitude}
+	submissions := []domain.KYCSubmission{{Documents: []domain.KYCDocument{{StorageKey: "kyc/id-card.jpg"}, {StorageKey: "kyc/selfie.png"}}}}
+
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+	m.borrowerRepo.On("GetByUserID", mock.Anything, request.UserID).Return(borrower, nil)
+	m.loanRepo.On("GetByBorrowerID", mock.Anything, borrower.ID).Return([]domain.Loan{
+		{ID: uuid.New(), State: domain.LoanStateDisbursed},
+		{ID: uuid.New(), State: domain.LoanStateRejected},
+	}, nil)
+	m.kycRepo.On("ListByUserID", mock.Anything, request.UserID).Return(submissions, nil)
+	m.erasureRepo.On("Complete", mock.Anything, mock.AnythingOfType("*domain.PersonalDataErasure")).Return(true, nil)
+	m.storage.On("Delete", mock.Anything, "kyc/id-card.jpg").Return(nil)
+	m.storage.On("Delete", mock.Anything, "kyc/selfie.png").Return(nil)
+
+	// Act
+	result, err := privacyService.ApproveErasure(context.Background(), adminID, request.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ErasureStatusCompleted, result.Status)
+	assert.Equal(t, &adminID, result.ProcessedByID)
+
+	erasure := m.erasureRepo.Calls[1].Arguments.Get(1).(*domain.PersonalDataErasure)
+	assert.Equal(t, "erased-"+request.UserID.String()+"@erased.invalid", erasure.User.Email)
+	assert.Empty(t, erasure.User.Password)
+	assert.Empty(t, erasure.User.TOTPSecret)
+	assert.True(t, erasure.User.IsErased())
+	assert.False(t, erasure.User.IsActive())
+	assert.NotNil(t, erasure.User.SessionsRevokedAt)
+
+	require.NotNil(t, erasure.Borrower)
+	assert.Equal(t, erasedFullName, erasure.Borrower.FullName)
+	assert.Empty(t, erasure.Borrower.PhoneNumber)
+	assert.Empty(t, erasure.Borrower.Address)
+	assert.Equal(t, "ERASED-"+borrower.ID.String(), erasure.Borrower.IdentityNumber)
+	assert.Nil(t, erasure.Borrower.Latitude)
+	assert.Equal(t, "jakarta", erasure.Borrower.Region)
+	assert.Nil(t, erasure.Investor)
+	// The loaded profile is left as it was
+	assert.Equal(t, "John Doe", borrower.FullName)
+
+	m.storage.AssertExpectations(t)
+}
+
+// Test Erasure Approval - Blocked While An Investment Has Not Been Disbursed
+func TestPrivacyService_ApproveErasure_Blocked(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleInvestor)
+	investor := &domain.Investor{ID: uuid.New(), UserID: request.UserID}
+
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+	m.investorRepo.On("GetByUserID", mock.Anything, request.UserID).Return(investor, nil)
+	m.investmentRepo.On("GetByInvestorID", mock.Anything, investor.ID).Return([]domain.Investment{
+		{ID: uuid.New(), Loan: domain.Loan{State: domain.LoanStateDisbursed}},
+		{ID: uuid.New(), Loan: domain.Loan{State: domain.LoanStateInvested}},
+	}, nil)
+
+	// Act
+	_, err := privacyService.ApproveErasure(context.Background(), uuid.New(), request.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrErasureBlocked, err)
+	m.erasureRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+	m.auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "privacy.erasure_completed" && entry.Outcome == domain.AuditOutcomeDenied
+	}))
+}
+
+// Test Erasure Approval - Blocked While The Investor Holds An Active Reservation
+func TestPrivacyService_ApproveErasure_ActiveHold(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleInvestor)
+	investor := &domain.Investor{ID: uuid.New(), UserID: request.UserID}
+
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+	m.investorRepo.On("GetByUserID", mock.Anything, request.UserID).Return(investor, nil)
+	m.investmentRepo.On("GetByInvestorID", mock.Anything, investor.ID).Return([]domain.Investment{
+		{ID: uuid.New(), Loan: domain.Loan{State: domain.LoanStateDisbursed}},
+	}, nil)
+	m.holdRepo.On("GetActiveByInvestorID", mock.Anything, investor.ID).Return([]domain.InvestmentHold{
+		{ID: uuid.New(), LoanID: uuid.New(), InvestorID: investor.ID, Amount: 100000, Status: domain.HoldStatusActive},
+	}, nil)
+
+	// Act
+	_, err := privacyService.ApproveErasure(context.Background(), uuid.New(), request.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrErasureBlocked, err)
+	m.erasureRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
+
+// Test Erasure Approval - An Investor Without Open Investments Or Reservations Is Anonymized
+func TestPrivacyService_ApproveErasure_Investor(t *testing.T) {
+	// Arrange
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleInvestor)
+	investor := &domain.Investor{ID: uuid.New(), UserID: request.UserID, FullName: "Alice Investor", IdentityNumber: "3179876543210002"}
+
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+	m.investorRepo.On("GetByUserID", mock.Anything, request.UserID).Return(investor, nil)
+	m.investmentRepo.On("GetByInvestorID", mock.Anything, investor.ID).Return([]domain.Investment{
+		{ID: uuid.New(), Loan: domain.Loan{State: domain.LoanStateDisbursed}},
+	}, nil)
+	m.holdRepo.On("GetActiveByInvestorID", mock.Anything, investor.ID).Return([]domain.InvestmentHold{}, nil)
+	m.kycRepo.On("ListByUserID", mock.Anything, request.UserID).Return([]domain.KYCSubmission{}, nil)
+	m.erasureRepo.On("Complete", mock.Anything, mock.AnythingOfType("*domain.PersonalDataErasure")).Return(true, nil)
+
+	// Act
+	result, err := privacyService.ApproveErasure(context.Background(), uuid.New(), request.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ErasureStatusCompleted, result.Status)
+
+	erasure := m.erasureRepo.Calls[1].Arguments.Get(1).(*domain.PersonalDataErasure)
+	require.NotNil(t, erasure.Investor)
+	assert.Equal(t, erasedFullName, erasure.Investor.FullName)
+	assert.Equal(t, "ERASED-"+investor.ID.String(), erasure.Investor.IdentityNumber)
+	assert.Nil(t, erasure.Borrower)
+}
+
+// Test Erasure Approval - Nobody Decides On Their Own Request
+func TestPrivacyService_ApproveErasure_OwnRequest(t *testing.T) {
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleBorrower)
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+
+	_, err := privacyService.ApproveErasure(context.Background(), request.UserID, request.ID)
+
+	assert.Equal(t, domain.ErrSegregationOfDuties, err)
+	m.erasureRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
+
+// Test Erasure Rejection - A Reason Is Required And Recorded
+func TestPrivacyService_RejectErasure(t *testing.T) {
+	privacyService, m := newPrivacyTestService()
+
+	request := pendingErasure(domain.RoleBorrower)
+	adminID := uuid.New()
+	m.erasureRepo.On("GetByID", mock.Anything, request.ID).Return(request, nil)
+	m.erasureRepo.On("Reject", mock.Anything, request).Return(true, nil)
+
+	_, err := privacyService.RejectErasure(context.Background(), adminID, request.ID, "  ")
+	assert.Equal(t, domain.ErrErasureReasonMissing, err)
+
+	result, err := privacyService.RejectErasure(context.Background(), adminID, request.ID, "Outstanding repayments")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ErasureStatusRejected, result.Status)
+	assert.Equal(t, "Outstanding repayments", result.RejectionReason)
+	assert.NotNil(t, result.ProcessedAt)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type segregationService struct {
+	userRepo          domain.UserRepository
+	borrowerRepo      domain.BorrowerRepository
+	relationshipRepo  domain.StaffRelationshipRepository
+	auditRepo         domain.AuditRepository
+	segregationConfig *config.SegregationConfig
+}
+
+func NewSegregationService(
+	userRepo domain.UserRepository,
+	borrowerRepo domain.BorrowerRepository,
+	relationshipRepo domain.StaffRelationshipRepository,
+	auditRepo domain.AuditRepository,
+	segregationConfig *config.SegregationConfig,
+) domain.SegregationService {
+	return &segregationService{
+		userRepo:          userRepo,
+		borrowerRepo:      borrowerRepo,
+		relationshipRepo:  relationshipRepo,
+		auditRepo:         auditRepo,
+		segregationConfig: segregationConfig,
+	}
+}
+
+func (s *segregationService) CheckActor(ctx context.Context, loan *domain.Loan, action domain.LoanAction, actorID uuid.UUID) error {
+	reason, err := s.findConflict(ctx, loan, action, actorID)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return nil
+	}
+
+	s.audit(ctx, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     "loan." + string(action),
+		EntityType: "loan",
+		EntityID:   &loan.ID,
+		Outcome:    domain.AuditOutcomeDenied,
+		Reason:     reason,
+	})
+
+	return domain.ErrSegregationOfDuties
+}
+
+func (s *segregationService) Permits(ctx context.Context, loan *domain.Loan, action domain.LoanAction, actorID uuid.UUID) (bool, error) {
+	reason, err := s.findConflict(ctx, loan, action, actorID)
+	if err != nil {
+		return false, err
+	}
+	return reason == "", nil
+}
+
+// findConflict returns why the actor may not perform the action, or an empty string when allowed
+func (s *segregationService) findConflict(ctx context.Context, loan *domain.Loan, action domain.LoanAction, actorID uuid.UUID) (string, error) {
+	cfg := s.segregationConfig
+
+	if cfg.DistinctActors {
+		if loan.Borrower.UserID == actorID {
+			return "actor is the borrower of the loan", nil
+		}
+		for _, prior := range priorActions(loan) {
+			if prior.actorID == actorID && prior.action != action {
+				return fmt.Sprintf("actor already performed %s on this loan", prior.action), nil
+			}
+		}
+	}
+
+	if cfg.EnforceRelationships {
+		related, err := s.relationshipRepo.Exists(ctx, actorID, loan.BorrowerID)
+		if err != nil {
+			return "", err
+		}
+		if related {
+			return "actor declared a relationship with the borrower", nil
+		}
+	}
+
+	if s.sameBranchForbidden(action) && loan.Borrower.Branch != "" {
+		actor, err := s.userRepo.GetByID(ctx, actorID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", domain.ErrUserNotFound
+			}
+			return "", err
+		}
+		if strings.EqualFold(actor.Branch, loan.Borrower.Branch) {
+			return fmt.Sprintf("actor works at the borrower's branch %s", loan.Borrower.Branch), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s *segregationService) sameBranchForbidden(action domain.LoanAction) bool {
+	for _, forbidden := range s.segregationConfig.SameBranchForbidden {
+		if forbidden == string(action) {
+			return true
+		}
+	}
+	return false
+}
+
+type loanActor struct {
+	actorID uuid.UUID
+	action  domain.LoanAction
+}
+
+// priorActions lists who already acted on the loan and in which stage
+func priorActions(loan *domain.Loan) []loanActor {
+	var actors []loanActor
+	if loan.Approval != nil {
+		actors = append(actors, loanActor{loan.Approval.ValidatorID, domain.LoanActionFieldVerification})
+	}
+	for _, stage := range loan.ApprovalStages {
+		// Stage types and lifecycle actions share their names
+		actors = append(actors, loanActor{stage.ActorID, domain.LoanAction(stage.Stage)})
+	}
+	if loan.Disbursement != nil {
+		actors = append(actors, loanActor{loan.Disbursement.OfficerID, domain.LoanActionDisbursement})
+	}
+	return actors
+}
+
+func (s *segregationService) DeclareRelationship(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID, relationship domain.RelationshipType, note string) (*domain.StaffRelationship, error) {
+	if _, err := s.borrowerRepo.GetByID(ctx, borrowerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrBorrowerNotFound
+		}
+		return nil, err
+	}
+
+	exists, err := s.relationshipRepo.Exists(ctx, staffUserID, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, domain.ErrRelationshipExists
+	}
+
+	declared := &domain.StaffRelationship{
+		ID:           uuid.New(),
+		StaffUserID:  staffUserID,
+		BorrowerID:   borrowerID,
+		Relationship: relationship,
+		Note:         strings.TrimSpace(note),
+		CreatedAt:    time.Now(),
+	}
+	if err := s.relationshipRepo.Create(ctx, declared); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, &domain.AuditEntry{
+		ActorID:    &staffUserID,
+		Action:     "staff.relationship_declared",
+		EntityType: "borrower",
+		EntityID:   &borrowerID,
+		Outcome:    domain.AuditOutcomeAllowed,
+		Reason:     string(relationship),
+	})
+
+	return declared, nil
+}
+
+func (s *segregationService) GetDeclaredRelationships(ctx context.Context, staffUserID uuid.UUID) ([]domain.StaffRelationship, error) {
+	return s.relationshipRepo.GetByStaffUserID(ctx, staffUserID)
+}
+
+func (s *segregationService) audit(ctx context.Context, entry *domain.AuditEntry) {
+	writeAudit(ctx, s.auditRepo, entry)
+}
+
+// writeAudit stores the entry, a failure is logged since the audited decision already stands
+func writeAudit(ctx context.Context, auditRepo domain.AuditRepository, entry *domain.AuditEntry) {
+	entry.ID = uuid.New()
+	entry.CreatedAt = time.Now()
+	if err := auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry %s: %v", entry.Action, err)
+	}
+}
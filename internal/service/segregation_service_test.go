@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Staff Relationship Repository
+type mockStaffRelationshipRepository struct {
+	mock.Mock
+}
+
+func (m *mockStaffRelationshipRepository) Create(ctx context.Context, relationship *domain.StaffRelationship) error {
+	args := m.Called(ctx, relationship)
+	return args.Error(0)
+}
+
+func (m *mockStaffRelationshipRepository) Exists(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, staffUserID, borrowerID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockStaffRelationshipRepository) GetByStaffUserID(ctx context.Context, staffUserID uuid.UUID) ([]domain.StaffRelationship, error) {
+	args := m.Called(ctx, staffUserID)
+	return args.Get(0).([]domain.StaffRelationship), args.Error(1)
+}
+
+// Mock Audit Repository
+type mockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *mockAuditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+// Mock Segregation Service
+type mockSegregationService struct {
+	mock.Mock
+}
+
+func (m *mockSegregationService) CheckActor(ctx context.Context, loan *domain.Loan, action domain.LoanAction, actorID uuid.UUID) error {
+	args := m.Called(ctx, loan, action, actorID)
+	return args.Error(0)
+}
+
+func (m *mockSegregationService) Permits(ctx context.Context, loan *domain.Loan, action domain.LoanAction, actorID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, loan, action, actorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockSegregationService) DeclareRelationship(ctx context.Context, staffUserID uuid.UUID, borrowerID uuid.UUID, relationship domain.RelationshipType, note string) (*domain.StaffRelationship, error) {
+	args := m.Called(ctx, staffUserID, borrowerID, relationship, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StaffRelationship), args.Error(1)
+}
+
+func (m *mockSegregationService) GetDeclaredRelationships(ctx context.Context, staffUserID uuid.UUID) ([]domain.StaffRelationship, error) {
+	args := m.Called(ctx, staffUserID)
+	return args.Get(0).([]domain.StaffRelationship), args.Error(1)
+}
+
+// Every rule enabled, same branch staff may not verify or disburse
+var strictSegregationConfig = &config.SegregationConfig{
+	DistinctActors:       true,
+	EnforceRelationships: true,
+	SameBranchForbidden:  []string{"field_verification", "disbursement"},
+}
+
+// Test Segregation Of Duties - Validator Cannot Also Disburse
+func TestSegregationService_CheckActor_SameUserAcrossStages(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockRelationshipRepo := new(mockStaffRelationshipRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	segregationService := NewSegregationService(mockUserRepo, mockBorrowerRepo, mockRelationshipRepo, mockAuditRepo, strictSegregationConfig)
+
+	validatorID := uuid.New()
+	officerID := uuid.New()
+	loan := &domain.Loan{
+		ID:         uuid.New(),
+		BorrowerID: uuid.New(),
+		State:      domain.LoanStateInvested,
+		Approval:   &domain.Approval{ValidatorID: validatorID},
+		ApprovalStages: []domain.ApprovalStage{
+			{Stage: domain.ApprovalStageFieldVerification, ActorID: validatorID},
+		},
+	}
+
+	mockRelationshipRepo.On("Exists", mock.Anything, officerID, loan.BorrowerID).Return(false, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return *entry.ActorID == validatorID &&
+			entry.Action == "loan.disbursement" &&
+			*entry.EntityID == loan.ID &&
+			entry.Outcome == domain.AuditOutcomeDenied &&
+			entry.Reason == "actor already performed field_verification on this loan"
+	})).Return(nil)
+
+	// Act
+	conflictErr := segregationService.CheckActor(context.Background(), loan, domain.LoanActionDisbursement, validatorID)
+	err := segregationService.CheckActor(context.Background(), loan, domain.LoanActionDisbursement, officerID)
+
+	// Assert
+	assert.Equal(t, domain.ErrSegregationOfDuties, conflictErr)
+	assert.NoError(t, err)
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test Segregation Of Duties - Candidate Checks Do Not Leave Denials In The Audit Log
+func TestSegregationService_Permits(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockRelationshipRepo := new(mockStaffRelationshipRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	segregationService := NewSegregationService(mockUserRepo, new(mockBorrowerRepository), mockRelationshipRepo, mockAuditRepo, strictSegregationConfig)
+
+	related := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Branch: "SF-02"}
+	unrelated := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Branch: "SF-02"}
+	loan := &domain.Loan{ID: uuid.New(), BorrowerID: uuid.New(), Borrower: domain.Borrower{UserID: uuid.New(), Branch: "NYC-01"}}
+
+	mockRelationshipRepo.On("Exists", mock.Anything, related.ID, loan.BorrowerID).Return(true, nil)
+	mockRelationshipRepo.On("Exists", mock.Anything, unrelated.ID, loan.BorrowerID).Return(false, nil)
+	mockUserRepo.On("GetByID", mock.Anything, unrelated.ID).Return(unrelated, nil)
+
+	// Act
+	relatedPermitted, relatedErr := segregationService.Permits(context.Background(), loan, domain.LoanActionFieldVerification, related.ID)
+	unrelatedPermitted, unrelatedErr := segregationService.Permits(context.Background(), loan, domain.LoanActionFieldVerification, unrelated.ID)
+
+	// Assert
+	assert.NoError(t, relatedErr)
+	assert.False(t, relatedPermitted)
+	assert.NoError(t, unrelatedErr)
+	assert.True(t, unrelatedPermitted)
+	mockAuditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Segregation Of Duties - Declared Relationships And Same Branch Staff Are Refused
+func TestSegregationService_CheckActor_RelationshipAndBranch(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockRelationshipRepo := new(mockStaffRelationshipRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	segregationService := NewSegregationService(mockUserRepo, mockBorrowerRepo, mockRelationshipRepo, mockAuditRepo, strictSegregationConfig)
+
+	cousin := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst}
+	sameBranch := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Branch: "NYC-01"}
+	loan := &domain.Loan{
+		ID:         uuid.New(),
+		BorrowerID: uuid.New(),
+		Borrower:   domain.Borrower{UserID: uuid.New(), Branch: "nyc-01"},
+	}
+
+	mockRelationshipRepo.On("Exists", mock.Anything, cousin.ID, loan.BorrowerID).Return(true, nil)
+	mockRelationshipRepo.On("Exists", mock.Anything, sameBranch.ID, loan.BorrowerID).Return(false, nil)
+	mockUserRepo.On("GetByID", mock.Anything, sameBranch.ID).Return(sameBranch, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	// Act
+	relatedErr := segregationService.CheckActor(context.Background(), loan, domain.LoanActionCreditReview, cousin.ID)
+	branchErr := segregationService.CheckActor(context.Background(), loan, domain.LoanActionFieldVerification, sameBranch.ID)
+	// Credit review is not restricted by branch
+	reviewErr := segregationService.CheckActor(context.Background(), loan, domain.LoanActionCreditReview, sameBranch.ID)
+	borrowerErr := segregationService.CheckActor(context.Background(), loan, domain.LoanActionCreditReview, loan.Borrower.UserID)
+
+	// Assert
+	assert.Equal(t, domain.ErrSegregationOfDuties, relatedErr)
+	assert.Equal(t, domain.ErrSegregationOfDuties, branchErr)
+	assert.NoError(t, reviewErr)
+	assert.Equal(t, domain.ErrSegregationOfDuties, borrowerErr)
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 3)
+}
+
+// Test Relationship Declaration - Duplicate Declarations Are Rejected
+func TestSegregationService_DeclareRelationship(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockRelationshipRepo := new(mockStaffRelationshipRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	segregationService := NewSegregationService(mockUserRepo, mockBorrowerRepo, mockRelationshipRepo, mockAuditRepo, strictSegregationConfig)
+
+	staffID := uuid.New()
+	borrower := &domain.Borrower{ID: uuid.New()}
+	declaredBorrower := &domain.Borrower{ID: uuid.New()}
+
+	mockBorrowerRepo.On("GetByID", mock.Anything, borrower.ID).Return(borrower, nil)
+	mockBorrowerRepo.On("GetByID", mock.Anything, declaredBorrower.ID).Return(declaredBorrower, nil)
+	mockRelationshipRepo.On("Exists", mock.Anything, staffID, borrower.ID).Return(false, nil)
+	mockRelationshipRepo.On("Exists", mock.Anything, staffID, declaredBorrower.ID).Return(true, nil)
+	mockRelationshipRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.StaffRelationship")).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	// Act
+	relationship, err := segregationService.DeclareRelationship(context.Background(), staffID, borrower.ID, domain.RelationshipFamily, " brother-in-law ")
+	_, duplicateErr := segregationService.DeclareRelationship(context.Background(), staffID, declaredBorrower.ID, domain.RelationshipBusiness, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, staffID, relationship.StaffUserID)
+	assert.Equal(t, "brother-in-law", relationship.Note)
+	assert.Equal(t, domain.ErrRelationshipExists, duplicateErr)
+	mockRelationshipRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test Loan Disbursement - Refused When The Officer Conflicts With An Earlier Stage
+func TestLoanService_DisburseLoan_SegregationOfDuties(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
+
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateInvested}
+	officerID := uuid.New()
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockSegregationService.On("CheckActor", mock.Anything, loan, domain.LoanActionDisbursement, officerID).Return(domain.ErrSegregationOfDuties)
+
+	// Act
+	err := loanService.DisburseLoan(context.Background(), loan.ID, officerID, uuid.New(), loan.CreatedAt)
+
+	// Assert
+	assert.Equal(t, domain.ErrSegregationOfDuties, err)
+	mockDisbursementRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Equal(t, domain.LoanStateInvested, loan.State)
+}
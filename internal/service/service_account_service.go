@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	// apiKeyPrefix starts every key so it is recognized in headers, logs and secret scanners
+	apiKeyPrefix = "amf_"
+	// apiKeyIDBytes is the randomness of the public part that identifies a key
+	apiKeyIDBytes = 8
+	// serviceAccountEmailDomain gives service accounts an address that can never receive mail
+	serviceAccountEmailDomain = "service-accounts.invalid"
+	// unusablePassword is not a bcrypt hash, so no password ever matches it
+	unusablePassword = "!"
+)
+
+var serviceAccountNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,49}$`)
+
+type serviceAccountService struct {
+	userRepo     domain.UserRepository
+	apiKeyRepo   domain.APIKeyRepository
+	auditRepo    domain.AuditRepository
+	policy       domain.PermissionPolicy
+	apiKeyConfig *config.APIKeyConfig
+}
+
+func NewServiceAccountService(
+	userRepo domain.UserRepository,
+	apiKeyRepo domain.APIKeyRepository,
+	auditRepo domain.AuditRepository,
+	policy domain.PermissionPolicy,
+	apiKeyConfig *config.APIKeyConfig,
+) domain.ServiceAccountService {
+	return &serviceAccountService{
+		userRepo:     userRepo,
+		apiKeyRepo:   apiKeyRepo,
+		auditRepo:    auditRepo,
+		policy:       policy,
+		apiKeyConfig: apiKeyConfig,
+	}
+}
+
+func (s *serviceAccountService) CreateServiceAccount(ctx context.Context, actorID uuid.UUID, input domain.ServiceAccountInput) (*domain.User, error) {
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+	if !serviceAccountNamePattern.MatchString(name) {
+		return nil, domain.ErrInvalidServiceAccount
+	}
+	// Machine clients act as staff, user administration stays with people
+	if !input.Role.IsStaff() || s.policy.Can(input.Role, domain.PermissionUserManage) {
+		return nil, domain.ErrInvalidRole
+	}
+
+	email := name + "@" + serviceAccountEmailDomain
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return nil, domain.ErrServiceAccountExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		ID:              uuid.New(),
+		Email:           email,
+		Password:        unusablePassword,
+		Role:            input.Role,
+		ServiceAccount:  true,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "service_account.created", "user", user.ID, "role "+string(user.Role))
+	return user, nil
+}
+
+func (s *serviceAccountService) ListServiceAccounts(ctx context.Context) ([]domain.User, error) {
+	return s.userRepo.GetServiceAccounts(ctx)
+}
+
+func (s *serviceAccountService) IssueAPIKey(ctx context.Context, actorID uuid.UUID, serviceAccountID uuid.UUID, input domain.APIKeyInput) (*domain.IssuedAPIKey, error) {
+	account, err := s.getServiceAccount(ctx, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive() {
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	scopes, err := s.checkScopes(account.Role, input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := input.TTL
+	if ttl == 0 {
+		ttl = s.apiKeyConfig.DefaultTTL
+	}
+	if ttl < 0 || ttl > s.apiKeyConfig.MaxTTL {
+		return nil, domain.ErrInvalidAPIKeyExpiry
+	}
+
+	issued, err := s.newKey(account.ID, strings.TrimSpace(input.Name), scopes, time.Now().Add(ttl), actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apiKeyRepo.Create(ctx, issued.APIKey); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "api_key.issued", "api_key", issued.APIKey.ID, fmt.Sprintf("%s for %s", issued.APIKey.Prefix, account.Email))
+	return issued, nil
+}
+
+func (s *serviceAccountService) ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]domain.APIKey, error) {
+	if _, err := s.getServiceAccount(ctx, serviceAccountID); err != nil {
+		return nil, err
+	}
+	return s.apiKeyRepo.GetByUserID(ctx, serviceAccountID)
+}
+
+func (s *serviceAccountService) RotateAPIKey(ctx context.Context, actorID uuid.UUID, keyID uuid.UUID) (*domain.IssuedAPIKey, error) {
+	key, err := s.getKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !key.IsActive(now) || key.ReplacedByID != nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	account, err := s.getServiceAccount(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive() {
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	// The successor keeps the lifetime the key was issued with
+	issued, err := s.newKey(key.UserID, key.Name, key.Scopes, now.Add(key.ExpiresAt.Sub(key.CreatedAt)), actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one of two concurrent rotations of the same key may win
+	rotated, err := s.apiKeyRepo.Rotate(ctx, key.ID, issued.APIKey, now.Add(s.apiKeyConfig.RotationGrace))
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	s.audit(ctx, actorID, "api_key.rotated", "api_key", key.ID, fmt.Sprintf("%s -> %s", key.Prefix, issued.APIKey.Prefix))
+	return issued, nil
+}
+
+func (s *serviceAccountService) RevokeAPIKey(ctx context.Context, actorID uuid.UUID, keyID uuid.UUID) error {
+	key, err := s.getKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.apiKeyRepo.Revoke(ctx, key.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domain.ErrInvalidAPIKey
+	}
+
+	s.audit(ctx, actorID, "api_key.revoked", "api_key", key.ID, key.Prefix)
+	return nil
+}
+
+func (s *serviceAccountService) Authenticate(ctx context.Context, rawKey string) (*domain.User, *domain.APIKey, error) {
+	prefix, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashSecureToken(secret)), []byte(key.SecretHash)) != 1 || !key.IsActive(now) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if !user.ServiceAccount {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	if !user.IsActive() {
+		return nil, nil, domain.ErrAccountDeactivated
+	}
+
+	// A failure only leaves the last use stale, the request itself is authentic
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		log.Printf("Failed to record use of API key %s: %v", key.Prefix, err)
+	}
+
+	user.Scopes = append(domain.Permissions{}, key.Scopes...)
+	return user, key, nil
+}
+
+// checkScopes keeps the distinct requested scopes, each of which the role must grant
+func (s *serviceAccountService) checkScopes(role domain.UserRole, requested []domain.Permission) (domain.Permissions, error) {
+	if len(requested) == 0 {
+		return nil, domain.ErrInvalidScope
+	}
+
+	var scopes domain.Permissions
+	for _, scope := range requested {
+		if !s.policy.Can(role, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+		if !scopes.Contains(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// newKey generates a key of the form amf_<prefix>_<secret>, only the prefix and a hash of the secret are kept
+func (s *serviceAccountService) newKey(userID uuid.UUID, name string, scopes domain.Permissions, expiresAt time.Time, actorID uuid.UUID) (*domain.IssuedAPIKey, error) {
+	buf := make([]byte, apiKeyIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret, err := generateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := apiKeyPrefix + hex.EncodeToString(buf)
+	return &domain.IssuedAPIKey{
+		Key: prefix + "_" + secret,
+		APIKey: &domain.APIKey{
+			ID:          uuid.New(),
+			UserID:      userID,
+			Name:        name,
+			Prefix:      prefix,
+			SecretHash:  hashSecureToken(secret),
+			Scopes:      scopes,
+			ExpiresAt:   expiresAt,
+			CreatedByID: actorID,
+			CreatedAt:   time.Now(),
+		},
+	}, nil
+}
+
+// parseAPIKey splits a key into the prefix that identifies it and its secret
+func parseAPIKey(rawKey string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(rawKey), apiKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != 2*apiKeyIDBytes || secret == "" {
+		return "", "", false
+	}
+	return apiKeyPrefix + id, secret, true
+}
+
+func (s *serviceAccountService) getServiceAccount(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.ServiceAccount {
+		return nil, domain.ErrNotServiceAccount
+	}
+	return user, nil
+}
+
+func (s *serviceAccountService) getKey(ctx context.Context, keyID uuid.UUID) (*domain.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *serviceAccountService) audit(ctx context.Context, actorID uuid.UUID, action string, entityType string, entityID uuid.UUID, reason string) {
+	writeAudit(ctx, s.auditRepo, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   &entityID,
+		Outcome:    domain.AuditOutcomeAllowed,
+		Reason:     reason,
+	})
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type mockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) Rotate(ctx context.Context, id uuid.UUID, successor *domain.APIKey, expiresAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, successor, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+var testAPIKeyConfig = &config.APIKeyConfig{
+	DefaultTTL:    90 * 24 * time.Hour,
+	MaxTTL:        365 * 24 * time.Hour,
+	RotationGrace: 24 * time.Hour,
+}
+
+// Test Service Account Creation - Staff Roles Only, Administrators Stay Human
+func TestServiceAccountService_CreateServiceAccount(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockAPIKeyRepo := new(mockAPIKeyRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	serviceAccountService := NewServiceAccountService(mockUserRepo, mockAPIKeyRepo, mockAuditRepo, testPermissionPolicy, testAPIKeyConfig)
+
+	adminID := uuid.New()
+	mockUserRepo.On("GetByEmail", mock.Anything, "nightly-review@service-accounts.invalid").Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "service_account.created" && *entry.ActorID == adminID
+	})).Return(nil)
+
+	// Act
+	account, err := serviceAccountService.CreateServiceAccount(context.Background(), adminID, domain.ServiceAccountInput{Name: " Nightly-Review ", Role: domain.RoleCreditAnalyst})
+	_, adminErr := serviceAccountService.CreateServiceAccount(context.Background(), adminID, domain.ServiceAccountInput{Name: "provisioning", Role: domain.RoleAdmin})
+	_, customerErr := serviceAccountService.CreateServiceAccount(context.Background(), adminID, domain.ServiceAccountInput{Name: "partner", Role: domain.RoleInvestor})
+	_, nameErr := serviceAccountService.CreateServiceAccount(context.Background(), adminID, domain.ServiceAccountInput{Name: "bad name!", Role: domain.RoleCreditAnalyst})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, account.ServiceAccount)
+	assert.Equal(t, "nightly-review@service-accounts.invalid", account.Email)
+	assert.Equal(t, unusablePassword, account.Password)
+	assert.Equal(t, domain.ErrInvalidRole, adminErr)
+	assert.Equal(t, domain.ErrInvalidRole, customerErr)
+	assert.Equal(t, domain.ErrInvalidServiceAccount, nameErr)
+	mockUserRepo.AssertNumberOfCalls(t, "Create", 1)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+// Test API Key Issue - Scopes Must Belong To The Role, Only A Hash Of The Secret Is Stored
+func TestServiceAccountService_IssueAPIKey(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockAPIKeyRepo := new(mockAPIKeyRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	serviceAccountService := NewServiceAccountService(mockUserRepo, mockAPIKeyRepo, mockAuditRepo, testPermissionPolicy, testAPIKeyConfig)
+
+	adminID := uuid.New()
+	account := &domain.User{ID: uuid.New(), Email: "nightly-review@service-accounts.invalid", Role: domain.RoleCreditAnalyst, ServiceAccount: true}
+
+	var stored *domain.APIKey
+	mockUserRepo.On("GetByID", mock.Anything, account.ID).Return(account, nil)
+	mockAPIKeyRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.APIKey)
+	}).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	// Act
+	issued, err := serviceAccountService.IssueAPIKey(context.Background(), adminID, account.ID, domain.APIKeyInput{
+		Name:   "nightly job",
+		Scopes: []domain.Permission{domain.PermissionLoanBrowse, domain.PermissionLoanViewFlagged, domain.PermissionLoanBrowse},
+	})
+	_, scopeErr := serviceAccountService.IssueAPIKey(context.Background(), adminID, account.ID, domain.APIKeyInput{
+		Name:   "too broad",
+		Scopes: []domain.Permission{domain.PermissionLoanDisburse},
+	})
+	_, expiryErr := serviceAccountService.IssueAPIKey(context.Background(), adminID, account.ID, domain.APIKeyInput{
+		Name:   "forever",
+		Scopes: []domain.Permission{domain.PermissionLoanBrowse},
+		TTL:    2 * testAPIKeyConfig.MaxTTL,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Key, stored.Prefix+"_"))
+	assert.True(t, strings.HasPrefix(stored.Prefix, "amf_"))
+	assert.NotContains(t, stored.SecretHash, strings.TrimPrefix(issued.Key, stored.Prefix+"_"))
+	assert.Equal(t, domain.Permissions{domain.PermissionLoanBrowse, domain.PermissionLoanViewFlagged}, stored.Scopes)
+	assert.WithinDuration(t, time.Now().Add(testAPIKeyConfig.DefaultTTL), stored.ExpiresAt, time.Minute)
+	assert.Equal(t, adminID, stored.CreatedByID)
+	assert.Equal(t, domain.ErrInvalidScope, scopeErr)
+	assert.Equal(t, domain.ErrInvalidAPIKeyExpiry, expiryErr)
+	mockAPIKeyRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test API Key Authentication - The Account Is Limited To The Key's Scopes
+func TestServiceAccountService_Authenticate(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockAPIKeyRepo := new(mockAPIKeyRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	serviceAccountService := NewServiceAccountService(mockUserRepo, mockAPIKeyRepo, mockAuditRepo, testPermissionPolicy, testAPIKeyConfig)
+
+	account := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst, ServiceAccount: true}
+	revokedAt := time.Now().Add(-time.Minute)
+	active := &domain.APIKey{ID: uuid.New(), UserID: account.ID, Prefix: "amf_0123456789abcdef", SecretHash: hashSecureToken("secret"), Scopes: domain.Permissions{domain.PermissionLoanBrowse}, ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &domain.APIKey{ID: uuid.New(), UserID: account.ID, Prefix: "amf_1111111111111111", SecretHash: hashSecureToken("secret"), ExpiresAt: time.Now().Add(-time.Hour)}
+	revoked := &domain.APIKey{ID: uuid.New(), UserID: account.ID, Prefix: "amf_2222222222222222", SecretHash: hashSecureToken("secret"), ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+
+	mockAPIKeyRepo.On("GetByPrefix", mock.Anything, active.Prefix).Return(active, nil)
+	mockAPIKeyRepo.On("GetByPrefix", mock.Anything, expired.Prefix).Return(expired, nil)
+	mockAPIKeyRepo.On("GetByPrefix", mock.Anything, revoked.Prefix).Return(revoked, nil)
+	mockAPIKeyRepo.On("TouchLastUsed", mock.Anything, active.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockUserRepo.On("GetByID", mock.Anything, account.ID).Return(account, nil)
+
+	// Act
+	user, key, err := serviceAccountService.Authenticate(context.Background(), active.Prefix+"_secret")
+	_, _, wrongSecretErr := serviceAccountService.Authenticate(context.Background(), active.Prefix+"_guess")
+	_, _, expiredErr := serviceAccountService.Authenticate(context.Background(), expired.Prefix+"_secret")
+	_, _, revokedErr := serviceAccountService.Authenticate(context.Background(), revoked.Prefix+"_secret")
+	_, _, malformedErr := serviceAccountService.Authenticate(context.Background(), "not-a-key")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, active.ID, key.ID)
+	assert.True(t, testPermissionPolicy.Allows(user, domain.PermissionLoanBrowse))
+	assert.False(t, testPermissionPolicy.Allows(user, domain.PermissionLoanReview))
+	assert.Equal(t, domain.ErrInvalidAPIKey, wrongSecretErr)
+	assert.Equal(t, domain.ErrInvalidAPIKey, expiredErr)
+	assert.Equal(t, domain.ErrInvalidAPIKey, revokedErr)
+	assert.Equal(t, domain.ErrInvalidAPIKey, malformedErr)
+	mockAPIKeyRepo.AssertNumberOfCalls(t, "TouchLastUsed", 1)
+}
+
+// Test API Key Rotation - The Successor Keeps Name And Scopes, The Old Key Gets A Grace Period
+func TestServiceAccountService_RotateAPIKey(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockAPIKeyRepo := new(mockAPIKeyRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	serviceAccountService := NewServiceAccountService(mockUserRepo, mockAPIKeyRepo, mockAuditRepo, testPermissionPolicy, testAPIKeyConfig)
+
+	adminID := uuid.New()
+	account := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst, ServiceAccount: true}
+	createdAt := time.Now().Add(-10 * 24 * time.Hour)
+	key := &domain.APIKey{
+		ID:        uuid.New(),
+		UserID:    account.ID,
+		Name:      "nightly job",
+		Prefix:    "amf_0123456789abcdef",
+		Scopes:    domain.Permissions{domain.PermissionLoanBrowse},
+		ExpiresAt: createdAt.Add(30 * 24 * time.Hour),
+		CreatedAt: createdAt,
+	}
+
+	var graceUntil time.Time
+	mockAPIKeyRepo.On("GetByID", mock.Anything, key.ID).Return(key, nil)
+	mockUserRepo.On("GetByID", mock.Anything, account.ID).Return(account, nil)
+	mockAPIKeyRepo.On("Rotate", mock.Anything, key.ID, mock.AnythingOfType("*domain.APIKey"), mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		graceUntil = args.Get(3).(time.Time)
+	}).Return(true, nil).Once()
+	mockAPIKeyRepo.On("Rotate", mock.Anything, key.ID, mock.AnythingOfType("*domain.APIKey"), mock.AnythingOfType("time.Time")).Return(false, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "api_key.rotated" && *entry.EntityID == key.ID
+	})).Return(nil)
+
+	// Act
+	issued, err := serviceAccountService.RotateAPIKey(context.Background(), adminID, key.ID)
+	_, raceErr := serviceAccountService.RotateAPIKey(context.Background(), adminID, key.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, key.Name, issued.APIKey.Name)
+	assert.Equal(t, key.Scopes, issued.APIKey.Scopes)
+	assert.NotEqual(t, key.Prefix, issued.APIKey.Prefix)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), issued.APIKey.ExpiresAt, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(testAPIKeyConfig.RotationGrace), graceUntil, time.Minute)
+	assert.Equal(t, domain.ErrInvalidAPIKey, raceErr)
+	mockAPIKeyRepo.AssertNumberOfCalls(t, "Rotate", 2)
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 1)
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// overdueTaskBatchSize bounds how many overdue tasks a single sweep reassigns
+const overdueTaskBatchSize = 100
+
+type taskService struct {
+	taskRepo           domain.VerificationTaskRepository
+	userRepo           domain.UserRepository
+	loanRepo           domain.LoanRepository
+	segregationService domain.SegregationService
+	taskConfig         *config.TaskConfig
+}
+
+func NewTaskService(
+	taskRepo domain.VerificationTaskRepository,
+	userRepo domain.UserRepository,
+	loanRepo domain.LoanRepository,
+	segregationService domain.SegregationService,
+	taskConfig *config.TaskConfig,
+) domain.TaskService {
+	return &taskService{
+		taskRepo:           taskRepo,
+		userRepo:           userRepo,
+		loanRepo:           loanRepo,
+		segregationService: segregationService,
+		taskConfig:         taskConfig,
+	}
+}
+
+func (s *taskService) CreateVerificationTask(ctx context.Context, loanID uuid.UUID, region string) (*domain.VerificationTask, error) {
+	assignee, err := s.pickValidator(ctx, loanID, region, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	task := &domain.VerificationTask{
+		ID:         uuid.New(),
+		LoanID:     loanID,
+		AssigneeID: &assignee.ID,
+		Region:     region,
+		Status:     domain.TaskStatusOpen,
+		DueAt:      now.Add(s.taskConfig.VerificationDueIn),
+		AssignedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *taskService) GetMyTasks(ctx context.Context, userID uuid.UUID, status domain.TaskStatus) ([]domain.VerificationTask, error) {
+	if status == "" {
+		status = domain.TaskStatusOpen
+	}
+	return s.taskRepo.GetByAssigneeID(ctx, userID, status)
+}
+
+// ReassignTask lets the current assignee hand an open task on, for example before leave
+func (s *taskService) ReassignTask(ctx context.Context, actorID uuid.UUID, taskID uuid.UUID, assigneeID *uuid.UUID) (*domain.VerificationTask, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	if !task.IsAssignedTo(actorID) {
+		return nil, domain.ErrTaskNotAssigned
+	}
+	if task.Status != domain.TaskStatusOpen {
+		return nil, domain.ErrTaskCompleted
+	}
+
+	var assignee *domain.User
+	if assigneeID != nil {
+		assignee, err = s.userRepo.GetByID(ctx, *assigneeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, domain.ErrInvalidTaskAssignee
+			}
+			return nil, err
+		}
+		if assignee.Role != domain.RoleFieldValidator || task.IsAssignedTo(assignee.ID) || !assignee.IsActive() || assignee.ServiceAccount {
+			return nil, domain.ErrInvalidTaskAssignee
+		}
+		loan, err := s.getLoan(ctx, task.LoanID)
+		if err != nil {
+			return nil, err
+		}
+		permitted, err := s.segregationService.Permits(ctx, loan, domain.LoanActionFieldVerification, assignee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !permitted {
+			return nil, domain.ErrInvalidTaskAssignee
+		}
+	} else {
+		assignee, err = s.pickValidator(ctx, task.LoanID, task.Region, task.AssigneeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.assign(ctx, task, assignee.ID); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *taskService) EnsureAssignee(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID) error {
+	task, err := s.taskRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTaskNotAssigned
+		}
+		return err
+	}
+
+	if task.Status != domain.TaskStatusOpen {
+		return domain.ErrTaskCompleted
+	}
+	if !task.IsAssignedTo(validatorID) {
+		return domain.ErrTaskNotAssigned
+	}
+
+	return nil
+}
+
+func (s *taskService) CompleteVerificationTask(ctx context.Context, loanID uuid.UUID) error {
+	task, err := s.taskRepo.GetByLoanID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTaskNotFound
+		}
+		return err
+	}
+
+	now := time.Now()
+	task.Status = domain.TaskStatusCompleted
+	task.CompletedAt = &now
+	task.UpdatedAt = now
+
+	return s.taskRepo.Update(ctx, task)
+}
+
+// HandOffTasks moves the open tasks of the validator to other validators. A task nobody can take over is
+// left unassigned, the sweeper assigns it as soon as a validator is available.
+func (s *taskService) HandOffTasks(ctx context.Context, assigneeID uuid.UUID) (int, error) {
+	tasks, err := s.taskRepo.GetByAssigneeID(ctx, assigneeID, domain.TaskStatusOpen)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+
+		assignee, err := s.pickValidator(ctx, task.LoanID, task.Region, &assigneeID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNoValidatorAvailable) {
+				return i, err
+			}
+			task.AssigneeID = nil
+			task.UpdatedAt = time.Now()
+			if err := s.taskRepo.Update(ctx, task); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		if err := s.assign(ctx, task, assignee.ID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(tasks), nil
+}
+
+func (s *taskService) SweepTasks(ctx context.Context) (int, int, error) {
+	assigned, err := s.assignUntaskedLoans(ctx)
+	if err != nil {
+		return assigned, 0, err
+	}
+
+	// Unassigned tasks come back with the overdue ones
+	overdue, err := s.taskRepo.GetOverdue(ctx, time.Now(), overdueTaskBatchSize)
+	if err != nil {
+		return assigned, 0, err
+	}
+
+	reassigned := 0
+	for i := range overdue {
+		task := &overdue[i]
+
+		assignee, err := s.pickValidator(ctx, task.LoanID, task.Region, task.AssigneeID)
+		if err != nil {
+			// With a single validator there is nobody to move the task to, it stays overdue or unassigned
+			if !errors.Is(err, domain.ErrNoValidatorAvailable) {
+				log.Printf("Failed to pick validator for overdue task %s: %v", task.ID, err)
+			}
+			continue
+		}
+
+		if err := s.assign(ctx, task, assignee.ID); err != nil {
+			log.Printf("Failed to reassign overdue task %s: %v", task.ID, err)
+			continue
+		}
+		reassigned++
+	}
+
+	return assigned, reassigned, nil
+}
+
+// assignUntaskedLoans creates tasks for proposed loans awaiting field verification without one,
+// such as loans created while no validator was available
+func (s *taskService) assignUntaskedLoans(ctx context.Context) (int, error) {
+	loans, err := s.loanRepo.GetByState(ctx, domain.LoanStateProposed)
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for _, loan := range loans {
+		if loan.Approval != nil {
+			continue
+		}
+
+		_, err := s.taskRepo.GetByLoanID(ctx, loan.ID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return assigned, err
+		}
+
+		if _, err := s.CreateVerificationTask(ctx, loan.ID, loan.Borrower.Region); err != nil {
+			if errors.Is(err, domain.ErrNoValidatorAvailable) {
+				return assigned, nil
+			}
+			return assigned, err
+		}
+		assigned++
+	}
+
+	return assigned, nil
+}
+
+// assign moves the task to assigneeID and restarts its due date
+func (s *taskService) assign(ctx context.Context, task *domain.VerificationTask, assigneeID uuid.UUID) error {
+	now := time.Now()
+	task.AssigneeID = &assigneeID
+	task.AssignedAt = now
+	task.DueAt = now.Add(s.taskConfig.VerificationDueIn)
+	task.ReassignmentCount++
+	task.UpdatedAt = now
+
+	return s.taskRepo.Update(ctx, task)
+}
+
+// pickValidator returns the validator with the fewest open tasks, preferring validators of the
+// region and falling back to all validators when the region has none. exclude, when set, is skipped,
+// as is every validator segregation of duties does not permit to verify the loan.
+func (s *taskService) pickValidator(ctx context.Context, loanID uuid.UUID, region string, exclude *uuid.UUID) (*domain.User, error) {
+	loan, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	validators, err := s.userRepo.GetByRole(ctx, domain.RoleFieldValidator)
+	if err != nil {
+		return nil, err
+	}
+
+	var regional, all []domain.User
+	for _, validator := range validators {
+		if exclude != nil && validator.ID == *exclude {
+			continue
+		}
+		permitted, err := s.segregationService.Permits(ctx, loan, domain.LoanActionFieldVerification, validator.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !permitted {
+			continue
+		}
+		all = append(all, validator)
+		if region != "" && strings.EqualFold(validator.Region, region) {
+			regional = append(regional, validator)
+		}
+	}
+
+	candidates := regional
+	if len(candidates) == 0 {
+		candidates = all
+	}
+	if len(candidates) == 0 {
+		return nil, domain.ErrNoValidatorAvailable
+	}
+
+	ids := make([]uuid.UUID, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ID
+	}
+	load, err := s.taskRepo.CountOpenByAssignee(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates keep the repository order, so ties go to the longest serving validator
+	best := &candidates[0]
+	for i := range candidates[1:] {
+		candidate := &candidates[i+1]
+		if load[candidate.ID] < load[best.ID] {
+			best = candidate
+		}
+	}
+
+	return best, nil
+}
+
+func (s *taskService) getLoan(ctx context.Context, loanID uuid.UUID) (*domain.Loan, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoanNotFound
+		}
+		return nil, err
+	}
+	return loan, nil
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock Verification Task Repository
+type mockVerificationTaskRepository struct {
+	mock.Mock
+}
+
+func (m *mockVerificationTaskRepository) Create(ctx context.Context, task *domain.VerificationTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *mockVerificationTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.VerificationTask, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockVerificationTaskRepository) GetByLoanID(ctx context.Context, loanID uuid.UUID) (*domain.VerificationTask, error) {
+	args := m.Called(ctx, loanID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockVerificationTaskRepository) GetByAssigneeID(ctx context.Context, assigneeID uuid.UUID, status domain.TaskStatus) ([]domain.VerificationTask, error) {
+	args := m.Called(ctx, assigneeID, status)
+	return args.Get(0).([]domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockVerificationTaskRepository) GetOverdue(ctx context.Context, now time.Time, limit int) ([]domain.VerificationTask, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockVerificationTaskRepository) CountOpenByAssignee(ctx context.Context, assigneeIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	args := m.Called(ctx, assigneeIDs)
+	return args.Get(0).(map[uuid.UUID]int64), args.Error(1)
+}
+
+func (m *mockVerificationTaskRepository) Update(ctx context.Context, task *domain.VerificationTask) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+// Mock Task Service
+type mockTaskService struct {
+	mock.Mock
+}
+
+func (m *mockTaskService) CreateVerificationTask(ctx context.Context, loanID uuid.UUID, region string) (*domain.VerificationTask, error) {
+	args := m.Called(ctx, loanID, region)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockTaskService) GetMyTasks(ctx context.Context, userID uuid.UUID, status domain.TaskStatus) ([]domain.VerificationTask, error) {
+	args := m.Called(ctx, userID, status)
+	return args.Get(0).([]domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockTaskService) ReassignTask(ctx context.Context, actorID uuid.UUID, taskID uuid.UUID, assigneeID *uuid.UUID) (*domain.VerificationTask, error) {
+	args := m.Called(ctx, actorID, taskID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.VerificationTask), args.Error(1)
+}
+
+func (m *mockTaskService) EnsureAssignee(ctx context.Context, loanID uuid.UUID, validatorID uuid.UUID) error {
+	args := m.Called(ctx, loanID, validatorID)
+	return args.Error(0)
+}
+
+func (m *mockTaskService) CompleteVerificationTask(ctx context.Context, loanID uuid.UUID) error {
+	args := m.Called(ctx, loanID)
+	return args.Error(0)
+}
+
+func (m *mockTaskService) HandOffTasks(ctx context.Context, assigneeID uuid.UUID) (int, error) {
+	args := m.Called(ctx, assigneeID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTaskService) SweepTasks(ctx context.Context) (int, int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+var testTaskConfig = &config.TaskConfig{
+	VerificationDueIn: 72 * time.Hour,
+	SweepInterval:     time.Minute,
+}
+
+// Test Task Assignment - Least Loaded Validator Of The Region
+func TestTaskService_CreateVerificationTask_RegionAndLoad(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	busyLocal := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "CA"}
+	freeLocal := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "ca"}
+	freeRemote := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "NY"}
+
+	mockLoanRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Loan{State: domain.LoanStateProposed}, nil)
+	mockUserRepo.On("GetByRole", mock.Anything, domain.RoleFieldValidator).Return([]domain.User{busyLocal, freeLocal, freeRemote}, nil)
+	mockSegregationService.On("Permits", mock.Anything, mock.Anything, domain.LoanActionFieldVerification, mock.Anything).Return(true, nil)
+	mockTaskRepo.On("CountOpenByAssignee", mock.Anything, mock.Anything).Return(map[uuid.UUID]int64{busyLocal.ID: 3, freeLocal.ID: 1}, nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.VerificationTask")).Return(nil)
+
+	// Act
+	local, localErr := taskService.CreateVerificationTask(context.Background(), uuid.New(), "CA")
+	fallback, fallbackErr := taskService.CreateVerificationTask(context.Background(), uuid.New(), "TX")
+
+	// Assert
+	assert.NoError(t, localErr)
+	assert.Equal(t, &freeLocal.ID, local.AssigneeID)
+	assert.Equal(t, domain.TaskStatusOpen, local.Status)
+	assert.WithinDuration(t, time.Now().Add(testTaskConfig.VerificationDueIn), local.DueAt, time.Minute)
+
+	// No validator covers TX, so the least loaded validator overall gets the task
+	assert.NoError(t, fallbackErr)
+	assert.Equal(t, &freeRemote.ID, fallback.AssigneeID)
+}
+
+// Test Task Reassignment - Only The Assignee Hands A Task To Another Validator
+func TestTaskService_ReassignTask(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	current := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "CA"}
+	colleague := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "CA"}
+	officer := &domain.User{ID: uuid.New(), Role: domain.RoleFieldOfficer}
+	task := &domain.VerificationTask{ID: uuid.New(), LoanID: uuid.New(), AssigneeID: &current.ID, Region: "CA", Status: domain.TaskStatusOpen, DueAt: time.Now().Add(-time.Hour)}
+
+	mockTaskRepo.On("GetByID", mock.Anything, task.ID).Return(task, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, task.LoanID).Return(&domain.Loan{ID: task.LoanID, State: domain.LoanStateProposed}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, officer.ID).Return(officer, nil)
+	mockSegregationService.On("Permits", mock.Anything, mock.Anything, domain.LoanActionFieldVerification, colleague.ID).Return(true, nil)
+	mockUserRepo.On("GetByRole", mock.Anything, domain.RoleFieldValidator).Return([]domain.User{current, colleague}, nil)
+	mockTaskRepo.On("CountOpenByAssignee", mock.Anything, []uuid.UUID{colleague.ID}).Return(map[uuid.UUID]int64{}, nil)
+	mockTaskRepo.On("Update", mock.Anything, task).Return(nil)
+
+	// Act
+	_, strangerErr := taskService.ReassignTask(context.Background(), colleague.ID, task.ID, nil)
+	_, officerErr := taskService.ReassignTask(context.Background(), current.ID, task.ID, &officer.ID)
+	reassigned, err := taskService.ReassignTask(context.Background(), current.ID, task.ID, nil)
+
+	// Assert
+	assert.Equal(t, domain.ErrTaskNotAssigned, strangerErr)
+	assert.Equal(t, domain.ErrInvalidTaskAssignee, officerErr)
+	assert.NoError(t, err)
+	assert.Equal(t, &colleague.ID, reassigned.AssigneeID)
+	assert.Equal(t, 1, reassigned.ReassignmentCount)
+	assert.False(t, reassigned.IsOverdue(time.Now()))
+}
+
+// Test Task Sweep - Untasked Loans Assigned, Overdue Tasks Moved And Unassigned Tasks Picked Up
+func TestTaskService_SweepTasks(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	slow := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+	other := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+
+	untasked := domain.Loan{ID: uuid.New(), State: domain.LoanStateProposed}
+	tasked := domain.Loan{ID: uuid.New(), State: domain.LoanStateProposed}
+	verified := domain.Loan{ID: uuid.New(), State: domain.LoanStateProposed, Approval: &domain.Approval{}}
+	overdue := domain.VerificationTask{ID: uuid.New(), LoanID: tasked.ID, AssigneeID: &slow.ID, Status: domain.TaskStatusOpen, DueAt: time.Now().Add(-time.Hour)}
+	unassigned := domain.VerificationTask{ID: uuid.New(), LoanID: uuid.New(), Status: domain.TaskStatusOpen, DueAt: time.Now().Add(24 * time.Hour)}
+
+	mockLoanRepo.On("GetByState", mock.Anything, domain.LoanStateProposed).Return([]domain.Loan{untasked, tasked, verified}, nil)
+	mockTaskRepo.On("GetByLoanID", mock.Anything, untasked.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockTaskRepo.On("GetByLoanID", mock.Anything, tasked.ID).Return(&overdue, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, mock.AnythingOfType("uuid.UUID")).Return(&domain.Loan{State: domain.LoanStateProposed}, nil)
+	mockUserRepo.On("GetByRole", mock.Anything, domain.RoleFieldValidator).Return([]domain.User{slow, other}, nil)
+	mockSegregationService.On("Permits", mock.Anything, mock.Anything, domain.LoanActionFieldVerification, mock.Anything).Return(true, nil)
+	mockTaskRepo.On("CountOpenByAssignee", mock.Anything, mock.Anything).Return(map[uuid.UUID]int64{slow.ID: 1}, nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *domain.VerificationTask) bool {
+		return task.LoanID == untasked.ID
+	})).Return(nil)
+	mockTaskRepo.On("GetOverdue", mock.Anything, mock.Anything, overdueTaskBatchSize).Return([]domain.VerificationTask{overdue, unassigned}, nil)
+	mockTaskRepo.On("Update", mock.Anything, mock.MatchedBy(func(task *domain.VerificationTask) bool {
+		return (task.ID == overdue.ID || task.ID == unassigned.ID) && task.IsAssignedTo(other.ID)
+	})).Return(nil)
+
+	// Act
+	assigned, reassigned, err := taskService.SweepTasks(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, assigned)
+	assert.Equal(t, 2, reassigned)
+	mockTaskRepo.AssertNotCalled(t, "GetByLoanID", mock.Anything, verified.ID)
+	mockTaskRepo.AssertExpectations(t)
+}
+
+// Test Task Assignment - Validators Segregation Of Duties Rejects For The Loan Are Skipped
+func TestTaskService_CreateVerificationTask_SegregationOfDuties(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	loan := &domain.Loan{ID: uuid.New(), State: domain.LoanStateProposed, Borrower: domain.Borrower{Branch: "Oakland"}}
+	sameBranch := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "CA", Branch: "Oakland"}
+	otherBranch := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, Region: "NY", Branch: "Queens"}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loan.ID).Return(loan, nil)
+	mockUserRepo.On("GetByRole", mock.Anything, domain.RoleFieldValidator).Return([]domain.User{sameBranch, otherBranch}, nil)
+	mockSegregationService.On("Permits", mock.Anything, loan, domain.LoanActionFieldVerification, sameBranch.ID).Return(false, nil)
+	mockSegregationService.On("Permits", mock.Anything, loan, domain.LoanActionFieldVerification, otherBranch.ID).Return(true, nil)
+	mockTaskRepo.On("CountOpenByAssignee", mock.Anything, []uuid.UUID{otherBranch.ID}).Return(map[uuid.UUID]int64{otherBranch.ID: 5}, nil)
+	mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.VerificationTask")).Return(nil)
+
+	// Act
+	task, err := taskService.CreateVerificationTask(context.Background(), loan.ID, "CA")
+
+	// Assert - the only regional validator conflicts, so the task leaves the region
+	assert.NoError(t, err)
+	assert.Equal(t, &otherBranch.ID, task.AssigneeID)
+	mockSegregationService.AssertExpectations(t)
+}
+
+// Test Task Hand Off - Tasks Move To Other Validators Or Wait Unassigned For The Sweeper
+func TestTaskService_HandOffTasks(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	leaving := uuid.New()
+	colleague := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+	movable := domain.VerificationTask{ID: uuid.New(), LoanID: uuid.New(), AssigneeID: &leaving, Status: domain.TaskStatusOpen}
+	conflicted := domain.VerificationTask{ID: uuid.New(), LoanID: uuid.New(), AssigneeID: &leaving, Status: domain.TaskStatusOpen, DueAt: time.Now().Add(48 * time.Hour)}
+	conflictedLoan := &domain.Loan{ID: conflicted.LoanID, State: domain.LoanStateProposed}
+
+	mockTaskRepo.On("GetByAssigneeID", mock.Anything, leaving, domain.TaskStatusOpen).Return([]domain.VerificationTask{movable, conflicted}, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, movable.LoanID).Return(&domain.Loan{ID: movable.LoanID, State: domain.LoanStateProposed}, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, conflicted.LoanID).Return(conflictedLoan, nil)
+	mockUserRepo.On("GetByRole", mock.Anything, domain.RoleFieldValidator).Return([]domain.User{colleague}, nil)
+	mockSegregationService.On("Permits", mock.Anything, conflictedLoan, domain.LoanActionFieldVerification, colleague.ID).Return(false, nil)
+	mockSegregationService.On("Permits", mock.Anything, mock.Anything, domain.LoanActionFieldVerification, colleague.ID).Return(true, nil)
+	mockTaskRepo.On("CountOpenByAssignee", mock.Anything, []uuid.UUID{colleague.ID}).Return(map[uuid.UUID]int64{}, nil)
+
+	var updated []domain.VerificationTask
+	mockTaskRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.VerificationTask")).Run(func(args mock.Arguments) {
+		updated = append(updated, *args.Get(1).(*domain.VerificationTask))
+	}).Return(nil)
+
+	// Act
+	handedOff, err := taskService.HandOffTasks(context.Background(), leaving)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, handedOff)
+	if assert.Len(t, updated, 2) {
+		assert.Equal(t, &colleague.ID, updated[0].AssigneeID)
+		assert.Nil(t, updated[1].AssigneeID)
+	}
+}
+
+// Test Task Reassignment - Deactivated Or Conflicted Validators Cannot Take Over A Task
+func TestTaskService_ReassignTask_IneligibleAssignee(t *testing.T) {
+	// Arrange
+	mockTaskRepo := new(mockVerificationTaskRepository)
+	mockUserRepo := new(mockUserRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockSegregationService := new(mockSegregationService)
+
+	taskService := NewTaskService(mockTaskRepo, mockUserRepo, mockLoanRepo, mockSegregationService, testTaskConfig)
+
+	deactivatedAt := time.Now().Add(-time.Hour)
+	current := domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+	deactivated := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator, DeactivatedAt: &deactivatedAt}
+	related := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+	task := &domain.VerificationTask{ID: uuid.New(), LoanID: uuid.New(), AssigneeID: &current.ID, Status: domain.TaskStatusOpen}
+
+	mockTaskRepo.On("GetByID", mock.Anything, task.ID).Return(task, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, task.LoanID).Return(&domain.Loan{ID: task.LoanID, State: domain.LoanStateProposed}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, deactivated.ID).Return(deactivated, nil)
+	mockUserRepo.On("GetByID", mock.Anything, related.ID).Return(related, nil)
+	mockSegregationService.On("Permits", mock.Anything, mock.Anything, domain.LoanActionFieldVerification, related.ID).Return(false, nil)
+
+	// Act
+	_, deactivatedErr := taskService.ReassignTask(context.Background(), current.ID, task.ID, &deactivated.ID)
+	_, relatedErr := taskService.ReassignTask(context.Background(), current.ID, task.ID, &related.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrInvalidTaskAssignee, deactivatedErr)
+	assert.Equal(t, domain.ErrInvalidTaskAssignee, relatedErr)
+	mockTaskRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// Test Loan Approval - Only The Assigned Validator Can Approve
+func TestLoanService_ApproveLoan_NotAssignedValidator(t *testing.T) {
+	// Arrange
+	mockLoanRepo := new(mockLoanRepository)
+	mockApprovalRepo := new(mockApprovalRepository)
+	mockApprovalStageRepo := new(mockApprovalStageRepository)
+	mockPhotoProofRepo := new(mockPhotoProofRepository)
+	mockDocumentRepo := new(mockDocumentRepository)
+	mockSignatureRepo := new(mockAgreementSignatureRepository)
+	mockDisbursementRepo := new(mockDisbursementRepository)
+	mockInvestmentRepo := new(mockInvestmentRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockTaskService := new(mockTaskService)
+	mockSegregationService := new(mockSegregationService)
+
+	loanService := NewLoanService(mockLoanRepo, mockApprovalRepo, mockApprovalStageRepo, mockPhotoProofRepo, mockDocumentRepo, mockSignatureRepo, nil, mockDisbursementRepo, mockInvestmentRepo, nil, mockBorrowerRepo, mockTaskService, mockSegregationService, singleStageApprovalConfig)
+
+	loanID := uuid.New()
+	validatorID := uuid.New()
+	proof := &domain.PhotoProof{ID: uuid.New(), LoanID: loanID, UploaderID: validatorID}
+
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(&domain.Loan{ID: loanID, State: domain.LoanStateProposed}, nil)
+	mockPhotoProofRepo.On("GetByID", mock.Anything, proof.ID).Return(proof, nil)
+	mockTaskService.On("EnsureAssignee", mock.Anything, loanID, validatorID).Return(domain.ErrTaskNotAssigned)
+
+	// Act
+	err := loanService.ApproveLoan(context.Background(), loanID, validatorID, proof.ID, time.Now(), testFieldEvidence)
+
+	// Assert
+	assert.Equal(t, domain.ErrTaskNotAssigned, err)
+	mockApprovalStageRepo.AssertNotCalled(t, "RecordDecision", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockTaskService.AssertNotCalled(t, "CompleteVerificationTask", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// TaskSweeper periodically assigns loans without a verification task and reassigns overdue tasks
+type TaskSweeper struct {
+	taskService domain.TaskService
+	interval    time.Duration
+	stop        chan struct{}
+}
+
+func NewTaskSweeper(taskService domain.TaskService, interval time.Duration) *TaskSweeper {
+	return &TaskSweeper{
+		taskService: taskService,
+		interval:    interval,
+		stop:        make(chan struct{}),
+	}
+}
+
+func (w *TaskSweeper) Start(ctx context.Context) {
+	log.Println("Starting verification task sweeper...")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			assigned, reassigned, err := w.taskService.SweepTasks(ctx)
+			if err != nil {
+				log.Printf("Error sweeping verification tasks: %v", err)
+				continue
+			}
+			if assigned > 0 || reassigned > 0 {
+				log.Printf("Assigned %d and reassigned %d overdue verification tasks", assigned, reassigned)
+			}
+		}
+	}
+}
+
+func (w *TaskSweeper) Stop() {
+	log.Println("Stopping verification task sweeper...")
+	close(w.stop)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+// TokenKeyRotator periodically creates the next token signing key and removes retired ones
+type TokenKeyRotator struct {
+	tokenKeyService domain.TokenKeyService
+	interval        time.Duration
+	stop            chan struct{}
+}
+
+func NewTokenKeyRotator(tokenKeyService domain.TokenKeyService, interval time.Duration) *TokenKeyRotator {
+	return &TokenKeyRotator{
+		tokenKeyService: tokenKeyService,
+		interval:        interval,
+		stop:            make(chan struct{}),
+	}
+}
+
+func (w *TokenKeyRotator) Start(ctx context.Context) {
+	log.Println("Starting token signing key rotator...")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.tokenKeyService.Rotate(ctx); err != nil {
+				log.Printf("Error rotating token signing keys: %v", err)
+			}
+		}
+	}
+}
+
+func (w *TokenKeyRotator) Stop() {
+	log.Println("Stopping token signing key rotator...")
+	close(w.stop)
+}
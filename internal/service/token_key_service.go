@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+const (
+	// tokenKeyCacheTTL bounds how long a key created by another instance stays unknown here
+	tokenKeyCacheTTL = time.Minute
+	// tokenKeyReloadInterval limits the reloads caused by tokens with an unknown kid
+	tokenKeyReloadInterval = 10 * time.Second
+	// rsaKeyBits is the modulus size of generated RS256 keys
+	rsaKeyBits = 2048
+	// signingKeyIDBytes is the randomness of a kid
+	signingKeyIDBytes = 8
+)
+
+type tokenKeyService struct {
+	signingKeyRepo domain.SigningKeyRepository
+	cipher         domain.FieldCipher
+	jwtConfig      *config.JWTConfig
+
+	mu       sync.RWMutex
+	keys     []domain.SigningKey // Ordered by activation
+	loadedAt time.Time
+}
+
+// NewTokenKeyService keeps the private keys encrypted at rest with the field cipher
+func NewTokenKeyService(signingKeyRepo domain.SigningKeyRepository, cipher domain.FieldCipher, jwtConfig *config.JWTConfig) domain.TokenKeyService {
+	return &tokenKeyService{
+		signingKeyRepo: signingKeyRepo,
+		cipher:         cipher,
+		jwtConfig:      jwtConfig,
+	}
+}
+
+func (s *tokenKeyService) SigningKey(ctx context.Context) (*domain.SigningKey, error) {
+	keys, err := s.cachedKeys(ctx, tokenKeyCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if key := activeKey(keys, time.Now()); key != nil {
+		return key, nil
+	}
+
+	// No instance rotated in time, e.g. while the service was down
+	if err := s.Rotate(ctx); err != nil {
+		return nil, err
+	}
+	keys, err = s.cachedKeys(ctx, tokenKeyCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if key := activeKey(keys, time.Now()); key != nil {
+		return key, nil
+	}
+	return nil, domain.ErrNoSigningKey
+}
+
+func (s *tokenKeyService) VerificationKey(ctx context.Context, kid string) (*domain.SigningKey, error) {
+	keys, err := s.cachedKeys(ctx, tokenKeyCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	key := findKey(keys, kid)
+	if key == nil {
+		// The key may have just been created by another instance
+		keys, err = s.cachedKeys(ctx, tokenKeyReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		key = findKey(keys, kid)
+	}
+
+	if key == nil || !time.Now().Before(s.verifiableUntil(key)) {
+		return nil, domain.ErrInvalidToken
+	}
+	return key, nil
+}
+
+func (s *tokenKeyService) JWKS(ctx context.Context) (*domain.JSONWebKeySet, error) {
+	keys, err := s.cachedKeys(ctx, tokenKeyCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	set := &domain.JSONWebKeySet{Keys: []domain.JSONWebKey{}}
+	for i := range keys {
+		if !now.Before(s.verifiableUntil(&keys[i])) {
+			continue
+		}
+		jwk, err := publicJWK(&keys[i])
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
+
+func (s *tokenKeyService) Rotate(ctx context.Context) error {
+	now := time.Now()
+	keys, err := s.load(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var latest *domain.SigningKey
+	for i := range keys {
+		if latest == nil || keys[i].RetiresAt.After(latest.RetiresAt) {
+			latest = &keys[i]
+		}
+	}
+
+	activatesAt := now
+	switch {
+	case latest == nil || !latest.RetiresAt.After(now):
+		// Nothing can sign, the new key is used right away
+	case latest.RetiresAt.Sub(now) <= s.jwtConfig.KeyPublishLead:
+		// Published now, it takes over when the current key retires
+		activatesAt = latest.RetiresAt
+	default:
+		activatesAt = time.Time{}
+	}
+
+	if !activatesAt.IsZero() {
+		// Two instances rotating at once both create a successor, verifiers accept either
+		key, err := newSigningKey(s.jwtConfig.Algorithm, activatesAt, s.jwtConfig.KeyLifetime)
+		if err != nil {
+			return err
+		}
+		if key.PrivateKey, err = s.cipher.Encrypt(ctx, key.PrivateKey, key.PrivateKeyLocation()); err != nil {
+			return fmt.Errorf("failed to encrypt token signing key: %w", err)
+		}
+		if err := s.signingKeyRepo.Create(ctx, key); err != nil {
+			return err
+		}
+		log.Printf("Created %s token signing key %s, signing from %s", key.Algorithm, key.ID, key.ActivatesAt.Format(time.RFC3339))
+	}
+
+	deleted, err := s.signingKeyRepo.DeleteRetiredBefore(ctx, now.Add(-s.verifyWindow()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Removed %d retired token signing keys", deleted)
+	}
+
+	_, err = s.load(ctx, now)
+	return err
+}
+
+// cachedKeys returns the loaded keys, reloading them when they are older than maxAge
+func (s *tokenKeyService) cachedKeys(ctx context.Context, maxAge time.Duration) ([]domain.SigningKey, error) {
+	s.mu.RLock()
+	keys, loadedAt := s.keys, s.loadedAt
+	s.mu.RUnlock()
+
+	if time.Since(loadedAt) <= maxAge {
+		return keys, nil
+	}
+	return s.load(ctx, time.Now())
+}
+
+// load reads every key that can still verify a token, keys that fail to decrypt or parse are skipped
+func (s *tokenKeyService) load(ctx context.Context, now time.Time) ([]domain.SigningKey, error) {
+	stored, err := s.signingKeyRepo.GetRetiringAfter(ctx, now.Add(-s.verifyWindow()))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]domain.SigningKey, 0, len(stored))
+	for _, key := range stored {
+		if err := s.openSigningKey(ctx, &key); err != nil {
+			log.Printf("Skipping token signing key %s: %v", key.ID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return keys, nil
+}
+
+// verifyWindow is how long after its retirement a key still verifies the tokens it signed
+func (s *tokenKeyService) verifyWindow() time.Duration {
+	return s.jwtConfig.Expiry + s.jwtConfig.Leeway
+}
+
+func (s *tokenKeyService) verifiableUntil(key *domain.SigningKey) time.Time {
+	return key.RetiresAt.Add(s.verifyWindow())
+}
+
+// activeKey returns the most recently activated key that signs at the given time
+func activeKey(keys []domain.SigningKey, now time.Time) *domain.SigningKey {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i].CanSign(now) {
+			key := keys[i]
+			return &key
+		}
+	}
+	return nil
+}
+
+func findKey(keys []domain.SigningKey, kid string) *domain.SigningKey {
+	for i := range keys {
+		if keys[i].ID == kid {
+			key := keys[i]
+			return &key
+		}
+	}
+	return nil
+}
+
+// newSigningKey generates a key pair for the algorithm that signs for the given lifetime
+func newSigningKey(algorithm string, activatesAt time.Time, lifetime time.Duration) (*domain.SigningKey, error) {
+	var signer crypto.Signer
+	switch algorithm {
+	case domain.SigningAlgorithmRS256:
+		privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		signer = privateKey
+	case domain.SigningAlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer = privateKey
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, signingKeyIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	return &domain.SigningKey{
+		ID:          hex.EncodeToString(buf),
+		Algorithm:   algorithm,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		ActivatesAt: activatesAt,
+		RetiresAt:   activatesAt.Add(lifetime),
+		CreatedAt:   time.Now(),
+		Signer:      signer,
+	}, nil
+}
+
+// openSigningKey decrypts the private key of a stored key and sets its signer. The PEM is not kept,
+// and a key stored without encryption is refused like one that cannot be decrypted.
+func (s *tokenKeyService) openSigningKey(ctx context.Context, key *domain.SigningKey) error {
+	if strings.HasPrefix(key.PrivateKey, "-----BEGIN") {
+		return errors.New("private key is stored unencrypted")
+	}
+	privatePEM, err := s.cipher.Decrypt(ctx, key.PrivateKey, key.PrivateKeyLocation())
+	if err != nil {
+		return fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return parseSigningKey(key, privatePEM)
+}
+
+// parseSigningKey sets the signer of a key from its private PEM, the key type must match its algorithm
+func parseSigningKey(key *domain.SigningKey, privatePEM string) error {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return errors.New("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	switch privateKey := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.Algorithm != domain.SigningAlgorithmRS256 {
+			return fmt.Errorf("RSA key cannot sign %s", key.Algorithm)
+		}
+		key.Signer = privateKey
+	case ed25519.PrivateKey:
+		if key.Algorithm != domain.SigningAlgorithmEdDSA {
+			return fmt.Errorf("Ed25519 key cannot sign %s", key.Algorithm)
+		}
+		key.Signer = privateKey
+	default:
+		return fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return nil
+}
+
+// publicJWK describes the public half of a key as a JWK (RFC 7517, RFC 8037 for Ed25519)
+func publicJWK(key *domain.SigningKey) (*domain.JSONWebKey, error) {
+	jwk := &domain.JSONWebKey{
+		Use:       "sig",
+		Algorithm: key.Algorithm,
+		KeyID:     key.ID,
+	}
+
+	switch publicKey := key.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", publicKey)
+	}
+	return jwk, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockSigningKeyRepository struct {
+	mock.Mock
+}
+
+func (m *mockSigningKeyRepository) Create(ctx context.Context, key *domain.SigningKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *mockSigningKeyRepository) GetRetiringAfter(ctx context.Context, after time.Time) ([]domain.SigningKey, error) {
+	args := m.Called(ctx, after)
+	return args.Get(0).([]domain.SigningKey), args.Error(1)
+}
+
+func (m *mockSigningKeyRepository) DeleteRetiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// sealingCipher stands in for the field cipher, values it did not seal for the same row cannot be decrypted
+type sealingCipher struct{}
+
+const sealedPrefix = "sealed:"
+
+func (sealingCipher) Encrypt(ctx context.Context, plaintext string, location domain.FieldLocation) (string, error) {
+	return sealedPrefix + location.RowID + ":" + base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+}
+
+func (sealingCipher) Decrypt(ctx context.Context, stored string, location domain.FieldLocation) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix+location.RowID+":") {
+		return "", errors.New("value was not sealed for this row")
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix+location.RowID+":"))
+	return string(plaintext), err
+}
+
+func (sealingCipher) IsCurrent(stored string) bool {
+	return strings.HasPrefix(stored, sealedPrefix)
+}
+
+func (sealingCipher) BlindIndex(value string) string {
+	return "index:" + value
+}
+
+// storedKey returns the key as the repository holds it, with the private key encrypted
+func storedKey(key *domain.SigningKey) domain.SigningKey {
+	stored := *key
+	stored.PrivateKey, _ = sealingCipher{}.Encrypt(context.Background(), key.PrivateKey, key.PrivateKeyLocation())
+	stored.Signer = nil
+	return stored
+}
+
+// testTokenKeys signs access tokens in tests with a key that is active for the whole run
+var testTokenKeys = newStaticTokenKeys(domain.SigningAlgorithmEdDSA)
+
+func newStaticTokenKeys(algorithm string) domain.TokenKeyService {
+	key, err := newSigningKey(algorithm, time.Now().Add(-time.Hour), 24*time.Hour)
+	if err != nil {
+		panic(err)
+	}
+	repo := new(mockSigningKeyRepository)
+	repo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{storedKey(key)}, nil)
+	return NewTokenKeyService(repo, sealingCipher{}, &config.JWTConfig{Expiry: time.Hour})
+}
+
+var testKeyRotationConfig = &config.JWTConfig{
+	Algorithm:      domain.SigningAlgorithmRS256,
+	Expiry:         15 * time.Minute,
+	KeyLifetime:    30 * 24 * time.Hour,
+	KeyPublishLead: 24 * time.Hour,
+}
+
+// Test Key Rotation - The First Key Signs Right Away
+func TestTokenKeyService_Rotate_FirstKey(t *testing.T) {
+	// Arrange
+	mockSigningKeyRepo := new(mockSigningKeyRepository)
+	tokenKeyService := NewTokenKeyService(mockSigningKeyRepo, sealingCipher{}, testKeyRotationConfig)
+
+	var created *domain.SigningKey
+	mockSigningKeyRepo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{}, nil)
+	mockSigningKeyRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.SigningKey")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.SigningKey)
+	}).Return(nil)
+	mockSigningKeyRepo.On("DeleteRetiredBefore", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	// Act
+	err := tokenKeyService.Rotate(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SigningAlgorithmRS256, created.Algorithm)
+	assert.True(t, created.CanSign(time.Now()))
+	assert.Equal(t, created.ActivatesAt.Add(testKeyRotationConfig.KeyLifetime), created.RetiresAt)
+	assert.True(t, strings.HasPrefix(created.PrivateKey, sealedPrefix), "private key must be stored encrypted")
+	privatePEM, err := sealingCipher{}.Decrypt(context.Background(), created.PrivateKey, created.PrivateKeyLocation())
+	assert.NoError(t, err)
+	assert.Contains(t, privatePEM, "BEGIN PRIVATE KEY")
+	assert.Contains(t, created.PublicKey, "BEGIN PUBLIC KEY")
+	mockSigningKeyRepo.AssertExpectations(t)
+}
+
+// Test Key Rotation - The Successor Is Published Ahead Of The Current Key's Retirement
+func TestTokenKeyService_Rotate_Schedule(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	retiringSoon, err := newSigningKey(domain.SigningAlgorithmRS256, now.Add(-30*24*time.Hour), 30*24*time.Hour+time.Hour)
+	assert.NoError(t, err)
+	retiringLater, err := newSigningKey(domain.SigningAlgorithmRS256, now.Add(-24*time.Hour), 30*24*time.Hour)
+	assert.NoError(t, err)
+
+	dueRepo := new(mockSigningKeyRepository)
+	notDueRepo := new(mockSigningKeyRepository)
+	dueService := NewTokenKeyService(dueRepo, sealingCipher{}, testKeyRotationConfig)
+	notDueService := NewTokenKeyService(notDueRepo, sealingCipher{}, testKeyRotationConfig)
+
+	var successor *domain.SigningKey
+	dueRepo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{storedKey(retiringSoon)}, nil)
+	dueRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.SigningKey")).Run(func(args mock.Arguments) {
+		successor = args.Get(1).(*domain.SigningKey)
+	}).Return(nil)
+	dueRepo.On("DeleteRetiredBefore", mock.Anything, mock.Anything).Return(int64(1), nil)
+	notDueRepo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{storedKey(retiringLater)}, nil)
+	notDueRepo.On("DeleteRetiredBefore", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	// Act
+	dueErr := dueService.Rotate(context.Background())
+	notDueErr := notDueService.Rotate(context.Background())
+
+	// Assert
+	assert.NoError(t, dueErr)
+	assert.Equal(t, retiringSoon.RetiresAt, successor.ActivatesAt)
+	assert.False(t, successor.CanSign(now))
+	assert.NotEqual(t, retiringSoon.ID, successor.ID)
+	dueRepo.AssertCalled(t, "DeleteRetiredBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return before.Before(now.Add(-testKeyRotationConfig.Expiry).Add(time.Second))
+	}))
+	assert.NoError(t, notDueErr)
+	notDueRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test JWKS - Current And Upcoming Keys Are Published, Keys Past Their Verification Window Are Not
+func TestTokenKeyService_JWKS(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	retired, err := newSigningKey(domain.SigningAlgorithmRS256, now.Add(-48*time.Hour), 24*time.Hour)
+	assert.NoError(t, err)
+	current, err := newSigningKey(domain.SigningAlgorithmEdDSA, now.Add(-time.Hour), 2*time.Hour)
+	assert.NoError(t, err)
+	next, err := newSigningKey(domain.SigningAlgorithmRS256, current.RetiresAt, 24*time.Hour)
+	assert.NoError(t, err)
+
+	mockSigningKeyRepo := new(mockSigningKeyRepository)
+	tokenKeyService := NewTokenKeyService(mockSigningKeyRepo, sealingCipher{}, testKeyRotationConfig)
+
+	mockSigningKeyRepo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{storedKey(retired), storedKey(current), storedKey(next)}, nil)
+
+	// Act
+	set, jwksErr := tokenKeyService.JWKS(context.Background())
+	signingKey, signingErr := tokenKeyService.SigningKey(context.Background())
+	_, retiredErr := tokenKeyService.VerificationKey(context.Background(), retired.ID)
+	nextKey, nextErr := tokenKeyService.VerificationKey(context.Background(), next.ID)
+
+	// Assert
+	assert.NoError(t, jwksErr)
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, domain.JSONWebKey{KeyType: "OKP", Use: "sig", Algorithm: "EdDSA", KeyID: current.ID, Curve: "Ed25519", X: set.Keys[0].X}, set.Keys[0])
+	assert.Equal(t, "RSA", set.Keys[1].KeyType)
+	assert.Equal(t, next.ID, set.Keys[1].KeyID)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+	assert.NotEmpty(t, set.Keys[1].N)
+	assert.NoError(t, signingErr)
+	assert.Equal(t, current.ID, signingKey.ID)
+	assert.Equal(t, domain.ErrInvalidToken, retiredErr)
+	assert.NoError(t, nextErr)
+	assert.Equal(t, next.ID, nextKey.ID)
+}
+
+// Test Key Parsing - A Stored Key Must Match Its Algorithm
+func TestParseSigningKey(t *testing.T) {
+	// Arrange
+	rsaKey, err := newSigningKey(domain.SigningAlgorithmRS256, time.Now(), time.Hour)
+	assert.NoError(t, err)
+	stored := domain.SigningKey{ID: rsaKey.ID, Algorithm: rsaKey.Algorithm}
+	mislabelled := domain.SigningKey{ID: rsaKey.ID, Algorithm: domain.SigningAlgorithmEdDSA}
+
+	// Act
+	storedErr := parseSigningKey(&stored, rsaKey.PrivateKey)
+	mislabelledErr := parseSigningKey(&mislabelled, rsaKey.PrivateKey)
+	_, unsupportedErr := newSigningKey("HS256", time.Now(), time.Hour)
+
+	// Assert
+	assert.NoError(t, storedErr)
+	assert.Equal(t, rsaKey.Signer.Public(), stored.Signer.Public())
+	assert.Error(t, mislabelledErr)
+	assert.Error(t, unsupportedErr)
+}
+
+// Test Key Loading - Keys Stored Unencrypted Or Under An Unknown Key Are Not Used
+func TestTokenKeyService_RefusesUndecryptableKeys(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	plain, err := newSigningKey(domain.SigningAlgorithmEdDSA, now.Add(-2*time.Hour), 24*time.Hour)
+	assert.NoError(t, err)
+	foreign, err := newSigningKey(domain.SigningAlgorithmEdDSA, now.Add(-90*time.Minute), 24*time.Hour)
+	assert.NoError(t, err)
+	sealed, err := newSigningKey(domain.SigningAlgorithmEdDSA, now.Add(-time.Hour), 24*time.Hour)
+	assert.NoError(t, err)
+
+	unencrypted := *plain
+	unencrypted.Signer = nil
+	undecryptable := *foreign
+	undecryptable.PrivateKey = "enc:v1:other:key"
+	undecryptable.Signer = nil
+
+	mockSigningKeyRepo := new(mockSigningKeyRepository)
+	tokenKeyService := NewTokenKeyService(mockSigningKeyRepo, sealingCipher{}, testKeyRotationConfig)
+
+	mockSigningKeyRepo.On("GetRetiringAfter", mock.Anything, mock.Anything).Return([]domain.SigningKey{unencrypted, undecryptable, storedKey(sealed)}, nil)
+
+	// Act
+	signingKey, signingErr := tokenKeyService.SigningKey(context.Background())
+	_, plainErr := tokenKeyService.VerificationKey(context.Background(), plain.ID)
+	_, foreignErr := tokenKeyService.VerificationKey(context.Background(), foreign.ID)
+
+	// Assert
+	assert.NoError(t, signingErr)
+	assert.Equal(t, sealed.ID, signingKey.ID)
+	assert.Equal(t, sealed.Signer.Public(), signingKey.Signer.Public())
+	assert.NotContains(t, signingKey.PrivateKey, "BEGIN PRIVATE KEY")
+	assert.Equal(t, domain.ErrInvalidToken, plainErr)
+	assert.Equal(t, domain.ErrInvalidToken, foreignErr)
+}
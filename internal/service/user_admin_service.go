@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type userAdminService struct {
+	userRepo       domain.UserRepository
+	loginEventRepo domain.LoginEventRepository
+	auditRepo      domain.AuditRepository
+	authService    domain.AuthService
+	taskService    domain.TaskService
+	passwordConfig *config.PasswordConfig
+}
+
+func NewUserAdminService(
+	userRepo domain.UserRepository,
+	loginEventRepo domain.LoginEventRepository,
+	auditRepo domain.AuditRepository,
+	authService domain.AuthService,
+	taskService domain.TaskService,
+	passwordConfig *config.PasswordConfig,
+) domain.UserAdminService {
+	return &userAdminService{
+		userRepo:       userRepo,
+		loginEventRepo: loginEventRepo,
+		auditRepo:      auditRepo,
+		authService:    authService,
+		taskService:    taskService,
+		passwordConfig: passwordConfig,
+	}
+}
+
+func (s *userAdminService) ListUsers(ctx context.Context, role domain.UserRole, limit, offset int) ([]domain.User, int64, error) {
+	return s.userRepo.List(ctx, role, limit, offset)
+}
+
+func (s *userAdminService) CreateStaffUser(ctx context.Context, actorID uuid.UUID, input domain.StaffUserInput) (*domain.User, error) {
+	// Customers sign up themselves since their accounts need a borrower or investor profile
+	if !input.Role.IsStaff() {
+		return nil, domain.ErrInvalidRole
+	}
+	if err := checkPasswordPolicy(s.passwordConfig, input.Password); err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return nil, domain.ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	// The administrator vouches for the address of an employee account
+	now := time.Now()
+	user := &domain.User{
+		ID:              uuid.New(),
+		Email:           email,
+		Password:        string(hashedPassword),
+		Role:            input.Role,
+		Region:          strings.TrimSpace(input.Region),
+		Branch:          strings.TrimSpace(input.Branch),
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "user.created", user.ID, domain.AuditOutcomeAllowed, "role "+string(user.Role))
+	return user, nil
+}
+
+func (s *userAdminService) DeactivateUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*domain.User, error) {
+	if actorID == userID {
+		s.audit(ctx, actorID, "user.deactivated", userID, domain.AuditOutcomeDenied, domain.ErrSelfAdministration.Error())
+		return nil, domain.ErrSelfAdministration
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrAccountDeactivated
+	}
+
+	// Loans waiting for the validator's field visit must not stall with the account
+	handedOff, err := s.handOffTasks(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.DeactivatedAt = &now
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before the deactivation must not outlive it
+	if err := s.authService.RevokeAllSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "user.deactivated", user.ID, domain.AuditOutcomeAllowed, handedOff)
+	return user, nil
+}
+
+func (s *userAdminService) ChangeRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, role domain.UserRole) (*domain.User, error) {
+	if actorID == userID {
+		s.audit(ctx, actorID, "user.role_changed", userID, domain.AuditOutcomeDenied, domain.ErrSelfAdministration.Error())
+		return nil, domain.ErrSelfAdministration
+	}
+	if !role.IsStaff() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Role.IsStaff() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	previous := user.Role
+	if previous == role {
+		return user, nil
+	}
+
+	// Open tasks go to someone who still verifies loans
+	handedOff, err := s.handOffTasks(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("%s -> %s", previous, role)
+	if handedOff != "" {
+		reason += ", " + handedOff
+	}
+	s.audit(ctx, actorID, "user.role_changed", user.ID, domain.AuditOutcomeAllowed, reason)
+	return user, nil
+}
+
+// handOffTasks moves the open verification tasks of a field validator who is deactivated or given
+// another role to other validators, returning a note for the audit entry when there were any
+func (s *userAdminService) handOffTasks(ctx context.Context, user *domain.User) (string, error) {
+	if user.Role != domain.RoleFieldValidator {
+		return "", nil
+	}
+
+	handedOff, err := s.taskService.HandOffTasks(ctx, user.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to hand off verification tasks: %w", err)
+	}
+	if handedOff == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%d open verification tasks handed off", handedOff), nil
+}
+
+func (s *userAdminService) ResetPassword(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.ServiceAccount {
+		return "", domain.ErrServiceAccount
+	}
+
+	temporaryPassword, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(temporaryPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return "", err
+	}
+
+	// Whoever knew the old password is signed out everywhere
+	if err := s.authService.RevokeAllSessions(ctx, user.ID); err != nil {
+		return "", err
+	}
+
+	s.audit(ctx, actorID, "user.password_reset", user.ID, domain.AuditOutcomeAllowed, "")
+	return temporaryPassword, nil
+}
+
+func (s *userAdminService) ResetTwoFactor(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error {
+	// Another administrator has to vouch for the person who lost their device
+	if actorID == userID {
+		s.audit(ctx, actorID, "user.two_factor_reset", userID, domain.AuditOutcomeDenied, domain.ErrSelfAdministration.Error())
+		return domain.ErrSelfAdministration
+	}
+
+	if err := s.authService.ResetTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, "user.two_factor_reset", userID, domain.AuditOutcomeAllowed, "")
+	return nil
+}
+
+func (s *userAdminService) UnlockUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	previous := user.FailedLoginCount
+	user.FailedLoginCount = 0
+	user.LockedUntil = nil
+
+	s.audit(ctx, actorID, "user.unlocked", user.ID, domain.AuditOutcomeAllowed, fmt.Sprintf("%d failed logins", previous))
+	return user, nil
+}
+
+func (s *userAdminService) GetLoginHistory(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, limit int) ([]domain.LoginEvent, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.loginEventRepo.GetByUserID(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, "user.login_history_viewed", userID, domain.AuditOutcomeAllowed, "")
+	return events, nil
+}
+
+func (s *userAdminService) SearchLoginEvents(ctx context.Context, actorID uuid.UUID, filter domain.LoginEventFilter, limit int) ([]domain.LoginEvent, error) {
+	filter.Email = strings.ToLower(strings.TrimSpace(filter.Email))
+	filter.IPAddress = strings.TrimSpace(filter.IPAddress)
+
+	events, err := s.loginEventRepo.Search(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	writeAudit(ctx, s.auditRepo, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     "login_event.searched",
+		EntityType: "login_event",
+		Outcome:    domain.AuditOutcomeAllowed,
+		Reason:     fmt.Sprintf("ip_address=%q email=%q failed_only=%t", filter.IPAddress, filter.Email, filter.FailedOnly),
+	})
+	return events, nil
+}
+
+func (s *userAdminService) getUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userAdminService) audit(ctx context.Context, actorID uuid.UUID, action string, userID uuid.UUID, outcome domain.AuditOutcome, reason string) {
+	writeAudit(ctx, s.auditRepo, &domain.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   &userID,
+		Outcome:    outcome,
+		Reason:     reason,
+	})
+}
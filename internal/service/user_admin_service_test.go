@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Test Staff Account Creation - Verified Employee Account Is Audited
+func TestUserAdminService_CreateStaffUser_Success(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, nil, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	input := domain.StaffUserInput{
+		Email:    " New.Validator@AMF.com ",
+		Password: "validator-secret-1",
+		Role:     domain.RoleFieldValidator,
+		Region:   "NY",
+		Branch:   "NY-01",
+	}
+
+	mockUserRepo.On("GetByEmail", mock.Anything, "new.validator@amf.com").Return(nil, gorm.ErrRecordNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return *entry.ActorID == adminID &&
+			entry.Action == "user.created" &&
+			entry.Outcome == domain.AuditOutcomeAllowed &&
+			entry.Reason == "role field_validator"
+	})).Return(nil)
+
+	// Act
+	user, err := userAdminService.CreateStaffUser(context.Background(), adminID, input)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "new.validator@amf.com", user.Email)
+	assert.Equal(t, domain.RoleFieldValidator, user.Role)
+	assert.True(t, user.IsEmailVerified())
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)))
+	mockAuditRepo.AssertExpectations(t)
+}
+
+// Test Staff Account Creation - Customer Roles Are Refused
+func TestUserAdminService_CreateStaffUser_CustomerRole(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, nil, nil, testPasswordConfig)
+
+	// Act
+	user, err := userAdminService.CreateStaffUser(context.Background(), uuid.New(), domain.StaffUserInput{
+		Email:    "someone@example.com",
+		Password: "password123",
+		Role:     domain.RoleBorrower,
+	})
+
+	// Assert
+	assert.Nil(t, user)
+	assert.Equal(t, domain.ErrInvalidRole, err)
+	mockUserRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Deactivation - Sessions Are Revoked And Admins Cannot Deactivate Themselves
+func TestUserAdminService_DeactivateUser(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+	mockAuditRepo := new(mockAuditRepository)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, authService, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	officer := &domain.User{ID: uuid.New(), Email: "officer@amf.com", Role: domain.RoleFieldOfficer}
+
+	mockUserRepo.On("GetByID", mock.Anything, officer.ID).Return(officer, nil)
+	mockUserRepo.On("Update", mock.Anything, officer).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, officer.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.deactivated" && entry.Outcome == domain.AuditOutcomeDenied && *entry.EntityID == adminID
+	})).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.deactivated" && entry.Outcome == domain.AuditOutcomeAllowed && *entry.EntityID == officer.ID
+	})).Return(nil)
+
+	// Act
+	_, selfErr := userAdminService.DeactivateUser(context.Background(), adminID, adminID)
+	deactivated, err := userAdminService.DeactivateUser(context.Background(), adminID, officer.ID)
+	_, repeatErr := userAdminService.DeactivateUser(context.Background(), adminID, officer.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrSelfAdministration, selfErr)
+	assert.NoError(t, err)
+	assert.False(t, deactivated.IsActive())
+	assert.NotNil(t, deactivated.SessionsRevokedAt)
+	assert.Equal(t, domain.ErrAccountDeactivated, repeatErr)
+	mockRefreshTokenRepo.AssertExpectations(t)
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+// Test Role Change - Customer Accounts Keep Their Role
+func TestUserAdminService_ChangeRole(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, nil, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	analyst := &domain.User{ID: uuid.New(), Role: domain.RoleCreditAnalyst}
+	borrower := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower}
+
+	mockUserRepo.On("GetByID", mock.Anything, analyst.ID).Return(analyst, nil)
+	mockUserRepo.On("GetByID", mock.Anything, borrower.ID).Return(borrower, nil)
+	mockUserRepo.On("Update", mock.Anything, analyst).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.role_changed" && entry.Reason == "credit_analyst -> credit_committee"
+	})).Return(nil)
+
+	// Act
+	updated, err := userAdminService.ChangeRole(context.Background(), adminID, analyst.ID, domain.RoleCreditCommittee)
+	_, customerErr := userAdminService.ChangeRole(context.Background(), adminID, borrower.ID, domain.RoleFieldOfficer)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleCreditCommittee, updated.Role)
+	assert.Equal(t, domain.ErrInvalidRole, customerErr)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+// Test Deactivation And Role Change - A Validator's Open Tasks Are Handed Off First
+func TestUserAdminService_HandsOffVerificationTasks(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockTaskService := new(mockTaskService)
+
+	authService := NewAuthService(mockUserRepo, new(mockBorrowerRepository), new(mockInvestorRepository), new(mockEmailVerificationRepository), mockRefreshTokenRepo, new(mockRevokedTokenRepository), mockLoginEventRepo, new(mockPasswordResetRepository), new(mockTwoFactorChallengeRepository), new(mockRecoveryCodeRepository), new(mockSingleSignOnStateRepository), new(mockNotificationService), new(mockEmailSender), new(mockIdentityProvider), testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, authService, mockTaskService, testPasswordConfig)
+
+	adminID := uuid.New()
+	leaving := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+	promoted := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+
+	mockUserRepo.On("GetByID", mock.Anything, leaving.ID).Return(leaving, nil)
+	mockUserRepo.On("GetByID", mock.Anything, promoted.ID).Return(promoted, nil)
+	mockUserRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, leaving.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockTaskService.On("HandOffTasks", mock.Anything, leaving.ID).Return(3, nil)
+	mockTaskService.On("HandOffTasks", mock.Anything, promoted.ID).Return(1, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.deactivated" && entry.Reason == "3 open verification tasks handed off"
+	})).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.role_changed" && entry.Reason == "field_validator -> credit_analyst, 1 open verification tasks handed off"
+	})).Return(nil)
+
+	// Act
+	deactivated, deactivateErr := userAdminService.DeactivateUser(context.Background(), adminID, leaving.ID)
+	changed, changeErr := userAdminService.ChangeRole(context.Background(), adminID, promoted.ID, domain.RoleCreditAnalyst)
+
+	// Assert
+	assert.NoError(t, deactivateErr)
+	assert.False(t, deactivated.IsActive())
+	assert.NoError(t, changeErr)
+	assert.Equal(t, domain.RoleCreditAnalyst, changed.Role)
+	mockTaskService.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+// Test Deactivation - A Validator Stays Active When The Tasks Cannot Be Handed Off
+func TestUserAdminService_DeactivateUser_HandOffFails(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockAuditRepo := new(mockAuditRepository)
+	mockTaskService := new(mockTaskService)
+
+	userAdminService := NewUserAdminService(mockUserRepo, new(mockLoginEventRepository), mockAuditRepo, nil, mockTaskService, testPasswordConfig)
+
+	validator := &domain.User{ID: uuid.New(), Role: domain.RoleFieldValidator}
+
+	mockUserRepo.On("GetByID", mock.Anything, validator.ID).Return(validator, nil)
+	mockTaskService.On("HandOffTasks", mock.Anything, validator.ID).Return(0, assert.AnError)
+
+	// Act
+	_, err := userAdminService.DeactivateUser(context.Background(), uuid.New(), validator.ID)
+
+	// Assert
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.True(t, validator.IsActive())
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockAuditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Two-Factor Reset - Another Administrator Removes The Authenticator And Ends Sessions
+func TestUserAdminService_ResetTwoFactor(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockBorrowerRepo := new(mockBorrowerRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockVerificationRepo := new(mockEmailVerificationRepository)
+	mockRefreshTokenRepo := new(mockRefreshTokenRepository)
+	mockRevokedTokenRepo := new(mockRevokedTokenRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockPasswordResetRepo := new(mockPasswordResetRepository)
+	mockChallengeRepo := new(mockTwoFactorChallengeRepository)
+	mockRecoveryCodeRepo := new(mockRecoveryCodeRepository)
+	mockSSOStateRepo := new(mockSingleSignOnStateRepository)
+	mockNotificationService := new(mockNotificationService)
+	mockEmailSender := new(mockEmailSender)
+	mockIdentityProvider := new(mockIdentityProvider)
+	mockAuditRepo := new(mockAuditRepository)
+
+	authService := NewAuthService(mockUserRepo, mockBorrowerRepo, mockInvestorRepo, mockVerificationRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockLoginEventRepo, mockPasswordResetRepo, mockChallengeRepo, mockRecoveryCodeRepo, mockSSOStateRepo, mockNotificationService, mockEmailSender, mockIdentityProvider, testPermissionPolicy, testTokenKeys, &config.JWTConfig{Expiry: time.Hour}, testRegistrationConfig, testPasswordConfig, testTwoFactorConfig, testLockoutConfig, &config.OIDCConfig{})
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, authService, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	enabledAt := time.Now().Add(-time.Hour)
+	officer := &domain.User{ID: uuid.New(), Role: domain.RoleFieldOfficer, TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPEnabledAt: &enabledAt}
+
+	mockUserRepo.On("GetByID", mock.Anything, officer.ID).Return(officer, nil)
+	mockUserRepo.On("Update", mock.Anything, officer).Return(nil)
+	mockRecoveryCodeRepo.On("DeleteByUserID", mock.Anything, officer.ID).Return(nil)
+	mockRefreshTokenRepo.On("RevokeByUserID", mock.Anything, officer.ID, mock.Anything).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.two_factor_reset"
+	})).Return(nil)
+
+	// Act
+	selfErr := userAdminService.ResetTwoFactor(context.Background(), adminID, adminID)
+	err := userAdminService.ResetTwoFactor(context.Background(), adminID, officer.ID)
+
+	// Assert
+	assert.Equal(t, domain.ErrSelfAdministration, selfErr)
+	assert.NoError(t, err)
+	assert.False(t, officer.TwoFactorEnabled())
+	assert.NotNil(t, officer.SessionsRevokedAt)
+	mockRecoveryCodeRepo.AssertExpectations(t)
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+// Test Unlock - The Lockout And Failure Count Are Cleared And Audited
+func TestUserAdminService_UnlockUser(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, nil, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	borrower := &domain.User{ID: uuid.New(), Role: domain.RoleBorrower, FailedLoginCount: 10, LockedUntil: &lockedUntil}
+
+	mockUserRepo.On("GetByID", mock.Anything, borrower.ID).Return(borrower, nil)
+	mockUserRepo.On("ResetFailedLogins", mock.Anything, borrower.ID).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "user.unlocked" && *entry.EntityID == borrower.ID && entry.Reason == "10 failed logins"
+	})).Return(nil)
+
+	// Act
+	unlocked, err := userAdminService.UnlockUser(context.Background(), adminID, borrower.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Zero(t, unlocked.FailedLoginCount)
+	assert.False(t, unlocked.IsLocked(time.Now()))
+	mockUserRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+// Test Login Event Search - Filters Are Normalized And The Search Is Audited
+func TestUserAdminService_SearchLoginEvents(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mockUserRepository)
+	mockLoginEventRepo := new(mockLoginEventRepository)
+	mockAuditRepo := new(mockAuditRepository)
+
+	userAdminService := NewUserAdminService(mockUserRepo, mockLoginEventRepo, mockAuditRepo, nil, nil, testPasswordConfig)
+
+	adminID := uuid.New()
+	events := []domain.LoginEvent{{ID: uuid.New(), Email: "borrower@example.com", Reason: "invalid_password", IPAddress: "198.51.100.9"}}
+
+	mockLoginEventRepo.On("Search", mock.Anything, domain.LoginEventFilter{IPAddress: "198.51.100.9", Email: "borrower@example.com", FailedOnly: true}, 100).Return(events, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == "login_event.searched" && *entry.ActorID == adminID
+	})).Return(nil)
+
+	// Act
+	found, err := userAdminService.SearchLoginEvents(context.Background(), adminID, domain.LoginEventFilter{
+		IPAddress:  " 198.51.100.9 ",
+		Email:      "Borrower@Example.com",
+		FailedOnly: true,
+	}, 100)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, events, found)
+	mockAuditRepo.AssertExpectations(t)
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/sigitisme/amf-loan-service/internal/config"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+)
+
+type waitlistService struct {
+	waitlistRepo        domain.WaitlistRepository
+	loanRepo            domain.LoanRepository
+	investorRepo        domain.InvestorRepository
+	holdRepo            domain.InvestmentHoldRepository
+	kafkaProducer       domain.KafkaProducer
+	notificationService domain.NotificationService
+	investmentConfig    *config.InvestmentConfig
+}
+
+func NewWaitlistService(
+	waitlistRepo domain.WaitlistRepository,
+	loanRepo domain.LoanRepository,
+	investorRepo domain.InvestorRepository,
+	holdRepo domain.InvestmentHoldRepository,
+	kafkaProducer domain.KafkaProducer,
+	notificationService domain.NotificationService,
+	investmentConfig *config.InvestmentConfig,
+) domain.WaitlistService {
+	return &waitlistService{
+		waitlistRepo:        waitlistRepo,
+		loanRepo:            loanRepo,
+		investorRepo:        investorRepo,
+		holdRepo:            holdRepo,
+		kafkaProducer:       kafkaProducer,
+		notificationService: notificationService,
+		investmentConfig:    investmentConfig,
+	}
+}
+
+// JoinWaitlist queues the investor for a loan that has no capacity left for the requested amount, including a
+// fully funded loan that gets capacity back when an investment is cancelled
+func (s *waitlistService) JoinWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, amount float64) (*domain.WaitlistEntry, error) {
+	investor, err := s.investorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if !investor.User.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
+	}
+	if !investor.User.IsKYCVerified() {
+		return nil, domain.ErrKYCNotVerified
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoanNotFound
+		}
+		return nil, err
+	}
+
+	// A disbursed loan never gets capacity back
+	if loan.State != domain.LoanStateApproved && loan.State != domain.LoanStateInvested {
+		return nil, domain.ErrInvalidLoanState
+	}
+
+	if loan.Borrower.UserID == userID {
+		return nil, domain.ErrSelfInvestment
+	}
+
+	if amount <= 0 {
+		return nil, domain.ErrInvalidInvestmentAmount
+	}
+
+	if amount > loan.PrincipalAmount {
+		return nil, domain.ErrInvestmentExceedsLimit
+	}
+
+	// Investors should invest directly while there is enough unreserved capacity
+	if amount <= loan.AvailableForInvestment() {
+		return nil, domain.ErrWaitlistNotNeeded
+	}
+
+	if _, err := s.waitlistRepo.GetActiveByLoanAndInvestor(ctx, loanID, investor.ID); err == nil {
+		return nil, domain.ErrAlreadyOnWaitlist
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	entry := &domain.WaitlistEntry{
+		ID:         uuid.New(),
+		LoanID:     loanID,
+		InvestorID: investor.ID,
+		Amount:     amount,
+		Status:     domain.WaitlistStatusWaiting,
+	}
+
+	if err := s.waitlistRepo.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	ahead, err := s.waitlistRepo.CountAhead(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	entry.Position = int(ahead) + 1
+
+	return entry, nil
+}
+
+// LeaveWaitlist cancels the investor's waiting entry for the loan
+func (s *waitlistService) LeaveWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) error {
+	investor, err := s.investorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+
+	entry, err := s.waitlistRepo.GetActiveByLoanAndInvestor(ctx, loanID, investor.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrWaitlistEntryNotFound
+		}
+		return err
+	}
+
+	return s.waitlistRepo.UpdateStatus(ctx, entry.ID, domain.WaitlistStatusCancelled)
+}
+
+// GetMyWaitlist returns the investor's entries, with queue positions for those still waiting
+func (s *waitlistService) GetMyWaitlist(ctx context.Context, userID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	investor, err := s.investorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	entries, err := s.waitlistRepo.GetByInvestorID(ctx, investor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Status != domain.WaitlistStatusWaiting {
+			continue
+		}
+		ahead, err := s.waitlistRepo.CountAhead(ctx, &entries[i])
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Position = int(ahead) + 1
+	}
+
+	return entries, nil
+}
+
+// ProcessWaitlist converts waiting entries into investment requests in FIFO order. Entries that do not
+// fit the freed capacity are skipped and keep their place for the next release.
+func (s *waitlistService) ProcessWaitlist(ctx context.Context, loanID uuid.UUID) error {
+	entries, err := s.waitlistRepo.GetWaitingByLoanID(ctx, loanID)
+	if err != nil {
+		return fmt.Errorf("failed to get waitlist: %w", err)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		hold, err := reserveInvestment(ctx, s.holdRepo, s.kafkaProducer, s.investmentConfig.HoldTTL, loanID, entry.InvestorID, entry.Amount)
+		if err != nil {
+			// Too large for what is left, a later and smaller entry may still fit
+			if errors.Is(err, domain.ErrInvestmentExceedsLimit) {
+				continue
+			}
+			// Loan no longer open, nothing else can be served
+			if errors.Is(err, domain.ErrLoanNotApproved) {
+				return nil
+			}
+			return err
+		}
+
+		converted, err := s.waitlistRepo.MarkConverted(ctx, entry.ID, hold.ID)
+		if err != nil {
+			return err
+		}
+
+		// Entry was cancelled or served concurrently, give the capacity back
+		if !converted {
+			if err := s.holdRepo.Release(ctx, hold.ID, domain.HoldStatusReleased); err != nil {
+				log.Printf("Failed to release hold %s for stale waitlist entry %s: %v", hold.ID, entry.ID, err)
+			}
+			continue
+		}
+
+		if s.notificationService != nil {
+			if err := s.notificationService.NotifyWaitlistPromoted(ctx, entry, hold); err != nil {
+				log.Printf("Failed to notify investor for waitlist entry %s: %v", entry.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
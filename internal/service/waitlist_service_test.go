@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sigitisme/amf-loan-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock Waitlist Repository
+type mockWaitlistRepository struct {
+	mock.Mock
+}
+
+func (m *mockWaitlistRepository) Create(ctx context.Context, entry *domain.WaitlistEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *mockWaitlistRepository) GetActiveByLoanAndInvestor(ctx context.Context, loanID, investorID uuid.UUID) (*domain.WaitlistEntry, error) {
+	args := m.Called(ctx, loanID, investorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitlistEntry), args.Error(1)
+}
+
+func (m *mockWaitlistRepository) GetWaitingByLoanID(ctx context.Context, loanID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).([]domain.WaitlistEntry), args.Error(1)
+}
+
+func (m *mockWaitlistRepository) GetByInvestorID(ctx context.Context, investorID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	args := m.Called(ctx, investorID)
+	return args.Get(0).([]domain.WaitlistEntry), args.Error(1)
+}
+
+func (m *mockWaitlistRepository) CountAhead(ctx context.Context, entry *domain.WaitlistEntry) (int64, error) {
+	args := m.Called(ctx, entry)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockWaitlistRepository) MarkConverted(ctx context.Context, id uuid.UUID, holdID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id, holdID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockWaitlistRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.WaitlistStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *mockWaitlistRepository) CancelWaitingByLoanID(ctx context.Context, loanID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, loanID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Mock Waitlist Service
+type mockWaitlistService struct {
+	mock.Mock
+}
+
+func (m *mockWaitlistService) JoinWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID, amount float64) (*domain.WaitlistEntry, error) {
+	args := m.Called(ctx, userID, loanID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WaitlistEntry), args.Error(1)
+}
+
+func (m *mockWaitlistService) LeaveWaitlist(ctx context.Context, userID uuid.UUID, loanID uuid.UUID) error {
+	args := m.Called(ctx, userID, loanID)
+	return args.Error(0)
+}
+
+func (m *mockWaitlistService) GetMyWaitlist(ctx context.Context, userID uuid.UUID) ([]domain.WaitlistEntry, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.WaitlistEntry), args.Error(1)
+}
+
+func (m *mockWaitlistService) ProcessWaitlist(ctx context.Context, loanID uuid.UUID) error {
+	args := m.Called(ctx, loanID)
+	return args.Error(0)
+}
+
+// Test Join Waitlist - Loan Without Enough Unreserved Capacity
+func TestWaitlistService_JoinWaitlist_Success(t *testing.T) {
+	// Arrange
+	mockWaitlistRepo := new(mockWaitlistRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+
+	waitlistService := NewWaitlistService(mockWaitlistRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, testInvestmentConfig)
+
+	userID := uuid.New()
+	loanID := uuid.New()
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: verifiedUser(userID)}
+	loan := &domain.Loan{
+		ID:                  loanID,
+		State:               domain.LoanStateApproved,
+		PrincipalAmount:     100000.0,
+		RemainingInvestment: 30000.0,
+		ReservedAmount:      20000.0,
+		Borrower:            domain.Borrower{UserID: uuid.New()},
+	}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+	mockWaitlistRepo.On("GetActiveByLoanAndInvestor", mock.Anything, loanID, investor.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockWaitlistRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WaitlistEntry")).Return(nil)
+	mockWaitlistRepo.On("CountAhead", mock.Anything, mock.AnythingOfType("*domain.WaitlistEntry")).Return(int64(2), nil)
+
+	// Act
+	entry, err := waitlistService.JoinWaitlist(context.Background(), userID, loanID, 25000.0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, entry)
+	assert.Equal(t, domain.WaitlistStatusWaiting, entry.Status)
+	assert.Equal(t, 3, entry.Position)
+
+	mockWaitlistRepo.AssertExpectations(t)
+}
+
+// Test Join Waitlist - Loan Still Has Capacity
+func TestWaitlistService_JoinWaitlist_NotNeeded(t *testing.T) {
+	// Arrange
+	mockWaitlistRepo := new(mockWaitlistRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+
+	waitlistService := NewWaitlistService(mockWaitlistRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, testInvestmentConfig)
+
+	userID := uuid.New()
+	loanID := uuid.New()
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: verifiedUser(userID)}
+	loan := &domain.Loan{
+		ID:                  loanID,
+		State:               domain.LoanStateApproved,
+		PrincipalAmount:     100000.0,
+		RemainingInvestment: 50000.0,
+		Borrower:            domain.Borrower{UserID: uuid.New()},
+	}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, loanID).Return(loan, nil)
+
+	// Act
+	entry, err := waitlistService.JoinWaitlist(context.Background(), userID, loanID, 25000.0)
+
+	// Assert
+	assert.Nil(t, entry)
+	assert.Equal(t, domain.ErrWaitlistNotNeeded, err)
+	mockWaitlistRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Test Join Waitlist - Fully Funded Loans Take Waiting Investors, Disbursed Loans Do Not
+func TestWaitlistService_JoinWaitlist_InvestedLoan(t *testing.T) {
+	// Arrange
+	mockWaitlistRepo := new(mockWaitlistRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+
+	waitlistService := NewWaitlistService(mockWaitlistRepo, mockLoanRepo, mockInvestorRepo, new(mockInvestmentHoldRepository), new(mockKafkaProducer), new(mockNotificationService), testInvestmentConfig)
+
+	userID := uuid.New()
+	investor := &domain.Investor{ID: uuid.New(), UserID: userID, User: verifiedUser(userID)}
+	invested := &domain.Loan{
+		ID:              uuid.New(),
+		State:           domain.LoanStateInvested,
+		PrincipalAmount: 100000.0,
+		InvestedAmount:  100000.0,
+		Borrower:        domain.Borrower{UserID: uuid.New()},
+	}
+	disbursed := &domain.Loan{
+		ID:              uuid.New(),
+		State:           domain.LoanStateDisbursed,
+		PrincipalAmount: 100000.0,
+		InvestedAmount:  100000.0,
+		Borrower:        domain.Borrower{UserID: uuid.New()},
+	}
+
+	mockInvestorRepo.On("GetByUserID", mock.Anything, userID).Return(investor, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, invested.ID).Return(invested, nil)
+	mockLoanRepo.On("GetByID", mock.Anything, disbursed.ID).Return(disbursed, nil)
+	mockWaitlistRepo.On("GetActiveByLoanAndInvestor", mock.Anything, invested.ID, investor.ID).Return(nil, gorm.ErrRecordNotFound)
+	mockWaitlistRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WaitlistEntry")).Return(nil)
+	mockWaitlistRepo.On("CountAhead", mock.Anything, mock.AnythingOfType("*domain.WaitlistEntry")).Return(int64(0), nil)
+
+	// Act
+	entry, err := waitlistService.JoinWaitlist(context.Background(), userID, invested.ID, 25000.0)
+	disbursedEntry, disbursedErr := waitlistService.JoinWaitlist(context.Background(), userID, disbursed.ID, 25000.0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, invested.ID, entry.LoanID)
+	assert.Equal(t, 1, entry.Position)
+	assert.Nil(t, disbursedEntry)
+	assert.Equal(t, domain.ErrInvalidLoanState, disbursedErr)
+	mockWaitlistRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Test Process Waitlist - FIFO Conversion Skips Entries That Do Not Fit
+func TestWaitlistService_ProcessWaitlist_FIFO(t *testing.T) {
+	// Arrange
+	mockWaitlistRepo := new(mockWaitlistRepository)
+	mockLoanRepo := new(mockLoanRepository)
+	mockInvestorRepo := new(mockInvestorRepository)
+	mockHoldRepo := new(mockInvestmentHoldRepository)
+	mockKafkaProducer := new(mockKafkaProducer)
+	mockNotificationService := new(mockNotificationService)
+
+	waitlistService := NewWaitlistService(mockWaitlistRepo, mockLoanRepo, mockInvestorRepo, mockHoldRepo, mockKafkaProducer, mockNotificationService, testInvestmentConfig)
+
+	loanID := uuid.New()
+	first := domain.WaitlistEntry{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 20000.0}
+	second := domain.WaitlistEntry{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 50000.0}
+	third := domain.WaitlistEntry{ID: uuid.New(), LoanID: loanID, InvestorID: uuid.New(), Amount: 5000.0}
+
+	mockWaitlistRepo.On("GetWaitingByLoanID", mock.Anything, loanID).Return([]domain.WaitlistEntry{first, second, third}, nil)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.MatchedBy(func(hold *domain.InvestmentHold) bool {
+		return hold.InvestorID == first.InvestorID
+	})).Return(&domain.Loan{ID: loanID}, nil)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.MatchedBy(func(hold *domain.InvestmentHold) bool {
+		return hold.InvestorID == second.InvestorID
+	})).Return(nil, domain.ErrInvestmentExceedsLimit)
+	mockHoldRepo.On("CreateWithLoanLock", mock.Anything, mock.MatchedBy(func(hold *domain.InvestmentHold) bool {
+		return hold.InvestorID == third.InvestorID
+	})).Return(&domain.Loan{ID: loanID}, nil)
+	mockKafkaProducer.On("PublishInvestmentEvent", mock.Anything, mock.AnythingOfType("domain.InvestmentEvent")).Return(nil).Twice()
+	mockWaitlistRepo.On("MarkConverted", mock.Anything, first.ID, mock.AnythingOfType("uuid.UUID")).Return(true, nil)
+	mockWaitlistRepo.On("MarkConverted", mock.Anything, third.ID, mock.AnythingOfType("uuid.UUID")).Return(true, nil)
+	mockNotificationService.On("NotifyWaitlistPromoted", mock.Anything, mock.AnythingOfType("*domain.WaitlistEntry"), mock.AnythingOfType("*domain.InvestmentHold")).Return(nil).Twice()
+
+	// Act
+	err := waitlistService.ProcessWaitlist(context.Background(), loanID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockHoldRepo.AssertNumberOfCalls(t, "CreateWithLoanLock", 3) // the second entry keeps waiting
+	mockWaitlistRepo.AssertNotCalled(t, "MarkConverted", mock.Anything, second.ID, mock.Anything)
+	mockWaitlistRepo.AssertExpectations(t)
+	mockKafkaProducer.AssertExpectations(t)
+	mockNotificationService.AssertExpectations(t)
+}